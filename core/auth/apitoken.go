@@ -0,0 +1,275 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// APIToken is a long-lived token. Only the hash of its secret is kept.
+	APIToken struct {
+		ID        string
+		UserID    string
+		Name      string
+		Scopes    []string
+		Hash      string
+		CreatedAt time.Time
+		ExpiresAt time.Time // zero means never
+		Revoked   bool
+
+		// RateLimit caps the requests per minute made with the token, 0 means
+		// unlimited.
+		RateLimit int
+	}
+
+	// APITokenStore persists API tokens, e.g. in a database table or a cache.
+	APITokenStore interface {
+		Save(t *APIToken) error
+		// FindByHash returns nil if no token has the hash.
+		FindByHash(hash string) (*APIToken, error)
+		Revoke(id string) error
+		List(userID string) ([]*APIToken, error)
+	}
+
+	// APITokens issues and validates API tokens.
+	APITokens struct {
+		// Prefix is prepended to issued keys so they are easy to recognize,
+		// e.g. by secret scanners.
+		Prefix string
+
+		// Clock tells the time for expiry and rate limits.
+		Clock core.Clock
+
+		store   APITokenStore
+		mu      sync.Mutex
+		windows map[string]*rateWindow
+	}
+
+	rateWindow struct {
+		minute int64
+		count  int
+	}
+
+	// MemoryAPITokenStore is an in-process APITokenStore.
+	MemoryAPITokenStore struct {
+		mu     sync.Mutex
+		tokens map[string]*APIToken // by ID
+	}
+)
+
+// apiTokenKey keeps the validated token in the core.Context store.
+const apiTokenKey = "_auth_api_token"
+
+var (
+	ErrTokenRevoked = errors.New("auth: token revoked")
+	ErrRateLimited  = errors.New("auth: token rate limit exceeded")
+)
+
+// NewAPITokens returns an APITokens backed by store.
+func NewAPITokens(store APITokenStore) *APITokens {
+	return &APITokens{
+		Prefix:  "tg_",
+		Clock:   core.SystemClock,
+		store:   store,
+		windows: map[string]*rateWindow{},
+	}
+}
+
+// Issue creates a token. The returned key is shown once to the user, it can't
+// be recovered later.
+func (a *APITokens) Issue(userID, name string, scopes []string, ttl time.Duration, rateLimit int) (key string, t *APIToken, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+	key = a.Prefix + base64.RawURLEncoding.EncodeToString(b)
+	t = &APIToken{
+		ID:        hex.EncodeToString(b[:8]),
+		UserID:    userID,
+		Name:      name,
+		Scopes:    scopes,
+		Hash:      hashToken(key),
+		CreatedAt: a.Clock.Now(),
+		RateLimit: rateLimit,
+	}
+	if ttl > 0 {
+		t.ExpiresAt = t.CreatedAt.Add(ttl)
+	}
+	if err = a.store.Save(t); err != nil {
+		return "", nil, err
+	}
+	return
+}
+
+// Revoke revokes a token by ID.
+func (a *APITokens) Revoke(id string) error {
+	return a.store.Revoke(id)
+}
+
+// List returns the tokens of a user.
+func (a *APITokens) List(userID string) ([]*APIToken, error) {
+	return a.store.List(userID)
+}
+
+// Validate returns the token of key, checking revocation, expiry and its rate
+// limit.
+func (a *APITokens) Validate(key string) (*APIToken, error) {
+	if !strings.HasPrefix(key, a.Prefix) {
+		return nil, ErrTokenInvalid
+	}
+	t, err := a.store.FindByHash(hashToken(key))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case t == nil:
+		return nil, ErrTokenInvalid
+	case t.Revoked:
+		return nil, ErrTokenRevoked
+	case !t.ExpiresAt.IsZero() && a.Clock.Now().After(t.ExpiresAt):
+		return nil, ErrTokenExpired
+	case !a.allow(t):
+		return t, ErrRateLimited
+	}
+	return t, nil
+}
+
+// Validator returns a key validator with the signature KeyAuth style
+// middlewares expect. The token is stored in the context on success.
+func (a *APITokens) Validator() func(key string, c *core.Context) (bool, error) {
+	return func(key string, c *core.Context) (bool, error) {
+		t, err := a.Validate(key)
+		switch err {
+		case nil:
+			c.Set(apiTokenKey, t)
+			return true, nil
+		case ErrRateLimited:
+			return false, core.NewHTTPError(http.StatusTooManyRequests, err.Error())
+		case ErrTokenInvalid, ErrTokenRevoked, ErrTokenExpired:
+			return false, nil
+		}
+		return false, err
+	}
+}
+
+// Middleware returns a middleware authenticating requests by the
+// `Authorization: Bearer <key>` header. The token must carry every scope
+// given.
+func (a *APITokens) Middleware(scopes ...string) core.MiddlewareFunc {
+	validate := a.Validator()
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			h := c.Request().Header.Get(core.Authorization)
+			if len(h) < 7 || !strings.EqualFold(h[:7], "Bearer ") {
+				c.Response().Header().Set(core.WWWAuthenticate, "Bearer")
+				return core.NewHTTPError(http.StatusUnauthorized)
+			}
+			ok, err := validate(strings.TrimSpace(h[7:]), c)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				c.Response().Header().Set(core.WWWAuthenticate, `Bearer error="invalid_token"`)
+				return core.NewHTTPError(http.StatusUnauthorized)
+			}
+			t := TokenOf(c)
+			for _, s := range scopes {
+				if !t.HasScope(s) {
+					c.Response().Header().Set(core.WWWAuthenticate, `Bearer error="insufficient_scope"`)
+					return core.NewHTTPError(http.StatusForbidden)
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// TokenOf returns the API token which authenticated the request.
+func TokenOf(c *core.Context) *APIToken {
+	t, _ := c.Get(apiTokenKey).(*APIToken)
+	return t
+}
+
+// HasScope reports whether the token grants scope. Granted scopes may end in
+// "*" to cover a family, e.g. "repo:*" grants "repo:read".
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope || s == "*" || (strings.HasSuffix(s, "*") && strings.HasPrefix(scope, s[:len(s)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// allow counts a request against the per-minute limit of t.
+func (a *APITokens) allow(t *APIToken) bool {
+	if t.RateLimit <= 0 {
+		return true
+	}
+	minute := a.Clock.Now().Unix() / 60
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	w := a.windows[t.ID]
+	if w == nil || w.minute != minute {
+		w = &rateWindow{minute: minute}
+		a.windows[t.ID] = w
+	}
+	w.count++
+	return w.count <= t.RateLimit
+}
+
+// NewMemoryAPITokenStore returns an empty MemoryAPITokenStore.
+func NewMemoryAPITokenStore() *MemoryAPITokenStore {
+	return &MemoryAPITokenStore{tokens: map[string]*APIToken{}}
+}
+
+// Save implements APITokenStore.
+func (m *MemoryAPITokenStore) Save(t *APIToken) error {
+	m.mu.Lock()
+	m.tokens[t.ID] = t
+	m.mu.Unlock()
+	return nil
+}
+
+// FindByHash implements APITokenStore.
+func (m *MemoryAPITokenStore) FindByHash(hash string) (*APIToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, t := range m.tokens {
+		if t.Hash == hash {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+// Revoke implements APITokenStore.
+func (m *MemoryAPITokenStore) Revoke(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t, ok := m.tokens[id]; ok {
+		t.Revoked = true
+	}
+	return nil
+}
+
+// List implements APITokenStore.
+func (m *MemoryAPITokenStore) List(userID string) ([]*APIToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var list []*APIToken
+	for _, t := range m.tokens {
+		if t.UserID == userID {
+			list = append(list, t)
+		}
+	}
+	return list, nil
+}
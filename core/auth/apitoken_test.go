@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPITokens(t *testing.T) {
+	tokens := NewAPITokens(NewMemoryAPITokenStore())
+	key, tok, err := tokens.Issue("bob", "ci", []string{"repo:*"}, 0, 2)
+	assert.NoError(t, err)
+
+	e := core.New()
+	e.Group("/repo", tokens.Middleware("repo:read")).Get("", func(c *core.Context) error {
+		return c.String(http.StatusOK, TokenOf(c).UserID)
+	})
+	e.Group("/admin", tokens.Middleware("admin")).Get("", func(c *core.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	serve := func(path, auth string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(core.GET, path, nil)
+		if auth != "" {
+			req.Header.Set(core.Authorization, auth)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve("/repo", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("/repo", "Bearer tg_nope").Code)
+	rec := serve("/repo", "Bearer "+key)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "bob", rec.Body.String())
+	assert.Equal(t, http.StatusForbidden, serve("/admin", "Bearer "+key).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve("/repo", "Bearer "+key).Code)
+
+	assert.NoError(t, tokens.Revoke(tok.ID))
+	_, err = tokens.Validate(key)
+	assert.Equal(t, ErrTokenRevoked, err)
+}
@@ -0,0 +1,280 @@
+// Package auth provides session based login scaffolding on top of the session
+// package: logging users in and out, remember-me cookies, a guard middleware
+// and password hashing.
+//
+//	e.Use(session.Sessions(session.DefaultConfig), auth.Setup(auth.Config{Loader: loadUser}))
+//	admin := e.Group("/admin", auth.Required())
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/henrylee2cn/thinkgo/core/session"
+)
+
+type (
+	// Authenticatable is implemented by the application's user type.
+	Authenticatable interface {
+		AuthID() string
+	}
+
+	// LoaderFunc loads a user by ID, it returns nil if the user doesn't exist.
+	LoaderFunc func(id string) (Authenticatable, error)
+
+	// Config defines the auth config.
+	Config struct {
+		// Loader loads the logged in user. Required.
+		Loader LoaderFunc
+
+		// Tokens keeps the remember-me tokens and the revocations, see Revoke.
+		// Optional. Default value is a MemoryTokenStore.
+		Tokens TokenStore
+
+		// TOTPSteps remembers the TOTP codes used, see CheckTOTP.
+		// Optional. Default value is a MemoryTOTPStepStore.
+		TOTPSteps TOTPStepStore
+
+		// SessionKey is the session value holding the user ID.
+		// Optional. Default value "_auth_id".
+		SessionKey string
+
+		// RememberCookie and RememberMaxAge configure the remember-me cookie.
+		// Optional. Default values "remember_token" and 30 days.
+		RememberCookie string
+		RememberMaxAge time.Duration
+
+		// LoginURL is where Required redirects anonymous users to. They get
+		// 401 Unauthorized if empty.
+		// Optional. Default value "".
+		LoginURL string
+	}
+)
+
+const (
+	// userKey caches the user in the core.Context store.
+	userKey = "_auth_user"
+	// configKey holds the config in the core.Context store.
+	configKey = "_auth_config"
+	// loginKey is the session value holding the login time, checked against
+	// revocations.
+	loginKey = "_auth_at"
+)
+
+var (
+	// DefaultConfig is the default auth config.
+	DefaultConfig = Config{
+		SessionKey:     "_auth_id",
+		RememberCookie: "remember_token",
+		RememberMaxAge: 30 * 24 * time.Hour,
+	}
+
+	// ErrNoSession is returned when the session middleware is missing.
+	ErrNoSession = errors.New("auth: no session, use the session.Sessions middleware")
+
+	// ErrNoSetup is returned when the Setup middleware is missing.
+	ErrNoSetup = errors.New("auth: no config, use the auth.Setup middleware")
+)
+
+// Setup returns a middleware making config the auth config of the requests,
+// so each Echo can have its own. A MemoryTokenStore without a clock gets the
+// Echo one. Register it after the session.Sessions middleware.
+func Setup(config Config) core.MiddlewareFunc {
+	if config.Tokens == nil {
+		config.Tokens = NewMemoryTokenStore()
+	}
+	if config.TOTPSteps == nil {
+		config.TOTPSteps = NewMemoryTOTPStepStore()
+	}
+	if config.SessionKey == "" {
+		config.SessionKey = DefaultConfig.SessionKey
+	}
+	if config.RememberCookie == "" {
+		config.RememberCookie = DefaultConfig.RememberCookie
+	}
+	if config.RememberMaxAge == 0 {
+		config.RememberMaxAge = DefaultConfig.RememberMaxAge
+	}
+	var once sync.Once
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			if m, ok := config.Tokens.(*MemoryTokenStore); ok {
+				once.Do(func() { m.defaultClock(c.Echo().Clock()) })
+			}
+			c.Set(configKey, &config)
+			return next(c)
+		}
+	}
+}
+
+// configOf returns the config set by Setup for the request.
+func configOf(c *core.Context) (*Config, *session.Session, error) {
+	config, _ := c.Get(configKey).(*Config)
+	if config == nil {
+		return nil, nil, ErrNoSetup
+	}
+	s := session.Get(c)
+	if s == nil {
+		return nil, nil, ErrNoSession
+	}
+	return config, s, nil
+}
+
+// Login logs user in, regenerating the session ID. With remember set, a
+// remember-me cookie logs the user back in once the session has expired. Its
+// token is kept in Config.Tokens, and replaced each time it's used.
+func Login(c *core.Context, user Authenticatable, remember ...bool) error {
+	config, s, err := configOf(c)
+	if err != nil {
+		return err
+	}
+	s.Regenerate()
+	s.Delete(twoFactorKey)
+	s.Set(config.SessionKey, user.AuthID())
+	s.Set(loginKey, formatTime(c.Now()))
+	c.Set(userKey, user)
+	if len(remember) > 0 && remember[0] {
+		return setRememberCookie(c, config, user.AuthID())
+	}
+	return nil
+}
+
+// Logout logs the current user out and forgets the remember-me token.
+func Logout(c *core.Context) error {
+	config, s, err := configOf(c)
+	if err != nil {
+		return err
+	}
+	s.Delete(config.SessionKey)
+	s.Delete(loginKey)
+	s.Delete(twoFactorKey)
+	s.Regenerate()
+	c.Set(userKey, nil)
+	if token := c.GetCookie(config.RememberCookie); token != "" {
+		if _, _, err = config.Tokens.Take(hashToken(token), PurposeRemember); err != nil {
+			return err
+		}
+		cookie := c.DefaultCookie(config.RememberCookie, "")
+		cookie.MaxAge, cookie.HttpOnly = -1, true
+		c.WriteCookie(cookie)
+	}
+	return nil
+}
+
+// Revoke logs the user out everywhere, e.g. once the password changed: the
+// sessions logged in so far end and the tokens issued to the user, such as
+// remember-me ones, are dropped.
+func Revoke(c *core.Context, userID string) error {
+	config, _ := c.Get(configKey).(*Config)
+	if config == nil {
+		return ErrNoSetup
+	}
+	return config.Tokens.Revoke(userID, c.Now())
+}
+
+// UserOf returns the logged in user, nil for anonymous requests.
+func UserOf(c *core.Context) (Authenticatable, error) {
+	if u, ok := c.Get(userKey).(Authenticatable); ok {
+		return u, nil
+	}
+	config, s, err := configOf(c)
+	if err != nil {
+		return nil, err
+	}
+	if id, ok := s.Get(config.SessionKey).(string); ok {
+		revoked, err := config.Tokens.RevokedAt(id)
+		if err != nil {
+			return nil, err
+		}
+		if !revoked.IsZero() && !sessionTime(s.Get(loginKey)).After(revoked) {
+			s.Delete(config.SessionKey)
+			s.Delete(loginKey)
+			s.Delete(twoFactorKey)
+			return nil, nil
+		}
+		u, err := config.Loader(id)
+		if u != nil && err == nil {
+			c.Set(userKey, u)
+		}
+		return u, err
+	}
+	token := c.GetCookie(config.RememberCookie)
+	if token == "" {
+		return nil, nil
+	}
+	id, err := consumeToken(config.Tokens, PurposeRemember, token, c.Now())
+	if err == ErrTokenInvalid || err == ErrTokenExpired {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	u, err := config.Loader(id)
+	if u == nil || err != nil {
+		return nil, err
+	}
+	s.Regenerate()
+	s.Set(config.SessionKey, id)
+	s.Set(loginKey, formatTime(c.Now()))
+	c.Set(userKey, u)
+	return u, setRememberCookie(c, config, id)
+}
+
+// User returns the logged in user, nil for anonymous requests or on error.
+func User(c *core.Context) Authenticatable {
+	u, _ := UserOf(c)
+	return u
+}
+
+// Check reports whether the request is authenticated.
+func Check(c *core.Context) bool {
+	return User(c) != nil
+}
+
+// Required returns a middleware which only lets authenticated requests
+// through, redirecting to Config.LoginURL or answering 401 otherwise.
+func Required() core.MiddlewareFunc {
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			u, err := UserOf(c)
+			if err != nil {
+				return err
+			}
+			if u != nil {
+				return next(c)
+			}
+			if url := c.Get(configKey).(*Config).LoginURL; url != "" {
+				return c.Redirect(http.StatusFound, url)
+			}
+			return core.NewHTTPError(http.StatusUnauthorized)
+		}
+	}
+}
+
+// setRememberCookie issues a remember-me token for the user.
+func setRememberCookie(c *core.Context, config *Config, id string) error {
+	token, err := issueToken(config.Tokens, Token{PurposeRemember, id, c.Now().Add(config.RememberMaxAge)})
+	if err != nil {
+		return err
+	}
+	cookie := c.DefaultCookie(config.RememberCookie, token)
+	cookie.MaxAge, cookie.HttpOnly = int(config.RememberMaxAge/time.Second), true
+	c.WriteCookie(cookie)
+	return nil
+}
+
+// formatTime formats a time to be stored in a session. A string survives any
+// session store, where numbers may come back as float64 from JSON.
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// sessionTime reads a time stored with formatTime, zero if v isn't one.
+func sessionTime(v interface{}) time.Time {
+	s, _ := v.(string)
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/henrylee2cn/thinkgo/core/session"
+	"github.com/stretchr/testify/assert"
+)
+
+type testUser string
+
+func (u testUser) AuthID() string { return string(u) }
+
+func TestPassword(t *testing.T) {
+	// RFC 6070
+	assert.Equal(t, "4b007901b765489abead49d926f721d065a429c1",
+		hex.EncodeToString(pbkdf2([]byte("password"), []byte("salt"), 4096, 20, sha1.New)))
+
+	h, err := HashPassword("secret")
+	assert.NoError(t, err)
+	ok, err := CheckPassword(h, "secret")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, _ = CheckPassword(h, "Secret")
+	assert.False(t, ok)
+	_, err = CheckPassword("plain", "secret")
+	assert.Equal(t, ErrInvalidHash, err)
+}
+
+func TestLogin(t *testing.T) {
+	e := core.New()
+	e.Use(session.Sessions(session.DefaultConfig), Setup(Config{
+		Loader: func(id string) (Authenticatable, error) { return testUser(id), nil },
+	}))
+	e.Post("/login", func(c *core.Context) error {
+		return Login(c, testUser("bob"), true)
+	})
+	e.Post("/logout", func(c *core.Context) error {
+		return Logout(c)
+	})
+	e.Post("/revoke", func(c *core.Context) error {
+		return Revoke(c, "bob")
+	})
+	g := e.Group("/admin", Required())
+	g.Get("", func(c *core.Context) error {
+		return c.String(http.StatusOK, User(c).AuthID())
+	})
+
+	serve := func(method, path string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		for _, ck := range cookies {
+			req.AddCookie(ck)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	cookies := func(rec *httptest.ResponseRecorder) []*http.Cookie {
+		return (&http.Response{Header: rec.Header()}).Cookies()
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve(core.GET, "/admin", nil).Code)
+
+	login := cookies(serve(core.POST, "/login", nil))
+	assert.Equal(t, 2, len(login))
+	rec := serve(core.GET, "/admin", login)
+	assert.Equal(t, "bob", rec.Body.String())
+
+	// Remember-me cookie alone restores the login.
+	var remember []*http.Cookie
+	for _, ck := range login {
+		if ck.Name == DefaultConfig.RememberCookie {
+			remember = append(remember, ck)
+		}
+	}
+	rec = serve(core.GET, "/admin", remember)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// It's replaced once used.
+	assert.Equal(t, http.StatusUnauthorized, serve(core.GET, "/admin", remember).Code)
+	var rotated []*http.Cookie
+	for _, ck := range cookies(rec) {
+		if ck.Name == DefaultConfig.RememberCookie {
+			rotated = append(rotated, ck)
+		}
+	}
+	assert.Equal(t, 1, len(rotated))
+	rotated[0].Value += "x"
+	assert.Equal(t, http.StatusUnauthorized, serve(core.GET, "/admin", rotated).Code)
+
+	// Revoke ends the sessions and the remember-me tokens.
+	login = cookies(serve(core.POST, "/login", nil))
+	assert.Equal(t, http.StatusOK, serve(core.GET, "/admin", login).Code)
+	serve(core.POST, "/revoke", nil)
+	for _, ck := range login {
+		assert.Equal(t, http.StatusUnauthorized, serve(core.GET, "/admin", []*http.Cookie{ck}).Code)
+	}
+	login = cookies(serve(core.POST, "/login", nil))
+
+	logout := cookies(serve(core.POST, "/logout", login))
+	assert.NotEqual(t, 0, len(logout))
+	for _, ck := range logout {
+		if ck.Name == session.DefaultConfig.CookieName {
+			assert.Equal(t, http.StatusUnauthorized, serve(core.GET, "/admin", []*http.Cookie{ck}).Code)
+		}
+	}
+	// The old session is gone.
+	assert.Equal(t, http.StatusUnauthorized, serve(core.GET, "/admin", login[:1]).Code)
+}
@@ -0,0 +1,279 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/henrylee2cn/thinkgo/core/session"
+	"github.com/henrylee2cn/thinkgo/core/template"
+	texttemplate "github.com/henrylee2cn/thinkgo/core/template/text/template"
+)
+
+type (
+	// Mailer sends plain text mails.
+	Mailer interface {
+		Send(to, subject, body string) error
+	}
+
+	// FlowConfig configures the password reset and email verification flows.
+	FlowConfig struct {
+		Mailer Mailer
+
+		// Tokens keeps the reset and verification tokens.
+		// Optional. Default value is the store of the Setup middleware, or
+		// a MemoryTokenStore without it.
+		Tokens TokenStore
+
+		// BaseURL is prepended to the links sent by mail,
+		// e.g. "https://example.com".
+		BaseURL string
+
+		ResetTTL  time.Duration
+		VerifyTTL time.Duration
+
+		// FindByEmail returns the user owning an email address, nil if none.
+		FindByEmail func(email string) (Authenticatable, error)
+		// SetPassword stores the new password hash of a user.
+		SetPassword func(id, hash string) error
+		// MarkVerified flags the email address of a user as verified.
+		MarkVerified func(id string) error
+
+		// Templates render the pages, see DefaultFlowTemplates.
+		Templates *template.Template
+
+		// MailTemplates render the plain text mails, see
+		// DefaultFlowMailTemplates.
+		MailTemplates *texttemplate.Template
+	}
+
+	// Flows serves the password reset and email verification routes.
+	Flows struct {
+		config FlowConfig
+		prefix string
+		tokens TokenStore // used without Tokens nor Setup middleware
+	}
+)
+
+// MinPasswordLength is the shortest password accepted on reset.
+var MinPasswordLength = 8
+
+// csrfKey is the session value holding the CSRF token of the forms.
+const csrfKey = "_auth_csrf"
+
+// DefaultFlowTemplates defines the "forgot", "forgot_sent", "reset",
+// "reset_done" and "verified" pages. Pages get a map with "Error" and "CSRF",
+// the token forms must post as "csrf_token" (and "Token" for "reset").
+const DefaultFlowTemplates = `
+{{define "forgot"}}<form method="post"><p>{{.Error}}</p><input name="csrf_token" type="hidden" value="{{.CSRF}}"><input name="email" type="email"><button>Send reset link</button></form>{{end}}
+{{define "forgot_sent"}}<p>If the address is known, a reset link is on its way.</p>{{end}}
+{{define "reset"}}<form method="post"><p>{{.Error}}</p><input name="csrf_token" type="hidden" value="{{.CSRF}}"><input name="password" type="password"><button>Reset password</button></form>{{end}}
+{{define "reset_done"}}<p>Your password has been reset.</p>{{end}}
+{{define "verified"}}<p>{{if .Error}}{{.Error}}{{else}}Your email address is verified.{{end}}</p>{{end}}
+`
+
+// DefaultFlowMailTemplates defines the "reset_mail" and "verify_mail" bodies,
+// which get the link.
+const DefaultFlowMailTemplates = `
+{{define "reset_mail"}}Reset your password: {{.}}{{end}}
+{{define "verify_mail"}}Verify your email address: {{.}}{{end}}
+`
+
+// Mount registers the flows on g, which needs the session.Sessions middleware
+// for the CSRF tokens of the forms:
+//
+//	GET/POST /password/forgot
+//	GET/POST /password/reset/:token
+//	GET      /verify/:token
+//
+// A password reset logs the user out everywhere, see Revoke.
+func Mount(g *core.Group, config FlowConfig) *Flows {
+	if config.ResetTTL == 0 {
+		config.ResetTTL = time.Hour
+	}
+	if config.VerifyTTL == 0 {
+		config.VerifyTTL = 48 * time.Hour
+	}
+	if config.Templates == nil {
+		config.Templates = template.Must(template.New("auth").Parse(DefaultFlowTemplates))
+	}
+	if config.MailTemplates == nil {
+		config.MailTemplates = texttemplate.Must(texttemplate.New("auth").Parse(DefaultFlowMailTemplates))
+	}
+	tokens := NewMemoryTokenStore()
+	tokens.Clock = g.Echo().Clock()
+	f := &Flows{config: config, prefix: g.Echo().Prefix(), tokens: tokens}
+	g.Get("/password/forgot", f.forgotForm)
+	g.Post("/password/forgot", f.forgot)
+	g.Get("/password/reset/:token", f.resetForm)
+	g.Post("/password/reset/:token", f.reset)
+	g.Get("/verify/:token", f.verify)
+	return f
+}
+
+// SendVerification mails an email verification link to the user.
+func (f *Flows) SendVerification(c *core.Context, user Authenticatable, email string) error {
+	token, err := IssueToken(c, f.tokensOf(c), PurposeVerify, user.AuthID(), f.config.VerifyTTL)
+	if err != nil {
+		return err
+	}
+	return f.mail(email, "Verify your email address", "verify_mail", "/verify/"+token)
+}
+
+func (f *Flows) forgotForm(c *core.Context) error {
+	return f.render(c, http.StatusOK, "forgot", nil)
+}
+
+// forgot answers the same whether the address is known or not, so it can't
+// be used to probe for accounts.
+func (f *Flows) forgot(c *core.Context) error {
+	if err := checkCSRF(c); err != nil {
+		return err
+	}
+	email := c.Form("email")
+	if email == "" {
+		return f.render(c, http.StatusUnprocessableEntity, "forgot", map[string]interface{}{"Error": "Email is required."})
+	}
+	user, err := f.config.FindByEmail(email)
+	if err != nil {
+		return err
+	}
+	if user != nil {
+		token, err := IssueToken(c, f.tokensOf(c), PurposeReset, user.AuthID(), f.config.ResetTTL)
+		if err != nil {
+			return err
+		}
+		if err = f.mail(email, "Reset your password", "reset_mail", "/password/reset/"+token); err != nil {
+			return err
+		}
+	}
+	return f.render(c, http.StatusOK, "forgot_sent", nil)
+}
+
+func (f *Flows) resetForm(c *core.Context) error {
+	return f.render(c, http.StatusOK, "reset", map[string]interface{}{"Token": c.Param("token")})
+}
+
+func (f *Flows) reset(c *core.Context) error {
+	if err := checkCSRF(c); err != nil {
+		return err
+	}
+	password := c.Form("password")
+	if len(password) < MinPasswordLength {
+		return f.render(c, http.StatusUnprocessableEntity, "reset", map[string]interface{}{
+			"Token": c.Param("token"),
+			"Error": "Password is too short.",
+		})
+	}
+	id, err := ConsumeToken(c, f.tokensOf(c), PurposeReset, c.Param("token"))
+	if err != nil {
+		return f.tokenError(c, "reset", err)
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err = f.config.SetPassword(id, hash); err != nil {
+		return err
+	}
+	if err = f.tokensOf(c).Revoke(id, c.Now()); err != nil {
+		return err
+	}
+	if err = Revoke(c, id); err != nil && err != ErrNoSetup {
+		return err
+	}
+	return f.render(c, http.StatusOK, "reset_done", nil)
+}
+
+func (f *Flows) verify(c *core.Context) error {
+	id, err := ConsumeToken(c, f.tokensOf(c), PurposeVerify, c.Param("token"))
+	if err != nil {
+		return f.tokenError(c, "verified", err)
+	}
+	if err = f.config.MarkVerified(id); err != nil {
+		return err
+	}
+	return f.render(c, http.StatusOK, "verified", nil)
+}
+
+func (f *Flows) tokenError(c *core.Context, page string, err error) error {
+	if err != ErrTokenInvalid && err != ErrTokenExpired {
+		return err
+	}
+	msg := "This link is invalid or has already been used."
+	if err == ErrTokenExpired {
+		msg = "This link has expired."
+	}
+	return f.render(c, http.StatusBadRequest, page, map[string]interface{}{"Error": msg})
+}
+
+func (f *Flows) mail(to, subject, name, path string) error {
+	buf := new(bytes.Buffer)
+	if err := f.config.MailTemplates.ExecuteTemplate(buf, name, f.config.BaseURL+f.prefix+path); err != nil {
+		return err
+	}
+	return f.config.Mailer.Send(to, subject, buf.String())
+}
+
+func (f *Flows) render(c *core.Context, code int, name string, data map[string]interface{}) error {
+	token, err := csrfToken(c)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	data["CSRF"] = token
+	buf := new(bytes.Buffer)
+	if err := f.config.Templates.ExecuteTemplate(buf, name, data); err != nil {
+		return err
+	}
+	return c.HTML(code, buf.String())
+}
+
+// tokensOf returns the token store of the flows.
+func (f *Flows) tokensOf(c *core.Context) TokenStore {
+	if f.config.Tokens != nil {
+		return f.config.Tokens
+	}
+	if config, _ := c.Get(configKey).(*Config); config != nil {
+		return config.Tokens
+	}
+	return f.tokens
+}
+
+// csrfToken returns the CSRF token of the session, creating it if needed.
+func csrfToken(c *core.Context) (string, error) {
+	s := session.Get(c)
+	if s == nil {
+		return "", ErrNoSession
+	}
+	if token, ok := s.Get(csrfKey).(string); ok {
+		return token, nil
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	s.Set(csrfKey, token)
+	return token, nil
+}
+
+// checkCSRF rejects a form not posted with the CSRF token of the session with
+// "403 - Forbidden".
+func checkCSRF(c *core.Context) error {
+	s := session.Get(c)
+	if s == nil {
+		return ErrNoSession
+	}
+	token, _ := s.Get(csrfKey).(string)
+	if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(c.Form("csrf_token"))) != 1 {
+		return core.NewHTTPError(http.StatusForbidden, "invalid CSRF token")
+	}
+	return nil
+}
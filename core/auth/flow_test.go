@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/henrylee2cn/thinkgo/core/session"
+	"github.com/stretchr/testify/assert"
+)
+
+type testMailer struct{ to, body string }
+
+func (m *testMailer) Send(to, subject, body string) error {
+	m.to, m.body = to, body
+	return nil
+}
+
+func TestToken(t *testing.T) {
+	e := core.New()
+	clock := core.NewManualClock(time.Unix(1000, 0))
+	e.SetClock(clock)
+	c := core.NewContext(httptest.NewRequest(core.GET, "/", nil), core.NewResponse(httptest.NewRecorder(), e), e)
+	store := NewMemoryTokenStore()
+	token, err := IssueToken(c, store, PurposeReset, "1", time.Hour)
+	assert.NoError(t, err)
+	_, err = ConsumeToken(c, store, PurposeVerify, token)
+	assert.Equal(t, ErrTokenInvalid, err)
+
+	// Still valid for its purpose
+	id, err := ConsumeToken(c, store, PurposeReset, token)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", id)
+
+	_, err = ConsumeToken(c, store, PurposeReset, token)
+	assert.Equal(t, ErrTokenInvalid, err)
+
+	// Expiry follows the Echo clock
+	token, _ = IssueToken(c, store, PurposeReset, "1", time.Hour)
+	clock.Advance(time.Hour + time.Second)
+	_, err = ConsumeToken(c, store, PurposeReset, token)
+	assert.Equal(t, ErrTokenExpired, err)
+}
+
+func TestFlows(t *testing.T) {
+	mailer := &testMailer{}
+	passwords := map[string]string{}
+	verified := map[string]bool{}
+	var f *Flows
+	e := core.New()
+	e.Use(session.Sessions(session.DefaultConfig), Setup(Config{
+		Loader: func(id string) (Authenticatable, error) { return testUser(id), nil },
+	}))
+	e.Post("/login", func(c *core.Context) error {
+		return Login(c, testUser("bob"), true)
+	})
+	e.Get("/me", Required()(func(c *core.Context) error {
+		return c.String(http.StatusOK, fmt.Sprint(User(c)))
+	}))
+	e.Post("/verification", func(c *core.Context) error {
+		return f.SendVerification(c, testUser("bob"), "bob@example.com")
+	})
+	f = Mount(e.Group("/account"), FlowConfig{
+		Mailer:  mailer,
+		BaseURL: "http://example.com",
+		FindByEmail: func(email string) (Authenticatable, error) {
+			if email == "bob@example.com" {
+				return testUser("bob"), nil
+			}
+			return nil, nil
+		},
+		SetPassword:  func(id, hash string) error { passwords[id] = hash; return nil },
+		MarkVerified: func(id string) error { verified[id] = true; return nil },
+	})
+
+	// jar serves requests of one client, holding its cookies.
+	jar := func() func(method, path string, form url.Values) *httptest.ResponseRecorder {
+		cookies := map[string]*http.Cookie{}
+		return func(method, path string, form url.Values) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest(method, path, strings.NewReader(form.Encode()))
+			req.Header.Set(core.ContentType, core.ApplicationForm)
+			for _, ck := range cookies {
+				req.AddCookie(ck)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			for _, ck := range (&http.Response{Header: rec.Header()}).Cookies() {
+				cookies[ck.Name] = ck
+			}
+			return rec
+		}
+	}
+	serve := jar()
+	link := regexp.MustCompile(`http://example.com(/\S+)`)
+	csrf := regexp.MustCompile(`name="csrf_token" type="hidden" value="(\w+)"`)
+
+	rec := serve(core.GET, "/account/password/forgot", nil)
+	token := csrf.FindStringSubmatch(rec.Body.String())[1]
+
+	// Without the CSRF token
+	rec = serve(core.POST, "/account/password/forgot", url.Values{"email": {"bob@example.com"}})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "", mailer.to)
+
+	rec = serve(core.POST, "/account/password/forgot", url.Values{"email": {"eve@example.com"}, "csrf_token": {token}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "", mailer.to)
+
+	serve(core.POST, "/account/password/forgot", url.Values{"email": {"bob@example.com"}, "csrf_token": {token}})
+	assert.Equal(t, "bob@example.com", mailer.to)
+	path := link.FindStringSubmatch(mailer.body)[1]
+
+	// Bob is logged in elsewhere.
+	bob := jar()
+	bob(core.POST, "/login", nil)
+	assert.Equal(t, "bob", bob(core.GET, "/me", nil).Body.String())
+
+	rec = serve(core.POST, path, url.Values{"password": {"short"}, "csrf_token": {token}})
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	rec = serve(core.POST, path, url.Values{"password": {"long enough"}, "csrf_token": {token}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	ok, _ := CheckPassword(passwords["bob"], "long enough")
+	assert.True(t, ok)
+	rec = serve(core.POST, path, url.Values{"password": {"long enough"}, "csrf_token": {token}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// The reset logged Bob out.
+	assert.Equal(t, http.StatusUnauthorized, bob(core.GET, "/me", nil).Code)
+
+	serve(core.POST, "/verification", nil)
+	rec = serve(core.GET, link.FindStringSubmatch(mailer.body)[1], nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, verified["bob"])
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+// PasswordIterations is the PBKDF2 work factor of new hashes. Existing hashes
+// keep the factor they were created with.
+var PasswordIterations = 100000
+
+// ErrInvalidHash is returned for malformed password hashes.
+var ErrInvalidHash = errors.New("auth: invalid password hash")
+
+// HashPassword hashes a password with PBKDF2-HMAC-SHA256 and a random salt.
+// The result is self-describing, `$pbkdf2-sha256$<iterations>$<salt>$<key>`,
+// the same layout bcrypt and argon2 use, so other schemes can be recognized
+// alongside it later. The standard library carries neither bcrypt nor argon2,
+// PBKDF2 keeps the module free of extra dependencies.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := pbkdf2([]byte(password), salt, PasswordIterations, sha256.Size, sha256.New)
+	return fmt.Sprintf("$pbkdf2-sha256$%d$%s$%s", PasswordIterations,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// CheckPassword reports whether password matches hash.
+func CheckPassword(hash, password string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 || parts[0] != "" || parts[1] != "pbkdf2-sha256" {
+		return false, ErrInvalidHash
+	}
+	iter, err := strconv.Atoi(parts[2])
+	if err != nil || iter < 1 {
+		return false, ErrInvalidHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, ErrInvalidHash
+	}
+	got := pbkdf2([]byte(password), salt, iter, len(key), sha256.New)
+	return subtle.ConstantTimeCompare(got, key) == 1, nil
+}
+
+// pbkdf2 implements RFC 2898 key derivation.
+func pbkdf2(password, salt []byte, iter, keyLen int, h func() hash.Hash) []byte {
+	prf := hmac.New(h, password)
+	hashLen := prf.Size()
+	blocks := (keyLen + hashLen - 1) / hashLen
+	dk := make([]byte, 0, blocks*hashLen)
+	u := make([]byte, hashLen)
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write([]byte{byte(block >> 24), byte(block >> 16), byte(block >> 8), byte(block)})
+		dk = prf.Sum(dk)
+		t := dk[len(dk)-hashLen:]
+		copy(u, t)
+		for n := 2; n <= iter; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = u[:0]
+			u = prf.Sum(u)
+			for i := range u {
+				t[i] ^= u[i]
+			}
+		}
+	}
+	return dk[:keyLen]
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// TokenStore keeps single-use tokens. Only a hash of each token is handed
+	// to the store, so a leaked store can't be used to reset passwords.
+	TokenStore interface {
+		Save(hash string, t Token) error
+		// Take returns and removes a token issued for purpose, ok is false if
+		// there's none. A token of another purpose is left in place.
+		Take(hash, purpose string) (t Token, ok bool, err error)
+		// Revoke removes the tokens of a user and records at as the time
+		// the user was revoked.
+		Revoke(userID string, at time.Time) error
+		// RevokedAt returns the time the user was last revoked, zero if
+		// never.
+		RevokedAt(userID string) (time.Time, error)
+	}
+
+	// Token is a stored single-use token.
+	Token struct {
+		Purpose string
+		UserID  string
+		Expires time.Time
+	}
+
+	// MemoryTokenStore is an in-process TokenStore.
+	MemoryTokenStore struct {
+		// Clock tells the time for expiry, the Echo clock when set up by
+		// Setup or Flows, else core.SystemClock if nil.
+		Clock core.Clock
+
+		mu      sync.Mutex
+		tokens  map[string]Token
+		revoked map[string]time.Time
+	}
+)
+
+// Token purposes.
+const (
+	PurposeReset    = "password_reset"
+	PurposeVerify   = "email_verify"
+	PurposeRemember = "remember"
+)
+
+var (
+	ErrTokenInvalid = errors.New("auth: invalid token")
+	ErrTokenExpired = errors.New("auth: token expired")
+)
+
+// IssueToken creates a token for the user, valid once for ttl from the time
+// of the Echo clock.
+func IssueToken(c *core.Context, store TokenStore, purpose, userID string, ttl time.Duration) (string, error) {
+	return issueToken(store, Token{purpose, userID, c.Now().Add(ttl)})
+}
+
+func issueToken(store TokenStore, t Token) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	if err := store.Save(hashToken(token), t); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ConsumeToken validates a token and invalidates it, returning the user ID it
+// was issued for.
+func ConsumeToken(c *core.Context, store TokenStore, purpose, token string) (string, error) {
+	return consumeToken(store, purpose, token, c.Now())
+}
+
+func consumeToken(store TokenStore, purpose, token string, now time.Time) (string, error) {
+	t, ok, err := store.Take(hashToken(token), purpose)
+	if err != nil {
+		return "", err
+	}
+	if !ok || t.Purpose != purpose {
+		return "", ErrTokenInvalid
+	}
+	if now.After(t.Expires) {
+		return "", ErrTokenExpired
+	}
+	return t.UserID, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewMemoryTokenStore returns an empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: map[string]Token{}, revoked: map[string]time.Time{}}
+}
+
+// Save implements TokenStore.
+func (m *MemoryTokenStore) Save(hash string, t Token) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for h, old := range m.tokens {
+		if now.After(old.Expires) {
+			delete(m.tokens, h)
+		}
+	}
+	m.tokens[hash] = t
+	return nil
+}
+
+// Take implements TokenStore.
+func (m *MemoryTokenStore) Take(hash, purpose string) (Token, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tokens[hash]
+	if !ok || t.Purpose != purpose {
+		return Token{}, false, nil
+	}
+	delete(m.tokens, hash)
+	return t, true, nil
+}
+
+// Revoke implements TokenStore.
+func (m *MemoryTokenStore) Revoke(userID string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for h, t := range m.tokens {
+		if t.UserID == userID {
+			delete(m.tokens, h)
+		}
+	}
+	m.revoked[userID] = at
+	return nil
+}
+
+// RevokedAt implements TokenStore.
+func (m *MemoryTokenStore) RevokedAt(userID string) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.revoked[userID], nil
+}
+
+// defaultClock sets the clock unless one was given.
+func (m *MemoryTokenStore) defaultClock(clock core.Clock) {
+	m.mu.Lock()
+	if m.Clock == nil {
+		m.Clock = clock
+	}
+	m.mu.Unlock()
+}
+
+func (m *MemoryTokenStore) now() time.Time {
+	if m.Clock == nil {
+		return time.Now()
+	}
+	return m.Clock.Now()
+}
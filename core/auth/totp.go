@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/henrylee2cn/thinkgo/core/session"
+)
+
+// TOTP parameters (RFC 6238), the defaults every authenticator app supports.
+const (
+	TOTPDigits = 6
+	TOTPPeriod = 30 * time.Second
+)
+
+// twoFactorKey is the session value holding the time of the last second
+// factor check.
+const twoFactorKey = "_auth_2fa_at"
+
+type (
+	// TOTPStepStore remembers the last time step a TOTP code was accepted
+	// for, per user, so each code is only accepted once.
+	TOTPStepStore interface {
+		// Accept records step for the user and reports whether it's after
+		// the last one accepted. It must be atomic.
+		Accept(userID string, step int64) (bool, error)
+	}
+
+	// MemoryTOTPStepStore is an in-process TOTPStepStore.
+	MemoryTOTPStepStore struct {
+		mu    sync.Mutex
+		steps map[string]int64
+	}
+)
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a random base32 encoded 160 bit secret.
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return b32.EncodeToString(b), nil
+}
+
+// TOTPURI returns the otpauth:// provisioning URI of a secret, to be shown as
+// a QR code to authenticator apps.
+func TOTPURI(secret, issuer, account string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(TOTPDigits))
+	v.Set("period", fmt.Sprint(int(TOTPPeriod/time.Second)))
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// TOTPCode returns the code of secret at t.
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := b32.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, uint64(t.Unix())/uint64(TOTPPeriod/time.Second)), nil
+}
+
+// ValidateTOTP reports whether code is valid at now, accepting codes up to
+// window periods old or ahead to allow for clock drift, and returns the time
+// step it's valid for. A valid code can be replayed within the window, see
+// CheckTOTP.
+func ValidateTOTP(secret, code string, window int, now time.Time) (step int64, ok bool) {
+	key, err := b32.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil || len(code) != TOTPDigits {
+		return 0, false
+	}
+	current := now.Unix() / int64(TOTPPeriod/time.Second)
+	for i := -window; i <= window; i++ {
+		s := current + int64(i)
+		if subtle.ConstantTimeCompare([]byte(hotp(key, uint64(s))), []byte(code)) == 1 {
+			step, ok = s, true
+		}
+	}
+	return
+}
+
+// CheckTOTP validates the TOTP code of a user like ValidateTOTP, at the time
+// of the request, and rejects codes of time steps already used, see
+// Config.TOTPSteps.
+func CheckTOTP(c *core.Context, userID, secret, code string, window int) (bool, error) {
+	config, _ := c.Get(configKey).(*Config)
+	if config == nil {
+		return false, ErrNoSetup
+	}
+	step, ok := ValidateTOTP(secret, code, window, c.Now())
+	if !ok {
+		return false, nil
+	}
+	return config.TOTPSteps.Accept(userID, step)
+}
+
+// NewMemoryTOTPStepStore returns an empty MemoryTOTPStepStore.
+func NewMemoryTOTPStepStore() *MemoryTOTPStepStore {
+	return &MemoryTOTPStepStore{steps: map[string]int64{}}
+}
+
+// Accept implements TOTPStepStore.
+func (m *MemoryTOTPStepStore) Accept(userID string, step int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if last, ok := m.steps[userID]; ok && step <= last {
+		return false, nil
+	}
+	m.steps[userID] = step
+	return true, nil
+}
+
+// hotp implements RFC 4226.
+func hotp(key []byte, counter uint64) string {
+	m := hmac.New(sha1.New, key)
+	binary.Write(m, binary.BigEndian, counter)
+	sum := m.Sum(nil)
+	off := sum[len(sum)-1] & 0xf
+	n := binary.BigEndian.Uint32(sum[off:]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TOTPDigits, n%mod)
+}
+
+// GenerateRecoveryCodes returns n one-time recovery codes, shown once to the
+// user, and their hashes to store.
+func GenerateRecoveryCodes(n int) (codes, hashes []string, err error) {
+	for i := 0; i < n; i++ {
+		b := make([]byte, 5)
+		if _, err = rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := hex.EncodeToString(b)
+		code = code[:5] + "-" + code[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return
+}
+
+// UseRecoveryCode checks code against the stored hashes. On success the hash
+// is removed from the returned list, which must replace the stored one.
+func UseRecoveryCode(hashes []string, code string) (remaining []string, ok bool) {
+	h := hashRecoveryCode(code)
+	for i, v := range hashes {
+		if subtle.ConstantTimeCompare([]byte(v), []byte(h)) == 1 {
+			remaining = append(remaining, hashes[:i]...)
+			return append(remaining, hashes[i+1:]...), true
+		}
+	}
+	return hashes, false
+}
+
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.Replace(strings.TrimSpace(code), "-", "", -1))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// ConfirmTwoFactor records that the user passed a second factor check, call it
+// once a TOTP or recovery code has been validated.
+func ConfirmTwoFactor(c *core.Context) error {
+	s := session.Get(c)
+	if s == nil {
+		return ErrNoSession
+	}
+	s.Set(twoFactorKey, formatTime(c.Now()))
+	return nil
+}
+
+// TwoFactorRequired returns a middleware for step-up authentication: the user
+// must be logged in and have confirmed a second factor within maxAge. Others
+// are redirected to verifyURL, or get 403 Forbidden if it is empty.
+func TwoFactorRequired(maxAge time.Duration, verifyURL string) core.MiddlewareFunc {
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return Required()(func(c *core.Context) error {
+			if at := sessionTime(session.Get(c).Get(twoFactorKey)); !at.IsZero() &&
+				c.Now().Sub(at) <= maxAge {
+				return next(c)
+			}
+			if verifyURL != "" {
+				return c.Redirect(http.StatusFound, verifyURL)
+			}
+			return core.NewHTTPError(http.StatusForbidden)
+		})
+	}
+}
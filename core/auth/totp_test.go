@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"encoding/base32"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/henrylee2cn/thinkgo/core/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 appendix B, truncated to 6 digits.
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	code, err := TOTPCode(secret, time.Unix(59, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, "287082", code)
+	code, _ = TOTPCode(secret, time.Unix(1111111109, 0))
+	assert.Equal(t, "081804", code)
+
+	secret, err = GenerateTOTPSecret()
+	assert.NoError(t, err)
+	now := time.Unix(1500000000, 0)
+	code, _ = TOTPCode(secret, now)
+	step, ok := ValidateTOTP(secret, code, 0, now)
+	assert.True(t, ok)
+	assert.Equal(t, int64(50000000), step)
+	code, _ = TOTPCode(secret, now.Add(-TOTPPeriod))
+	step, ok = ValidateTOTP(secret, code, 1, now)
+	assert.True(t, ok)
+	assert.Equal(t, int64(49999999), step)
+	code, _ = TOTPCode(secret, now.Add(-3*TOTPPeriod))
+	_, ok = ValidateTOTP(secret, code, 1, now)
+	assert.False(t, ok)
+
+	assert.Equal(t, "otpauth://totp/Acme:bob@example.com?algorithm=SHA1&digits=6&issuer=Acme&period=30&secret=ABC",
+		TOTPURI("ABC", "Acme", "bob@example.com"))
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes(3)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(codes))
+	hashes, ok := UseRecoveryCode(hashes, codes[1])
+	assert.True(t, ok)
+	assert.Equal(t, 2, len(hashes))
+	_, ok = UseRecoveryCode(hashes, codes[1])
+	assert.False(t, ok)
+}
+
+func TestCheckTOTP(t *testing.T) {
+	secret, _ := GenerateTOTPSecret()
+	clock := core.NewManualClock(time.Unix(1500000000, 0))
+	e := core.New()
+	e.SetClock(clock)
+	e.Use(session.Sessions(session.DefaultConfig), Setup(Config{
+		Loader: func(id string) (Authenticatable, error) { return testUser(id), nil },
+	}))
+	e.Post("/login", func(c *core.Context) error {
+		return Login(c, testUser("bob"))
+	})
+	e.Post("/2fa", func(c *core.Context) error {
+		ok, err := CheckTOTP(c, "bob", secret, c.Form("code"), 1)
+		if err != nil || !ok {
+			return core.NewHTTPError(http.StatusUnauthorized)
+		}
+		return ConfirmTwoFactor(c)
+	})
+	e.Get("/admin", TwoFactorRequired(time.Minute, "")(func(c *core.Context) error {
+		return c.NoContent(http.StatusOK)
+	}))
+
+	var cookies []*http.Cookie
+	serve := func(method, path string) int {
+		req, _ := http.NewRequest(method, path, nil)
+		for _, ck := range cookies {
+			req.AddCookie(ck)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if ck := (&http.Response{Header: rec.Header()}).Cookies(); len(ck) > 0 {
+			cookies = ck
+		}
+		return rec.Code
+	}
+	serve(core.POST, "/login")
+	assert.Equal(t, http.StatusForbidden, serve(core.GET, "/admin"))
+
+	code, _ := TOTPCode(secret, clock.Now())
+	assert.Equal(t, http.StatusOK, serve(core.POST, "/2fa?code="+code))
+	assert.Equal(t, http.StatusOK, serve(core.GET, "/admin"))
+
+	// A code is only accepted once, and no older one either.
+	assert.Equal(t, http.StatusUnauthorized, serve(core.POST, "/2fa?code="+code))
+	old, _ := TOTPCode(secret, clock.Now().Add(-TOTPPeriod))
+	assert.Equal(t, http.StatusUnauthorized, serve(core.POST, "/2fa?code="+old))
+
+	clock.Advance(2 * time.Minute)
+	assert.Equal(t, http.StatusForbidden, serve(core.GET, "/admin"))
+	code, _ = TOTPCode(secret, clock.Now())
+	assert.Equal(t, http.StatusOK, serve(core.POST, "/2fa?code="+code))
+}
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// CacheControl builds the Cache-Control response header declaratively, e.g.
+	// `c.CacheControl().MaxAge(time.Hour).Public().Immutable()`.
+	// Every call updates the header right away, so no terminating call is needed.
+	CacheControl struct {
+		header     http.Header
+		directives []cacheDirective
+	}
+
+	cacheDirective struct {
+		name  string
+		value string
+	}
+)
+
+func newCacheControl(header http.Header) *CacheControl {
+	cc := &CacheControl{header: header}
+	if header == nil {
+		return cc
+	}
+	for _, v := range header[CacheControlHeader] {
+		for _, d := range strings.Split(v, ",") {
+			d = strings.TrimSpace(d)
+			if d == "" {
+				continue
+			}
+			name, value := d, ""
+			if i := strings.Index(d, "="); i != -1 {
+				name, value = d[:i], d[i+1:]
+			}
+			cc.directives = append(cc.directives, cacheDirective{strings.ToLower(name), value})
+		}
+	}
+	return cc
+}
+
+// Public marks the response as cacheable by any cache.
+func (cc *CacheControl) Public() *CacheControl {
+	cc.del("private")
+	return cc.set("public", "")
+}
+
+// Private marks the response as cacheable by the browser only.
+func (cc *CacheControl) Private() *CacheControl {
+	cc.del("public")
+	return cc.set("private", "")
+}
+
+// NoCache forces caches to revalidate before using a stored response.
+func (cc *CacheControl) NoCache() *CacheControl {
+	return cc.set("no-cache", "")
+}
+
+// NoStore forbids caches from storing the response at all.
+func (cc *CacheControl) NoStore() *CacheControl {
+	return cc.set("no-store", "")
+}
+
+// NoTransform forbids intermediaries from transforming the payload.
+func (cc *CacheControl) NoTransform() *CacheControl {
+	return cc.set("no-transform", "")
+}
+
+// MustRevalidate forbids serving the response stale.
+func (cc *CacheControl) MustRevalidate() *CacheControl {
+	return cc.set("must-revalidate", "")
+}
+
+// ProxyRevalidate is MustRevalidate for shared caches only.
+func (cc *CacheControl) ProxyRevalidate() *CacheControl {
+	return cc.set("proxy-revalidate", "")
+}
+
+// Immutable tells clients the response will never change while fresh.
+func (cc *CacheControl) Immutable() *CacheControl {
+	return cc.set("immutable", "")
+}
+
+// MaxAge sets how long the response stays fresh.
+func (cc *CacheControl) MaxAge(d time.Duration) *CacheControl {
+	return cc.set("max-age", seconds(d))
+}
+
+// SMaxAge sets how long the response stays fresh in shared caches.
+func (cc *CacheControl) SMaxAge(d time.Duration) *CacheControl {
+	return cc.set("s-maxage", seconds(d))
+}
+
+// StaleWhileRevalidate allows serving a stale response while it is revalidated
+// in the background.
+func (cc *CacheControl) StaleWhileRevalidate(d time.Duration) *CacheControl {
+	return cc.set("stale-while-revalidate", seconds(d))
+}
+
+// StaleIfError allows serving a stale response when revalidation fails.
+func (cc *CacheControl) StaleIfError(d time.Duration) *CacheControl {
+	return cc.set("stale-if-error", seconds(d))
+}
+
+// Reset drops all directives.
+func (cc *CacheControl) Reset() *CacheControl {
+	cc.directives = nil
+	cc.update()
+	return cc
+}
+
+// String returns the header value.
+func (cc *CacheControl) String() string {
+	s := make([]string, len(cc.directives))
+	for i, d := range cc.directives {
+		s[i] = d.name
+		if d.value != "" {
+			s[i] += "=" + d.value
+		}
+	}
+	return strings.Join(s, ", ")
+}
+
+func (cc *CacheControl) set(name, value string) *CacheControl {
+	for i, d := range cc.directives {
+		if d.name == name {
+			cc.directives[i].value = value
+			cc.update()
+			return cc
+		}
+	}
+	cc.directives = append(cc.directives, cacheDirective{name, value})
+	cc.update()
+	return cc
+}
+
+func (cc *CacheControl) del(name string) {
+	for i, d := range cc.directives {
+		if d.name == name {
+			cc.directives = append(cc.directives[:i], cc.directives[i+1:]...)
+			return
+		}
+	}
+}
+
+func (cc *CacheControl) update() {
+	if cc.header == nil {
+		return
+	}
+	if len(cc.directives) == 0 {
+		cc.header.Del(CacheControlHeader)
+		return
+	}
+	cc.header.Set(CacheControlHeader, cc.String())
+}
+
+// apply writes the directives into h, used for Echo/Group defaults.
+func (cc *CacheControl) apply(h http.Header) {
+	if len(cc.directives) > 0 {
+		h.Set(CacheControlHeader, cc.String())
+	}
+}
+
+func (cc *CacheControl) clone() *CacheControl {
+	if cc == nil {
+		return nil
+	}
+	d := make([]cacheDirective, len(cc.directives))
+	copy(d, cc.directives)
+	return &CacheControl{directives: d}
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10)
+}
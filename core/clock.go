@@ -0,0 +1,141 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core/template"
+)
+
+type (
+	// Clock tells the time. Echo.SetClock replaces the system clock, e.g. with
+	// a ManualClock to freeze time in tests.
+	Clock interface {
+		Now() time.Time
+	}
+
+	// IDGenerator generates unique IDs, e.g. for sessions or requests.
+	IDGenerator interface {
+		NewID() string
+	}
+
+	// ManualClock is a Clock which only moves when told to.
+	ManualClock struct {
+		mu  sync.Mutex
+		now time.Time
+	}
+
+	// SequenceIDs generates the IDs `<prefix>1`, `<prefix>2`...
+	SequenceIDs struct {
+		Prefix string
+		mu     sync.Mutex
+		n      uint64
+	}
+
+	// environment is shared by an Echo and its groups.
+	environment struct {
+		clock        Clock
+		ids          IDGenerator
+		redact       RedactFunc
+		cookie       CookieDefaults
+		secureCookie *SecureCookie
+		funcs        template.FuncMap
+		renderers    map[string]Renderer
+		// groupRenderers are the renderers set on groups.
+		groupRenderers []Renderer
+		streamFlush    time.Duration
+		offers         []string
+		sseHeartbeat   time.Duration
+		offload        *FileOffload
+		meta           MetaDefaults
+	}
+
+	systemClock struct{}
+	randomIDs   struct{}
+)
+
+// SystemClock is the default Clock, backed by time.Now.
+var SystemClock Clock = systemClock{}
+
+// RandomIDs is the default IDGenerator, it returns 128 bit random hex IDs.
+var RandomIDs IDGenerator = randomIDs{}
+
+// SetClock sets the clock used by the framework and its middleware.
+func (e *Echo) SetClock(c Clock) {
+	e.env.clock = c
+}
+
+// Clock returns the clock.
+func (e *Echo) Clock() Clock {
+	return e.env.clock
+}
+
+// SetIDGenerator sets the ID generator used by the framework and its
+// middleware.
+func (e *Echo) SetIDGenerator(g IDGenerator) {
+	e.env.ids = g
+}
+
+// IDGenerator returns the ID generator.
+func (e *Echo) IDGenerator() IDGenerator {
+	return e.env.ids
+}
+
+// Now returns the current time of the Echo clock.
+func (c *Context) Now() time.Time {
+	return c.echo.env.clock.Now()
+}
+
+// NewID returns an ID from the Echo ID generator.
+func (c *Context) NewID() string {
+	return c.echo.env.ids.NewID()
+}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (randomIDs) NewID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// NewManualClock returns a ManualClock set at t.
+func NewManualClock(t time.Time) *ManualClock {
+	return &ManualClock{now: t}
+}
+
+// Now implements Clock.
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set sets the time.
+func (c *ManualClock) Set(t time.Time) {
+	c.mu.Lock()
+	c.now = t
+	c.mu.Unlock()
+}
+
+// Advance moves the time forward by d.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// NewID implements IDGenerator.
+func (s *SequenceIDs) NewID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.n++
+	return fmt.Sprintf("%s%d", s.Prefix, s.n)
+}
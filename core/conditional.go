@@ -0,0 +1,101 @@
+package core
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LastModified declares when the resource was last modified and sets the
+// Last-Modified header. If the client's copy is still fresh per its
+// If-Modified-Since header, it answers 304 Not Modified right away and returns
+// true; whatever is written to the response afterwards is dropped, so the
+// handler can return early and skip the expensive serialization:
+//
+//	func show(c *core.Context) error {
+//		doc := load(c.Param("id"))
+//		if c.LastModified(doc.UpdatedAt) {
+//			return nil
+//		}
+//		return c.JSON(http.StatusOK, doc)
+//	}
+//
+// If-None-Match takes precedence, If-Modified-Since is then ignored.
+func (c *Context) LastModified(t time.Time) bool {
+	if t.IsZero() || t.Unix() == 0 {
+		return false
+	}
+	t = t.Truncate(time.Second)
+	c.response.Header().Set(LastModified, t.UTC().Format(http.TimeFormat))
+	r := c.request
+	if (r.Method != GET && r.Method != HEAD) || r.Header.Get(IfNoneMatch) != "" {
+		return false
+	}
+	ims, err := http.ParseTime(r.Header.Get(IfModifiedSince))
+	if err != nil || t.After(ims) {
+		return false
+	}
+	h := c.response.Header()
+	h.Del(ContentType)
+	h.Del(ContentLength)
+	c.response.WriteHeader(http.StatusNotModified)
+	c.response.notModified = true
+	return true
+}
+
+// RequireIfMatch implements ETag based optimistic concurrency for write
+// endpoints. It returns a 428 error when the request carries no If-Match header
+// and a 412 error when none of the listed entity tags matches `etag`, the tag
+// of the current state of the resource. A nil result means the write may go on.
+//
+//	func update(c *core.Context) error {
+//		doc := load(c.Param("id"))
+//		if err := c.RequireIfMatch(doc.Version); err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (c *Context) RequireIfMatch(etag string) error {
+	im := c.request.Header.Get(IfMatch)
+	if im == "" {
+		return NewHTTPError(http.StatusPreconditionRequired)
+	}
+	if !etagMatch(im, quoteETag(etag), false) {
+		return NewHTTPError(http.StatusPreconditionFailed)
+	}
+	return nil
+}
+
+// quoteETag wraps a bare tag in double quotes.
+func quoteETag(etag string) string {
+	if etag == "" || strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+// etagMatch reports whether `etag` is in the comma separated list `list`. With
+// weak false, weak tags never match (strong comparison, RFC 7232 2.3.2).
+func etagMatch(list, etag string, weak bool) bool {
+	if etag == "" {
+		return false
+	}
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+	if weak {
+		etag = strings.TrimPrefix(etag, "W/")
+	} else if strings.HasPrefix(etag, "W/") {
+		return false
+	}
+	for _, t := range strings.Split(list, ",") {
+		t = strings.TrimSpace(t)
+		if weak {
+			t = strings.TrimPrefix(t, "W/")
+		}
+		if t == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLastModifiedGatesBody(t *testing.T) {
+	e := New()
+	mod := time.Date(2016, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, ims := range []string{"", mod.Add(-time.Hour).Format(http.TimeFormat), mod.Format(http.TimeFormat)} {
+		req := httptest.NewRequest(GET, "/", nil)
+		if ims != "" {
+			req.Header.Set(IfModifiedSince, ims)
+		}
+		rec := httptest.NewRecorder()
+		c := NewContext(req, NewResponse(rec, e), e)
+		fresh := c.LastModified(mod)
+		assert.NoError(t, c.JSON(http.StatusOK, map[string]int{"a": 1}))
+		assert.NoError(t, c.String(http.StatusOK, "again"))
+		if ims == mod.Format(http.TimeFormat) {
+			assert.True(t, fresh)
+			assert.Equal(t, http.StatusNotModified, rec.Code)
+			assert.Equal(t, "", rec.Body.String())
+			assert.Equal(t, http.StatusNotModified, c.Response().Status())
+			assert.Equal(t, int64(0), c.Response().Size())
+		} else {
+			assert.False(t, fresh, ims)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), `{"a":1}`)
+		}
+	}
+}
@@ -5,7 +5,6 @@ package core
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/henrylee2cn/thinkgo/core/config"
@@ -16,19 +15,35 @@ type Config struct {
 	AppName       string // 应用名称
 	Debug         bool   // 是否开启调试模式
 	LogLevel      log.Level
-	HttpAddr      string // 应用监听地址，默认为空，监听所有的网卡 IP
-	HttpPort      int    // 应用监听端口，默认为 8080
-	TplSuffix     string // 模板后缀名
-	TplLeft       string // 模板左定界符
-	TplRight      string // 模板右定界符
-	DefaultModule string // 默认模块的名称
+	HttpAddr      string   // 应用监听地址，默认为空，监听所有的网卡 IP
+	HttpPort      int      // 应用监听端口，默认为 8080
+	TplSuffix     string   // 模板后缀名
+	TplLeft       string   // 模板左定界符
+	TplRight      string   // 模板右定界符
+	DefaultModule string   // 默认模块的名称
+	SiteName      string   // 站点名称，用于页面标题与 Open Graph，默认为应用名称
+	SiteURL       string   // 站点根地址，如 https://example.com，用于生成规范链接
+	SiteHosts     []string // 未设置 SiteURL 时，可用于生成规范链接的请求主机名，以 ; 分隔
+	TrustProxies  []string // 可信代理的 IP 或网段，以 ; 分隔，仅信任其转发的客户端 IP 头
 }
 
-func getConfig() Config {
+// getConfig reads conf/app.conf. On error the defaults are returned along
+// with the error, which Think.Run reports.
+func getConfig() (Config, error) {
 	iniconf, err := config.NewConfig("ini", "conf/app.conf")
 	if err != nil {
-		fmt.Println("\n  请确保在项目目录下运行，且存在配置文件 conf/app.conf")
-		os.Exit(1)
+		return Config{
+			AppName:       "thinkgo",
+			Debug:         true,
+			LogLevel:      log.DEBUG,
+			HttpAddr:      "0.0.0.0",
+			HttpPort:      8080,
+			TplSuffix:     ".html",
+			TplLeft:       "{{{",
+			TplRight:      "}}}",
+			DefaultModule: "home",
+			SiteName:      "thinkgo",
+		}, fmt.Errorf("请确保在项目目录下运行，且存在配置文件 conf/app.conf: %v", err)
 	}
 
 	var logLevel log.Level
@@ -53,8 +68,9 @@ func getConfig() Config {
 		logLevel = log.DEBUG
 	}
 	defaultModule := iniconf.DefaultString("defmodule", "home")
+	appName := iniconf.DefaultString("appname", "thinkgo")
 	return Config{
-		AppName:       iniconf.DefaultString("appname", "thinkgo"),
+		AppName:       appName,
 		Debug:         iniconf.DefaultBool("debug", true),
 		LogLevel:      logLevel,
 		HttpAddr:      iniconf.DefaultString("httpaddr", "0.0.0.0"),
@@ -63,5 +79,9 @@ func getConfig() Config {
 		TplLeft:       iniconf.DefaultString("tplleft", "{{{"),
 		TplRight:      iniconf.DefaultString("tplright", "}}}"),
 		DefaultModule: SnakeString(strings.Trim(defaultModule, "/")),
-	}
+		SiteName:      iniconf.DefaultString("sitename", appName),
+		SiteURL:       iniconf.String("siteurl"),
+		SiteHosts:     iniconf.Strings("sitehosts"),
+		TrustProxies:  iniconf.Strings("trustedproxies"),
+	}, nil
 }
@@ -3,14 +3,20 @@ package core
 import (
 	"encoding/json"
 	"encoding/xml"
+	"errors"
+	"io"
 	"net/http"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"net/url"
 
 	"bytes"
 
 	"github.com/henrylee2cn/thinkgo/core/context"
+	"github.com/henrylee2cn/thinkgo/core/msgpack"
+	"github.com/henrylee2cn/thinkgo/core/template"
 	"github.com/henrylee2cn/thinkgo/core/websocket"
 )
 
@@ -19,15 +25,23 @@ type (
 	// response objects, path parameters, data and registered handler.
 	Context struct {
 		context.Context
-		request  *http.Request
-		response *Response
-		socket   *websocket.Conn
-		path     string
-		pnames   []string
-		pvalues  []string
-		query    url.Values
-		store    store
-		echo     *Echo
+		request    *http.Request
+		response   *Response
+		socket     *websocket.Conn
+		path       string
+		pnames     []string
+		pvalues    []string
+		trace      *MatchTrace
+		query      url.Values
+		store      store
+		echo       *Echo
+		locale     string
+		tenant     string
+		validation *Validation
+		bound      interface{}
+		sent       interface{} // value last sent as JSON
+		requestID  string
+		sse        *EventWriter
 		// @ modified by henrylee2cn 2016.2.2
 		Layout   string            // 模板布局
 		Sections map[string]string // 子模板
@@ -37,13 +51,17 @@ type (
 
 // NewContext creates a Context object.
 func NewContext(req *http.Request, res *Response, e *Echo) *Context {
-	return &Context{
+	c := &Context{
 		request:  req,
 		response: res,
 		echo:     e,
 		pvalues:  make([]string, *e.maxParam),
 		store:    make(store),
 	}
+	if req != nil {
+		c.Context = req.Context()
+	}
+	return c
 }
 
 // Request returns *http.Request.
@@ -131,20 +149,97 @@ func (c *Context) TrySet(key string, val interface{}) {
 	}
 }
 
+// RequestID returns the ID correlating the request across services, see
+// middleware.RequestID.
+func (c *Context) RequestID() string {
+	return c.requestID
+}
+
+// SetRequestID sets the request ID.
+func (c *Context) SetRequestID(id string) {
+	c.requestID = id
+}
+
 // Bind binds the request body into specified type `i`. The default binder does
-// it based on Content-Type header.
+// it based on Content-Type header, bodiless GET, HEAD and DELETE requests are
+// bound from the query string, other bodiless requests skip the binder.
+// Struct fields tagged `param:"id"`, `header:"X-Token"` or `query:"q"` are
+// then set from the path parameters, the request headers and the query
+// string, whatever the binder. The result is finally validated, see
+// Echo.SetValidator.
 func (c *Context) Bind(i interface{}) error {
-	return c.echo.binder.Bind(c.request, i)
+	c.bound = i
+	if c.request.ContentLength != 0 || bindsQuery(c.request.Method) {
+		if err := c.echo.getBinder().Bind(c.request, i); err != nil {
+			return err
+		}
+	}
+	if err := c.bindTagged(i); err != nil {
+		return err
+	}
+	return c.validate(i)
+}
+
+func (c *Context) bindTagged(i interface{}) error {
+	err := decodeTagged(i, "param", func(name string) []string {
+		for j, n := range c.pnames {
+			if n == name && j < len(c.pvalues) {
+				return []string{c.pvalues[j]}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = decodeTagged(i, "header", func(name string) []string {
+		return c.request.Header[http.CanonicalHeaderKey(name)]
+	})
+	if err != nil {
+		return err
+	}
+	return decodeTagged(i, "query", func(name string) []string {
+		return c.request.URL.Query()[name]
+	})
+}
+
+// BindQuery binds the query string into specified type `i`, using the `form`
+// tag for field names.
+func (c *Context) BindQuery(i interface{}) error {
+	return DecodeForm(c.request.URL.Query(), i)
+}
+
+// CacheControl returns a builder for the Cache-Control response header, seeded
+// with the value already set (e.g. by a Group default).
+func (c *Context) CacheControl() *CacheControl {
+	return newCacheControl(c.response.Header())
 }
 
 // Render renders a template with data and sends a text/html response with status
-// code. Templates can be registered using `Echo.SetRenderer()`.
+// code. Templates can be registered using `Echo.SetRenderer()`, or
+// `Echo.AddRenderer()` for the engine of a file extension.
+//
+// When the request has a tenant and the renderer implements TemplateIndex, a
+// template named `Context#TenantKey(name)`, e.g. "acme:/home/default/index/index",
+// overrides the one named name.
 func (c *Context) Render(code int, name string, data interface{}) (err error) {
-	if c.echo.renderer == nil {
-		return RendererNotRegistered
+	return c.render(code, c.echo.rendererFor(name), name, data)
+}
+
+// RenderFuncs is Render with funcs overriding the template functions
+// registered with Echo.SetTemplateFuncs for this render only, e.g. a
+// translation function bound to the locale of the request.
+func (c *Context) RenderFuncs(code int, name string, data interface{}, funcs template.FuncMap) (err error) {
+	r := c.echo.rendererFor(name)
+	fr, ok := r.(FuncRenderer)
+	if !ok {
+		if r == nil {
+			return RendererNotRegistered
+		}
+		return errors.New("renderer doesn't support template functions")
 	}
 	buf := new(bytes.Buffer)
-	if err = c.echo.renderer.Render(buf, name, data); err != nil {
+	if err = fr.RenderFuncs(buf, c.tenantView(r, name), data, funcs); err != nil {
 		return
 	}
 	c.response.Header().Set(ContentType, TextHTMLCharsetUTF8)
@@ -169,8 +264,13 @@ func (c *Context) String(code int, s string) (err error) {
 	return
 }
 
-// JSON sends a JSON response with status code.
+// JSON sends a JSON response with status code. The output is indented in
+// debug mode or when the query has a `pretty` flag, e.g. `?pretty`.
 func (c *Context) JSON(code int, i interface{}) (err error) {
+	if c.pretty() {
+		return c.JSONIndent(code, i, "", "  ")
+	}
+	c.sent = i
 	b, err := json.Marshal(i)
 	if err != nil {
 		return err
@@ -183,6 +283,7 @@ func (c *Context) JSON(code int, i interface{}) (err error) {
 
 // JSONIndent sends a JSON response with status code, but it applies prefix and indent to format the output.
 func (c *Context) JSONIndent(code int, i interface{}, prefix string, indent string) (err error) {
+	c.sent = i
 	b, err := json.MarshalIndent(i, prefix, indent)
 	if err != nil {
 		return err
@@ -191,6 +292,22 @@ func (c *Context) JSONIndent(code int, i interface{}, prefix string, indent stri
 	return
 }
 
+// pretty reports whether JSON and XML output should be indented: in debug
+// mode, or if the query has a `pretty` flag which isn't "false" or "0".
+func (c *Context) pretty() bool {
+	if c.echo.debug {
+		return true
+	}
+	if c.request.URL.RawQuery == "" {
+		return false
+	}
+	if c.query == nil {
+		c.query = c.request.URL.Query()
+	}
+	v, ok := c.query["pretty"]
+	return ok && (len(v) == 0 || (v[0] != "false" && v[0] != "0"))
+}
+
 func (c *Context) json(code int, b []byte) {
 	c.response.Header().Set(ContentType, ApplicationJSONCharsetUTF8)
 	c.response.WriteHeader(code)
@@ -198,22 +315,75 @@ func (c *Context) json(code int, b []byte) {
 }
 
 // JSONP sends a JSONP response with status code. It uses `callback` to construct
-// the JSONP payload.
+// the JSONP payload, e.g. `c.JSONP(http.StatusOK, c.Query("callback"), data)`.
+//
+// The callback must be a dotted JavaScript identifier such as `jQuery123_4` or
+// `app.cb`, else 400 Bad Request is returned, so it can't inject script. The
+// payload is prefixed with an empty comment and sent with
+// `X-Content-Type-Options: nosniff` against content sniffing attacks.
 func (c *Context) JSONP(code int, callback string, i interface{}) (err error) {
+	if !validCallback(callback) {
+		return NewHTTPError(http.StatusBadRequest, "invalid JSONP callback")
+	}
 	b, err := json.Marshal(i)
 	if err != nil {
 		return err
 	}
-	c.response.Header().Set(ContentType, ApplicationJavaScriptCharsetUTF8)
+	buf := make([]byte, 0, len(callback)+len(b)+7)
+	buf = append(buf, "/**/"...)
+	buf = append(buf, callback...)
+	buf = append(buf, '(')
+	buf = append(buf, b...)
+	buf = append(buf, ");"...)
+	h := c.response.Header()
+	h.Set(ContentType, ApplicationJavaScriptCharsetUTF8)
+	h.Set(XContentTypeOptions, "nosniff")
 	c.response.WriteHeader(code)
-	c.response.Write([]byte(callback + "("))
-	c.response.Write(b)
-	c.response.Write([]byte(");"))
+	_, err = c.response.Write(buf)
 	return
 }
 
-// XML sends an XML response with status code.
+// validCallback reports whether s is a dotted JavaScript identifier.
+func validCallback(s string) bool {
+	if s == "" || len(s) > 128 {
+		return false
+	}
+	start := true
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch == '.' && !start:
+			start = true
+			continue
+		case ch == '_' || ch == '$' || 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z':
+		case '0' <= ch && ch <= '9' && !start:
+		default:
+			return false
+		}
+		start = false
+	}
+	return !start
+}
+
+// Msgpack sends a MessagePack response with status code, the counterpart of
+// binding application/msgpack bodies. It's encoded with the msgpack package,
+// see its documentation for the `msgpack` struct tags.
+func (c *Context) Msgpack(code int, i interface{}) (err error) {
+	b, err := msgpack.Marshal(i)
+	if err != nil {
+		return err
+	}
+	c.response.Header().Set(ContentType, ApplicationMsgpack)
+	c.response.WriteHeader(code)
+	_, err = c.response.Write(b)
+	return
+}
+
+// XML sends an XML response with status code, indented like JSON.
 func (c *Context) XML(code int, i interface{}) (err error) {
+	if c.pretty() {
+		return c.XMLIndent(code, i, "", "  ")
+	}
 	b, err := xml.Marshal(i)
 	if err != nil {
 		return err
@@ -247,9 +417,31 @@ func (c *Context) xml(code int, b []byte) {
 // to true, the client is prompted to save the file with provided `name`,
 // name can be empty, in that case name of the file is used.
 func (c *Context) File(path, name string, attachment bool) (err error) {
-	dir, file := filepath.Split(path)
 	if attachment {
-		c.response.Header().Set(ContentDisposition, "attachment; filename="+name)
+		return c.Attachment(path, name)
+	}
+	return c.file(path, "", "")
+}
+
+// Attachment sends the file at path as a download, the client being prompted
+// to save it as name, the name of the file if empty.
+func (c *Context) Attachment(path, name string) error {
+	return c.file(path, "attachment", name)
+}
+
+// Inline sends the file at path to be shown by the client, name being the
+// name to save it as, the name of the file if empty.
+func (c *Context) Inline(path, name string) error {
+	return c.file(path, "inline", name)
+}
+
+func (c *Context) file(path, disposition, name string) (err error) {
+	dir, file := filepath.Split(path)
+	if disposition != "" {
+		if name == "" {
+			name = file
+		}
+		c.response.Header().Set(ContentDisposition, contentDisposition(disposition, name))
 	}
 	fs := http.Dir(dir)
 	if err = c.echo.serveFile(fs, file, c); err != nil {
@@ -258,6 +450,53 @@ func (c *Context) File(path, name string, attachment bool) (err error) {
 	return
 }
 
+// ServeContent sends the content of rs, e.g. a file fetched from object
+// storage, like static files are sent: Range and If-Range requests are served
+// so downloads can resume, conditional requests are answered with 304, and
+// Content-Length is set. Content-Type, unless set, is guessed from the
+// extension of name, else from the content. A zero modtime leaves
+// Last-Modified out.
+func (c *Context) ServeContent(name string, modtime time.Time, rs io.ReadSeeker) error {
+	http.ServeContent(c.response, c.request, name, modtime, rs)
+	return nil
+}
+
+// contentDisposition returns the Content-Disposition header value proposing
+// the file name, with an ASCII fallback and the RFC 5987 encoded name when
+// it's not plain ASCII.
+func contentDisposition(disposition, name string) string {
+	ascii := true
+	fallback := make([]byte, 0, len(name))
+	for _, r := range name {
+		switch {
+		case r == '"' || r == '\\':
+			fallback = append(fallback, '\\', byte(r))
+		case r < ' ' || r == 0x7f:
+			ascii = false
+		case r > 0x7f:
+			ascii = false
+			fallback = append(fallback, '_')
+		default:
+			fallback = append(fallback, byte(r))
+		}
+	}
+	v := disposition + `; filename="` + string(fallback) + `"`
+	if ascii {
+		return v
+	}
+	const hex = "0123456789ABCDEF"
+	enc := make([]byte, 0, len(name)*3)
+	for i := 0; i < len(name); i++ {
+		b := name[i]
+		if 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' || strings.IndexByte("!#$&+-.^_`|~", b) != -1 {
+			enc = append(enc, b)
+		} else {
+			enc = append(enc, '%', hex[b>>4], hex[b&15])
+		}
+	}
+	return v + "; filename*=UTF-8''" + string(enc)
+}
+
 // NoContent sends a response with no body and a status code.
 func (c *Context) NoContent(code int) error {
 	c.response.WriteHeader(code)
@@ -285,12 +524,23 @@ func (c *Context) Echo() *Echo {
 
 func (c *Context) reset(r *http.Request, w http.ResponseWriter, e *Echo) {
 	c.request = r
+	c.Context = r.Context()
 	c.response.reset(w, e)
 	c.query = nil
 	c.store = nil
 	c.echo = e
-}
-
+	c.locale = ""
+	c.tenant = ""
+	c.validation = nil
+	c.bound = nil
+	c.sent = nil
+	c.requestID = ""
+	c.sse = nil
+}
+
+// SetCookie sets a cookie whose value is query escaped, see GetCookie.
+// The optional others are the max age, path, domain, secure and http only
+// flags, in that order. The cookie defaults aren't applied, see WriteCookie.
 // @ modified by ikfmt 2016.1.20
 func (c *Context) SetCookie(name string, value string, others ...interface{}) {
 	cookie := http.Cookie{}
@@ -0,0 +1,240 @@
+package core
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core/msgpack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONP(t *testing.T) {
+	e := New()
+	for _, tt := range []struct {
+		callback string
+		valid    bool
+	}{
+		{"cb", true},
+		{"jQuery123_4", true},
+		{"app.cb", true},
+		{"$._cb.$1", true},
+		{"", false},
+		{"1cb", false},
+		{"app.1cb", false},
+		{".cb", false},
+		{"cb.", false},
+		{"app..cb", false},
+		{"cb(1);alert", false},
+		{"cb<script>", false},
+		{"cb\n", false},
+		{"café", false},
+	} {
+		req := httptest.NewRequest(GET, "/", nil)
+		rec := httptest.NewRecorder()
+		c := NewContext(req, NewResponse(rec, e), e)
+		err := c.JSONP(http.StatusOK, tt.callback, map[string]string{"a": "</script>"})
+		if !tt.valid {
+			if assert.Error(t, err, tt.callback) {
+				he, ok := err.(*HTTPError)
+				assert.True(t, ok, tt.callback)
+				assert.Equal(t, http.StatusBadRequest, he.Code(), tt.callback)
+			}
+			assert.Equal(t, "", rec.Body.String(), tt.callback)
+			continue
+		}
+		assert.NoError(t, err, tt.callback)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, ApplicationJavaScriptCharsetUTF8, rec.Header().Get(ContentType))
+		assert.Equal(t, "nosniff", rec.Header().Get(XContentTypeOptions))
+		// The exact payload, HTML escaped so it can't close a script element
+		assert.Equal(t, "/**/"+tt.callback+`({"a":"\u003c/script\u003e"});`, rec.Body.String())
+	}
+	assert.False(t, validCallback(string(make([]byte, 129))))
+}
+
+// failingWriter is a ResponseWriter whose writes fail, e.g. once the client
+// went away.
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestMsgpack(t *testing.T) {
+	type doc struct {
+		Name string   `msgpack:"name"`
+		Tags []string `msgpack:"tags"`
+	}
+	e := New()
+	rec := httptest.NewRecorder()
+	c := NewContext(httptest.NewRequest(GET, "/", nil), NewResponse(rec, e), e)
+	assert.NoError(t, c.Msgpack(http.StatusCreated, doc{"bob", []string{"a", "b"}}))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, ApplicationMsgpack, rec.Header().Get(ContentType))
+	var got doc
+	assert.NoError(t, msgpack.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, doc{"bob", []string{"a", "b"}}, got)
+
+	// Values which don't encode send nothing
+	rec = httptest.NewRecorder()
+	c = NewContext(httptest.NewRequest(GET, "/", nil), NewResponse(rec, e), e)
+	assert.Error(t, c.Msgpack(http.StatusOK, make(chan int)))
+	assert.Equal(t, "", rec.Header().Get(ContentType))
+	assert.Equal(t, 0, rec.Body.Len())
+
+	// The error of the write is returned
+	w := failingWriter{httptest.NewRecorder()}
+	c = NewContext(httptest.NewRequest(GET, "/", nil), NewResponse(w, e), e)
+	assert.Equal(t, "broken pipe", fmt.Sprint(c.Msgpack(http.StatusOK, doc{Name: "bob"})))
+}
+
+func TestPretty(t *testing.T) {
+	type doc struct {
+		A int `json:"a" xml:"a"`
+	}
+	const (
+		flatJSON   = `{"a":1}`
+		indentJSON = "{\n  \"a\": 1\n}"
+		flatXML    = xml.Header + `<doc><a>1</a></doc>`
+		indentXML  = xml.Header + "<doc>\n  <a>1</a>\n</doc>"
+	)
+	for _, tt := range []struct {
+		debug  bool
+		query  string
+		pretty bool
+	}{
+		{false, "", false},
+		{false, "?pretty", true},
+		{false, "?pretty=", true},
+		{false, "?pretty=1", true},
+		{false, "?pretty=true", true},
+		{false, "?pretty=0", false},
+		{false, "?pretty=false", false},
+		{false, "?other=1", false},
+		{true, "", true},
+		{true, "?pretty=0", true},
+	} {
+		name := fmt.Sprint("debug ", tt.debug, " ", tt.query)
+		e := New()
+		e.SetDebug(tt.debug)
+		serve := func(f func(c *Context) error) string {
+			req := httptest.NewRequest(GET, "/"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			assert.NoError(t, f(NewContext(req, NewResponse(rec, e), e)), name)
+			return rec.Body.String()
+		}
+		j := serve(func(c *Context) error { return c.JSON(http.StatusOK, doc{1}) })
+		x := serve(func(c *Context) error { return c.XML(http.StatusOK, doc{1}) })
+		if tt.pretty {
+			assert.Equal(t, indentJSON, j, name)
+			assert.Equal(t, indentXML, x, name)
+		} else {
+			assert.Equal(t, flatJSON, j, name)
+			assert.Equal(t, flatXML, x, name)
+		}
+	}
+}
+
+func TestServeContent(t *testing.T) {
+	e := New()
+	mod := time.Date(2016, 3, 1, 12, 0, 0, 0, time.UTC)
+	serve := func(name string, modtime time.Time, h http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(GET, "/", nil)
+		for k, v := range h {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		c := NewContext(req, NewResponse(rec, e), e)
+		assert.NoError(t, c.ServeContent(name, modtime, strings.NewReader("0123456789")))
+		return rec
+	}
+
+	rec := serve("report.csv", mod, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "0123456789", rec.Body.String())
+	assert.Equal(t, "10", rec.Header().Get(ContentLength))
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get(ContentType))
+	assert.Equal(t, mod.Format(http.TimeFormat), rec.Header().Get(LastModified))
+	assert.Equal(t, "bytes", rec.Header().Get("Accept-Ranges"))
+
+	// Resumed download
+	rec = serve("report.csv", mod, http.Header{"Range": {"bytes=4-"}})
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Equal(t, "456789", rec.Body.String())
+	assert.Equal(t, "bytes 4-9/10", rec.Header().Get("Content-Range"))
+
+	// The content changed since the download started
+	rec = serve("report.csv", mod, http.Header{"Range": {"bytes=4-"}, "If-Range": {mod.Add(-time.Hour).Format(http.TimeFormat)}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "0123456789", rec.Body.String())
+
+	rec = serve("report.csv", mod, http.Header{IfModifiedSince: {mod.Format(http.TimeFormat)}})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, "", rec.Body.String())
+
+	// Content type sniffed, no Last-Modified
+	rec = serve("report", time.Time{}, nil)
+	assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get(ContentType))
+	assert.Equal(t, "", rec.Header().Get(LastModified))
+}
+
+func TestContentDisposition(t *testing.T) {
+	for _, tt := range []struct {
+		name, value string
+	}{
+		{"report.pdf", `attachment; filename="report.pdf"`},
+		{"my report (1).pdf", `attachment; filename="my report (1).pdf"`},
+		{`say "hi".txt`, `attachment; filename="say \"hi\".txt"`},
+		{`a\b.txt`, `attachment; filename="a\\b.txt"`},
+		{"résumé.pdf", `attachment; filename="r_sum_.pdf"; filename*=UTF-8''r%C3%A9sum%C3%A9.pdf`},
+		{"報告.pdf", `attachment; filename="__.pdf"; filename*=UTF-8''%E5%A0%B1%E5%91%8A.pdf`},
+		{"a\r\nSet-Cookie: x.txt", `attachment; filename="aSet-Cookie: x.txt"; filename*=UTF-8''a%0D%0ASet-Cookie%3A%20x.txt`},
+		{"tab\there\x7f.txt", `attachment; filename="tabhere.txt"; filename*=UTF-8''tab%09here%7F.txt`},
+		{"\"é\".txt", `attachment; filename="\"_\".txt"; filename*=UTF-8''%22%C3%A9%22.txt`},
+		{"bad\xff.txt", `attachment; filename="bad_.txt"; filename*=UTF-8''bad%FF.txt`},
+	} {
+		assert.Equal(t, tt.value, contentDisposition("attachment", tt.name), tt.name)
+	}
+	assert.Equal(t, `inline; filename="a.png"`, contentDisposition("inline", "a.png"))
+}
+
+func TestAttachment(t *testing.T) {
+	f, err := ioutil.TempFile("", "attachment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("content")
+	f.Close()
+
+	e := New()
+	serve := func(fn func(c *Context) error) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c := NewContext(httptest.NewRequest(GET, "/", nil), NewResponse(rec, e), e)
+		if err := fn(c); err != nil {
+			e.httpErrorHandler(err, c)
+		}
+		return rec
+	}
+	rec := serve(func(c *Context) error { return c.Attachment(f.Name(), "données.csv") })
+	assert.Equal(t, "content", rec.Body.String())
+	assert.Equal(t, `attachment; filename="donn_es.csv"; filename*=UTF-8''donn%C3%A9es.csv`, rec.Header().Get(ContentDisposition))
+	rec = serve(func(c *Context) error { return c.Inline(f.Name(), "") })
+	assert.Equal(t, `inline; filename="`+filepath.Base(f.Name())+`"`, rec.Header().Get(ContentDisposition))
+
+	// Not left on the error response
+	rec = serve(func(c *Context) error { return c.Attachment(f.Name()+".missing", "a.csv") })
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "", rec.Header().Get(ContentDisposition))
+}
@@ -0,0 +1,59 @@
+// Package ace adapts the ace template engine to core through contrib/render.
+// A template name is "base:inner", or "base" alone:
+//
+//	e.SetRenderer(render.Must(render.New(ace.New("views"), e.Debug())))
+//	...
+//	c.Render(http.StatusOK, "layout:users/index", users)
+package ace
+
+import (
+	"html/template"
+	"io"
+	"strings"
+
+	aceengine "github.com/yosssi/ace"
+)
+
+// Engine renders ace templates of a directory.
+type Engine struct {
+	// Funcs are the functions available inside templates.
+	Funcs template.FuncMap
+
+	dir string
+	ext string
+}
+
+// New returns the engine of the templates of dir, named *.ace.
+func New(dir string) *Engine {
+	return &Engine{dir: dir, ext: "ace"}
+}
+
+// SetExt sets the extension of the template files, without the dot.
+func (e *Engine) SetExt(ext string) *Engine {
+	e.ext = strings.TrimPrefix(ext, ".")
+	return e
+}
+
+// Load implements render.Engine, it drops the compiled templates, which ace
+// caches on first use.
+func (e *Engine) Load() error {
+	aceengine.FlushCache()
+	return nil
+}
+
+// Execute implements render.Engine.
+func (e *Engine) Execute(w io.Writer, name string, data interface{}) error {
+	base, inner := name, ""
+	if i := strings.IndexByte(name, ':'); i != -1 {
+		base, inner = name[:i], name[i+1:]
+	}
+	tpl, err := aceengine.Load(base, inner, &aceengine.Options{
+		BaseDir:   e.dir,
+		Extension: e.ext,
+		FuncMap:   e.Funcs,
+	})
+	if err != nil {
+		return err
+	}
+	return tpl.Execute(w, data)
+}
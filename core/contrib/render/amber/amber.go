@@ -0,0 +1,56 @@
+// Package amber adapts the amber template engine to core through
+// contrib/render. Templates are named by path relative to the directory,
+// without extension:
+//
+//	e.AddRenderer("amber", render.Must(render.New(amber.New("views"), e.Debug())))
+//	...
+//	c.RenderWith(http.StatusOK, "amber", "users/index", users)
+package amber
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+
+	amberengine "github.com/eknkc/amber"
+)
+
+// Engine renders the amber templates of a directory.
+type Engine struct {
+	// Options are the compiler options.
+	Options amberengine.Options
+
+	dir       string
+	ext       string
+	templates map[string]*template.Template
+}
+
+// New returns the engine of the templates of dir, named *.amber.
+func New(dir string) *Engine {
+	return &Engine{dir: dir, ext: ".amber", Options: amberengine.DefaultOptions}
+}
+
+// SetExt sets the extension of the template files.
+func (e *Engine) SetExt(ext string) *Engine {
+	e.ext = ext
+	return e
+}
+
+// Load implements render.Engine, it compiles every template of the directory.
+func (e *Engine) Load() error {
+	templates, err := amberengine.CompileDir(e.dir, amberengine.DirOptions{Ext: e.ext, Recursive: true}, e.Options)
+	if err != nil {
+		return err
+	}
+	e.templates = templates
+	return nil
+}
+
+// Execute implements render.Engine.
+func (e *Engine) Execute(w io.Writer, name string, data interface{}) error {
+	tpl, ok := e.templates[name]
+	if !ok {
+		return fmt.Errorf("amber: template %q not found", name)
+	}
+	return tpl.Execute(w, data)
+}
@@ -0,0 +1,67 @@
+// Package pongo2 adapts the pongo2 template engine, Django syntax, to
+// core through contrib/render:
+//
+//	e.SetRenderer(render.Must(render.New(pongo2.New("views"), e.Debug())))
+//	...
+//	c.Render(http.StatusOK, "users/index.html", map[string]interface{}{"users": users})
+package pongo2
+
+import (
+	"io"
+
+	p2 "github.com/flosch/pongo2"
+)
+
+// Engine renders pongo2 templates by file name relative to a directory.
+type Engine struct {
+	dir string
+	set *p2.TemplateSet
+}
+
+// New returns the engine of the templates of dir.
+func New(dir string) *Engine {
+	return &Engine{dir: dir}
+}
+
+// Set returns the template set, e.g. to register globals or filters. It's
+// nil until the engine is loaded.
+func (e *Engine) Set() *p2.TemplateSet {
+	return e.set
+}
+
+// Load implements render.Engine, it starts a new set, templates are compiled
+// on first use.
+func (e *Engine) Load() error {
+	loader, err := p2.NewLocalFileSystemLoader(e.dir)
+	if err != nil {
+		return err
+	}
+	set := p2.NewSet(e.dir, loader)
+	if e.set != nil {
+		set.Globals = e.set.Globals
+	}
+	e.set = set
+	return nil
+}
+
+// Execute implements render.Engine. data is a map of the template variables,
+// any other value is available as "data".
+func (e *Engine) Execute(w io.Writer, name string, data interface{}) error {
+	tpl, err := e.set.FromCache(name)
+	if err != nil {
+		return err
+	}
+	return tpl.ExecuteWriter(context(data), w)
+}
+
+func context(data interface{}) p2.Context {
+	switch data := data.(type) {
+	case p2.Context:
+		return data
+	case map[string]interface{}:
+		return p2.Context(data)
+	case nil:
+		return nil
+	}
+	return p2.Context{"data": data}
+}
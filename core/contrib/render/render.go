@@ -0,0 +1,76 @@
+// Package render plugs third-party template engines into core.
+//
+// An adapter implements Engine, and New turns it into a core.Renderer:
+//
+//	r, err := render.New(pongo2.New("views"), e.Debug())
+//	...
+//	e.SetRenderer(r)                     // or
+//	e.AddRenderer(".amber", render.Must(render.New(amber.New("views"), false)))
+//
+// Adapters for pongo2, ace and amber live in the subpackages of the same name.
+package render
+
+import (
+	"io"
+	"sync"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// Engine is the contract of a template engine adapter.
+	Engine interface {
+		// Load parses the templates, or drops the cached ones. It's called
+		// once by New, then before every render in reload mode.
+		Load() error
+
+		// Execute renders the template name with data into w.
+		Execute(w io.Writer, name string, data interface{}) error
+	}
+
+	// Renderer is a core.Renderer rendering through an Engine.
+	Renderer struct {
+		engine Engine
+		reload bool
+		mu     sync.RWMutex
+	}
+)
+
+var _ core.Renderer = (*Renderer)(nil)
+
+// New loads the templates of engine and returns its Renderer. In reload mode,
+// meant for development, templates are loaded again before every render.
+func New(engine Engine, reload bool) (*Renderer, error) {
+	if err := engine.Load(); err != nil {
+		return nil, err
+	}
+	return &Renderer{engine: engine, reload: reload}, nil
+}
+
+// Must returns r, it panics if err isn't nil.
+func Must(r *Renderer, err error) *Renderer {
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Engine returns the engine of r.
+func (r *Renderer) Engine() Engine {
+	return r.engine
+}
+
+// Render implements core.Renderer.
+func (r *Renderer) Render(w io.Writer, name string, data interface{}) error {
+	if r.reload {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if err := r.engine.Load(); err != nil {
+			return err
+		}
+		return r.engine.Execute(w, name, data)
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.engine.Execute(w, name, data)
+}
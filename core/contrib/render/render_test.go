@@ -0,0 +1,51 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeEngine struct {
+	loads int
+}
+
+func (e *fakeEngine) Load() error {
+	e.loads++
+	return nil
+}
+
+func (e *fakeEngine) Execute(w io.Writer, name string, data interface{}) error {
+	_, err := fmt.Fprintf(w, "%s %v %d", name, data, e.loads)
+	return err
+}
+
+func TestRenderer(t *testing.T) {
+	engine := new(fakeEngine)
+	e := core.New()
+	e.SetRenderer(Must(New(engine, false)))
+	e.Get("/", func(c *core.Context) error {
+		return c.Render(http.StatusOK, "index", "data")
+	})
+	req, _ := http.NewRequest(core.GET, "/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, "index data 1", rec.Body.String())
+	assert.Equal(t, core.TextHTMLCharsetUTF8, rec.Header().Get(core.ContentType))
+
+	// Reload
+	r := Must(New(engine, true))
+	s, _ := rendered(r, "x")
+	assert.Equal(t, "x <nil> 3", s)
+}
+
+func rendered(r core.Renderer, name string) (string, error) {
+	rec := httptest.NewRecorder()
+	err := r.Render(rec, name, nil)
+	return rec.Body.String(), err
+}
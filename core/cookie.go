@@ -0,0 +1,60 @@
+package core
+
+import "net/http"
+
+// CookieDefaults are the fields of the cookies made by Context.DefaultCookie.
+type CookieDefaults struct {
+	Path string
+	// HttpOnly hides the cookies from scripts.
+	HttpOnly bool
+	SameSite http.SameSite
+	// SecureOnTLS marks the cookies Secure when the request came over TLS.
+	SecureOnTLS bool
+}
+
+// DefaultCookieDefaults are the cookie defaults of a new Echo.
+var DefaultCookieDefaults = CookieDefaults{
+	Path:        "/",
+	HttpOnly:    true,
+	SameSite:    http.SameSiteLaxMode,
+	SecureOnTLS: true,
+}
+
+// SetCookieDefaults sets the fields of the cookies made by
+// Context.DefaultCookie.
+func (e *Echo) SetCookieDefaults(d CookieDefaults) {
+	e.env.cookie = d
+}
+
+// Cookie returns the named request cookie, or http.ErrNoCookie.
+// Unlike GetCookie, the value isn't unescaped.
+func (c *Context) Cookie(name string) (*http.Cookie, error) {
+	return c.request.Cookie(name)
+}
+
+// Cookies returns the request cookies.
+func (c *Context) Cookies() []*http.Cookie {
+	return c.request.Cookies()
+}
+
+// DefaultCookie returns a cookie with the cookie defaults of the Echo, see
+// SetCookieDefaults, for the caller to change before sending it with
+// WriteCookie, e.g. turning HttpOnly off for a cookie read by scripts.
+func (c *Context) DefaultCookie(name, value string) *http.Cookie {
+	d := c.echo.env.cookie
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     d.Path,
+		HttpOnly: d.HttpOnly,
+		SameSite: d.SameSite,
+		Secure:   d.SecureOnTLS && c.request.TLS != nil,
+	}
+}
+
+// WriteCookie adds a Set-Cookie header for cookie as it is, the cookie
+// defaults only come with DefaultCookie: a field left unset can't be told
+// from one turned off on purpose.
+func (c *Context) WriteCookie(cookie *http.Cookie) {
+	http.SetCookie(c.response, cookie)
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteCookie(t *testing.T) {
+	e := New()
+	set := func(secure bool, fn func(c *Context) *http.Cookie) *http.Cookie {
+		req := httptest.NewRequest(GET, "/", nil)
+		if secure {
+			req.TLS = &tls.ConnectionState{}
+		}
+		rec := httptest.NewRecorder()
+		c := NewContext(req, NewResponse(rec, e), e)
+		c.WriteCookie(fn(c))
+		return (&http.Response{Header: rec.Header()}).Cookies()[0]
+	}
+
+	// DefaultCookie fills in the defaults
+	got := set(true, func(c *Context) *http.Cookie { return c.DefaultCookie("a", "1") })
+	assert.Equal(t, "a", got.Name)
+	assert.Equal(t, "1", got.Value)
+	assert.Equal(t, "/", got.Path)
+	assert.True(t, got.HttpOnly)
+	assert.True(t, got.Secure)
+	assert.Equal(t, http.SameSiteLaxMode, got.SameSite)
+	assert.False(t, set(false, func(c *Context) *http.Cookie { return c.DefaultCookie("a", "1") }).Secure)
+
+	// which the caller can turn off, e.g. for a cookie read by scripts
+	got = set(true, func(c *Context) *http.Cookie {
+		ck := c.DefaultCookie("csrf", "t")
+		ck.HttpOnly = false
+		return ck
+	})
+	assert.False(t, got.HttpOnly)
+	assert.True(t, got.Secure)
+
+	// WriteCookie sends a cookie as it is
+	got = set(true, func(c *Context) *http.Cookie { return &http.Cookie{Name: "a", Value: "1"} })
+	assert.Equal(t, "", got.Path)
+	assert.False(t, got.HttpOnly)
+	assert.False(t, got.Secure)
+
+	// Other defaults
+	e.SetCookieDefaults(CookieDefaults{Path: "/admin", SameSite: http.SameSiteStrictMode})
+	got = set(true, func(c *Context) *http.Cookie { return c.DefaultCookie("a", "1") })
+	assert.Equal(t, "/admin", got.Path)
+	assert.Equal(t, http.SameSiteStrictMode, got.SameSite)
+	assert.False(t, got.HttpOnly)
+	assert.False(t, got.Secure)
+}
+
+func TestSetCookie(t *testing.T) {
+	e := New()
+	rec := httptest.NewRecorder()
+	c := NewContext(httptest.NewRequest(GET, "/", nil), NewResponse(rec, e), e)
+	c.SetCookie("name", "a b&c", 60, "/x")
+	assert.Equal(t, "name=a+b%26c; Path=/x; Max-Age=60", rec.Header().Get("Set-Cookie"))
+
+	req := httptest.NewRequest(GET, "/", nil)
+	req.Header.Set("Cookie", "name=a+b%26c")
+	c = NewContext(req, NewResponse(httptest.NewRecorder(), e), e)
+	assert.Equal(t, "a b&c", c.GetCookie("name"))
+}
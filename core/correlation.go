@@ -0,0 +1,74 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// correlatingTransport propagates the correlation headers of a Context to the
+// requests it sends.
+type correlatingTransport struct {
+	c    *Context
+	base http.RoundTripper
+}
+
+// Correlate prepares an outgoing request to another service on behalf of the
+// current one: it carries the request ID, locale, tenant and the time left
+// before the deadline (in milliseconds) as X-Request-ID, X-Locale, X-Tenant
+// and X-Request-Timeout. A request without context of its own is canceled
+// along with the current request. The receiving side restores them with the
+// Correlation middleware, if it trusts the caller.
+func (c *Context) Correlate(req *http.Request) *http.Request {
+	ctx := req.Context()
+	if ctx == context.Background() {
+		ctx = c.StdContext()
+	}
+	req = req.WithContext(ctx)
+	req.Header = cloneHeader(req.Header)
+	if c.requestID != "" {
+		req.Header.Set(XRequestID, c.requestID)
+	}
+	if c.locale != "" {
+		req.Header.Set(XLocale, c.locale)
+	}
+	if c.tenant != "" {
+		req.Header.Set(XTenant, c.tenant)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		ms := int64(deadline.Sub(c.Now()) / time.Millisecond)
+		if ms < 1 {
+			ms = 1
+		}
+		req.Header.Set(XRequestTimeout, strconv.FormatInt(ms, 10))
+	}
+	return req
+}
+
+// Client returns a copy of base (http.DefaultClient if nil) which correlates
+// every request it sends, see Correlate. It must not outlive the request.
+func (c *Context) Client(base *http.Client) *http.Client {
+	if base == nil {
+		base = http.DefaultClient
+	}
+	client := *base
+	rt := client.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	client.Transport = &correlatingTransport{c: c, base: rt}
+	return &client
+}
+
+func (t *correlatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(t.c.Correlate(req))
+}
+
+func cloneHeader(h http.Header) http.Header {
+	h2 := make(http.Header, len(h)+4)
+	for k, v := range h {
+		h2[k] = append([]string(nil), v...)
+	}
+	return h2
+}
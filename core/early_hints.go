@@ -0,0 +1,61 @@
+package core
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Link is the Link header.
+const Link = "Link"
+
+// WriteEarlyHints sends a 103 Early Hints response carrying the links, so the
+// browser starts fetching assets while the page is still rendered. Links are
+// Link header values, e.g. `</app.css>; rel=preload; as=style`, or plain URLs
+// turned into preload links. The links are kept for the final response too.
+//
+// It does nothing once the response is committed or for HTTP/1.0 requests,
+// which can't receive 1xx responses; other clients ignore the ones they don't
+// support. It relies on net/http sending 1xx responses ahead of the final
+// one, which Go does since 1.19 over HTTP/1.1 and HTTP/2: older versions, or
+// another HTTP/2 server configured by hand, would send 103 as the final
+// status.
+func (c *Context) WriteEarlyHints(links ...string) {
+	if c.response.committed || len(links) == 0 || !c.request.ProtoAtLeast(1, 1) {
+		return
+	}
+	h := c.response.Header()
+	for _, l := range links {
+		h.Add(Link, preloadLink(l))
+	}
+	c.response.writer.WriteHeader(http.StatusEarlyHints)
+}
+
+// preloadLink turns a plain URL into a preload Link value.
+func preloadLink(l string) string {
+	if strings.HasPrefix(l, "<") {
+		return l
+	}
+	v := "<" + l + ">; rel=preload"
+	ext := path.Ext(l)
+	if i := strings.IndexAny(ext, "?#"); i != -1 {
+		ext = ext[:i]
+	}
+	switch strings.ToLower(ext) {
+	case ".css":
+		v += "; as=style"
+	case ".js", ".mjs":
+		v += "; as=script"
+	case ".woff", ".woff2", ".ttf", ".otf":
+		v += "; as=font; crossorigin"
+	case ".png", ".jpg", ".jpeg", ".gif", ".webp", ".avif", ".svg":
+		v += "; as=image"
+	}
+	return v
+}
+
+// Informational reports whether code is a 1xx response which precedes the
+// final one, such as 103 Early Hints. 101 Switching Protocols is final.
+func Informational(code int) bool {
+	return code >= 100 && code < 200 && code != http.StatusSwitchingProtocols
+}
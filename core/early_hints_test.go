@@ -0,0 +1,92 @@
+package core
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// codeRecorder records every status code written.
+type codeRecorder struct {
+	*httptest.ResponseRecorder
+	codes []int
+}
+
+func (r *codeRecorder) WriteHeader(code int) {
+	r.codes = append(r.codes, code)
+	if !Informational(code) {
+		r.ResponseRecorder.WriteHeader(code)
+	}
+}
+
+func TestWriteEarlyHints(t *testing.T) {
+	e := New()
+	e.Get("/", func(c *Context) error {
+		c.WriteEarlyHints("/app.css", "</font.woff2>; rel=preload; as=font")
+		return c.String(http.StatusOK, "page")
+	})
+
+	rec := &codeRecorder{ResponseRecorder: httptest.NewRecorder()}
+	e.ServeHTTP(rec, httptest.NewRequest(GET, "/", nil))
+	assert.Equal(t, []int{http.StatusEarlyHints, http.StatusOK}, rec.codes)
+	assert.Equal(t, []string{"</app.css>; rel=preload; as=style", "</font.woff2>; rel=preload; as=font"}, rec.Header()[Link])
+
+	// Not for HTTP/1.0 clients
+	req := httptest.NewRequest(GET, "/", nil)
+	req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/1.0", 1, 0
+	rec = &codeRecorder{ResponseRecorder: httptest.NewRecorder()}
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, []int{http.StatusOK}, rec.codes)
+}
+
+func TestInformational(t *testing.T) {
+	assert.True(t, Informational(http.StatusContinue))
+	assert.True(t, Informational(http.StatusEarlyHints))
+	assert.False(t, Informational(http.StatusSwitchingProtocols))
+	assert.False(t, Informational(http.StatusOK))
+}
+
+func TestWriteEarlyHintsHTTP2(t *testing.T) {
+	for _, h2 := range []bool{true, false} {
+		e := New()
+		e.HTTP2(h2)
+		e.Get("/", func(c *Context) error {
+			c.WriteEarlyHints("/app.css")
+			return c.String(http.StatusOK, c.Request().Proto)
+		})
+		ts := httptest.NewUnstartedServer(nil)
+		ts.Config = e.Server("")
+		ts.EnableHTTP2 = h2
+		ts.StartTLS()
+
+		var codes []int
+		trace := &httptrace.ClientTrace{
+			Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+				codes = append(codes, code)
+				assert.Equal(t, "</app.css>; rel=preload; as=style", header.Get(Link))
+				return nil
+			},
+		}
+		req, _ := http.NewRequest(GET, ts.URL, nil)
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+		res, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		ts.Close()
+		proto := "HTTP/2.0"
+		if !h2 {
+			proto = "HTTP/1.1"
+		}
+		assert.Equal(t, proto, string(body))
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, []int{http.StatusEarlyHints}, codes)
+	}
+}
@@ -2,11 +2,14 @@ package core
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	pathpkg "path"
 	"path/filepath"
@@ -14,10 +17,12 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/henrylee2cn/thinkgo/core/http2"
 	"github.com/henrylee2cn/thinkgo/core/log"
+	"github.com/henrylee2cn/thinkgo/core/msgpack"
+	"github.com/henrylee2cn/thinkgo/core/template"
 	"github.com/henrylee2cn/thinkgo/core/websocket"
 )
 
@@ -31,11 +36,23 @@ type (
 		defaultHTTPErrorHandler HTTPErrorHandler
 		httpErrorHandler        HTTPErrorHandler
 		binder                  Binder
+		validator               StructValidator
 		renderer                Renderer
 		pool                    sync.Pool
 		debug                   bool
 		hook                    http.HandlerFunc
 		autoIndex               bool
+		cacheControl            *CacheControl
+		locales                 []string
+		trustedProxies          []*net.IPNet
+		transforms              []TransformFunc
+		envelope                *Envelope
+		servers                 *serverList
+		hooks                   *shutdownHooks
+		warmers                 *cacheWarmers
+		env                     *environment
+		examples                *exampleStore
+		plugs                   *plugChain
 		logger                  *log.Logger
 		router                  *Router
 		// @ modified by henrylee2cn 2016.1.22
@@ -43,10 +60,32 @@ type (
 		fileSystem *FileSystem     // 静态文件系统
 	}
 
+	// serverList tracks the running servers and the requests and WebSockets
+	// they serve, shared with groups.
+	serverList struct {
+		inflight int64 // accessed atomically
+		sync.Mutex
+		list    []*http.Server
+		sockets map[*websocket.Conn]struct{}
+		// stopped is set once Shutdown or Close began, no server can run
+		// anymore.
+		stopped bool
+	}
+
+	// shutdownHooks holds the OnShutdown hooks and the last ShutdownReport,
+	// shared with groups.
+	shutdownHooks struct {
+		sync.Mutex
+		hooks  []func()
+		report *ShutdownReport
+	}
+
 	Route struct {
 		Method  string
 		Path    string
 		Handler Handler
+		// WebSocket is set on the routes added by WebSocket.
+		WebSocket bool
 	}
 
 	HTTPError struct {
@@ -67,7 +106,11 @@ type (
 		Bind(*http.Request, interface{}) error
 	}
 
+	// BindFunc decodes a request body of one content type into i.
+	BindFunc func(body io.Reader, i interface{}) error
+
 	binder struct {
+		funcs map[string]BindFunc
 	}
 
 	// Validator is the interface that wraps the Validate method.
@@ -80,6 +123,14 @@ type (
 		Render(w io.Writer, name string, data interface{}) error
 	}
 
+	// FuncRenderer is a Renderer supporting template functions, see
+	// Echo.SetTemplateFuncs and Context.RenderFuncs.
+	FuncRenderer interface {
+		Renderer
+		AddFuncs(funcs template.FuncMap) error
+		RenderFuncs(w io.Writer, name string, data interface{}, funcs template.FuncMap) error
+	}
+
 	// @ modified by henrylee2cn 2016.1.22
 	FileSystem struct {
 		fs   http.FileSystem // 静态文件系统
@@ -118,17 +169,25 @@ const (
 
 	ApplicationJSON                  = "application/json"
 	ApplicationJSONCharsetUTF8       = ApplicationJSON + "; " + CharsetUTF8
+	ApplicationNDJSON                = "application/x-ndjson"
 	ApplicationJavaScript            = "application/javascript"
 	ApplicationJavaScriptCharsetUTF8 = ApplicationJavaScript + "; " + CharsetUTF8
 	ApplicationXML                   = "application/xml"
 	ApplicationXMLCharsetUTF8        = ApplicationXML + "; " + CharsetUTF8
 	ApplicationForm                  = "application/x-www-form-urlencoded"
 	ApplicationProtobuf              = "application/protobuf"
+	ApplicationXProtobuf             = "application/x-protobuf"
 	ApplicationMsgpack               = "application/msgpack"
+	ApplicationXMsgpack              = "application/x-msgpack"
+	ApplicationYAML                  = "application/yaml"
+	ApplicationYAMLCharsetUTF8       = ApplicationYAML + "; " + CharsetUTF8
+	ApplicationXYAML                 = "application/x-yaml"
+	TextYAML                         = "text/yaml"
 	TextHTML                         = "text/html"
 	TextHTMLCharsetUTF8              = TextHTML + "; " + CharsetUTF8
 	TextPlain                        = "text/plain"
 	TextPlainCharsetUTF8             = TextPlain + "; " + CharsetUTF8
+	TextEventStream                  = "text/event-stream"
 	MultipartForm                    = "multipart/form-data"
 
 	//---------
@@ -141,18 +200,51 @@ const (
 	// Headers
 	//---------
 
+	Accept             = "Accept"
 	AcceptEncoding     = "Accept-Encoding"
 	Authorization      = "Authorization"
+	CacheControlHeader = "Cache-Control"
 	ContentDisposition = "Content-Disposition"
 	ContentEncoding    = "Content-Encoding"
 	ContentLength      = "Content-Length"
 	ContentType        = "Content-Type"
+	ETag               = "ETag"
+	Expect             = "Expect"
+	Forwarded          = "Forwarded"
+	IfMatch            = "If-Match"
+	IfModifiedSince    = "If-Modified-Since"
+	IfNoneMatch        = "If-None-Match"
+	LastEventID        = "Last-Event-ID"
+	LastModified       = "Last-Modified"
 	Location           = "Location"
 	Upgrade            = "Upgrade"
 	Vary               = "Vary"
 	WWWAuthenticate    = "WWW-Authenticate"
 	XForwardedFor      = "X-Forwarded-For"
 	XRealIP            = "X-Real-IP"
+	XRequestID         = "X-Request-ID"
+	Origin             = "Origin"
+
+	AccessControlRequestMethod    = "Access-Control-Request-Method"
+	AccessControlRequestHeaders   = "Access-Control-Request-Headers"
+	AccessControlAllowOrigin      = "Access-Control-Allow-Origin"
+	AccessControlAllowMethods     = "Access-Control-Allow-Methods"
+	AccessControlAllowHeaders     = "Access-Control-Allow-Headers"
+	AccessControlAllowCredentials = "Access-Control-Allow-Credentials"
+	AccessControlExposeHeaders    = "Access-Control-Expose-Headers"
+	AccessControlMaxAge           = "Access-Control-Max-Age"
+
+	StrictTransportSecurity = "Strict-Transport-Security"
+	XContentTypeOptions     = "X-Content-Type-Options"
+	XFrameOptions           = "X-Frame-Options"
+	XXSSProtection          = "X-XSS-Protection"
+	ContentSecurityPolicy   = "Content-Security-Policy"
+	ReferrerPolicy          = "Referrer-Policy"
+	XForwardedProto         = "X-Forwarded-Proto"
+
+	XLocale         = "X-Locale"
+	XTenant         = "X-Tenant"
+	XRequestTimeout = "X-Request-Timeout"
 	//-----------
 	// Protocols
 	//-----------
@@ -182,6 +274,7 @@ var (
 	UnsupportedMediaType  = errors.New("unsupported media type")
 	RendererNotRegistered = errors.New("renderer not registered")
 	InvalidRedirectCode   = errors.New("invalid redirect status code")
+	ServerStopped         = errors.New("server stopped, see Echo.Shutdown")
 
 	//----------------
 	// Error handlers
@@ -208,8 +301,15 @@ func New() (e *Echo) {
 		maxParam:   new(int),
 		http2:      true,
 		logger:     Log,
-		binder:     &binder{},
+		binder:     newBinder(),
+		validator:  TagValidator{},
 		fileSystem: new(FileSystem),
+		servers:    new(serverList),
+		hooks:      new(shutdownHooks),
+		warmers:    new(cacheWarmers),
+		env:        &environment{clock: SystemClock, ids: RandomIDs, cookie: DefaultCookieDefaults},
+		examples:   new(exampleStore),
+		plugs:      new(plugChain),
 		blackfile: map[string]bool{
 			".html": true,
 		},
@@ -222,10 +322,19 @@ func New() (e *Echo) {
 			}
 			if e.debug {
 				msg = err.Error()
+				if c.bound != nil {
+					if b, err := json.Marshal(c.Redact(c.bound)); err == nil {
+						msg += "\n\nbound: " + string(b)
+					}
+				}
 			}
 			if !c.response.committed {
 				http.Error(c.response, msg, code)
 			}
+			if c.requestID != "" {
+				e.logger.Error("[%s] %v", c.requestID, err)
+				return
+			}
 			e.logger.Error(err)
 		},
 	}
@@ -268,6 +377,15 @@ func (e *Echo) HTTP2(on bool) {
 	e.http2 = on
 }
 
+// configureHTTP2 leaves HTTP/2 over TLS to net/http, which supports 1xx
+// responses and server push, or turns it off. A server configured by its
+// owner is left alone.
+func (e *Echo) configureHTTP2(s *http.Server) {
+	if !e.http2 && s.TLSNextProto == nil {
+		s.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+}
+
 // DefaultHTTPErrorHandler invokes the default HTTP error handler.
 func (e *Echo) DefaultHTTPErrorHandler(err error, c *Context) {
 	e.defaultHTTPErrorHandler(err, c)
@@ -283,19 +401,72 @@ func (e *Echo) SetBinder(b Binder) {
 	e.binder = b
 }
 
+// RegisterBinder registers fn to decode request bodies of contentType, e.g.
+// "text/csv" or a vendor media type, replacing any previous one.
+// It has no effect once the binder was replaced by SetBinder.
+func (e *Echo) RegisterBinder(contentType string, fn BindFunc) {
+	if e.binder == nil {
+		// A group gets its own copy of the default binder.
+		if b, ok := e.root().binder.(*binder); ok {
+			e.binder = b.clone()
+		}
+	}
+	if b, ok := e.binder.(*binder); ok {
+		b.funcs[mediaType(contentType)] = fn
+	}
+}
+
 // SetRenderer registers an HTML template renderer. It's invoked by Context.Render().
 func (e *Echo) SetRenderer(r Renderer) {
 	e.renderer = r
+	if e != e.root() && r != nil {
+		e.env.groupRenderers = append(e.env.groupRenderers, r)
+	}
+	if fr, ok := r.(FuncRenderer); ok && len(e.env.funcs) > 0 {
+		if err := fr.AddFuncs(e.env.funcs); err != nil {
+			e.logger.Error("%v", err)
+		}
+	}
+}
+
+// SetTemplateFuncs registers functions available inside views, e.g. date
+// formatting, URL generation with URI or translation. They are kept for
+// renderers set later, and must be registered before the first Render.
+func (e *Echo) SetTemplateFuncs(funcs template.FuncMap) error {
+	if e.env.funcs == nil {
+		e.env.funcs = make(template.FuncMap, len(funcs))
+	}
+	for name, fn := range funcs {
+		e.env.funcs[name] = fn
+	}
+	var err error
+	for _, r := range e.renderers() {
+		if fr, ok := r.(FuncRenderer); ok {
+			if e := fr.AddFuncs(funcs); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return err
 }
 
 // @ modified by henrylee2cn 2016.1.22
 func (e *Echo) Render(w io.Writer, name string, data interface{}) error {
-	return e.renderer.Render(w, name, data)
+	r := e.rendererFor(name)
+	if r == nil {
+		return RendererNotRegistered
+	}
+	return r.Render(w, name, data)
 }
 
 // SetDebug enable/disable debug mode.
 func (e *Echo) SetDebug(on bool) {
 	e.debug = on
+	for _, r := range e.renderers() {
+		if t, ok := r.(*Template); ok {
+			t.SetDebug(on)
+		}
+	}
 }
 
 // Debug returns debug mode (enabled or disabled).
@@ -308,6 +479,15 @@ func (e *Echo) AutoIndex(on bool) {
 	e.autoIndex = on
 }
 
+// CacheControl returns the default Cache-Control policy applied to responses of
+// routes registered on this instance. Groups inherit a copy of it.
+func (e *Echo) CacheControl() *CacheControl {
+	if e.cacheControl == nil {
+		e.cacheControl = newCacheControl(nil)
+	}
+	return e.cacheControl
+}
+
 // Hook registers a callback which is invoked from `Echo#ServerHTTP` as the first
 // statement. Hook is useful if you want to modify response/response objects even
 // before it hits the router or any middleware.
@@ -375,6 +555,12 @@ func (e *Echo) Trace(path string, h Handler) {
 	e.add(TRACE, path, h)
 }
 
+// Method adds a route > handler to the router for any method, including
+// non-standard ones such as PURGE, PROPFIND, REPORT or LINK.
+func (e *Echo) Method(method, path string, h Handler) {
+	e.add(method, path, h)
+}
+
 // Any adds a route > handler to the router for all HTTP methods.
 func (e *Echo) Any(path string, h Handler) {
 	for _, m := range methods {
@@ -396,9 +582,15 @@ func (e *Echo) Match(path string, h Handler, method ...string) {
 // @ modified by henrylee2cn 2016.1.22
 // WebSocket adds a WebSocket route > handler to the router.
 func (e *Echo) WebSocket(path string, h HandlerFunc) {
-	e.Get(path, func(c *Context) (err error) {
+	ws := func(c *Context) (err error) {
 		wss := websocket.Server{
 			Handler: func(ws *websocket.Conn) {
+				if !e.servers.addSocket(ws) {
+					// Upgraded while shutting down
+					ws.Close()
+					return
+				}
+				defer e.servers.removeSocket(ws)
 				c.socket = ws
 				c.response.status = http.StatusSwitchingProtocols
 				err = h(c)
@@ -406,25 +598,72 @@ func (e *Echo) WebSocket(path string, h HandlerFunc) {
 		}
 		wss.ServeHTTP(c.response, c.request)
 		return err
-	})
+	}
+	full := pathpkg.Join(e.prefix, "/", path)
+	e.addRoute(Route{Method: GET, Path: full, WebSocket: true}, wrapRouteHandler(full, ws), ws)
 	if e.debug {
 		e.logger.Notice("%-5s %-25s --> %v", "SOCKET", path, h)
 	}
 }
 
-// @ modified by henrylee2cn 2016.1.22
-func (e *Echo) add(method, path string, h Handler) {
+// Remove unregisters the route method > path, it's safe to call while
+// serving: the routing tree is rebuilt and swapped in at once, requests in
+// flight finish on the removed handler. It reports whether the route existed.
+func (e *Echo) Remove(method, path string) bool {
+	path = pathpkg.Join(e.prefix, "/", path)
+	if !e.router.Remove(method, path) {
+		return false
+	}
+	if e.debug {
+		e.logger.Notice("%-5s %-25s --> removed", method, path)
+	}
+	return true
+}
+
+// Replace swaps the handler of the route method > path while serving, e.g.
+// for plugins or A/B handler swaps. It reports whether the route existed,
+// use Method etc. to add a new one.
+func (e *Echo) Replace(method, path string, h Handler) bool {
 	path = pathpkg.Join(e.prefix, "/", path)
-	e.router.Add(method, path, wrapHandler(h), e)
-	r := Route{
-		Method:  method,
-		Path:    path,
-		Handler: runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name(),
+	name := runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+	if !e.router.change(method, path, wrapRouteHandler(path, h), e, name) {
+		return false
 	}
-	e.router.routes = append(e.router.routes, r)
 	if e.debug {
 		e.logger.Notice("%-5s %-25s --> %v", method, path, h)
 	}
+	return true
+}
+
+// validMethod reports whether method is a valid HTTP method token.
+func validMethod(method string) bool {
+	if method == "" {
+		return false
+	}
+	for i := 0; i < len(method); i++ {
+		if !strings.ContainsRune("!#$%&'*+-.^_`|~", rune(method[i])) &&
+			!('0' <= method[i] && method[i] <= '9') && !('A' <= method[i] && method[i] <= 'Z') && !('a' <= method[i] && method[i] <= 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+// @ modified by henrylee2cn 2016.1.22
+func (e *Echo) add(method, path string, h Handler) {
+	if err := e.TryMethod(method, path, h); err != nil {
+		panic(err.Error())
+	}
+}
+
+// addRoute registers the route r served by fn, its Handler being named after
+// h.
+func (e *Echo) addRoute(r Route, fn HandlerFunc, h Handler) {
+	r.Handler = runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+	e.router.addRoute(r, fn, e, true)
+	if e.debug {
+		e.logger.Notice("%-5s %-25s --> %v", r.Method, r.Path, h)
+	}
 }
 
 // Static serves static files from a directory. It's an alias for `Echo.ServeDir`
@@ -499,6 +738,9 @@ func (e *Echo) serveFile(fs http.FileSystem, file string, c *Context) (err error
 		fi, _ = f.Stat() // Index file stat
 	}
 
+	if o := e.env.offload; o != nil && o.offload(c, fs, file) {
+		return
+	}
 	http.ServeContent(c.response, c.request, fi.Name(), fi.ModTime(), f)
 	return
 }
@@ -535,10 +777,21 @@ func (e *Echo) Group(prefix string, m ...Middleware) *Group {
 	mw := make([]MiddlewareFunc, len(g.echo.middleware))
 	copy(mw, g.echo.middleware)
 	g.echo.middleware = mw
+	g.echo.transforms = append([]TransformFunc(nil), e.transforms...)
+	g.echo.cacheControl = e.cacheControl.clone()
+	if e == e.root() {
+		// Fall back to the current defaults, see Group.SetRenderer.
+		g.echo.renderer, g.echo.binder = nil, nil
+	}
 	g.Use(m...)
 	return g
 }
 
+// root returns the Echo instance the groups derive from.
+func (e *Echo) root() *Echo {
+	return e.router.echo
+}
+
 // @ modified by henrylee2cn 2016.1.22
 func (e *Echo) Prefix() string {
 	return e.prefix
@@ -550,7 +803,7 @@ func (e *Echo) URI(h Handler, params ...interface{}) string {
 	pl := len(params)
 	n := 0
 	hn := runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
-	for _, r := range e.router.routes {
+	for _, r := range e.router.Routes() {
 		if r.Handler == hn {
 			for i, l := 0, len(r.Path); i < l; i++ {
 				if r.Path[i] == ':' && n < pl {
@@ -574,34 +827,113 @@ func (e *Echo) URL(h Handler, params ...interface{}) string {
 	return e.URI(h, params...)
 }
 
+// URIWithQuery generates a URI from handler like `URI` and appends `query`, a
+// struct tagged like the ones used for binding (see `EncodeQuery`), as the query
+// string. It keeps pagination and filter links consistent with binding.
+func (e *Echo) URIWithQuery(h Handler, query interface{}, params ...interface{}) string {
+	uri := e.URI(h, params...)
+	vals, err := EncodeQuery(query)
+	if err != nil {
+		e.logger.Error(err)
+		return uri
+	}
+	if q := vals.Encode(); q != "" {
+		uri += "?" + q
+	}
+	return uri
+}
+
+// URLWithQuery is an alias for `URIWithQuery` function.
+func (e *Echo) URLWithQuery(h Handler, query interface{}, params ...interface{}) string {
+	return e.URIWithQuery(h, query, params...)
+}
+
 // Routes returns the registered routes.
 func (e *Echo) Routes() []Route {
-	return e.router.routes
+	return e.router.Routes()
 }
 
 // @ modified by henrylee2cn 2016.1.22
 // ServeHTTP implements `http.Handler` interface, which serves HTTP requests.
 func (e *Echo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	locale := e.stripLocale(r)
 	if e.hook != nil {
 		e.hook(w, r)
 	}
 	if r.URL.Path != "/" {
 		r.URL.Path = strings.TrimRight(r.URL.Path, "/")
 	}
+	if r.Method == CONNECT && r.URL.Path == "" {
+		// Authority-form CONNECT requests are routed to "/".
+		r.URL.Path = "/"
+	}
+
+	atomic.AddInt64(&e.servers.inflight, 1)
+	defer atomic.AddInt64(&e.servers.inflight, -1)
 
 	c := e.pool.Get().(*Context)
+	if e.wantsTrace(r) {
+		c.trace = &MatchTrace{Method: r.Method, Path: r.URL.Path}
+		e.router.Find(r.Method, r.URL.Path, c)
+		c.reset(r, w, e)
+		c.JSON(http.StatusOK, c.trace)
+		c.trace = nil
+		e.pool.Put(c)
+		return
+	}
 	h, e := e.router.Find(r.Method, r.URL.Path, c)
+	var (
+		er *exampleReader
+		ew *exampleWriter
+		tw *BufferWriter
+	)
+	if e.wantsExample(r.Method, c.path) {
+		er = &exampleReader{ReadCloser: r.Body}
+		if r.Body != nil {
+			r.Body = er
+		}
+		ew = &exampleWriter{ResponseWriter: w}
+		w = ew
+	}
+	if len(e.transforms) > 0 {
+		tw = NewBufferWriter(w)
+		w = tw
+	}
 	c.reset(r, w, e)
+	c.locale = locale
+	if e.debug {
+		c.response.guard = &responseGuard{route: r.Method + " " + c.path}
+	}
+	if e.cacheControl != nil {
+		e.cacheControl.apply(w.Header())
+	}
 
 	// Chain middleware with handler in the end
 	for i := len(e.middleware) - 1; i >= 0; i-- {
 		h = e.middleware[i](h)
 	}
+	plugs := e.plugs.load()
+	for i := len(plugs) - 1; i >= 0; i-- {
+		h = plugs[i].mw(h)
+	}
 
 	// Execute chain
-	if err := h(c); err != nil {
+	err := h(c)
+	if c.response.guard != nil {
+		c.response.guard.check(c.response, err)
+	}
+	if err != nil {
 		e.httpErrorHandler(err, c)
 	}
+	if c.sse != nil {
+		c.sse.Close()
+	}
+	if tw != nil {
+		e.transform(c, tw)
+	}
+	if ew != nil {
+		e.captureExample(c, er, ew)
+	}
 
 	e.pool.Put(c)
 }
@@ -609,10 +941,7 @@ func (e *Echo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // Server returns the internal *http.Server.
 func (e *Echo) Server(addr string) *http.Server {
 	s := &http.Server{Addr: addr, Handler: e}
-	// TODO: Remove in Go 1.6+
-	if e.http2 {
-		http2.ConfigureServer(s, nil)
-	}
+	e.configureHTTP2(s)
 
 	// @ modified by henrylee2cn 2016.1.22
 	e.logger.Notice("	%s %s Running on %v", NAME, VERSION, addr)
@@ -620,39 +949,111 @@ func (e *Echo) Server(addr string) *http.Server {
 	return s
 }
 
-// Run runs a server.
-func (e *Echo) Run(addr string) {
-	e.run(e.Server(addr))
+// Run runs a server. It blocks until the server stops and returns nil once
+// stopped by Shutdown or Close.
+func (e *Echo) Run(addr string) error {
+	return e.run(e.Server(addr))
 }
 
 // RunTLS runs a server with TLS configuration.
-func (e *Echo) RunTLS(addr, crtFile, keyFile string) {
-	e.run(e.Server(addr), crtFile, keyFile)
+func (e *Echo) RunTLS(addr, crtFile, keyFile string) error {
+	return e.run(e.Server(addr), crtFile, keyFile)
 }
 
 // RunServer runs a custom server.
-func (e *Echo) RunServer(s *http.Server) {
-	e.run(s)
+func (e *Echo) RunServer(s *http.Server) error {
+	return e.run(s)
 }
 
 // RunTLSServer runs a custom server with TLS configuration.
-func (e *Echo) RunTLSServer(s *http.Server, crtFile, keyFile string) {
-	e.run(s, crtFile, keyFile)
+func (e *Echo) RunTLSServer(s *http.Server, crtFile, keyFile string) error {
+	return e.run(s, crtFile, keyFile)
+}
+
+// Shutdown gracefully stops the running servers: their listeners are closed
+// first and it waits for in-flight requests until ctx is done, then the open
+// WebSockets are closed, new ones being refused meanwhile. What happened is
+// logged and kept, see ShutdownReport.
+func (e *Echo) Shutdown(ctx context.Context) error {
+	return e.stop(func(s *http.Server) error {
+		return s.Shutdown(ctx)
+	})
+}
+
+// Close immediately stops the running servers and their connections.
+func (e *Echo) Close() error {
+	return e.stop(func(s *http.Server) error {
+		return s.Close()
+	})
+}
+
+// OnShutdown registers fn to run once the servers stopped, through Shutdown,
+// Close or because Run failed, e.g. to flush buffers or close databases.
+// Hooks run in reverse order of registration, and only once. A panicking hook
+// is reported as failed, see ShutdownReport.
+func (e *Echo) OnShutdown(fn func()) {
+	e.hooks.Lock()
+	e.hooks.hooks = append(e.hooks.hooks, fn)
+	e.hooks.Unlock()
 }
 
-func (e *Echo) run(s *http.Server, files ...string) {
+func (e *Echo) run(s *http.Server, files ...string) (err error) {
 	s.Handler = e
-	// TODO: Remove in Go 1.6+
-	if e.http2 {
-		http2.ConfigureServer(s, nil)
+	e.configureHTTP2(s)
+	if len(files) != 0 && len(files) != 2 {
+		return errors.New("invalid TLS configuration")
 	}
+	if err = e.servers.add(s); err != nil {
+		return
+	}
+	defer e.servers.remove(s)
+	e.warmOnStart()
 	if len(files) == 0 {
-		e.logger.Fatal(s.ListenAndServe())
-	} else if len(files) == 2 {
-		e.logger.Fatal(s.ListenAndServeTLS(files[0], files[1]))
+		err = s.ListenAndServe()
 	} else {
-		e.logger.Fatal("invalid TLS configuration")
+		err = s.ListenAndServeTLS(files[0], files[1])
+	}
+	if err == http.ErrServerClosed {
+		// Shutdown or Close runs the hooks.
+		return nil
+	}
+	if err != nil {
+		e.hooks.run(e.env.clock)
+		e.logger.Flush()
+	}
+	return
+}
+
+// add adds a running server, it fails once the servers were stopped.
+func (l *serverList) add(s *http.Server) error {
+	l.Lock()
+	defer l.Unlock()
+	if l.stopped {
+		return ServerStopped
 	}
+	l.list = append(l.list, s)
+	return nil
+}
+
+func (l *serverList) remove(s *http.Server) {
+	l.Lock()
+	defer l.Unlock()
+	for i, v := range l.list {
+		if v == s {
+			l.list = append(l.list[:i], l.list[i+1:]...)
+			return
+		}
+	}
+}
+
+// take marks the servers stopped and returns the running ones.
+func (l *serverList) take() []*http.Server {
+	l.Lock()
+	defer l.Unlock()
+	l.stopped = true
+	list := l.list
+	l.list = nil
+	return list
 }
 
 func NewHTTPError(code int, msg ...string) *HTTPError {
@@ -681,15 +1082,24 @@ func (e *HTTPError) Error() string {
 
 // wrapMiddleware wraps middleware.
 func wrapMiddleware(m Middleware) MiddlewareFunc {
+	fn, err := toMiddleware(m)
+	if err != nil {
+		panic(err.Error())
+	}
+	return fn
+}
+
+// toMiddleware is wrapMiddleware returning an error for unknown types.
+func toMiddleware(m Middleware) (MiddlewareFunc, error) {
 	switch m := m.(type) {
 	case MiddlewareFunc:
-		return m
+		return m, nil
 	case func(HandlerFunc) HandlerFunc:
-		return m
+		return m, nil
 	case HandlerFunc:
-		return wrapHandlerFuncMW(m)
+		return wrapHandlerFuncMW(m), nil
 	case func(*Context) error:
-		return wrapHandlerFuncMW(m)
+		return wrapHandlerFuncMW(m), nil
 	case func(http.Handler) http.Handler:
 		return func(h HandlerFunc) HandlerFunc {
 			return func(c *Context) (err error) {
@@ -700,13 +1110,13 @@ func wrapMiddleware(m Middleware) MiddlewareFunc {
 				})).ServeHTTP(c.response.writer, c.request)
 				return
 			}
-		}
+		}, nil
 	case http.Handler:
-		return wrapHTTPHandlerFuncMW(m.ServeHTTP)
+		return wrapHTTPHandlerFuncMW(m.ServeHTTP), nil
 	case func(http.ResponseWriter, *http.Request):
-		return wrapHTTPHandlerFuncMW(m)
+		return wrapHTTPHandlerFuncMW(m), nil
 	default:
-		panic("unknown middleware")
+		return nil, fmt.Errorf("unknown middleware type %T", m)
 	}
 }
 
@@ -736,33 +1146,98 @@ func wrapHTTPHandlerFuncMW(m http.HandlerFunc) MiddlewareFunc {
 
 // wrapHandler wraps handler.
 func wrapHandler(h Handler) HandlerFunc {
+	fn, err := toHandler(h)
+	if err != nil {
+		panic(err.Error())
+	}
+	return fn
+}
+
+// toHandler is wrapHandler returning an error for unknown types.
+func toHandler(h Handler) (HandlerFunc, error) {
 	switch h := h.(type) {
 	case HandlerFunc:
-		return h
+		return h, nil
 	case func(*Context) error:
-		return h
+		return h, nil
 	case http.Handler, http.HandlerFunc:
 		return func(c *Context) error {
 			h.(http.Handler).ServeHTTP(c.response, c.request)
 			return nil
-		}
+		}, nil
 	case func(http.ResponseWriter, *http.Request):
 		return func(c *Context) error {
 			h(c.response, c.request)
 			return nil
-		}
+		}, nil
 	default:
-		panic("unknown handler")
+		return nil, fmt.Errorf("unknown handler type %T", h)
 	}
 }
 
-func (binder) Bind(r *http.Request, i interface{}) (err error) {
-	ct := r.Header.Get(ContentType)
-	err = UnsupportedMediaType
-	if strings.HasPrefix(ct, ApplicationJSON) {
-		err = json.NewDecoder(r.Body).Decode(i)
-	} else if strings.HasPrefix(ct, ApplicationXML) {
-		err = xml.NewDecoder(r.Body).Decode(i)
+func (b *binder) Bind(r *http.Request, i interface{}) (err error) {
+	if r.ContentLength == 0 && bindsQuery(r.Method) {
+		return DecodeForm(r.URL.Query(), i)
+	}
+	switch ct := mediaType(r.Header.Get(ContentType)); ct {
+	case ApplicationForm:
+		if err = r.ParseForm(); err == nil {
+			err = decodeForm(r.PostForm, nil, i)
+		}
+	case MultipartForm:
+		if err = r.ParseMultipartForm(defaultMaxMemory); err == nil {
+			err = decodeForm(r.MultipartForm.Value, r.MultipartForm.File, i)
+		}
+	default:
+		fn, ok := b.funcs[ct]
+		if !ok {
+			return UnsupportedMediaType
+		}
+		err = fn(r.Body, i)
 	}
 	return
 }
+
+// getBinder returns the binder of e, the default one for a group which
+// didn't set its own.
+func (e *Echo) getBinder() Binder {
+	if e.binder != nil {
+		return e.binder
+	}
+	return e.root().binder
+}
+
+func (b *binder) clone() *binder {
+	funcs := make(map[string]BindFunc, len(b.funcs))
+	for t, fn := range b.funcs {
+		funcs[t] = fn
+	}
+	return &binder{funcs: funcs}
+}
+
+func newBinder() *binder {
+	return &binder{funcs: map[string]BindFunc{
+		ApplicationJSON: func(r io.Reader, i interface{}) error {
+			return json.NewDecoder(r).Decode(i)
+		},
+		ApplicationXML: func(r io.Reader, i interface{}) error {
+			return xml.NewDecoder(r).Decode(i)
+		},
+		ApplicationProtobuf:  bindProto,
+		ApplicationXProtobuf: bindProto,
+		ApplicationMsgpack:   msgpack.Decode,
+		ApplicationXMsgpack:  msgpack.Decode,
+		ApplicationYAML:      bindYAML,
+		ApplicationXYAML:     bindYAML,
+		TextYAML:             bindYAML,
+	}}
+}
+
+// mediaType returns the lower-cased media type of a Content-Type value
+// without its parameters.
+func mediaType(ct string) string {
+	if i := strings.IndexByte(ct, ';'); i != -1 {
+		ct = ct[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(ct))
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+type (
+	// Envelope configures the `{code, msg, data}` JSON envelope written by
+	// Context.OK, Context.Fail and Context.Page. Empty fields fall back to
+	// DefaultEnvelope.
+	Envelope struct {
+		// Field names.
+		Code string
+		Msg  string
+		Data string
+
+		// OKCode and OKMsg are sent by OK and Page.
+		OKCode int
+		OKMsg  string
+
+		// FailStatus is the HTTP status used by Fail, 200 by default since the
+		// error is carried by the envelope code.
+		FailStatus int
+	}
+
+	// Paginator describes a page of results, serialized the ThinkPHP way next
+	// to the list: `{total, per_page, current_page, last_page, data}`.
+	Paginator struct {
+		Total       int64
+		PerPage     int
+		CurrentPage int
+	}
+)
+
+// DefaultEnvelope is the envelope used until Echo.SetEnvelope is called.
+var DefaultEnvelope = Envelope{
+	Code:       "code",
+	Msg:        "msg",
+	Data:       "data",
+	OKCode:     0,
+	OKMsg:      "ok",
+	FailStatus: http.StatusOK,
+}
+
+// SetEnvelope sets the API response envelope.
+func (e *Echo) SetEnvelope(env Envelope) {
+	if env.Code == "" {
+		env.Code = DefaultEnvelope.Code
+	}
+	if env.Msg == "" {
+		env.Msg = DefaultEnvelope.Msg
+	}
+	if env.Data == "" {
+		env.Data = DefaultEnvelope.Data
+	}
+	if env.OKMsg == "" {
+		env.OKMsg = DefaultEnvelope.OKMsg
+	}
+	if env.FailStatus == 0 {
+		env.FailStatus = DefaultEnvelope.FailStatus
+	}
+	e.envelope = &env
+}
+
+// Envelope returns the API response envelope.
+func (e *Echo) Envelope() Envelope {
+	if e.envelope == nil {
+		return DefaultEnvelope
+	}
+	return *e.envelope
+}
+
+// LastPage returns the number of the last page.
+func (p Paginator) LastPage() int {
+	if p.PerPage <= 0 || p.Total <= 0 {
+		return 1
+	}
+	return int((p.Total + int64(p.PerPage) - 1) / int64(p.PerPage))
+}
+
+// OK sends data wrapped in a success envelope.
+func (c *Context) OK(data interface{}) error {
+	env := c.echo.Envelope()
+	return c.envelope(http.StatusOK, env, env.OKCode, env.OKMsg, data)
+}
+
+// Fail sends an error envelope with the given business code and message.
+func (c *Context) Fail(code int, msg string) error {
+	env := c.echo.Envelope()
+	return c.envelope(env.FailStatus, env, code, msg, nil)
+}
+
+// Page sends a page of data wrapped in a success envelope.
+func (c *Context) Page(data interface{}, p Paginator) error {
+	env := c.echo.Envelope()
+	return c.envelope(http.StatusOK, env, env.OKCode, env.OKMsg, struct {
+		Total       int64       `json:"total"`
+		PerPage     int         `json:"per_page"`
+		CurrentPage int         `json:"current_page"`
+		LastPage    int         `json:"last_page"`
+		Data        interface{} `json:"data"`
+	}{p.Total, p.PerPage, p.CurrentPage, p.LastPage(), data})
+}
+
+// envelope writes the fields in `code, msg, data` order.
+func (c *Context) envelope(status int, env Envelope, code int, msg string, data interface{}) error {
+	buf := new(bytes.Buffer)
+	enc := func(k string, v interface{}) error {
+		kb, _ := json.Marshal(k)
+		vb, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+		buf.Write(vb)
+		return nil
+	}
+	buf.WriteByte('{')
+	enc(env.Code, code)
+	buf.WriteByte(',')
+	enc(env.Msg, msg)
+	buf.WriteByte(',')
+	if err := enc(env.Data, data); err != nil {
+		return err
+	}
+	buf.WriteByte('}')
+	c.json(status, buf.Bytes())
+	return nil
+}
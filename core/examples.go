@@ -0,0 +1,245 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// RouteExample is a live request/response pair recorded for a route in
+	// debug mode, e.g. to document the API.
+	RouteExample struct {
+		Method     string          `json:"method"`
+		Path       string          `json:"path"`
+		Request    ExampleRequest  `json:"request"`
+		Response   ExampleResponse `json:"response"`
+		CapturedAt time.Time       `json:"captured_at"`
+	}
+
+	// ExampleRequest is the sanitized request of a RouteExample. Bodies bound
+	// by Context.Bind or sent by Context.JSON are recorded as JSON, run
+	// through the redaction function of the Echo instance, see
+	// Echo.SetRedactFunc.
+	ExampleRequest struct {
+		URL    string      `json:"url"`
+		Header http.Header `json:"header,omitempty"`
+		Body   string      `json:"body,omitempty"`
+	}
+
+	// ExampleResponse is the sanitized response of a RouteExample.
+	ExampleResponse struct {
+		Status int         `json:"status"`
+		Header http.Header `json:"header,omitempty"`
+		Body   string      `json:"body,omitempty"`
+	}
+
+	// exampleStore keeps one example per route, shared with groups.
+	exampleStore struct {
+		sync.Mutex
+		on bool
+		m  map[string]*RouteExample
+	}
+
+	// exampleBuffer keeps the first maxExampleBody bytes written to it.
+	exampleBuffer struct {
+		bytes.Buffer
+	}
+
+	// exampleReader records the request body as the handler reads it.
+	exampleReader struct {
+		io.ReadCloser
+		buf exampleBuffer
+	}
+
+	// exampleWriter records the response while passing it through.
+	exampleWriter struct {
+		http.ResponseWriter
+		buf  exampleBuffer
+		code int
+	}
+)
+
+// maxExampleBody bounds the recorded request and response bodies.
+const maxExampleBody = 4 << 10
+
+// redactedHeaders are masked in recorded examples.
+var redactedHeaders = []string{
+	Authorization,
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Csrf-Token",
+}
+
+// CaptureExamples enables/disables recording the first request/response of
+// each route. Examples are only recorded in debug mode.
+func (e *Echo) CaptureExamples(on bool) {
+	e.examples.Lock()
+	e.examples.on = on
+	e.examples.Unlock()
+}
+
+// Examples returns the recorded examples ordered by path and method.
+func (e *Echo) Examples() []RouteExample {
+	e.examples.Lock()
+	defer e.examples.Unlock()
+	list := make([]RouteExample, 0, len(e.examples.m))
+	for _, ex := range e.examples.m {
+		list = append(list, *ex)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Path != list[j].Path {
+			return list[i].Path < list[j].Path
+		}
+		return list[i].Method < list[j].Method
+	})
+	return list
+}
+
+// Example returns the recorded example of a route.
+func (e *Echo) Example(method, path string) (RouteExample, bool) {
+	e.examples.Lock()
+	defer e.examples.Unlock()
+	ex, ok := e.examples.m[method+" "+path]
+	if !ok {
+		return RouteExample{}, false
+	}
+	return *ex, true
+}
+
+// ResetExamples drops the recorded examples so they get captured again.
+func (e *Echo) ResetExamples() {
+	e.examples.Lock()
+	e.examples.m = nil
+	e.examples.Unlock()
+}
+
+// ExamplesHandler serves the recorded examples as JSON, to be mounted on a
+// debug-only route.
+func (e *Echo) ExamplesHandler() HandlerFunc {
+	return func(c *Context) error {
+		return c.JSON(http.StatusOK, e.Examples())
+	}
+}
+
+// wantsExample reports whether the request of route method/path should be
+// recorded.
+func (e *Echo) wantsExample(method, path string) bool {
+	if !e.debug || path == "" {
+		return false
+	}
+	e.examples.Lock()
+	defer e.examples.Unlock()
+	if !e.examples.on {
+		return false
+	}
+	_, ok := e.examples.m[method+" "+path]
+	return !ok
+}
+
+func (e *Echo) captureExample(c *Context, er *exampleReader, ew *exampleWriter) {
+	ex := &RouteExample{
+		Method: c.request.Method,
+		Path:   c.Path(),
+		Request: ExampleRequest{
+			URL:    c.request.URL.RequestURI(),
+			Header: sanitizeHeader(c.request.Header),
+			Body:   c.redactBody(c.bound, &er.buf),
+		},
+		Response: ExampleResponse{
+			Status: ew.code,
+			Header: sanitizeHeader(ew.Header()),
+			Body:   c.redactBody(c.sent, &ew.buf),
+		},
+		CapturedAt: c.Now(),
+	}
+	if ex.Response.Status == 0 {
+		ex.Response.Status = http.StatusOK
+	}
+	key := ex.Method + " " + ex.Path
+	e.examples.Lock()
+	if e.examples.m == nil {
+		e.examples.m = make(map[string]*RouteExample)
+	}
+	if _, ok := e.examples.m[key]; !ok {
+		e.examples.m[key] = ex
+	}
+	e.examples.Unlock()
+}
+
+func sanitizeHeader(h http.Header) http.Header {
+	s := make(http.Header, len(h))
+	for k, v := range h {
+		s[k] = append([]string(nil), v...)
+	}
+	for _, k := range redactedHeaders {
+		if _, ok := s[k]; ok {
+			s.Set(k, "[REDACTED]")
+		}
+	}
+	return s
+}
+
+// redactBody returns the recorded body, or v redacted as JSON if set.
+func (c *Context) redactBody(v interface{}, recorded *exampleBuffer) string {
+	if v != nil {
+		if b, err := json.Marshal(c.Redact(v)); err == nil {
+			var buf exampleBuffer
+			buf.record(b)
+			return buf.String()
+		}
+	}
+	return recorded.String()
+}
+
+func (b *exampleBuffer) record(p []byte) {
+	if n := maxExampleBody - b.Len(); n > 0 {
+		if len(p) > n {
+			p = p[:n]
+		}
+		b.Write(p)
+	}
+}
+
+func (r *exampleReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.buf.record(p[:n])
+	return n, err
+}
+
+func (w *exampleWriter) WriteHeader(code int) {
+	if w.code == 0 && !Informational(code) {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *exampleWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	w.buf.record(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *exampleWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *exampleWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (w *exampleWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExampleRedaction(t *testing.T) {
+	type login struct {
+		User     string `json:"user"`
+		Password string `json:"password" redact:"true"`
+	}
+	type session struct {
+		Token string `json:"token" redact:"true"`
+		Name  string `json:"name"`
+	}
+	e := New()
+	e.Logger().SetLevel(0)
+	e.SetDebug(true)
+	e.CaptureExamples(true)
+	e.Post("/login", func(c *Context) error {
+		var l login
+		if err := c.Bind(&l); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, session{Token: "t0ps3cret", Name: l.User})
+	})
+	e.Post("/echo", func(c *Context) error {
+		b, _ := ioutil.ReadAll(c.Request().Body)
+		return c.String(http.StatusOK, string(b))
+	})
+
+	for _, path := range []string{"/login", "/echo"} {
+		req := httptest.NewRequest(POST, path, strings.NewReader(`{"user":"joe","password":"hunter2"}`))
+		req.Header.Set(ContentType, ApplicationJSON)
+		e.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	ex, ok := e.Example(POST, "/login")
+	if assert.True(t, ok) {
+		assert.Equal(t, `{"password":"[REDACTED]","user":"joe"}`, ex.Request.Body)
+		assert.Equal(t, `{"name":"joe","token":"[REDACTED]"}`, ex.Response.Body)
+	}
+
+	// Bodies neither bound nor sent as JSON are recorded as they are
+	ex, ok = e.Example(POST, "/echo")
+	if assert.True(t, ok) {
+		assert.Equal(t, `{"user":"joe","password":"hunter2"}`, ex.Request.Body)
+		assert.Equal(t, ex.Request.Body, ex.Response.Body)
+	}
+}
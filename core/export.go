@@ -0,0 +1,121 @@
+package core
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type (
+	// ExportConfig configures a static export, see Echo.Export.
+	ExportConfig struct {
+		// Dir is the output directory.
+		Dir string
+
+		// Routes lists the GET paths to render.
+		Routes []string
+
+		// FollowLinks also renders the internal pages and assets linked from
+		// rendered HTML pages (href and src attributes of absolute paths).
+		// A page failing then doesn't stop the export, see ExportErrors.
+		FollowLinks bool
+	}
+
+	// ExportErrors lists the pages which failed to export when following
+	// links, in the order they were rendered.
+	ExportErrors []error
+)
+
+var linkRegexp = regexp.MustCompile(`(?i)\s(?:href|src)\s*=\s*["']?(/[^"'\s>]*)`)
+
+// Export renders GET routes to static files under dir, so pages can be
+// pre-rendered at deploy time. HTML pages are written as `<path>/index.html`,
+// other responses at their path.
+func (e *Echo) Export(dir string, routes ...string) error {
+	return e.ExportWithConfig(ExportConfig{Dir: dir, Routes: routes})
+}
+
+// ExportWithConfig renders a static export from config. It stops at the
+// first failing page, unless links are followed: the other pages are then
+// still exported and the failed ones returned as ExportErrors.
+func (e *Echo) ExportWithConfig(config ExportConfig) error {
+	queue := append([]string(nil), config.Routes...)
+	seen := map[string]bool{}
+	var errs ExportErrors
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+
+		rec, err := e.exportPage(config.Dir, p)
+		if err != nil {
+			if !config.FollowLinks {
+				return err
+			}
+			errs = append(errs, err)
+			continue
+		}
+
+		if config.FollowLinks && strings.HasPrefix(rec.Header().Get(ContentType), TextHTML) {
+			for _, m := range linkRegexp.FindAllSubmatch(rec.Body.Bytes(), -1) {
+				link := string(m[1])
+				if strings.HasPrefix(link, "//") {
+					continue // other host
+				}
+				if i := strings.IndexAny(link, "?#"); i != -1 {
+					link = link[:i]
+				}
+				if !seen[link] {
+					queue = append(queue, link)
+				}
+			}
+		}
+	}
+	if errs != nil {
+		return errs
+	}
+	return nil
+}
+
+// exportPage renders the page at p and writes it under dir.
+func (e *Echo) exportPage(dir, p string) (*httptest.ResponseRecorder, error) {
+	req, err := http.NewRequest(GET, p, nil)
+	if err != nil {
+		return nil, err
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		return nil, fmt.Errorf("export %s: status %d", p, rec.Code)
+	}
+
+	file := filepath.Join(dir, filepath.FromSlash(path.Clean("/"+req.URL.Path)))
+	if strings.HasPrefix(rec.Header().Get(ContentType), TextHTML) && !strings.HasSuffix(file, ".html") {
+		file = filepath.Join(file, "index.html")
+	}
+	if err = os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return nil, err
+	}
+	if err = ioutil.WriteFile(file, rec.Body.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// Error implements error.
+func (errs ExportErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExport(t *testing.T) {
+	e := New()
+	e.Logger().SetLevel(0)
+	e.Get("/", func(c *Context) error {
+		return c.HTML(200, `<a href="/about">About</a> <a href="/missing">Gone</a> <img src="/logo.txt?v=1">`)
+	})
+	e.Get("/about", func(c *Context) error {
+		return c.HTML(200, "about")
+	})
+	e.Get("/logo.txt", func(c *Context) error {
+		return c.String(200, "logo")
+	})
+
+	dir, err := ioutil.TempDir("", "export")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	// Without FollowLinks the first failure stops the export
+	err = e.Export(dir, "/missing", "/about")
+	if assert.Error(t, err) {
+		assert.Equal(t, "export /missing: status 404", err.Error())
+	}
+	_, err = os.Stat(filepath.Join(dir, "about", "index.html"))
+	assert.True(t, os.IsNotExist(err))
+
+	// With it, the failed pages are collected and the others exported
+	err = e.ExportWithConfig(ExportConfig{Dir: dir, Routes: []string{"/"}, FollowLinks: true})
+	if errs, ok := err.(ExportErrors); assert.True(t, ok) {
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, "export /missing: status 404", errs.Error())
+	}
+	for file, body := range map[string]string{
+		"index.html":       `<a href="/about">`,
+		"about/index.html": "about",
+		"logo.txt":         "logo",
+	} {
+		b, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+		if assert.NoError(t, err, file) {
+			assert.Contains(t, string(b), body)
+		}
+	}
+}
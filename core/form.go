@@ -0,0 +1,412 @@
+package core
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// formTag is the struct tag shared by form/query binding and query encoding,
+// e.g. `form:"page"`, `form:"q,omitempty"` or `form:"-"`.
+const formTag = "form"
+
+// defaultMaxMemory is the part of a multipart body kept in memory when bound,
+// the rest is stored in temporary files.
+const defaultMaxMemory = 32 << 20
+
+var (
+	fileHeaderType  = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeadersType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+// EncodeQuery encodes the exported fields of a struct (or a pointer to it) into
+// url.Values, using the `form` tag for field names. Maps of string keys and
+// url.Values are accepted as well.
+func EncodeQuery(i interface{}) (url.Values, error) {
+	vals := url.Values{}
+	if i == nil {
+		return vals, nil
+	}
+	if v, ok := i.(url.Values); ok {
+		return v, nil
+	}
+	v := reflect.ValueOf(i)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return vals, nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return vals, encodeStruct(vals, v)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		for _, k := range v.MapKeys() {
+			if err := encodeValue(vals, k.String(), v.MapIndex(k)); err != nil {
+				return nil, err
+			}
+		}
+		return vals, nil
+	}
+	return nil, fmt.Errorf("query: cannot encode %s", v.Type())
+}
+
+func encodeStruct(vals url.Values, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := v.Field(i)
+		name, omitempty := parseFormTag(sf)
+		if name == "-" {
+			continue
+		}
+		if sf.Anonymous && name == "" {
+			for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				if err := encodeStruct(vals, fv); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if omitempty && isEmptyValue(fv) {
+			continue
+		}
+		if err := encodeValue(vals, name, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeValue(vals url.Values, name string, v reflect.Value) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		if _, ok := v.Interface().([]byte); !ok {
+			for i := 0; i < v.Len(); i++ {
+				if err := encodeValue(vals, name, v.Index(i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	s, err := formatValue(v)
+	if err != nil {
+		return fmt.Errorf("query: field %s: %v", name, err)
+	}
+	vals.Add(name, s)
+	return nil
+}
+
+func formatValue(v reflect.Value) (string, error) {
+	if v.CanInterface() {
+		switch x := v.Interface().(type) {
+		case time.Time:
+			return x.Format(time.RFC3339), nil
+		case []byte:
+			return string(x), nil
+		case encoding.TextMarshaler:
+			b, err := x.MarshalText()
+			return string(b), err
+		}
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
+
+func parseFormTag(sf reflect.StructField) (name string, omitempty bool) {
+	tag := sf.Tag.Get(formTag)
+	if i := strings.Index(tag, ","); i != -1 {
+		return tag[:i], strings.Contains(tag[i:], ",omitempty")
+	}
+	return tag, false
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	case reflect.Struct:
+		if t, ok := v.Interface().(time.Time); ok {
+			return t.IsZero()
+		}
+	}
+	return false
+}
+
+// bindsQuery reports whether the default binder reads bodiless requests of
+// method from the query string.
+func bindsQuery(method string) bool {
+	return method == GET || method == HEAD || method == DELETE
+}
+
+// DecodeForm decodes url.Values into the struct pointed to by i, using the
+// `form` tag for field names. Scalars, slices of them, pointers,
+// encoding.TextUnmarshaler implementations and time.Time (RFC 3339) are
+// supported. Empty values leave non-string fields untouched.
+func DecodeForm(vals url.Values, i interface{}) error {
+	return decodeForm(vals, nil, i)
+}
+
+// decodeForm decodes vals, and the uploaded files into *multipart.FileHeader
+// and []*multipart.FileHeader fields.
+func decodeForm(vals url.Values, files map[string][]*multipart.FileHeader, i interface{}) error {
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("form: decode into non-pointer")
+	}
+	v = v.Elem()
+	switch v.Kind() {
+	case reflect.Struct:
+		return decodeStruct(vals, files, v)
+	case reflect.Map:
+		t := v.Type()
+		if t.Key().Kind() != reflect.String {
+			break
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(t))
+		}
+		for k, ss := range vals {
+			ev := reflect.New(t.Elem()).Elem()
+			if err := decodeValue(ev, ss); err != nil {
+				return fmt.Errorf("form: field %s: %v", k, err)
+			}
+			v.SetMapIndex(reflect.ValueOf(k).Convert(t.Key()), ev)
+		}
+		return nil
+	}
+	return fmt.Errorf("form: cannot decode into %s", v.Type())
+}
+
+func decodeStruct(vals url.Values, files map[string][]*multipart.FileHeader, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := v.Field(i)
+		name, _ := parseFormTag(sf)
+		if name == "-" {
+			continue
+		}
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && ft != timeType {
+				if fv.Kind() == reflect.Ptr {
+					if fv.IsNil() {
+						if !fv.CanSet() {
+							continue
+						}
+						fv.Set(reflect.New(ft))
+					}
+					fv = fv.Elem()
+				}
+				if err := decodeStruct(vals, files, fv); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		switch sf.Type {
+		case fileHeaderType:
+			if fh := files[name]; len(fh) > 0 {
+				fv.Set(reflect.ValueOf(fh[0]))
+			}
+			continue
+		case fileHeadersType:
+			if fh := files[name]; len(fh) > 0 {
+				fv.Set(reflect.ValueOf(fh))
+			}
+			continue
+		}
+		ss, ok := vals[name]
+		if !ok || len(ss) == 0 {
+			continue
+		}
+		if err := decodeValue(fv, ss); err != nil {
+			return fmt.Errorf("form: field %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func decodeValue(v reflect.Value, ss []string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			if len(ss) == 1 && ss[0] == "" && v.Type().Elem().Kind() != reflect.String {
+				return nil
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeValue(v.Elem(), ss)
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		sl := reflect.MakeSlice(v.Type(), len(ss), len(ss))
+		for i, s := range ss {
+			if err := parseValue(sl.Index(i), s); err != nil {
+				return err
+			}
+		}
+		v.Set(sl)
+		return nil
+	}
+	return parseValue(v, ss[0])
+}
+
+func parseValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if s == "" {
+			return nil
+		}
+		return u.UnmarshalText([]byte(s))
+	}
+	if v.Kind() == reflect.String {
+		v.SetString(s)
+		return nil
+	}
+	if v.Kind() == reflect.Slice { // []byte
+		v.SetBytes([]byte(s))
+		return nil
+	}
+	if s == "" {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if s == "on" { // checkboxes
+			s = "true"
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// decodeTagged sets the fields of the struct pointed to by i which have the
+// given tag, from the values returned by lookup. Fields without a value are
+// left untouched.
+func decodeTagged(i interface{}, tag string, lookup func(name string) []string) error {
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	return decodeTaggedStruct(v, tag, lookup)
+}
+
+func decodeTaggedStruct(v reflect.Value, tag string, lookup func(name string) []string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := v.Field(i)
+		name := sf.Tag.Get(tag)
+		if sf.Anonymous && name == "" {
+			if fv.Kind() == reflect.Ptr && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+				if err := decodeTaggedStruct(fv, tag, lookup); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if name == "" || name == "-" || sf.PkgPath != "" {
+			continue
+		}
+		ss := lookup(name)
+		if len(ss) == 0 {
+			continue
+		}
+		if err := decodeValue(fv, ss); err != nil {
+			return fmt.Errorf("%s: field %s: %v", tag, name, err)
+		}
+	}
+	return nil
+}
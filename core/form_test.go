@@ -0,0 +1,312 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// upper is a form field type decoded through encoding.TextUnmarshaler.
+type upper string
+
+func (u *upper) UnmarshalText(b []byte) error {
+	if len(b) > 8 {
+		return errors.New("too long")
+	}
+	*u = upper(strings.ToUpper(string(b)))
+	return nil
+}
+
+type (
+	formPage struct {
+		Page int `form:"page"`
+		Size uint8
+	}
+
+	formUser struct {
+		formPage
+		Name    string    `form:"name"`
+		Tags    []string  `form:"tag"`
+		IDs     []int     `form:"id"`
+		Admin   bool      `form:"admin"`
+		Score   *float64  `form:"score"`
+		Born    time.Time `form:"born"`
+		Code    upper     `form:"code"`
+		Raw     []byte    `form:"raw"`
+		Skipped string    `form:"-"`
+		secret  string
+	}
+)
+
+func TestDecodeForm(t *testing.T) {
+	score := 1.5
+	born := time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, tt := range []struct {
+		query string
+		want  formUser
+		err   string
+	}{
+		{"", formUser{}, ""},
+		{
+			"page=2&Size=10&name=Ann&tag=a&tag=b&id=1&id=2&admin=on&score=1.5&born=2000-01-02T03:04:05Z&code=ab&raw=xyz&Skipped=x&secret=x",
+			formUser{formPage: formPage{2, 10}, Name: "Ann", Tags: []string{"a", "b"}, IDs: []int{1, 2}, Admin: true,
+				Score: &score, Born: born, Code: "AB", Raw: []byte("xyz")},
+			"",
+		},
+		// Empty values leave the fields untouched, but strings
+		{"page=&admin=&score=&born=&code=&name=", formUser{}, ""},
+		{"admin=false", formUser{}, ""},
+
+		{"page=x", formUser{}, "form: field page: strconv.ParseInt: parsing \"x\": invalid syntax"},
+		{"Size=300", formUser{}, "form: field Size: strconv.ParseUint: parsing \"300\": value out of range"},
+		{"id=1&id=x", formUser{}, "form: field id: strconv.ParseInt: parsing \"x\": invalid syntax"},
+		{"admin=maybe", formUser{}, "form: field admin: strconv.ParseBool: parsing \"maybe\": invalid syntax"},
+		{"born=yesterday", formUser{}, "form: field born: parsing time \"yesterday\" as \"2006-01-02T15:04:05Z07:00\": cannot parse \"yesterday\" as \"2006\""},
+		{"code=abcdefghij", formUser{}, "form: field code: too long"},
+	} {
+		vals, _ := url.ParseQuery(tt.query)
+		var u formUser
+		err := DecodeForm(vals, &u)
+		if tt.err != "" {
+			if assert.Error(t, err, tt.query) {
+				assert.Equal(t, tt.err, err.Error())
+			}
+			continue
+		}
+		assert.NoError(t, err, tt.query)
+		assert.Equal(t, tt.want, u, tt.query)
+	}
+
+	// Maps of string keys
+	m := map[string]int{}
+	assert.NoError(t, DecodeForm(url.Values{"a": {"1"}, "b": {"2", "3"}}, &m))
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, m)
+	var ms map[string][]string
+	assert.NoError(t, DecodeForm(url.Values{"a": {"1", "2"}}, &ms))
+	assert.Equal(t, map[string][]string{"a": {"1", "2"}}, ms)
+
+	// Nothing else
+	var u formUser
+	assert.Error(t, DecodeForm(url.Values{}, u))
+	assert.Error(t, DecodeForm(url.Values{}, (*formUser)(nil)))
+	var n int
+	assert.Error(t, DecodeForm(url.Values{}, &n))
+	assert.Error(t, DecodeForm(url.Values{}, &map[int]string{}))
+	var unsupported struct {
+		C chan int `form:"c"`
+	}
+	assert.Error(t, DecodeForm(url.Values{"c": {"1"}}, &unsupported))
+}
+
+// multipartBody returns a multipart body of the fields and files, a file
+// being named after its field.
+func multipartBody(fields [][2]string, files [][2]string) (*bytes.Buffer, string) {
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	for _, f := range fields {
+		w.WriteField(f[0], f[1])
+	}
+	for _, f := range files {
+		fw, _ := w.CreateFormFile(f[0], f[0]+".txt")
+		fw.Write([]byte(f[1]))
+	}
+	w.Close()
+	return &b, w.FormDataContentType()
+}
+
+func TestBindForm(t *testing.T) {
+	type upload struct {
+		Title  string                  `form:"title"`
+		Tags   []string                `form:"tag"`
+		Avatar *multipart.FileHeader   `form:"avatar"`
+		Docs   []*multipart.FileHeader `form:"doc"`
+		None   *multipart.FileHeader   `form:"none"`
+	}
+	e := New()
+	bind := func(method, target, contentType string, body *bytes.Buffer, i interface{}) error {
+		var req *http.Request
+		if body == nil {
+			req = httptest.NewRequest(method, target, nil)
+		} else {
+			req = httptest.NewRequest(method, target, body)
+		}
+		if contentType != "" {
+			req.Header.Set(ContentType, contentType)
+		}
+		c := NewContext(req, NewResponse(httptest.NewRecorder(), e), e)
+		return c.Bind(i)
+	}
+
+	// URL-encoded, the query isn't bound
+	var u formUser
+	body := bytes.NewBufferString("name=Ann&tag=a&tag=b&admin=on")
+	assert.NoError(t, bind(POST, "/?page=3", ApplicationForm+"; charset=utf-8", body, &u))
+	assert.Equal(t, formUser{Name: "Ann", Tags: []string{"a", "b"}, Admin: true}, u)
+
+	u = formUser{}
+	err := bind(POST, "/", ApplicationForm, bytes.NewBufferString("page=x"), &u)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "form: field page")
+	}
+
+	// Multipart, with files
+	var up upload
+	body, ct := multipartBody(
+		[][2]string{{"title", "Report"}, {"tag", "a"}, {"tag", "b"}},
+		[][2]string{{"avatar", "me"}, {"doc", "one"}, {"doc", "two"}},
+	)
+	assert.NoError(t, bind(POST, "/", ct, body, &up))
+	assert.Equal(t, "Report", up.Title)
+	assert.Equal(t, []string{"a", "b"}, up.Tags)
+	assert.Nil(t, up.None)
+	if assert.NotNil(t, up.Avatar) {
+		assert.Equal(t, "avatar.txt", up.Avatar.Filename)
+		f, _ := up.Avatar.Open()
+		b, _ := ioutil.ReadAll(f)
+		f.Close()
+		assert.Equal(t, "me", string(b))
+	}
+	if assert.Equal(t, 2, len(up.Docs)) {
+		assert.Equal(t, "doc.txt", up.Docs[0].Filename)
+		assert.Equal(t, int64(3), up.Docs[1].Size)
+	}
+
+	// A malformed multipart body
+	assert.Error(t, bind(POST, "/", MultipartForm+"; boundary=x", bytes.NewBufferString("nope"), &up))
+
+	// Bodiless GET, HEAD and DELETE requests bind the query
+	for _, method := range []string{GET, HEAD, DELETE} {
+		u = formUser{}
+		assert.NoError(t, bind(method, "/?page=3&Size=5&name=Bob", "", nil, &u), method)
+		assert.Equal(t, formUser{formPage: formPage{3, 5}, Name: "Bob"}, u, method)
+	}
+	// but not others
+	u = formUser{}
+	assert.NoError(t, bind(POST, "/?page=3", "", nil, &u))
+	assert.Equal(t, formUser{}, u)
+
+	// Unknown media types
+	assert.Equal(t, UnsupportedMediaType, bind(POST, "/", "text/csv", bytes.NewBufferString("a,b"), &u))
+}
+
+func TestEncodeQuery(t *testing.T) {
+	score := 1.5
+	u := formUser{formPage: formPage{2, 10}, Name: "Ann", Tags: []string{"a", "b"}, Score: &score,
+		Born: time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC), Code: "AB", Skipped: "x", secret: "x"}
+	vals, err := EncodeQuery(&u)
+	assert.NoError(t, err)
+	assert.Equal(t, "Size=10&admin=false&born=2000-01-02T03%3A04%3A05Z&code=AB&name=Ann&page=2&raw=&score=1.5&tag=a&tag=b", vals.Encode())
+
+	// What's encoded decodes back
+	var back formUser
+	assert.NoError(t, DecodeForm(vals, &back))
+	u.Skipped, u.secret = "", ""
+	u.Raw = []byte{}
+	assert.Equal(t, u, back)
+
+	type omit struct {
+		Q    string `form:"q,omitempty"`
+		Page int    `form:"page,omitempty"`
+	}
+	vals, _ = EncodeQuery(omit{})
+	assert.Equal(t, "", vals.Encode())
+	vals, _ = EncodeQuery(map[string]interface{}{"a": 1, "b": []string{"x", "y"}})
+	assert.Equal(t, "a=1&b=x&b=y", vals.Encode())
+	_, err = EncodeQuery(3)
+	assert.Error(t, err)
+}
+
+func TestBindTagged(t *testing.T) {
+	type (
+		auth struct {
+			Token string `header:"X-Token"`
+		}
+		request struct {
+			auth
+			ID      int64    `param:"id"`
+			Slug    upper    `param:"slug"`
+			Lang    []string `header:"accept-language"`
+			Trace   *int     `header:"X-Trace"`
+			Q       string   `query:"q"`
+			Page    int      `query:"page" json:"page"`
+			Name    string   `json:"name" form:"name"`
+			Ignored string   `param:"-"`
+		}
+	)
+	e := New()
+	var got request
+	e.Any("/posts/:id/:slug", func(c *Context) error {
+		got = request{}
+		return c.Bind(&got)
+	})
+	e.SetHTTPErrorHandler(func(err error, c *Context) {
+		c.String(http.StatusBadRequest, err.Error())
+	})
+	trace := 7
+	for _, tt := range []struct {
+		method, target, contentType, body string
+		header                            http.Header
+		want                              request
+		err                               string
+	}{
+		// From the path, the headers and the query, with a JSON body
+		{
+			POST, "/posts/42/hello?q=go&page=2", ApplicationJSON, `{"name":"Ann","page":9}`,
+			http.Header{"X-Token": {"t"}, "Accept-Language": {"fr", "en"}, "X-Trace": {"7"}},
+			request{auth{"t"}, 42, "HELLO", []string{"fr", "en"}, &trace, "go", 2, "Ann", ""},
+			"",
+		},
+		// a form body
+		{
+			PUT, "/posts/1/a", ApplicationForm, "name=Bob&q=body", nil,
+			request{ID: 1, Slug: "A", Name: "Bob"},
+			"",
+		},
+		// or no body at all, whatever the method
+		{
+			POST, "/posts/1/a?q=go", "", "", nil,
+			request{ID: 1, Slug: "A", Q: "go"},
+			"",
+		},
+		{
+			GET, "/posts/1/a?name=Cid&q=go", "", "", nil,
+			request{ID: 1, Slug: "A", Q: "go", Name: "Cid"},
+			"",
+		},
+
+		// Values which don't convert
+		{GET, "/posts/x/a", "", "", nil, request{}, "param: field id: strconv.ParseInt: parsing \"x\": invalid syntax"},
+		{GET, "/posts/1/abcdefghij", "", "", nil, request{}, "param: field slug: too long"},
+		{GET, "/posts/1/a", "", "", http.Header{"X-Trace": {"x"}}, request{}, "header: field X-Trace: strconv.ParseInt: parsing \"x\": invalid syntax"},
+		{GET, "/posts/1/a?page=x", "", "", nil, request{}, "query: field page: strconv.ParseInt: parsing \"x\": invalid syntax"},
+
+		// The body still needs a known media type
+		{POST, "/posts/1/a", "text/csv", "a,b", nil, request{}, UnsupportedMediaType.Error()},
+	} {
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			req.Header.Set(ContentType, tt.contentType)
+		}
+		for k, v := range tt.header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if tt.err != "" {
+			assert.Equal(t, http.StatusBadRequest, rec.Code, tt.target)
+			assert.Contains(t, rec.Body.String(), tt.err, tt.target)
+			continue
+		}
+		assert.Equal(t, http.StatusOK, rec.Code, tt.target+" "+rec.Body.String())
+		assert.Equal(t, tt.want, got, tt.target)
+	}
+}
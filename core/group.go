@@ -13,6 +13,11 @@ func (g *Group) Use(m ...Middleware) {
 	// }
 }
 
+// CacheControl returns the default Cache-Control policy of the group.
+func (g *Group) CacheControl() *CacheControl {
+	return g.echo.CacheControl()
+}
+
 func (g *Group) Connect(path string, h Handler) {
 	g.echo.Connect(path, h)
 }
@@ -49,6 +54,21 @@ func (g *Group) Trace(path string, h Handler) {
 	g.echo.Trace(path, h)
 }
 
+// Method adds a route for any method, see Echo.Method.
+func (g *Group) Method(method, path string, h Handler) {
+	g.echo.Method(method, path, h)
+}
+
+// Remove unregisters a route of the group, see Echo.Remove.
+func (g *Group) Remove(method, path string) bool {
+	return g.echo.Remove(method, path)
+}
+
+// Replace swaps the handler of a route of the group, see Echo.Replace.
+func (g *Group) Replace(method, path string, h Handler) bool {
+	return g.echo.Replace(method, path, h)
+}
+
 func (g *Group) Any(path string, h Handler) {
 	for _, m := range methods {
 		g.echo.add(m, path, h)
@@ -84,3 +104,23 @@ func (g *Group) Group(prefix string, m ...Middleware) *Group {
 func (g *Group) Echo() *Echo {
 	return &(g.echo)
 }
+
+// SetRenderer sets the renderer of the routes of the group, e.g. the views of
+// an admin site. Without it, the group uses the renderer of the Echo instance.
+// Groups created from the group afterwards inherit it.
+func (g *Group) SetRenderer(r Renderer) {
+	g.echo.SetRenderer(r)
+}
+
+// SetBinder sets the binder of the routes of the group. Without it, the group
+// uses the binder of the Echo instance. Groups created from the group
+// afterwards inherit it.
+func (g *Group) SetBinder(b Binder) {
+	g.echo.SetBinder(b)
+}
+
+// RegisterBinder registers fn to decode request bodies of contentType for the
+// routes of the group only, see Echo.RegisterBinder.
+func (g *Group) RegisterBinder(contentType string, fn BindFunc) {
+	g.echo.RegisterBinder(contentType, fn)
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// nameRenderer renders its name then the template name.
+type nameRenderer string
+
+func (r nameRenderer) Render(w io.Writer, name string, data interface{}) error {
+	_, err := fmt.Fprintf(w, "%s:%s", r, name)
+	return err
+}
+
+func TestGroupRenderer(t *testing.T) {
+	e := New()
+	e.SetRenderer(nameRenderer("root"))
+	admin := e.Group("/admin")
+	admin.SetRenderer(nameRenderer("admin"))
+	users := admin.Group("/users")
+	blog := e.Group("/blog")
+	render := func(c *Context) error { return c.Render(http.StatusOK, "index", nil) }
+	e.Get("/", render)
+	admin.Get("/", render)
+	users.Get("/", render)
+	blog.Get("/", render)
+
+	get := func(path string) string {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(GET, path, nil))
+		return rec.Body.String()
+	}
+	assert.Equal(t, "root:index", get("/"))
+	assert.Equal(t, "admin:index", get("/admin/"))
+	assert.Equal(t, "admin:index", get("/admin/users/"))
+	assert.Equal(t, "root:index", get("/blog/"))
+
+	// Groups without their own renderer follow the Echo instance
+	e.SetRenderer(nameRenderer("site"))
+	assert.Equal(t, "site:index", get("/"))
+	assert.Equal(t, "site:index", get("/blog/"))
+	assert.Equal(t, "admin:index", get("/admin/"))
+}
+
+func TestGroupBinder(t *testing.T) {
+	e := New()
+	csv := e.Group("/csv")
+	csv.RegisterBinder("text/csv", func(r io.Reader, i interface{}) error {
+		b, err := ioutil.ReadAll(r)
+		*(i.(*[]string)) = strings.Split(string(b), ",")
+		return err
+	})
+	plain := e.Group("/plain")
+	bind := func(c *Context) error {
+		var v []string
+		if err := c.Bind(&v); err != nil {
+			return c.String(http.StatusUnsupportedMediaType, err.Error())
+		}
+		return c.JSON(http.StatusOK, v)
+	}
+	e.Post("/", bind)
+	csv.Post("/", bind)
+	plain.Post("/", bind)
+
+	post := func(path, contentType, body string) string {
+		req := httptest.NewRequest(POST, path, strings.NewReader(body))
+		req.Header.Set(ContentType, contentType)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return fmt.Sprint(rec.Code, " ", rec.Body.String())
+	}
+	assert.Equal(t, `200 ["a","b"]`, post("/csv/", "text/csv", "a,b"))
+	assert.Equal(t, `200 ["a"]`, post("/csv/", ApplicationJSON, `["a"]`))
+	assert.Equal(t, "415 unsupported media type", post("/", "text/csv", "a,b"))
+	assert.Equal(t, "415 unsupported media type", post("/plain/", "text/csv", "a,b"))
+
+	// Groups without their own binder follow the Echo instance
+	e.RegisterBinder("text/csv", func(r io.Reader, i interface{}) error {
+		*(i.(*[]string)) = []string{"root"}
+		return nil
+	})
+	assert.Equal(t, `200 ["root"]`, post("/", "text/csv", "a,b"))
+	assert.Equal(t, `200 ["root"]`, post("/plain/", "text/csv", "a,b"))
+	assert.Equal(t, `200 ["a","b"]`, post("/csv/", "text/csv", "a,b"))
+}
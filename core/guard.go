@@ -0,0 +1,124 @@
+package core
+
+import (
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// responseGuard watches a response in debug mode for common mistakes, which
+// are logged as warnings with the route: sending the status twice, setting
+// headers once it's sent, and returning an error after it.
+//
+// Headers are changed through the map returned by Response.Header, out of
+// sight, so the stack logged with a change is the one of the last call to
+// Header before the change was seen. There's none when the map was obtained
+// before the response was committed.
+type responseGuard struct {
+	route string
+	// header is a copy of the header as it was when the response was
+	// committed, nil before.
+	header http.Header
+	// last is the stack of the last call to Header once committed, culprit
+	// the one of the call before the first change was seen.
+	last, culprit []byte
+}
+
+// committed records the header sent with the status.
+func (g *responseGuard) committed(h http.Header) {
+	if g.header == nil {
+		g.header = cloneHeader(h)
+	}
+}
+
+// headerCalled records the stack of a call to Response.Header once
+// committed, blaming the previous call if the header changed since.
+func (g *responseGuard) headerCalled(h http.Header) {
+	if g.culprit == nil && g.last != nil && len(changedHeaders(g.header, h)) > 0 {
+		g.culprit = g.last
+	}
+	g.last = debug.Stack()
+}
+
+// rewrite reports WriteHeader being called again, with the stack of the call.
+func (g *responseGuard) rewrite(r *Response, code int) {
+	r.echo.Logger().Warn("%s: WriteHeader(%d) called after the status %d was sent\n%s", g.route, code, r.status, debug.Stack())
+}
+
+// check reports the mistakes visible once the handler returned err.
+func (g *responseGuard) check(r *Response, err error) {
+	log := r.echo.Logger()
+	if g.header == nil || r.notModified {
+		// After a 304 the body writers still set their headers, harmlessly
+		return
+	}
+	if err != nil {
+		log.Warn("%s: handler returned an error after the status %d was sent, the client won't see it: %v", g.route, r.status, err)
+	}
+	h := r.writer.Header()
+	changed := changedHeaders(g.header, h)
+	if len(changed) == 0 {
+		return
+	}
+	for _, k := range changed {
+		if k == ContentType {
+			log.Warn("%s: Content-Type changed from %q to %q after the response was committed, the client got the first one", g.route, g.header.Get(k), h.Get(k))
+		}
+	}
+	stack := g.culprit
+	if stack == nil {
+		stack = g.last
+	}
+	if stack == nil {
+		log.Warn("%s: headers changed after the response was committed, they weren't sent: %s", g.route, strings.Join(changed, ", "))
+		return
+	}
+	log.Warn("%s: headers changed after the response was committed, they weren't sent: %s\n%s", g.route, strings.Join(changed, ", "), stack)
+}
+
+// changedHeaders returns the sorted keys of h which differ from sent,
+// trailers excepted.
+func changedHeaders(sent, h http.Header) []string {
+	var changed []string
+	for k, v := range h {
+		if !isTrailer(k, sent) && !equalValues(v, sent[k]) {
+			changed = append(changed, k)
+		}
+	}
+	for k := range sent {
+		if _, ok := h[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// isTrailer reports whether the header key is a trailer, which may be set
+// after the body.
+func isTrailer(key string, header http.Header) bool {
+	if strings.HasPrefix(key, http.TrailerPrefix) {
+		return true
+	}
+	for _, v := range header[Trailer] {
+		for _, t := range strings.Split(v, ",") {
+			if http.CanonicalHeaderKey(strings.TrimSpace(t)) == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseGuard(t *testing.T) {
+	mod := time.Date(2016, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name     string
+		h        HandlerFunc
+		warnings []string
+		stack    bool
+	}{
+		{"clean", func(c *Context) error {
+			c.Response().Header().Set("X-Early", "1")
+			return c.String(http.StatusOK, "ok")
+		}, nil, false},
+		{"rewrite", func(c *Context) error {
+			c.String(http.StatusOK, "ok")
+			c.Response().WriteHeader(http.StatusInternalServerError)
+			return nil
+		}, []string{"GET /: WriteHeader(500) called after the status 200 was sent"}, true},
+		{"late header", func(c *Context) error {
+			c.String(http.StatusOK, "ok")
+			c.Response().Header().Set("X-Late", "1")
+			return nil
+		}, []string{"GET /: headers changed after the response was committed, they weren't sent: X-Late"}, true},
+		{"header obtained before", func(c *Context) error {
+			h := c.Response().Header()
+			c.String(http.StatusOK, "ok")
+			h.Set("X-Late", "1")
+			h.Del(ContentType)
+			return nil
+		}, []string{
+			`GET /: Content-Type changed from "text/plain; charset=utf-8" to "" after the response was committed, the client got the first one`,
+			"GET /: headers changed after the response was committed, they weren't sent: Content-Type, X-Late",
+		}, false},
+		{"error after commit", func(c *Context) error {
+			c.String(http.StatusOK, "ok")
+			return errors.New("too late")
+		}, []string{"GET /: handler returned an error after the status 200 was sent, the client won't see it: too late"}, false},
+		{"trailer", func(c *Context) error {
+			c.Response().Header().Set(Trailer, "X-Checksum")
+			c.String(http.StatusOK, "ok")
+			c.Response().Header().Set("X-Checksum", "abc")
+			return nil
+		}, nil, false},
+		{"not modified", func(c *Context) error {
+			if c.LastModified(mod) {
+				return nil
+			}
+			return c.JSON(http.StatusOK, 1)
+		}, nil, false},
+	} {
+		var buf bytes.Buffer
+		e := New()
+		e.logger = log.New("guard")
+		e.logger.SetOutput(&buf)
+		e.SetDebug(true)
+		e.Get("/", tt.h)
+		req := httptest.NewRequest(GET, "/", nil)
+		req.Header.Set(IfModifiedSince, mod.Format(http.TimeFormat))
+		e.ServeHTTP(httptest.NewRecorder(), req)
+
+		out := buf.String()
+		for _, w := range tt.warnings {
+			assert.Contains(t, out, w, tt.name)
+		}
+		if len(tt.warnings) == 0 {
+			assert.NotContains(t, out, "WARN", tt.name)
+		}
+		// The stack points at the test
+		if tt.stack {
+			assert.Contains(t, out, "guard_test.go", tt.name)
+		} else {
+			assert.NotContains(t, out, "goroutine", tt.name)
+		}
+	}
+}
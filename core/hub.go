@@ -0,0 +1,182 @@
+package core
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/henrylee2cn/thinkgo/core/websocket"
+)
+
+// DefaultHubBuffer is the number of messages buffered per client by default.
+const DefaultHubBuffer = 16
+
+// ErrNotWebSocket is returned by Hub.ServeWebSocket outside WebSocket routes.
+var ErrNotWebSocket = errors.New("not a WebSocket request")
+
+type (
+	// Hub broadcasts messages by topic to the connected SSE and WebSocket
+	// clients, e.g. for chats or notifications. It's safe for concurrent use.
+	//
+	//	hub := core.NewHub(0)
+	//	e.Get("/events", func(c *core.Context) error {
+	//		return hub.ServeSSE(c, "news")
+	//	})
+	//	e.WebSocket("/ws", func(c *core.Context) error {
+	//		return hub.ServeWebSocket(c, "news", "user:"+c.Param("id"))
+	//	})
+	//	hub.Publish("news", article)
+	Hub struct {
+		buffer int
+		mu     sync.RWMutex
+		topics map[string]map[*HubClient]struct{}
+	}
+
+	// HubMessage is a message published on a topic.
+	HubMessage struct {
+		Topic string      `json:"topic"`
+		Data  interface{} `json:"data"`
+	}
+
+	// HubClient is a subscriber of a Hub.
+	HubClient struct {
+		hub      *Hub
+		topics   []string
+		messages chan HubMessage
+		done     chan struct{}
+		once     sync.Once
+	}
+)
+
+// NewHub returns a Hub buffering buffer messages per client, DefaultHubBuffer
+// if not positive.
+func NewHub(buffer int) *Hub {
+	if buffer <= 0 {
+		buffer = DefaultHubBuffer
+	}
+	return &Hub{buffer: buffer, topics: make(map[string]map[*HubClient]struct{})}
+}
+
+// Subscribe returns a client receiving the messages of topics, which must be
+// closed once done.
+func (h *Hub) Subscribe(topics ...string) *HubClient {
+	cl := &HubClient{
+		hub:      h,
+		topics:   topics,
+		messages: make(chan HubMessage, h.buffer),
+		done:     make(chan struct{}),
+	}
+	h.mu.Lock()
+	for _, t := range topics {
+		if h.topics[t] == nil {
+			h.topics[t] = make(map[*HubClient]struct{})
+		}
+		h.topics[t][cl] = struct{}{}
+	}
+	h.mu.Unlock()
+	return cl
+}
+
+// Publish sends data to the clients subscribed to topic and returns how many
+// got it. It never blocks: a client whose buffer is full is too slow to keep
+// up and gets closed, an SSE client will reconnect.
+func (h *Hub) Publish(topic string, data interface{}) int {
+	m := HubMessage{Topic: topic, Data: data}
+	var n int
+	var slow []*HubClient
+	h.mu.RLock()
+	for cl := range h.topics[topic] {
+		select {
+		case cl.messages <- m:
+			n++
+		default:
+			slow = append(slow, cl)
+		}
+	}
+	h.mu.RUnlock()
+	for _, cl := range slow {
+		cl.Close()
+	}
+	return n
+}
+
+// Clients returns the number of clients subscribed to topic.
+func (h *Hub) Clients(topic string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.topics[topic])
+}
+
+// ServeSSE subscribes the request to topics and streams the messages to it as
+// Server-Sent Events, whose type is the topic, until the client goes away.
+func (h *Hub) ServeSSE(c *Context, topics ...string) error {
+	cl := h.Subscribe(topics...)
+	defer cl.Close()
+	w := c.SSE()
+	done := c.StdContext().Done()
+	for {
+		select {
+		case m := <-cl.messages:
+			if w.Send(m.Topic, m.Data, "") != nil {
+				return nil
+			}
+		case <-cl.done:
+			return nil
+		case <-done:
+			return nil
+		}
+	}
+}
+
+// ServeWebSocket subscribes the WebSocket of the request to topics and sends
+// it the messages as JSON HubMessages until it's closed. Messages from the
+// client are discarded.
+func (h *Hub) ServeWebSocket(c *Context, topics ...string) error {
+	ws := c.Socket()
+	if ws == nil {
+		return ErrNotWebSocket
+	}
+	cl := h.Subscribe(topics...)
+	defer cl.Close()
+	go func() {
+		defer cl.Close()
+		var s string
+		for websocket.Message.Receive(ws, &s) == nil {
+		}
+	}()
+	for {
+		select {
+		case m := <-cl.messages:
+			if websocket.JSON.Send(ws, m) != nil {
+				return nil
+			}
+		case <-cl.done:
+			return nil
+		}
+	}
+}
+
+// Messages returns the channel of the messages published to the client.
+func (cl *HubClient) Messages() <-chan HubMessage {
+	return cl.messages
+}
+
+// Done returns a channel closed once the client is closed.
+func (cl *HubClient) Done() <-chan struct{} {
+	return cl.done
+}
+
+// Close unsubscribes the client.
+func (cl *HubClient) Close() {
+	cl.once.Do(func() {
+		h := cl.hub
+		h.mu.Lock()
+		for _, t := range cl.topics {
+			delete(h.topics[t], cl)
+			if len(h.topics[t]) == 0 {
+				delete(h.topics, t)
+			}
+		}
+		h.mu.Unlock()
+		close(cl.done)
+	})
+}
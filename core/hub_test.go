@@ -0,0 +1,149 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// eventually waits up to a second for cond to hold.
+func eventually(t *testing.T, cond func() bool, msg string) {
+	for i := 0; i < 1000; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Error(msg)
+}
+
+func TestHub(t *testing.T) {
+	h := NewHub(2)
+	a := h.Subscribe("news", "sport")
+	b := h.Subscribe("news")
+	assert.Equal(t, 2, h.Clients("news"))
+	assert.Equal(t, 1, h.Clients("sport"))
+
+	assert.Equal(t, 2, h.Publish("news", 1))
+	assert.Equal(t, 1, h.Publish("sport", 2))
+	assert.Equal(t, 0, h.Publish("weather", 3))
+	assert.Equal(t, HubMessage{"news", 1}, <-a.Messages())
+	assert.Equal(t, HubMessage{"sport", 2}, <-a.Messages())
+	assert.Equal(t, HubMessage{"news", 1}, <-b.Messages())
+
+	// b is too slow: its buffer is full, it's closed
+	assert.Equal(t, 2, h.Publish("news", 4))
+	assert.Equal(t, 2, h.Publish("news", 5))
+	assert.Equal(t, HubMessage{"news", 4}, <-a.Messages())
+	assert.Equal(t, 1, h.Publish("news", 6))
+	select {
+	case <-b.Done():
+	default:
+		t.Error("slow client not closed")
+	}
+	assert.Equal(t, 1, h.Clients("news"))
+	select {
+	case <-a.Done():
+		t.Error("client closed")
+	default:
+	}
+
+	a.Close()
+	a.Close()
+	assert.Equal(t, 0, h.Clients("news"))
+	assert.Equal(t, 0, h.Clients("sport"))
+	assert.Equal(t, 0, len(h.topics))
+}
+
+// TestHubConcurrency is meant for -race: clients come and go while messages
+// are published.
+func TestHubConcurrency(t *testing.T) {
+	h := NewHub(1)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				h.Publish(fmt.Sprint("topic", j%3), j)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				cl := h.Subscribe(fmt.Sprint("topic", i%3), "all")
+				select {
+				case <-cl.Messages():
+				case <-cl.Done():
+				default:
+				}
+				cl.Close()
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 0, len(h.topics))
+}
+
+func TestHubServeSSE(t *testing.T) {
+	h := NewHub(0)
+	e := New()
+	e.Get("/", func(c *Context) error {
+		return h.ServeSSE(c, "news")
+	})
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := readLines(res.Body)
+	eventually(t, func() bool { return h.Clients("news") == 1 }, "not subscribed")
+	h.Publish("news", map[string]int{"id": 1})
+	assert.Equal(t, "event: news", <-lines)
+	assert.Equal(t, `data: {"id":1}`, <-lines)
+
+	// The client goes away, it's unsubscribed
+	res.Body.Close()
+	eventually(t, func() bool { return h.Clients("news") == 0 }, "not unsubscribed")
+}
+
+func TestHubServeWebSocket(t *testing.T) {
+	h := NewHub(0)
+	e := New()
+	e.WebSocket("/ws", func(c *Context) error {
+		return h.ServeWebSocket(c, "news")
+	})
+	e.Get("/", func(c *Context) error {
+		return h.ServeWebSocket(c, "news")
+	})
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, func() bool { return h.Clients("news") == 1 }, "not subscribed")
+	// Messages from the client are discarded
+	assert.NoError(t, websocket.Message.Send(ws, "hello"))
+	h.Publish("news", "first")
+	var m HubMessage
+	assert.NoError(t, websocket.JSON.Receive(ws, &m))
+	assert.Equal(t, HubMessage{"news", "first"}, m)
+
+	ws.Close()
+	eventually(t, func() bool { return h.Clients("news") == 0 }, "not unsubscribed")
+
+	rec := httptest.NewRecorder()
+	c := NewContext(httptest.NewRequest(GET, "/", nil), NewResponse(rec, e), e)
+	assert.Equal(t, ErrNotWebSocket, h.ServeWebSocket(c, "news"))
+}
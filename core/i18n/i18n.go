@@ -0,0 +1,182 @@
+// Package i18n extracts the translation keys used by an application and keeps
+// its locale catalogs complete.
+//
+// Keys are the string literals passed to a function named T, in Go source
+// (`T("key")`, `c.T("key")`) and in templates (`{{T "key"}}`). A catalog is a
+// JSON object mapping the keys to their translations in one locale, e.g.
+// locales/en.json.
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TemplateExts are the extensions of the template files Extract reads.
+var TemplateExts = []string{".html", ".tpl", ".tmpl"}
+
+// templateKey matches `T "key"` and `T("key")` inside template actions.
+var (
+	templateAction = regexp.MustCompile(`(?s){{.*?}}`)
+	templateKey    = regexp.MustCompile("(?:^|[^\\w.])T\\s*\\(?\\s*(\"(?:[^\"\\\\]|\\\\.)*\"|`[^`]*`)")
+)
+
+// Extract walks paths, directories recursively, and returns the sorted keys
+// used in the Go source, tests excepted, and in the templates. Hidden and
+// vendor directories are skipped.
+func Extract(paths ...string) ([]string, error) {
+	seen := map[string]bool{}
+	for _, root := range paths {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			name := info.Name()
+			if info.IsDir() {
+				if path != root && (name == "vendor" || strings.HasPrefix(name, ".")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !isSource(name) {
+				return nil
+			}
+			src, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			keys, err := ExtractFile(path, src)
+			for _, k := range keys {
+				seen[k] = true
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sortedKeys(seen), nil
+}
+
+// ExtractFile returns the keys used in src, Go source or a template depending
+// on the extension of name, in order of appearance.
+func ExtractFile(name string, src []byte) ([]string, error) {
+	if filepath.Ext(name) != ".go" {
+		var keys []string
+		for _, action := range templateAction.FindAll(src, -1) {
+			for _, m := range templateKey.FindAllSubmatch(action, -1) {
+				if k, err := strconv.Unquote(string(m[1])); err == nil {
+					keys = append(keys, k)
+				}
+			}
+		}
+		return keys, nil
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), name, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		switch fn := call.Fun.(type) {
+		case *ast.Ident:
+			ok = fn.Name == "T"
+		case *ast.SelectorExpr:
+			ok = fn.Sel.Name == "T"
+		default:
+			ok = false
+		}
+		if lit, isLit := call.Args[0].(*ast.BasicLit); ok && isLit && lit.Kind == token.STRING {
+			if k, err := strconv.Unquote(lit.Value); err == nil {
+				keys = append(keys, k)
+			}
+		}
+		return true
+	})
+	return keys, nil
+}
+
+// Merge adds the keys missing from the catalog file, created if needed, with
+// empty translations, and returns them along with the keys of the catalog
+// which aren't used anymore. Existing translations are kept, unused ones too
+// unless prune is set.
+func Merge(catalog string, keys []string, prune bool) (added, unused []string, err error) {
+	entries := map[string]string{}
+	if b, err := ioutil.ReadFile(catalog); err == nil {
+		if err = json.Unmarshal(b, &entries); err != nil {
+			return nil, nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	used := map[string]bool{}
+	for _, k := range keys {
+		used[k] = true
+		if _, ok := entries[k]; !ok {
+			entries[k] = ""
+			added = append(added, k)
+		}
+	}
+	for k := range entries {
+		if !used[k] {
+			unused = append(unused, k)
+			if prune {
+				delete(entries, k)
+			}
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(unused)
+	if len(added) == 0 && (!prune || len(unused) == 0) {
+		if _, err := os.Stat(catalog); err == nil {
+			return added, unused, nil
+		}
+	}
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err = enc.Encode(entries); err != nil {
+		return nil, nil, err
+	}
+	if err = os.MkdirAll(filepath.Dir(catalog), 0755); err != nil {
+		return nil, nil, err
+	}
+	return added, unused, ioutil.WriteFile(catalog, buf.Bytes(), 0644)
+}
+
+func isSource(name string) bool {
+	ext := filepath.Ext(name)
+	if ext == ".go" {
+		return !strings.HasSuffix(name, "_test.go")
+	}
+	for _, e := range TemplateExts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
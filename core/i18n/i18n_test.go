@@ -0,0 +1,60 @@
+package i18n
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractFile(t *testing.T) {
+	keys, err := ExtractFile("a.go", []byte(`package a
+
+func f(c *Context) {
+	T("hello")
+	c.T("bye", 1)
+	T(key)
+	Tx("no")
+}
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hello", "bye"}, keys)
+
+	keys, err = ExtractFile("a.html", []byte(`<p>T "outside"</p>{{T "title"}} {{ .T "x" }} {{printf "%s" (T `+"`raw`"+`)}}{{T("call")}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"title", "raw", "call"}, keys)
+}
+
+func TestExtractMerge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "i18n")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	write := func(name, content string) {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write("app/main.go", `package main; func main() { T("b") }`)
+	write("app/main_test.go", `package main; func f() { T("test") }`)
+	write("app/vendor/x.go", `package x; func f() { T("vendor") }`)
+	write("app/views/index.html", `{{T "a"}}{{T "b"}}`)
+
+	keys, err := Extract(filepath.Join(dir, "app"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, keys)
+
+	catalog := filepath.Join(dir, "locales", "en.json")
+	write("locales/en.json", `{"b": "B", "old": "Old"}`)
+	added, unused, err := Merge(catalog, keys, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, added)
+	assert.Equal(t, []string{"old"}, unused)
+	b, _ := ioutil.ReadFile(catalog)
+	assert.Equal(t, "{\n  \"a\": \"\",\n  \"b\": \"B\",\n  \"old\": \"Old\"\n}\n", string(b))
+
+	_, _, err = Merge(catalog, keys, true)
+	assert.NoError(t, err)
+	b, _ = ioutil.ReadFile(catalog)
+	assert.Equal(t, "{\n  \"a\": \"\",\n  \"b\": \"B\"\n}\n", string(b))
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+// paramConverter converts a raw path parameter to an argument value.
+type paramConverter func(string) (reflect.Value, error)
+
+var (
+	contextType         = reflect.TypeOf((*Context)(nil))
+	errorType           = reflect.TypeOf((*error)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// wrapRouteHandler wraps h like wrapHandler, but also accepts handlers taking
+// path parameters as extra arguments, e.g. `func(c *Context, id int) error`
+// for `/users/:id`. Arguments are matched to the route parameters by position
+// and converted by an invoker built once at registration; a parameter which
+// fails to convert is answered with 400 Bad Request.
+func wrapRouteHandler(path string, h Handler) HandlerFunc {
+	fn, err := routeHandler(path, h)
+	if err != nil {
+		panic(err.Error())
+	}
+	return fn
+}
+
+// routeHandler is wrapRouteHandler returning an error instead of panicking.
+func routeHandler(path string, h Handler) (HandlerFunc, error) {
+	if fn, err := paramHandler(path, h); fn != nil || err != nil {
+		return fn, err
+	}
+	return toHandler(h)
+}
+
+func paramHandler(path string, h Handler) (HandlerFunc, error) {
+	v := reflect.ValueOf(h)
+	if !v.IsValid() {
+		return nil, nil
+	}
+	t := v.Type()
+	if t.Kind() != reflect.Func || t.IsVariadic() || t.NumIn() < 2 || t.In(0) != contextType ||
+		t.NumOut() != 1 || t.Out(0) != errorType {
+		return nil, nil
+	}
+	names := pathParams(path)
+	n := t.NumIn() - 1
+	if n > len(names) {
+		return nil, fmt.Errorf("handler takes %d path parameters, route %q has %d", n, path, len(names))
+	}
+	convs := make([]paramConverter, n)
+	for i := range convs {
+		if convs[i] = newParamConverter(t.In(i + 1)); convs[i] == nil {
+			return nil, fmt.Errorf("unsupported type %v for path parameter %q of route %q", t.In(i+1), names[i], path)
+		}
+	}
+	return func(c *Context) error {
+		in := make([]reflect.Value, n+1)
+		in[0] = reflect.ValueOf(c)
+		for i, conv := range convs {
+			arg, err := conv(c.P(i))
+			if err != nil {
+				return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid path parameter %s: %v", names[i], err))
+			}
+			in[i+1] = arg
+		}
+		err, _ := v.Call(in)[0].Interface().(error)
+		return err
+	}, nil
+}
+
+// pathParams returns the parameter names of a route path in order, "_*" being
+// the match-any parameter.
+func pathParams(path string) (names []string) {
+	for i, l := 0, len(path); i < l; i++ {
+		switch path[i] {
+		case ':':
+			j := i + 1
+			for i < l && path[i] != '/' {
+				i++
+			}
+			names = append(names, path[j:i])
+		case '*':
+			return append(names, "_*")
+		}
+	}
+	return
+}
+
+func newParamConverter(t reflect.Type) paramConverter {
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return func(s string) (reflect.Value, error) {
+			v := reflect.New(t)
+			err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+			return v.Elem(), err
+		}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return func(s string) (reflect.Value, error) {
+			return reflect.ValueOf(s).Convert(t), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(s string) (reflect.Value, error) {
+			n, err := strconv.ParseInt(s, 10, t.Bits())
+			v := reflect.New(t).Elem()
+			v.SetInt(n)
+			return v, err
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(s string) (reflect.Value, error) {
+			n, err := strconv.ParseUint(s, 10, t.Bits())
+			v := reflect.New(t).Elem()
+			v.SetUint(n)
+			return v, err
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(s string) (reflect.Value, error) {
+			f, err := strconv.ParseFloat(s, t.Bits())
+			v := reflect.New(t).Elem()
+			v.SetFloat(f)
+			return v, err
+		}
+	case reflect.Bool:
+		return func(s string) (reflect.Value, error) {
+			b, err := strconv.ParseBool(s)
+			v := reflect.New(t).Elem()
+			v.SetBool(b)
+			return v, err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// slug is a path parameter type converted through encoding.TextUnmarshaler.
+type slug string
+
+func (s *slug) UnmarshalText(b []byte) error {
+	if strings.ContainsAny(string(b), " _") {
+		return errors.New("not a slug")
+	}
+	*s = slug(strings.ToLower(string(b)))
+	return nil
+}
+
+type userID uint16
+
+func TestParamHandler(t *testing.T) {
+	e := New()
+	e.Get("/users/:id", func(c *Context, id int) error {
+		return c.String(http.StatusOK, fmt.Sprintf("%T %d", id, id))
+	})
+	e.Get("/users/:id/posts/:slug", func(c *Context, id userID, s slug) error {
+		return c.String(http.StatusOK, fmt.Sprintf("%T %d %T %s", id, id, s, s))
+	})
+	e.Get("/price/:amount/:ok", func(c *Context, amount float64, ok bool) error {
+		return c.String(http.StatusOK, fmt.Sprint(amount, ok))
+	})
+	// Extra route parameters are left to the handler
+	e.Get("/files/:dir/*", func(c *Context, dir string) error {
+		return c.String(http.StatusOK, dir+" "+c.P(1))
+	})
+	e.Get("/fail/:id", func(c *Context, id int8) error {
+		return errors.New("fail " + fmt.Sprint(id))
+	})
+	e.SetHTTPErrorHandler(func(err error, c *Context) {
+		code := http.StatusInternalServerError
+		if he, ok := err.(*HTTPError); ok {
+			code = he.Code()
+		}
+		c.String(code, err.Error())
+	})
+
+	for _, tt := range []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/users/42", http.StatusOK, "int 42"},
+		{"/users/-7", http.StatusOK, "int -7"},
+		{"/users/7/posts/Hello-World", http.StatusOK, "core.userID 7 core.slug hello-world"},
+		{"/price/1.5/true", http.StatusOK, "1.5 true"},
+		{"/files/docs/a/b.txt", http.StatusOK, "docs a/b.txt"},
+
+		// Parameters which don't convert
+		{"/users/abc", http.StatusBadRequest, "invalid path parameter id"},
+		{"/users/70000/posts/a", http.StatusBadRequest, "invalid path parameter id"},
+		{"/users/-1/posts/a", http.StatusBadRequest, "invalid path parameter id"},
+		{"/users/7/posts/a_b", http.StatusBadRequest, "invalid path parameter slug: not a slug"},
+		{"/price/x/true", http.StatusBadRequest, "invalid path parameter amount"},
+		{"/price/1/maybe", http.StatusBadRequest, "invalid path parameter ok"},
+		{"/fail/300", http.StatusBadRequest, "invalid path parameter id"},
+
+		// The error of the handler is returned
+		{"/fail/3", http.StatusInternalServerError, "fail 3"},
+	} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(GET, tt.path, nil))
+		assert.Equal(t, tt.code, rec.Code, tt.path)
+		assert.Contains(t, rec.Body.String(), tt.body, tt.path)
+	}
+}
+
+func TestParamHandlerSignatures(t *testing.T) {
+	for _, tt := range []struct {
+		path string
+		h    Handler
+		err  string
+	}{
+		{"/users/:id", func(c *Context, id, page int) error { return nil },
+			`handler takes 2 path parameters, route "/users/:id" has 1`},
+		{"/users", func(c *Context, id int) error { return nil },
+			`handler takes 1 path parameters, route "/users" has 0`},
+		{"/users/:id", func(c *Context, id []int) error { return nil },
+			`unsupported type []int for path parameter "id" of route "/users/:id"`},
+		{"/users/:id/:at", func(c *Context, id int, at struct{}) error { return nil },
+			`unsupported type struct {} for path parameter "at" of route "/users/:id/:at"`},
+	} {
+		_, err := routeHandler(tt.path, tt.h)
+		if assert.Error(t, err, tt.path) {
+			assert.Equal(t, tt.err, err.Error())
+		}
+		func() {
+			defer func() {
+				assert.Equal(t, "echo => GET "+tt.path+": "+tt.err, fmt.Sprint(recover()))
+			}()
+			New().Get(tt.path, tt.h)
+		}()
+	}
+
+	// Other functions are left to the usual handler types
+	for _, h := range []Handler{
+		func(c *Context) error { return nil },
+		HandlerFunc(func(c *Context) error { return nil }),
+	} {
+		fn, err := routeHandler("/users/:id", h)
+		assert.NoError(t, err)
+		assert.NotNil(t, fn)
+	}
+	for _, h := range []Handler{
+		func(c *Context, id int) {},
+		func(c *Context, ids ...int) error { return nil },
+		func(id int, c *Context) error { return nil },
+	} {
+		_, err := routeHandler("/users/:id", h)
+		assert.Error(t, err, fmt.Sprintf("%T", h))
+	}
+}
+
+func TestPathParams(t *testing.T) {
+	assert.Equal(t, []string(nil), pathParams("/users"))
+	assert.Equal(t, []string{"id"}, pathParams("/users/:id"))
+	assert.Equal(t, []string{"id", "slug"}, pathParams("/users/:id/posts/:slug"))
+	assert.Equal(t, []string{"dir", "_*"}, pathParams("/files/:dir/*"))
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+)
+
+// JSONGroup sends a JSON response with status code, keeping only the fields
+// of i visible to one of groups. Fields are assigned to groups with the
+// `json_group:"public,admin"` tag, untagged fields are always sent:
+//
+//	type User struct {
+//		ID    int    `json:"id"`
+//		Email string `json:"email" json_group:"self,admin"`
+//		Notes string `json:"notes" json_group:"admin"`
+//	}
+//	c.JSONGroup(http.StatusOK, user, "public")
+func (c *Context) JSONGroup(code int, i interface{}, groups ...string) error {
+	return c.JSON(code, FilterGroups(i, groups...))
+}
+
+// FilterGroups returns a copy of i with only the fields visible to one of
+// groups, see Context.JSONGroup. Structs become maps keyed by their json
+// names, honoring omitempty; values implementing json.Marshaler or
+// encoding.TextMarshaler are kept as they are.
+func FilterGroups(i interface{}, groups ...string) interface{} {
+	set := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		set[g] = true
+	}
+	w := &jsonWalker{
+		marshalers: true,
+		field: func(f reflect.StructField, _ reflect.Value) (interface{}, fieldAction) {
+			if tag := f.Tag.Get("json_group"); tag != "" && !inGroups(tag, set) {
+				return nil, fieldSkip
+			}
+			return nil, fieldWalk
+		},
+	}
+	return w.value(reflect.ValueOf(i))
+}
+
+func inGroups(tag string, groups map[string]bool) bool {
+	for _, g := range strings.Split(tag, ",") {
+		if groups[strings.TrimSpace(g)] {
+			return true
+		}
+	}
+	return false
+}
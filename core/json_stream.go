@@ -0,0 +1,72 @@
+package core
+
+import (
+	"encoding/json"
+)
+
+type (
+	// JSONIterator yields the items of JSONStream one by one, e.g. the rows
+	// of a database cursor.
+	JSONIterator interface {
+		// Next returns the next item, ok false once there are no more.
+		Next() (item interface{}, ok bool, err error)
+	}
+
+	// JSONIteratorFunc adapts a function to JSONIterator.
+	JSONIteratorFunc func() (item interface{}, ok bool, err error)
+)
+
+// Next implements JSONIterator.
+func (f JSONIteratorFunc) Next() (interface{}, bool, error) {
+	return f()
+}
+
+// JSONStream sends the items of it as a JSON array with status code, encoding
+// them one at a time and flushing each within the stream flush interval, see
+// Echo.SetStreamFlushInterval, even while the next one is being fetched, so an
+// endpoint returning hundreds of thousands of rows doesn't build them in
+// memory. The next item is only asked for once
+// the previous one is written, so a slow client slows the iteration down.
+//
+// The status code is sent before the first item; if the iteration or an
+// encoding fails, or the request's context is done, JSONStream stops and
+// returns the error, leaving the array unterminated so the client can't take
+// the truncated response for a complete one.
+func (c *Context) JSONStream(code int, it JSONIterator) error {
+	ctx := c.StdContext()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	interval := c.echo.env.streamFlush
+	if interval <= 0 {
+		interval = DefaultStreamFlushInterval
+	}
+	c.response.Header().Set(ContentType, ApplicationJSONCharsetUTF8)
+	c.response.WriteHeader(code)
+	w := &streamWriter{c: c, ctx: ctx, interval: interval}
+	defer w.stop()
+	sep := []byte{'['}
+	for {
+		item, ok, err := it.Next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		b, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		if _, err = w.Write(append(sep, b...)); err != nil {
+			return err
+		}
+		sep = []byte{','}
+	}
+	if sep[0] == '[' {
+		_, err := w.Write([]byte("[]"))
+		return err
+	}
+	_, err := w.Write([]byte{']'})
+	return err
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+type (
+	// jsonWalker copies a value the way encoding/json would see it: structs
+	// become maps keyed by their json names, honoring omitempty, so fields
+	// can be dropped or replaced on the way.
+	jsonWalker struct {
+		// field is called on each struct field which would be encoded.
+		field func(f reflect.StructField, v reflect.Value) (interface{}, fieldAction)
+		// marshalers keeps json.Marshaler values as they are, like
+		// encoding.TextMarshaler ones.
+		marshalers bool
+	}
+
+	// fieldAction tells the walker what to do with a struct field.
+	fieldAction int
+)
+
+// Field actions.
+const (
+	// fieldWalk walks the field value.
+	fieldWalk fieldAction = iota
+	// fieldSkip leaves the field out.
+	fieldSkip
+	// fieldReplace sends the value returned instead.
+	fieldReplace
+)
+
+func (w *jsonWalker) value(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.CanInterface() {
+		switch v.Interface().(type) {
+		case encoding.TextMarshaler:
+			return v.Interface()
+		case json.Marshaler:
+			if w.marshalers {
+				return v.Interface()
+			}
+		}
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return w.value(v.Elem())
+	case reflect.Struct:
+		m := make(map[string]interface{}, v.NumField())
+		w.fields(v, m)
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && (v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8) {
+			return v.Interface()
+		}
+		s := make([]interface{}, v.Len())
+		for j := range s {
+			s[j] = w.value(v.Index(j))
+		}
+		return s
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		m := make(map[string]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			m[mapKey(k)] = w.value(v.MapIndex(k))
+		}
+		return m
+	}
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	return nil
+}
+
+// fields adds the fields of the struct v to m, embedded structs inlined.
+func (w *jsonWalker) fields(v reflect.Value, m map[string]interface{}) {
+	t := v.Type()
+	for j := 0; j < t.NumField(); j++ {
+		f := t.Field(j)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		name, opts := f.Name, ""
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			parts := strings.SplitN(tag, ",", 2)
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			if len(parts) == 2 {
+				opts = "," + parts[1] + ","
+			}
+		}
+		fv := v.Field(j)
+		switch r, action := w.field(f, fv); action {
+		case fieldSkip:
+			continue
+		case fieldReplace:
+			m[name] = r
+			continue
+		}
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				w.fields(fv, m)
+				continue
+			}
+			if f.PkgPath != "" {
+				continue
+			}
+		}
+		if strings.Contains(opts, ",omitempty,") && isEmptyValue(fv) {
+			continue
+		}
+		m[name] = w.value(fv)
+	}
+}
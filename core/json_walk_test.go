@@ -0,0 +1,76 @@
+package core
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type walkBase struct {
+	ID      int       `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+type walkUser struct {
+	walkBase
+	Name     string            `json:"name"`
+	Email    string            `json:"email,omitempty" json_group:"self,admin"`
+	Password string            `json:"password" redact:"true" json_group:"admin"`
+	Secret   string            `json:"-"`
+	Tags     []string          `json:"tags"`
+	Meta     map[string]string `json:"meta"`
+	Friend   *walkUser         `json:"friend,omitempty"`
+	private  string
+}
+
+func walkJSON(t *testing.T, i interface{}) string {
+	b, err := json.Marshal(i)
+	assert.NoError(t, err)
+	return string(b)
+}
+
+func TestFilterGroups(t *testing.T) {
+	u := walkUser{
+		walkBase: walkBase{ID: 1, Created: time.Unix(0, 0).UTC()},
+		Name:     "joe",
+		Password: "hunter2",
+		Secret:   "s",
+		Friend:   &walkUser{Name: "ann", Email: "ann@example.com"},
+		private:  "p",
+	}
+	assert.Equal(t,
+		`{"created":"1970-01-01T00:00:00Z","friend":{"created":"0001-01-01T00:00:00Z","id":0,"meta":null,"name":"ann","tags":null},"id":1,"meta":null,"name":"joe","tags":null}`,
+		walkJSON(t, FilterGroups(u, "public")))
+	type row struct {
+		Name  string `json:"name"`
+		Email string `json:"email" json_group:"admin"`
+	}
+	rows := []row{{"ann", "ann@example.com"}}
+	assert.Equal(t, `[{"name":"ann"}]`, walkJSON(t, FilterGroups(rows, "public")))
+	assert.Equal(t, `[{"email":"ann@example.com","name":"ann"}]`, walkJSON(t, FilterGroups(rows, "public", "admin")))
+	m := FilterGroups(&u, "admin").(map[string]interface{})
+	assert.Equal(t, "hunter2", m["password"])
+	_, ok := m["email"]
+	assert.False(t, ok, "omitempty")
+}
+
+func TestRedact(t *testing.T) {
+	u := walkUser{Name: "joe", Password: "hunter2", Friend: &walkUser{Password: "pw"}}
+	m := Redact(u, nil).(map[string]interface{})
+	assert.Equal(t, RedactMask, m["password"])
+	assert.Equal(t, RedactMask, m["friend"].(map[string]interface{})["password"])
+	assert.Equal(t, "joe", m["name"])
+	_, ok := m["Secret"]
+	assert.False(t, ok)
+
+	// A redact function returning nil still hides the value
+	m = Redact([]walkUser{u}, func(reflect.StructField, reflect.Value) interface{} {
+		return nil
+	}).([]interface{})[0].(map[string]interface{})
+	v, ok := m["password"]
+	assert.True(t, ok)
+	assert.Equal(t, nil, v)
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SetLocales enables locale prefixed routing. Requests like `/en/user/list`
+// are matched as `/user/list` with the locale "en" available through
+// `Context#Locale`. The first locale is the default one, used for requests
+// without a known prefix and left out of generated URLs.
+func (e *Echo) SetLocales(locales ...string) {
+	e.locales = locales
+}
+
+// Locales returns the registered locales, the default one first.
+func (e *Echo) Locales() []string {
+	return e.locales
+}
+
+// DefaultLocale returns the default locale, or "" if locale routing is off.
+func (e *Echo) DefaultLocale() string {
+	if len(e.locales) == 0 {
+		return ""
+	}
+	return e.locales[0]
+}
+
+// LocaleURI generates a URI from handler like `URI`, prefixed with `locale`
+// unless it is the default locale.
+func (e *Echo) LocaleURI(locale string, h Handler, params ...interface{}) string {
+	uri := e.URI(h, params...)
+	if locale == "" || locale == e.DefaultLocale() {
+		return uri
+	}
+	return "/" + locale + uri
+}
+
+// stripLocale removes a known locale prefix from the request path and returns
+// the locale in use for the request.
+func (e *Echo) stripLocale(r *http.Request) string {
+	if len(e.locales) == 0 {
+		return ""
+	}
+	p := strings.TrimPrefix(r.URL.Path, "/")
+	seg := p
+	if i := strings.Index(p, "/"); i != -1 {
+		seg = p[:i]
+	}
+	for _, l := range e.locales {
+		if strings.EqualFold(seg, l) {
+			r.URL.Path = "/" + strings.TrimPrefix(p[len(seg):], "/")
+			r.URL.RawPath = stripSegment(r.URL.RawPath, r.URL.Path)
+			return l
+		}
+	}
+	return e.locales[0]
+}
+
+// stripSegment removes the first segment of the escaped path raw, or returns
+// "" if what is left doesn't unescape to path.
+func stripSegment(raw, path string) string {
+	if raw == "" {
+		return ""
+	}
+	raw = strings.TrimPrefix(raw, "/")
+	if i := strings.Index(raw, "/"); i != -1 {
+		raw = raw[i:]
+	} else {
+		raw = "/"
+	}
+	if p, err := url.PathUnescape(raw); err != nil || p != path {
+		return ""
+	}
+	return raw
+}
+
+// Locale returns the locale of the request, taken from its URL prefix.
+func (c *Context) Locale() string {
+	return c.locale
+}
+
+// SetLocale overrides the locale of the request.
+func (c *Context) SetLocale(locale string) {
+	c.locale = locale
+}
+
+// URI generates a URI from handler, re-inserting the locale prefix of the
+// current request.
+func (c *Context) URI(h Handler, params ...interface{}) string {
+	return c.echo.LocaleURI(c.locale, h, params...)
+}
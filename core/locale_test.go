@@ -0,0 +1,45 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripLocale(t *testing.T) {
+	e := New()
+	e.SetLocales("en", "fr")
+	e.Get("/files/*", func(c *Context) error {
+		u := c.Request().URL
+		return c.String(http.StatusOK, c.Locale()+" "+u.Path+" "+u.RawPath+" "+u.EscapedPath())
+	})
+
+	for _, tt := range []struct {
+		path, want string
+	}{
+		{"/files/a", "en /files/a  /files/a"},
+		{"/fr/files/a", "fr /files/a  /files/a"},
+		{"/FR/files/a", "fr /files/a  /files/a"},
+		// Escaped paths lose the prefix too
+		{"/fr/files/a%2Fb", "fr /files/a/b /files/a%2Fb /files/a%2Fb"},
+		{"/en/files/a%2fb%20c", "en /files/a/b c /files/a%2fb%20c /files/a%2fb%20c"},
+		{"/files/a%2Fb", "en /files/a/b /files/a%2Fb /files/a%2Fb"},
+		{"/f%72/files/a%2Fb", "fr /files/a/b /files/a%2Fb /files/a%2Fb"},
+	} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(GET, tt.path, nil))
+		assert.Equal(t, http.StatusOK, rec.Code, tt.path)
+		assert.Equal(t, tt.want, rec.Body.String(), tt.path)
+	}
+}
+
+func TestStripSegment(t *testing.T) {
+	assert.Equal(t, "", stripSegment("", "/a"))
+	assert.Equal(t, "/a%2Fb", stripSegment("/fr/a%2Fb", "/a/b"))
+	assert.Equal(t, "/", stripSegment("/fr", "/"))
+	// A raw path which doesn't match is dropped
+	assert.Equal(t, "", stripSegment("/fr%2Fx/a", "/x/a"))
+	assert.Equal(t, "", stripSegment("/fr/a%zz", "/a"))
+}
@@ -115,9 +115,40 @@ func (l *Logger) Error(msg interface{}, args ...interface{}) {
 
 func (l *Logger) Fatal(msg interface{}, args ...interface{}) {
 	l.log(FATAL, l.err, msg, args...)
+	l.Flush()
 	os.Exit(1)
 }
 
+// Flush writes out the log lines buffered by the outputs, if they buffer,
+// e.g. a bufio.Writer, and syncs files. It's meant to be called before the
+// program exits.
+func (l *Logger) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var err error
+	for i, w := range []io.Writer{l.out, l.err} {
+		if i == 1 && w == l.out {
+			break
+		}
+		var ferr error
+		switch w := w.(type) {
+		case interface {
+			Flush() error
+		}:
+			ferr = w.Flush()
+		case interface {
+			Sync() error
+		}:
+			// Terminals and pipes can't be synced, that's not an error.
+			w.Sync()
+		}
+		if ferr != nil && err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
 func SetPrefix(p string) {
 	global.SetPrefix(p)
 }
@@ -1,9 +1,6 @@
 package core
 
 import (
-	"net"
-	"time"
-
 	// "github.com/henrylee2cn/thinkgo/core"
 	"github.com/henrylee2cn/thinkgo/core/color"
 )
@@ -15,20 +12,13 @@ func Logger() MiddlewareFunc {
 			res := c.Response()
 			logger := c.Echo().Logger()
 
-			remoteAddr := req.RemoteAddr
-			if ip := req.Header.Get(XRealIP); ip != "" {
-				remoteAddr = ip
-			} else if ip = req.Header.Get(XForwardedFor); ip != "" {
-				remoteAddr = ip
-			} else {
-				remoteAddr, _, _ = net.SplitHostPort(remoteAddr)
-			}
+			remoteAddr := c.RealIP()
 
-			start := time.Now()
+			start := c.Now()
 			if err := next(c); err != nil {
 				c.Error(err)
 			}
-			stop := time.Now()
+			stop := c.Now()
 			method := req.Method
 			path := req.URL.Path
 			if path == "" {
@@ -47,6 +37,10 @@ func Logger() MiddlewareFunc {
 				code = color.Cyan(n)
 			}
 
+			if id := c.RequestID(); id != "" {
+				logger.Info("%s %s %s %s %s %d %s", remoteAddr, method, path, code, stop.Sub(start), size, id)
+				return nil
+			}
 			logger.Info("%s %s %s %s %s %d", remoteAddr, method, path, code, stop.Sub(start), size)
 			return nil
 		}
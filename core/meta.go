@@ -0,0 +1,212 @@
+package core
+
+import (
+	"bytes"
+	"html/template"
+	"net"
+	"strings"
+)
+
+type (
+	// MetaDefaults are the defaults of the page metadata, see Context.Meta.
+	MetaDefaults struct {
+		// SiteName is appended to the titles, "Title - SiteName", and is the
+		// og:site_name.
+		SiteName string
+		// BaseURL is the scheme and host of the canonical URLs, e.g.
+		// "https://example.com".
+		BaseURL string
+		// Hosts are the hosts the request may name when BaseURL is empty, its
+		// scheme and host then being used. The Host header is chosen by the
+		// client, so the canonical URLs are only paths for the other hosts,
+		// and og:url is left out, lest cached pages point elsewhere.
+		Hosts []string
+		// Description and Image are used by the pages which set none.
+		Description string
+		Image       string
+	}
+
+	// Meta builds the metadata of a page: title, description, canonical link
+	// and Open Graph properties.
+	Meta struct {
+		c           *Context
+		title       string
+		description string
+		canonical   string
+		robots      string
+		image       string
+		og          [][2]string
+	}
+)
+
+// metaKey is the key of the Meta in the context store, so templates rendered
+// with it, like BaseController.Render does, can write {{.Meta.HTML}}.
+const metaKey = "Meta"
+
+// SetMetaDefaults sets the defaults of the page metadata. Think sets them from
+// the sitename and siteurl settings of the config.
+func (e *Echo) SetMetaDefaults(d MetaDefaults) {
+	d.BaseURL = strings.TrimRight(d.BaseURL, "/")
+	e.env.meta = d
+}
+
+// Meta returns the metadata of the page being served, stored as "Meta" in the
+// context so the templates can render it in the head:
+//
+//	c.Meta().Title(post.Title).Description(post.Summary).OG("og:type", "article")
+//
+//	<head>{{.Meta.HTML}}</head>
+func (c *Context) Meta() *Meta {
+	if m, ok := c.Get(metaKey).(*Meta); ok {
+		return m
+	}
+	m := &Meta{c: c}
+	c.Set(metaKey, m)
+	return m
+}
+
+// Title sets the title of the page, followed by the site name.
+func (m *Meta) Title(title string) *Meta {
+	m.title = title
+	return m
+}
+
+// Description sets the description of the page.
+func (m *Meta) Description(description string) *Meta {
+	m.description = description
+	return m
+}
+
+// Canonical sets the canonical URL of the page, relative to the base URL if
+// it's a path. It's the URL of the request without its query by default.
+func (m *Meta) Canonical(url string) *Meta {
+	m.canonical = url
+	return m
+}
+
+// CanonicalRoute sets the canonical URL of the page to the route of h, see
+// Context.URI.
+func (m *Meta) CanonicalRoute(h Handler, params ...interface{}) *Meta {
+	m.canonical = m.c.URI(h, params...)
+	return m
+}
+
+// Robots sets the robots directives of the page, e.g. "noindex".
+func (m *Meta) Robots(robots string) *Meta {
+	m.robots = robots
+	return m
+}
+
+// Image sets the image shared with the page, og:image.
+func (m *Meta) Image(url string) *Meta {
+	m.image = url
+	return m
+}
+
+// OG adds an Open Graph property, e.g. OG("og:type", "article").
+// og:title, og:description, og:url, og:image and og:site_name are derived
+// from the other metadata unless added.
+func (m *Meta) OG(property, content string) *Meta {
+	m.og = append(m.og, [2]string{property, content})
+	return m
+}
+
+// CanonicalURL returns the absolute canonical URL of the page, or its path
+// when there's neither BaseURL nor trusted host, see MetaDefaults.
+func (m *Meta) CanonicalURL() string {
+	u := m.canonical
+	if u == "" {
+		u = m.c.request.URL.Path
+		if l := m.c.locale; l != "" && l != m.c.echo.DefaultLocale() {
+			u = "/" + l + u
+		}
+	}
+	if strings.Contains(u, "://") {
+		return u
+	}
+	d := m.c.echo.env.meta
+	base := d.BaseURL
+	if base == "" && trustedHost(m.c.request.Host, d.Hosts) {
+		scheme := "http"
+		if m.c.request.TLS != nil {
+			scheme = "https"
+		}
+		base = scheme + "://" + m.c.request.Host
+	}
+	return base + "/" + strings.TrimLeft(u, "/")
+}
+
+// trustedHost reports whether host, with or without its port, is one of hosts.
+func trustedHost(host string, hosts []string) bool {
+	name := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		name = h
+	}
+	for _, t := range hosts {
+		if strings.EqualFold(t, host) || strings.EqualFold(t, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// absoluteURL returns u if it's absolute, og:url requiring it, else "".
+func absoluteURL(u string) string {
+	if strings.Contains(u, "://") {
+		return u
+	}
+	return ""
+}
+
+// HTML returns the tags of the metadata, escaped.
+func (m *Meta) HTML() template.HTML {
+	d := m.c.echo.env.meta
+	title := m.title
+	if d.SiteName != "" {
+		if title == "" {
+			title = d.SiteName
+		} else {
+			title += " - " + d.SiteName
+		}
+	}
+	description := m.description
+	if description == "" {
+		description = d.Description
+	}
+	image := m.image
+	if image == "" {
+		image = d.Image
+	}
+	canonical := m.CanonicalURL()
+
+	var b bytes.Buffer
+	esc := template.HTMLEscapeString
+	if title != "" {
+		b.WriteString("<title>" + esc(title) + "</title>\n")
+	}
+	meta := func(attr, key, content string) {
+		if content != "" {
+			b.WriteString(`<meta ` + attr + `="` + esc(key) + `" content="` + esc(content) + `">` + "\n")
+		}
+	}
+	meta("name", "description", description)
+	meta("name", "robots", m.robots)
+	b.WriteString(`<link rel="canonical" href="` + esc(canonical) + `">` + "\n")
+	set := map[string]bool{}
+	for _, p := range m.og {
+		set[p[0]] = true
+		meta("property", p[0], p[1])
+	}
+	for _, p := range [][2]string{
+		{"og:title", m.title},
+		{"og:description", description},
+		{"og:url", absoluteURL(canonical)},
+		{"og:image", image},
+		{"og:site_name", d.SiteName},
+	} {
+		if !set[p[0]] {
+			meta("property", p[0], p[1])
+		}
+	}
+	return template.HTML(b.String())
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// metaPage serves the metadata of its pages, set by m.
+func metaPage(d MetaDefaults, m func(*Meta)) *Echo {
+	e := New()
+	e.SetMetaDefaults(d)
+	e.SetLocales("en", "fr")
+	e.Get("/posts/:id", func(c *Context) error {
+		meta := c.Meta()
+		if m != nil {
+			m(meta)
+		}
+		return c.HTML(http.StatusOK, string(meta.HTML()))
+	})
+	return e
+}
+
+func metaGet(e *Echo, host, path string) string {
+	req := httptest.NewRequest(GET, path, nil)
+	req.Host = host
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec.Body.String()
+}
+
+func TestMetaCanonicalURL(t *testing.T) {
+	for _, tt := range []struct {
+		d         MetaDefaults
+		host      string
+		path      string
+		canonical string
+		og        bool
+	}{
+		// The Host header isn't trusted by default
+		{MetaDefaults{}, "evil.com", "/posts/1?a=b", "/posts/1", false},
+		{MetaDefaults{Hosts: []string{"example.com"}}, "evil.com", "/posts/1", "/posts/1", false},
+		{MetaDefaults{Hosts: []string{"example.com"}}, "Example.com:8080", "/posts/1", "http://Example.com:8080/posts/1", true},
+		{MetaDefaults{BaseURL: "https://example.com/", Hosts: []string{"evil.com"}}, "evil.com", "/posts/1", "https://example.com/posts/1", true},
+		// The locale prefix is kept, but the default one
+		{MetaDefaults{BaseURL: "https://example.com"}, "evil.com", "/fr/posts/1", "https://example.com/fr/posts/1", true},
+		{MetaDefaults{BaseURL: "https://example.com"}, "evil.com", "/en/posts/1", "https://example.com/posts/1", true},
+	} {
+		out := metaGet(metaPage(tt.d, nil), tt.host, tt.path)
+		assert.Contains(t, out, `<link rel="canonical" href="`+tt.canonical+`">`, tt.path)
+		if tt.og {
+			assert.Contains(t, out, `<meta property="og:url" content="`+tt.canonical+`">`, tt.path)
+		} else {
+			assert.NotContains(t, out, "og:url", tt.path)
+		}
+	}
+
+	// An absolute canonical URL is kept, a path is relative to the base URL
+	out := metaGet(metaPage(MetaDefaults{BaseURL: "https://example.com"}, func(m *Meta) {
+		m.Canonical("https://other.com/p")
+	}), "example.com", "/posts/1")
+	assert.Contains(t, out, `<link rel="canonical" href="https://other.com/p">`)
+	out = metaGet(metaPage(MetaDefaults{BaseURL: "https://example.com"}, func(m *Meta) {
+		m.Canonical("p/2")
+	}), "example.com", "/fr/posts/1")
+	assert.Contains(t, out, `<link rel="canonical" href="https://example.com/p/2">`)
+}
+
+func TestMetaHTML(t *testing.T) {
+	d := MetaDefaults{
+		SiteName:    `Tom & "Jerry"`,
+		BaseURL:     "https://example.com",
+		Description: "default",
+		Image:       "https://example.com/logo.png",
+	}
+	out := metaGet(metaPage(d, func(m *Meta) {
+		m.Title("<script>alert(1)</script>").
+			Description(`a "quoted" <b>summary</b>`).
+			Robots("noindex").
+			OG("og:type", "article").
+			OG("og:image", `https://example.com/a.png?x="1"`)
+	}), "example.com", "/posts/1")
+	for _, s := range []string{
+		"<title>&lt;script&gt;alert(1)&lt;/script&gt; - Tom &amp; &#34;Jerry&#34;</title>\n",
+		`<meta name="description" content="a &#34;quoted&#34; &lt;b&gt;summary&lt;/b&gt;">`,
+		`<meta name="robots" content="noindex">`,
+		`<meta property="og:type" content="article">`,
+		`<meta property="og:image" content="https://example.com/a.png?x=&#34;1&#34;">`,
+		`<meta property="og:title" content="&lt;script&gt;alert(1)&lt;/script&gt;">`,
+		`<meta property="og:site_name" content="Tom &amp; &#34;Jerry&#34;">`,
+	} {
+		assert.Contains(t, out, s)
+	}
+	assert.NotContains(t, out, "<script>")
+	assert.NotContains(t, out, "logo.png")
+
+	// The defaults fill in what the page doesn't set
+	out = metaGet(metaPage(d, nil), "example.com", "/posts/1")
+	assert.Contains(t, out, "<title>Tom &amp; &#34;Jerry&#34;</title>\n")
+	assert.Contains(t, out, `<meta name="description" content="default">`)
+	assert.Contains(t, out, `<meta property="og:image" content="https://example.com/logo.png">`)
+	assert.NotContains(t, out, "robots")
+}
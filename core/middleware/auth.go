@@ -1,49 +1,285 @@
 package middleware
 
 import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/henrylee2cn/thinkgo/core"
 )
 
 type (
-	BasicValidateFunc func(string, string) bool
+	// BasicValidateFunc validates the credentials of a request.
+	BasicValidateFunc func(user, pass string, c *core.Context) bool
+
+	// BasicAuthConfig defines the config for BasicAuth middleware.
+	BasicAuthConfig struct {
+		// Validator checks the credentials.
+		// Required.
+		Validator BasicValidateFunc
+
+		// Realm is sent in the WWW-Authenticate challenge.
+		// Optional. Default value "Restricted".
+		Realm string
+	}
+
+	// DigestSecretFunc returns HA1, i.e. DigestHA1(user, realm, password), of
+	// a user, so passwords needn't be stored in clear.
+	DigestSecretFunc func(user, realm string, c *core.Context) (ha1 string, ok bool)
+
+	// DigestAuthConfig defines the config for DigestAuth middleware.
+	DigestAuthConfig struct {
+		// Secret returns HA1 of a user.
+		// Required.
+		Secret DigestSecretFunc
+
+		// Realm is sent in the WWW-Authenticate challenge.
+		// Optional. Default value "Restricted".
+		Realm string
+
+		// NonceTTL is how long a nonce is accepted.
+		// Optional. Default value 5 minutes.
+		NonceTTL time.Duration
+
+		// Store remembers the nonce, nonce count and client nonce of the
+		// authorizations accepted, so they can't be replayed.
+		// Optional. Default value is a MemoryNonceStore.
+		Store NonceStore
+	}
 )
 
 const (
-	Basic = "Basic"
+	Basic  = "Basic"
+	Digest = "Digest"
 )
 
+// DefaultBasicAuthConfig is the default BasicAuth middleware config.
+var DefaultBasicAuthConfig = BasicAuthConfig{
+	Realm: "Restricted",
+}
+
+// DefaultDigestAuthConfig is the default DigestAuth middleware config.
+var DefaultDigestAuthConfig = DigestAuthConfig{
+	Realm:    "Restricted",
+	NonceTTL: 5 * time.Minute,
+}
+
 // BasicAuth returns an HTTP basic authentication middleware.
 //
 // For valid credentials it calls the next handler.
 // For invalid credentials, it sends "401 - Unauthorized" response.
-func BasicAuth(fn BasicValidateFunc) core.HandlerFunc {
-	return func(c *core.Context) error {
-		// Skip WebSocket
-		if (c.Request().Header.Get(core.Upgrade)) == core.WebSocket {
-			return nil
+func BasicAuth(fn BasicValidateFunc) core.MiddlewareFunc {
+	c := DefaultBasicAuthConfig
+	c.Validator = fn
+	return BasicAuthWithConfig(c)
+}
+
+// BasicAuthWithConfig returns a BasicAuth middleware from config.
+// See `BasicAuth()`.
+func BasicAuthWithConfig(config BasicAuthConfig) core.MiddlewareFunc {
+	if config.Validator == nil {
+		panic("basic auth middleware requires a validator")
+	}
+	if config.Realm == "" {
+		config.Realm = DefaultBasicAuthConfig.Realm
+	}
+	challenge := Basic + " realm=" + strconv.Quote(config.Realm)
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			// Skip WebSocket
+			if (c.Request().Header.Get(core.Upgrade)) == core.WebSocket {
+				return next(c)
+			}
+
+			auth := c.Request().Header.Get(core.Authorization)
+			l := len(Basic)
+
+			if len(auth) > l+1 && auth[:l] == Basic {
+				b, err := base64.StdEncoding.DecodeString(auth[l+1:])
+				if err == nil {
+					cred := string(b)
+					for i := 0; i < len(cred); i++ {
+						if cred[i] == ':' {
+							// Verify credentials
+							if config.Validator(cred[:i], cred[i+1:], c) {
+								return next(c)
+							}
+							break
+						}
+					}
+				}
+			}
+			c.Response().Header().Set(core.WWWAuthenticate, challenge)
+			return core.NewHTTPError(http.StatusUnauthorized)
 		}
+	}
+}
+
+// DigestHA1 returns the HA1 hash of a user for DigestAuth.
+func DigestHA1(user, realm, password string) string {
+	return md5Hex(user + ":" + realm + ":" + password)
+}
 
-		auth := c.Request().Header.Get(core.Authorization)
-		l := len(Basic)
-
-		if len(auth) > l+1 && auth[:l] == Basic {
-			b, err := base64.StdEncoding.DecodeString(auth[l+1:])
-			if err == nil {
-				cred := string(b)
-				for i := 0; i < len(cred); i++ {
-					if cred[i] == ':' {
-						// Verify credentials
-						if fn(cred[:i], cred[i+1:]) {
-							return nil
+// DigestAuth returns an HTTP digest authentication middleware (MD5, qop=auth)
+// for legacy clients which can't use Basic over TLS.
+func DigestAuth(secret DigestSecretFunc) core.MiddlewareFunc {
+	c := DefaultDigestAuthConfig
+	c.Secret = secret
+	return DigestAuthWithConfig(c)
+}
+
+// DigestAuthWithConfig returns a DigestAuth middleware from config.
+// See `DigestAuth()`.
+//
+// Nonces are stateless: they carry their creation time signed with a key
+// generated at startup. Each authorization is accepted once, clients count
+// their requests with the nonce count (nc). The uri parameter is checked
+// against the request target as sent, before the locale prefix or trailing
+// slash are stripped.
+func DigestAuthWithConfig(config DigestAuthConfig) core.MiddlewareFunc {
+	if config.Secret == nil {
+		panic("digest auth middleware requires a secret function")
+	}
+	if config.Realm == "" {
+		config.Realm = DefaultDigestAuthConfig.Realm
+	}
+	if config.NonceTTL == 0 {
+		config.NonceTTL = DefaultDigestAuthConfig.NonceTTL
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryNonceStore()
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			if (c.Request().Header.Get(core.Upgrade)) == core.WebSocket {
+				return next(c)
+			}
+			stale := false
+			auth := c.Request().Header.Get(core.Authorization)
+			if l := len(Digest); len(auth) > l+1 && auth[:l] == Digest {
+				p := parseDigest(auth[l+1:])
+				if p["realm"] == config.Realm && p["uri"] == requestURI(c.Request()) {
+					if !checkNonce(key, p["nonce"], c.Now(), config.NonceTTL) {
+						stale = checkNonce(key, p["nonce"], time.Time{}, 0)
+					} else if ha1, ok := config.Secret(p["username"], config.Realm, c); ok {
+						ha2 := md5Hex(c.Request().Method + ":" + p["uri"])
+						var want string
+						switch p["qop"] {
+						case "auth":
+							want = md5Hex(ha1 + ":" + p["nonce"] + ":" + p["nc"] + ":" + p["cnonce"] + ":auth:" + ha2)
+						case "":
+							want = md5Hex(ha1 + ":" + p["nonce"] + ":" + ha2)
+						}
+						if want != "" && subtle.ConstantTimeCompare([]byte(want), []byte(p["response"])) == 1 {
+							now := c.Now()
+							used := p["nonce"] + ":" + p["nc"] + ":" + p["cnonce"]
+							if !config.Store.Seen(used, now.Add(config.NonceTTL), now) {
+								return next(c)
+							}
 						}
 					}
 				}
 			}
+			challenge := fmt.Sprintf(`%s realm=%q, qop="auth", algorithm=MD5, nonce=%q`,
+				Digest, config.Realm, newNonce(key, c.Now()))
+			if stale {
+				challenge += ", stale=true"
+			}
+			c.Response().Header().Set(core.WWWAuthenticate, challenge)
+			return core.NewHTTPError(http.StatusUnauthorized)
 		}
-		c.Response().Header().Set(core.WWWAuthenticate, Basic+" realm=Restricted")
-		return core.NewHTTPError(http.StatusUnauthorized)
 	}
 }
+
+// requestURI returns the request target as sent by the client.
+func requestURI(r *http.Request) string {
+	if r.RequestURI != "" {
+		return r.RequestURI
+	}
+	return r.URL.RequestURI()
+}
+
+func newNonce(key []byte, now time.Time) string {
+	ts := strconv.FormatInt(now.UnixNano(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(ts + ":" + nonceMAC(key, ts)))
+}
+
+// checkNonce verifies the signature of nonce and, if ttl isn't zero, its age.
+func checkNonce(key []byte, nonce string, now time.Time, ttl time.Duration) bool {
+	b, err := base64.RawURLEncoding.DecodeString(nonce)
+	if err != nil {
+		return false
+	}
+	parts := strings.SplitN(string(b), ":", 2)
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(nonceMAC(key, parts[0]))) {
+		return false
+	}
+	if ttl == 0 {
+		return true
+	}
+	ts, err := strconv.ParseInt(parts[0], 10, 64)
+	return err == nil && now.Sub(time.Unix(0, ts)) < ttl
+}
+
+func nonceMAC(key []byte, ts string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(ts))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// parseDigest parses the comma separated parameters of a Digest
+// authorization, values may be quoted.
+func parseDigest(s string) map[string]string {
+	p := make(map[string]string)
+	for s != "" {
+		s = strings.TrimLeft(s, " ,")
+		i := strings.IndexByte(s, '=')
+		if i == -1 {
+			break
+		}
+		name := strings.ToLower(strings.TrimSpace(s[:i]))
+		s = strings.TrimLeft(s[i+1:], " ")
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			j := 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(s) {
+				break
+			}
+			value = strings.Replace(s[1:j], `\`, "", -1)
+			s = s[j+1:]
+		} else {
+			j := strings.IndexByte(s, ',')
+			if j == -1 {
+				j = len(s)
+			}
+			value = strings.TrimSpace(s[:j])
+			s = s[j:]
+		}
+		p[name] = value
+	}
+	return p
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
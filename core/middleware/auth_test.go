@@ -2,9 +2,11 @@ package middleware
 
 import (
 	"encoding/base64"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/henrylee2cn/thinkgo/core"
 	"github.com/stretchr/testify/assert"
@@ -15,13 +17,15 @@ func TestBasicAuth(t *testing.T) {
 	req, _ := http.NewRequest(core.GET, "/", nil)
 	rec := httptest.NewRecorder()
 	c := core.NewContext(req, core.NewResponse(rec, e), e)
-	fn := func(u, p string) bool {
+	fn := func(u, p string, c *core.Context) bool {
 		if u == "joe" && p == "secret" {
 			return true
 		}
 		return false
 	}
-	ba := BasicAuth(fn)
+	ba := BasicAuth(fn)(func(c *core.Context) error {
+		return nil
+	})
 
 	// Valid credentials
 	auth := Basic + " " + base64.StdEncoding.EncodeToString([]byte("joe:secret"))
@@ -37,22 +41,89 @@ func TestBasicAuth(t *testing.T) {
 	req.Header.Set(core.Authorization, auth)
 	he := ba(c).(*core.HTTPError)
 	assert.Equal(t, http.StatusUnauthorized, he.Code())
-	assert.Equal(t, Basic+" realm=Restricted", rec.Header().Get(core.WWWAuthenticate))
+	assert.Equal(t, Basic+` realm="Restricted"`, rec.Header().Get(core.WWWAuthenticate))
 
 	// Empty Authorization header
 	req.Header.Set(core.Authorization, "")
 	he = ba(c).(*core.HTTPError)
 	assert.Equal(t, http.StatusUnauthorized, he.Code())
-	assert.Equal(t, Basic+" realm=Restricted", rec.Header().Get(core.WWWAuthenticate))
+	assert.Equal(t, Basic+` realm="Restricted"`, rec.Header().Get(core.WWWAuthenticate))
 
 	// Invalid Authorization header
 	auth = base64.StdEncoding.EncodeToString([]byte("invalid"))
 	req.Header.Set(core.Authorization, auth)
 	he = ba(c).(*core.HTTPError)
 	assert.Equal(t, http.StatusUnauthorized, he.Code())
-	assert.Equal(t, Basic+" realm=Restricted", rec.Header().Get(core.WWWAuthenticate))
+	assert.Equal(t, Basic+` realm="Restricted"`, rec.Header().Get(core.WWWAuthenticate))
 
 	// WebSocket
 	c.Request().Header.Set(core.Upgrade, core.WebSocket)
 	assert.NoError(t, ba(c))
 }
+
+func TestDigestAuth(t *testing.T) {
+	e := core.New()
+	clock := core.NewManualClock(time.Unix(1500000000, 0))
+	e.SetClock(clock)
+	e.SetLocales("en", "fr")
+	e.Use(DigestAuth(func(user, realm string, c *core.Context) (string, bool) {
+		if user != "joe" {
+			return "", false
+		}
+		return DigestHA1("joe", realm, "secret"), true
+	}))
+	e.Get("/dir/index.html", func(c *core.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	do := func(uri, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(core.GET, uri, nil)
+		req.Header.Set(core.Authorization, auth)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	header := func(uri, nonce, nc, pass string) string {
+		ha1 := DigestHA1("joe", "Restricted", pass)
+		ha2 := md5Hex("GET:" + uri)
+		response := md5Hex(ha1 + ":" + nonce + ":" + nc + ":0a4f113b:auth:" + ha2)
+		return `Digest username="joe", realm="Restricted", nonce="` + nonce +
+			`", uri="` + uri + `", qop=auth, nc=` + nc + `, cnonce="0a4f113b", response="` + response + `"`
+	}
+
+	// Challenge
+	rec := do("/dir/index.html", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	challenge := rec.Header().Get(core.WWWAuthenticate)
+	p := parseDigest(challenge[len(Digest)+1:])
+	assert.Equal(t, "Restricted", p["realm"])
+	assert.Equal(t, "auth", p["qop"])
+	nonce := p["nonce"]
+
+	// Valid credentials
+	rec = do("/dir/index.html", header("/dir/index.html", nonce, "00000001", "secret"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Replayed, then the next request of the client
+	rec = do("/dir/index.html", header("/dir/index.html", nonce, "00000001", "secret"))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = do("/dir/index.html", header("/dir/index.html", nonce, "00000002", "secret"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// The uri is the one sent, whatever the router strips
+	for i, uri := range []string{"/dir/index.html/", "/fr/dir/index.html", "/dir/index.html?x=1"} {
+		rec = do(uri, header(uri, nonce, fmt.Sprintf("%08x", 3+i), "secret"))
+		assert.Equal(t, http.StatusOK, rec.Code, uri)
+		rec = do(uri, header("/dir/index.html", nonce, fmt.Sprintf("%08x", 3+i), "secret"))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, uri)
+	}
+
+	// Incorrect password
+	rec = do("/dir/index.html", header("/dir/index.html", nonce, "00000010", "password"))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Expired nonce
+	clock.Advance(time.Hour)
+	rec = do("/dir/index.html", header("/dir/index.html", nonce, "00000011", "secret"))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Header().Get(core.WWWAuthenticate), "stale=true")
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// BodyDumpHandler receives the request and response bodies of a request.
+	BodyDumpHandler func(c *core.Context, reqBody, resBody []byte)
+
+	// BodyDumpConfig defines the config for BodyDump middleware.
+	BodyDumpConfig struct {
+		// Handler receives the dumped bodies.
+		// Required.
+		Handler BodyDumpHandler
+
+		// Redact dumps the value bound by c.Bind instead of the raw request
+		// body, with the fields tagged `redact:"true"` masked.
+		// Optional. Default value true.
+		Redact bool
+	}
+
+	bodyDumpWriter struct {
+		io.Writer
+		http.ResponseWriter
+	}
+)
+
+// BodyDump returns a middleware which passes the request and response bodies
+// to handler, e.g. for debug logging. Passwords and tokens bound into fields
+// tagged `redact:"true"` never reach the handler.
+func BodyDump(handler BodyDumpHandler) core.MiddlewareFunc {
+	return BodyDumpWithConfig(BodyDumpConfig{Handler: handler, Redact: true})
+}
+
+// BodyDumpWithConfig returns a BodyDump middleware from config.
+// See `BodyDump()`.
+func BodyDumpWithConfig(config BodyDumpConfig) core.MiddlewareFunc {
+	if config.Handler == nil {
+		panic("body dump middleware requires a handler")
+	}
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			var reqBody []byte
+			if r := c.Request(); r.Body != nil {
+				reqBody, _ = ioutil.ReadAll(r.Body)
+				r.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+			}
+			resBody := new(bytes.Buffer)
+			w := c.Response().Writer()
+			c.Response().SetWriter(&bodyDumpWriter{Writer: io.MultiWriter(w, resBody), ResponseWriter: w})
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+			c.Response().SetWriter(w)
+			if bound := c.Bound(); config.Redact && bound != nil {
+				if b, err := json.Marshal(c.Redact(bound)); err == nil {
+					reqBody = b
+				}
+			}
+			config.Handler(c, reqBody, resBody.Bytes())
+			return nil
+		}
+	}
+}
+
+func (w *bodyDumpWriter) Write(b []byte) (int, error) {
+	return w.Writer.Write(b)
+}
+
+func (w *bodyDumpWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *bodyDumpWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (w *bodyDumpWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
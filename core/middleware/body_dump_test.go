@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyDump(t *testing.T) {
+	e := core.New()
+	type login struct {
+		User     string `json:"user"`
+		Password string `json:"password" redact:"true"`
+	}
+	var reqDump, resDump string
+	dump := BodyDump(func(c *core.Context, req, res []byte) {
+		reqDump, resDump = string(req), string(res)
+	})
+
+	// Bound values are redacted
+	req, _ := http.NewRequest(core.POST, "/", strings.NewReader(`{"user":"joe","password":"secret"}`))
+	req.Header.Set(core.ContentType, core.ApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := core.NewContext(req, core.NewResponse(rec, e), e)
+	h := dump(func(c *core.Context) error {
+		l := new(login)
+		if err := c.Bind(l); err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, "hi "+l.User)
+	})
+	assert.NoError(t, h(c))
+	assert.Equal(t, `{"password":"[REDACTED]","user":"joe"}`, reqDump)
+	assert.Equal(t, "hi joe", resDump)
+	assert.Equal(t, "hi joe", rec.Body.String())
+
+	// Raw bodies are dumped as they are
+	req, _ = http.NewRequest(core.POST, "/", strings.NewReader("raw"))
+	rec = httptest.NewRecorder()
+	c = core.NewContext(req, core.NewResponse(rec, e), e)
+	h = dump(func(c *core.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	assert.NoError(t, h(c))
+	assert.Equal(t, "raw", reqDump)
+	assert.Equal(t, "", resDump)
+}
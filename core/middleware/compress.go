@@ -2,11 +2,14 @@ package middleware
 
 import (
 	"bufio"
+	"bytes"
+	"compress/flate"
 	"compress/gzip"
 	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -14,56 +17,183 @@ import (
 )
 
 type (
-	gzipWriter struct {
+	// CompressConfig defines the config for Compress middleware.
+	CompressConfig struct {
+		// Level is the compression level, see compress/flate.
+		// Optional. Default value flate.DefaultCompression.
+		Level int
+
+		// Schemes lists the supported encodings, "gzip" and/or "deflate".
+		// The client's q-values decide, this order breaks ties.
+		// Optional. Default value []string{"gzip", "deflate"}.
+		Schemes []string
+
+		// ExcludePaths are path prefixes which are never compressed.
+		// Optional.
+		ExcludePaths []string
+
+		// ExcludeTypes are content type prefixes which are never compressed,
+		// in addition to DefaultCompressExcludeTypes and event streams.
+		// Optional.
+		ExcludeTypes []string
+
+		// MinLength is the default policy: the size a response must reach to
+		// be compressed.
+		// Optional. Default value 0, always compress.
+		MinLength int
+
+		// Routes overrides the policy per route path, as registered, e.g.
+		// {"/export/:id": CompressNever, "/api/*": 1024}.
+		// Optional.
+		Routes map[string]CompressPolicy
+	}
+
+	// CompressPolicy is the size in bytes a response must reach to be
+	// compressed, or CompressNever.
+	CompressPolicy int
+
+	// compressWriter compresses the response. While pending, it holds back the
+	// status code and the body until the policy threshold is reached.
+	compressWriter struct {
 		io.Writer
 		http.ResponseWriter
+		pending *compressPending
 	}
-)
 
-func (w gzipWriter) Write(b []byte) (int, error) {
-	if w.Header().Get(core.ContentType) == "" {
-		w.Header().Set(core.ContentType, http.DetectContentType(b))
+	compressPending struct {
+		buf    bytes.Buffer
+		code   int
+		policy func() CompressPolicy
+		start  func() io.Writer
 	}
-	return w.Writer.Write(b)
-}
 
-func (w gzipWriter) Flush() error {
-	return w.Writer.(*gzip.Writer).Flush()
-}
+	compressor struct {
+		scheme string
+		pool   sync.Pool
+	}
 
-func (w gzipWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	return w.ResponseWriter.(http.Hijacker).Hijack()
-}
+	resetWriteCloser interface {
+		io.WriteCloser
+		Reset(io.Writer)
+		Flush() error
+	}
+)
 
-func (w *gzipWriter) CloseNotify() <-chan bool {
-	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+// Compression policies.
+const (
+	CompressAlways CompressPolicy = 0
+	CompressNever  CompressPolicy = -1
+)
+
+// compressPolicyKey holds the policy set by SetCompressPolicy.
+const compressPolicyKey = "_compress_policy"
+
+// DefaultCompressExcludeTypes are content types which don't benefit from
+// compression, they are always skipped.
+var DefaultCompressExcludeTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"video/",
+	"audio/",
+	"font/woff",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+	"application/octet-stream",
 }
 
-var writerPool = sync.Pool{
-	New: func() interface{} {
-		return gzip.NewWriter(ioutil.Discard)
-	},
+// DefaultCompressConfig is the default Compress middleware config.
+var DefaultCompressConfig = CompressConfig{
+	Level:   flate.DefaultCompression,
+	Schemes: []string{"gzip", "deflate"},
 }
 
 // Gzip returns a middleware which compresses HTTP response using gzip compression
 // scheme.
 func Gzip() core.MiddlewareFunc {
-	scheme := "gzip"
+	c := DefaultCompressConfig
+	c.Schemes = []string{"gzip"}
+	return CompressWithConfig(c)
+}
+
+// Compress returns a middleware which compresses HTTP responses with gzip or
+// deflate, as negotiated through the Accept-Encoding header.
+func Compress() core.MiddlewareFunc {
+	return CompressWithConfig(DefaultCompressConfig)
+}
+
+// CompressWithConfig returns a Compress middleware from config.
+// See `Compress()`.
+//
+// WebSocket upgrades and event streams are skipped, and so are responses which
+// already carry a Content-Encoding (e.g. precompressed static files) or an
+// excluded type.
+func CompressWithConfig(config CompressConfig) core.MiddlewareFunc {
+	if len(config.Schemes) == 0 {
+		config.Schemes = DefaultCompressConfig.Schemes
+	}
+	if config.Level < flate.HuffmanOnly || config.Level > flate.BestCompression {
+		panic("compress middleware: invalid level " + strconv.Itoa(config.Level))
+	}
+	compressors := make([]*compressor, len(config.Schemes))
+	for i, scheme := range config.Schemes {
+		compressors[i] = newCompressor(scheme, config.Level)
+	}
 
 	return func(h core.HandlerFunc) core.HandlerFunc {
 		return func(c *core.Context) error {
-			c.Response().Header().Add(core.Vary, core.AcceptEncoding)
-			if strings.Contains(c.Request().Header.Get(core.AcceptEncoding), scheme) {
-				w := writerPool.Get().(*gzip.Writer)
-				w.Reset(c.Response().Writer())
-				defer func() {
-					w.Close()
-					writerPool.Put(w)
-				}()
-				gw := gzipWriter{Writer: w, ResponseWriter: c.Response().Writer()}
-				c.Response().Header().Set(core.ContentEncoding, scheme)
-				c.Response().SetWriter(gw)
+			r := c.Request()
+			if isUpgrade(r) || hasPrefix(r.URL.Path, config.ExcludePaths) {
+				return h(c)
+			}
+			c.Response().AddVary(core.AcceptEncoding)
+			cp := negotiate(r.Header.Get(core.AcceptEncoding), compressors)
+			routePolicy, ok := config.Routes[c.Path()]
+			if !ok {
+				routePolicy = CompressPolicy(config.MinLength)
 			}
+			if cp == nil || routePolicy == CompressNever {
+				return h(c)
+			}
+
+			var cw resetWriteCloser
+			rw := c.Response().Writer()
+			w := &compressWriter{ResponseWriter: rw}
+			w.pending = &compressPending{
+				policy: func() CompressPolicy {
+					if p, ok := c.Get(compressPolicyKey).(CompressPolicy); ok {
+						return p
+					}
+					return routePolicy
+				},
+				start: func() io.Writer {
+					header := w.Header()
+					ct := header.Get(core.ContentType)
+					if header.Get(core.ContentEncoding) != "" || strings.HasPrefix(ct, "text/event-stream") ||
+						hasPrefix(ct, DefaultCompressExcludeTypes) || hasPrefix(ct, config.ExcludeTypes) {
+						return nil
+					}
+					header.Set(core.ContentEncoding, cp.scheme)
+					header.Del(core.ContentLength)
+					cw = cp.pool.Get().(resetWriteCloser)
+					cw.Reset(rw)
+					return cw
+				},
+			}
+			c.Response().SetWriter(w)
+			defer func() {
+				if w.pending != nil {
+					w.commit(false)
+				}
+				if cw != nil {
+					cw.Close()
+					cp.pool.Put(cw)
+				}
+			}()
 			if err := h(c); err != nil {
 				c.Error(err)
 			}
@@ -71,3 +201,129 @@ func Gzip() core.MiddlewareFunc {
 		}
 	}
 }
+
+// SetCompressPolicy overrides the compression policy of the current response,
+// it must be called before the body is written.
+func SetCompressPolicy(c *core.Context, p CompressPolicy) {
+	c.Set(compressPolicyKey, p)
+}
+
+func newCompressor(scheme string, level int) *compressor {
+	cp := &compressor{scheme: scheme}
+	switch scheme {
+	case "gzip":
+		cp.pool.New = func() interface{} {
+			w, _ := gzip.NewWriterLevel(ioutil.Discard, level)
+			return w
+		}
+	case "deflate":
+		cp.pool.New = func() interface{} {
+			w, _ := flate.NewWriter(ioutil.Discard, level)
+			return w
+		}
+	default:
+		panic("compress middleware: unsupported scheme " + scheme)
+	}
+	return cp
+}
+
+// negotiate picks the compressor accept prefers, the first one of the
+// config among equally accepted ones.
+func negotiate(accept string, compressors []*compressor) *compressor {
+	schemes := make([]string, len(compressors))
+	for i, cp := range compressors {
+		schemes[i] = cp.scheme
+	}
+	scheme := core.NegotiateEncoding(accept, schemes)
+	for _, cp := range compressors {
+		if cp.scheme == scheme {
+			return cp
+		}
+	}
+	return nil
+}
+
+func isUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get(core.Upgrade), "websocket")
+}
+
+func hasPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.pending == nil || core.Informational(code) {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified {
+		w.commit(false)
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.pending.code = code
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.Header().Get(core.ContentType) == "" {
+		w.Header().Set(core.ContentType, http.DetectContentType(b))
+	}
+	if p := w.pending; p != nil {
+		policy := p.policy()
+		if policy == CompressNever {
+			w.commit(false)
+		} else if p.buf.Len()+len(b) >= int(policy) {
+			w.commit(true)
+		} else {
+			return p.buf.Write(b)
+		}
+	}
+	return w.Writer.Write(b)
+}
+
+// commit ends the pending state, compressing the rest of the response if
+// compress is true and the response qualifies.
+func (w *compressWriter) commit(compress bool) {
+	p := w.pending
+	w.pending = nil
+	w.Writer = w.ResponseWriter
+	if compress {
+		if cw := p.start(); cw != nil {
+			w.Writer = cw
+		}
+	}
+	if p.code != 0 {
+		w.ResponseWriter.WriteHeader(p.code)
+	}
+	if p.buf.Len() > 0 {
+		w.Writer.Write(p.buf.Bytes())
+	}
+}
+
+func (w *compressWriter) Flush() (err error) {
+	if w.pending != nil {
+		w.commit(w.pending.policy() != CompressNever)
+	}
+	if f, ok := w.Writer.(interface {
+		Flush() error
+	}); ok {
+		err = f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+	return
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (w *compressWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
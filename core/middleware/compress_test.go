@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"bytes"
+	"compress/flate"
 	"compress/gzip"
 	"net/http"
 	"net/http/httptest"
@@ -46,6 +47,14 @@ func TestGzip(t *testing.T) {
 	Gzip()(h)(c)
 	assert.Equal(t, http.StatusOK, rec.Code)
 	assert.Equal(t, "test", rec.Body.String())
+	assert.Equal(t, []string{core.AcceptEncoding}, rec.Header()[core.Vary])
+
+	// Vary is not duplicated
+	rec = httptest.NewRecorder()
+	c = core.NewContext(req, core.NewResponse(rec, e), e)
+	rec.Header().Set(core.Vary, "accept-encoding")
+	Gzip()(h)(c)
+	assert.Equal(t, []string{"accept-encoding"}, rec.Header()[core.Vary])
 
 	req, _ = http.NewRequest(core.GET, "/", nil)
 	req.Header.Set(core.AcceptEncoding, "gzip")
@@ -66,11 +75,140 @@ func TestGzip(t *testing.T) {
 	}
 }
 
+func TestCompress(t *testing.T) {
+	e := core.New()
+	do := func(mw core.MiddlewareFunc, h core.HandlerFunc, path string, header ...string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(core.GET, path, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		c := core.NewContext(req, core.NewResponse(rec, e), e)
+		mw(h)(c)
+		return rec
+	}
+	text := func(c *core.Context) error {
+		return c.String(http.StatusOK, "test")
+	}
+
+	// The client's q-values decide
+	rec := do(Compress(), text, "/", core.AcceptEncoding, "gzip;q=0.5, deflate")
+	assert.Equal(t, "deflate", rec.Header().Get(core.ContentEncoding))
+	rec = do(Compress(), text, "/", core.AcceptEncoding, "deflate;q=0.1, *;q=0.8")
+	assert.Equal(t, "gzip", rec.Header().Get(core.ContentEncoding))
+
+	// then the config order
+	rec = do(Compress(), text, "/", core.AcceptEncoding, "deflate, gzip")
+	assert.Equal(t, "gzip", rec.Header().Get(core.ContentEncoding))
+	rec = do(CompressWithConfig(CompressConfig{Schemes: []string{"deflate", "gzip"}}), text, "/", core.AcceptEncoding, "gzip, deflate")
+	assert.Equal(t, "deflate", rec.Header().Get(core.ContentEncoding))
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(flate.NewReader(rec.Body))
+	assert.Equal(t, "test", buf.String())
+
+	// Refused encodings
+	rec = do(Compress(), text, "/", core.AcceptEncoding, "gzip;q=0, deflate;q=0")
+	assert.Equal(t, "", rec.Header().Get(core.ContentEncoding))
+	assert.Equal(t, "test", rec.Body.String())
+
+	// Excluded path
+	rec = do(CompressWithConfig(CompressConfig{ExcludePaths: []string{"/raw"}}), text, "/raw/x", core.AcceptEncoding, "gzip")
+	assert.Equal(t, "test", rec.Body.String())
+
+	// Excluded content type
+	png := func(c *core.Context) error {
+		c.Response().Header().Set(core.ContentType, "image/png")
+		return c.NoContent(http.StatusOK)
+	}
+	rec = do(Compress(), png, "/", core.AcceptEncoding, "gzip")
+	assert.Equal(t, "", rec.Header().Get(core.ContentEncoding))
+
+	// Precompressed file
+	gz := func(c *core.Context) error {
+		c.Response().Header().Set(core.ContentEncoding, "br")
+		return c.String(http.StatusOK, "brotli")
+	}
+	rec = do(Compress(), gz, "/", core.AcceptEncoding, "gzip, br")
+	assert.Equal(t, "br", rec.Header().Get(core.ContentEncoding))
+	assert.Equal(t, "brotli", rec.Body.String())
+
+	// WebSocket upgrade
+	rec = do(Compress(), text, "/", core.AcceptEncoding, "gzip", core.Upgrade, "websocket")
+	assert.Equal(t, "test", rec.Body.String())
+	assert.Equal(t, 0, len(rec.Header()[core.Vary]))
+
+	// Empty body
+	rec = do(Compress(), func(c *core.Context) error { return nil }, "/", core.AcceptEncoding, "gzip")
+	assert.Equal(t, "", rec.Header().Get(core.ContentEncoding))
+	assert.Equal(t, 0, rec.Body.Len())
+
+	defer func() {
+		assert.NotNil(t, recover())
+	}()
+	CompressWithConfig(CompressConfig{Level: 42})
+}
+
+func TestCompressPolicy(t *testing.T) {
+	e := core.New()
+	mw := CompressWithConfig(CompressConfig{
+		MinLength: 8,
+		Routes:    map[string]CompressPolicy{"/raw": CompressNever, "/small": CompressAlways},
+	})
+	do := func(path string, h core.HandlerFunc) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(core.GET, path, nil)
+		req.Header.Set(core.AcceptEncoding, "gzip")
+		rec := httptest.NewRecorder()
+		c := core.NewContext(req, core.NewResponse(rec, e), e)
+		c.SetPath(path)
+		mw(h)(c)
+		return rec
+	}
+	write := func(body string) core.HandlerFunc {
+		return func(c *core.Context) error {
+			return c.String(http.StatusCreated, body)
+		}
+	}
+
+	// Below the threshold
+	rec := do("/", write("short"))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "", rec.Header().Get(core.ContentEncoding))
+	assert.Equal(t, "short", rec.Body.String())
+
+	// Above the threshold
+	rec = do("/", write("long enough"))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "gzip", rec.Header().Get(core.ContentEncoding))
+
+	// Route policies
+	rec = do("/raw", write("long enough"))
+	assert.Equal(t, "long enough", rec.Body.String())
+	rec = do("/small", write("short"))
+	assert.Equal(t, "gzip", rec.Header().Get(core.ContentEncoding))
+
+	// Handler policy
+	rec = do("/", func(c *core.Context) error {
+		SetCompressPolicy(c, CompressNever)
+		return c.String(http.StatusOK, "long enough")
+	})
+	assert.Equal(t, "long enough", rec.Body.String())
+
+	// Event streams
+	rec = do("/small", func(c *core.Context) error {
+		c.Response().Header().Set(core.ContentType, "text/event-stream")
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Write([]byte("data: x\n\n"))
+		return nil
+	})
+	assert.Equal(t, "", rec.Header().Get(core.ContentEncoding))
+	assert.Equal(t, "data: x\n\n", rec.Body.String())
+}
+
 func TestGzipFlush(t *testing.T) {
 	rec := httptest.NewRecorder()
 	buf := new(bytes.Buffer)
 	w := gzip.NewWriter(buf)
-	gw := gzipWriter{Writer: w, ResponseWriter: rec}
+	gw := compressWriter{Writer: w, ResponseWriter: rec}
 
 	n0 := buf.Len()
 	if n0 != 0 {
@@ -107,7 +245,7 @@ func TestGzipCloseNotify(t *testing.T) {
 	rec := newCloseNotifyingRecorder()
 	buf := new(bytes.Buffer)
 	w := gzip.NewWriter(buf)
-	gw := gzipWriter{Writer: w, ResponseWriter: rec}
+	gw := compressWriter{Writer: w, ResponseWriter: rec}
 	closed := false
 	notifier := gw.CloseNotify()
 	rec.close()
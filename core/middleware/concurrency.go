@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// ConcurrencyConfig defines the config for the Concurrency middleware.
+	ConcurrencyConfig struct {
+		// Limit is the number of requests executed at once.
+		Limit int
+
+		// Queue is the number of requests waiting for a slot, the others are
+		// rejected with 429 Too Many Requests.
+		Queue int
+
+		// Wait bounds the time spent queued, 0 waits until the client goes
+		// away.
+		Wait time.Duration
+	}
+)
+
+// Concurrency returns a middleware limiting concurrent executions of the
+// handlers it wraps, e.g. for report generation or exports. Each call has its
+// own limit, so wrap a route or a group to limit it separately:
+//
+//	e.Get("/export", middleware.Concurrency(2, 10)(export))
+func Concurrency(limit, queue int) core.MiddlewareFunc {
+	return ConcurrencyWithConfig(ConcurrencyConfig{Limit: limit, Queue: queue})
+}
+
+// ConcurrencyWithConfig returns a Concurrency middleware from config.
+func ConcurrencyWithConfig(config ConcurrencyConfig) core.MiddlewareFunc {
+	if config.Limit < 1 {
+		panic("concurrency limit must be positive")
+	}
+	admitted := make(chan struct{}, config.Limit+config.Queue)
+	running := make(chan struct{}, config.Limit)
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			select {
+			case admitted <- struct{}{}:
+			default:
+				return core.NewHTTPError(http.StatusTooManyRequests)
+			}
+			defer func() { <-admitted }()
+
+			var timeout <-chan time.Time
+			if config.Wait > 0 {
+				t := time.NewTimer(config.Wait)
+				defer t.Stop()
+				timeout = t.C
+			}
+			select {
+			case running <- struct{}{}:
+			case <-timeout:
+				return core.NewHTTPError(http.StatusTooManyRequests)
+			case <-c.StdContext().Done():
+				return c.StdContext().Err()
+			}
+			defer func() { <-running }()
+			return next(c)
+		}
+	}
+}
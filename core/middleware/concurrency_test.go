@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrency(t *testing.T) {
+	e := core.New()
+	release := make(chan struct{})
+	h := ConcurrencyWithConfig(ConcurrencyConfig{Limit: 1, Queue: 1, Wait: time.Second})(func(c *core.Context) error {
+		<-release
+		return c.NoContent(http.StatusOK)
+	})
+	serve := func() int {
+		req, _ := http.NewRequest(core.GET, "/", nil)
+		rec := httptest.NewRecorder()
+		c := core.NewContext(req, core.NewResponse(rec, e), e)
+		if err := h(c); err != nil {
+			return err.(*core.HTTPError).Code()
+		}
+		return rec.Code
+	}
+
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = serve()
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	// One running, one queued: the third is rejected.
+	assert.Equal(t, http.StatusTooManyRequests, serve())
+	close(release)
+	wg.Wait()
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK}, codes)
+}
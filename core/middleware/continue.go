@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// ContinueConfig defines the config for Continue middleware.
+	ContinueConfig struct {
+		// Check decides whether the client may send the body, e.g. by
+		// authenticating the request. An error rejects the request before the
+		// body is sent, e.g. core.NewHTTPError(http.StatusUnauthorized).
+		// Optional.
+		Check func(c *core.Context) error
+
+		// MaxBodySize is the largest Content-Length accepted, larger requests
+		// are answered with "413 - Request Entity Too Large".
+		// Optional. Default value 0, no limit.
+		MaxBodySize int64
+	}
+)
+
+// Continue returns a middleware which runs check on requests sent with
+// `Expect: 100-continue` before their body is sent.
+func Continue(check func(c *core.Context) error) core.MiddlewareFunc {
+	return ContinueWithConfig(ContinueConfig{Check: check})
+}
+
+// ContinueWithConfig returns a Continue middleware from config.
+// See `Continue()`.
+//
+// The server only sends "100 Continue" once the body is read, so a request
+// rejected here is answered at once and its body never leaves the client,
+// which saves the bandwidth of large uploads. Requests without the
+// expectation pass through unchecked.
+func ContinueWithConfig(config ContinueConfig) core.MiddlewareFunc {
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			r := c.Request()
+			if !strings.EqualFold(r.Header.Get(core.Expect), "100-continue") {
+				return next(c)
+			}
+			if config.MaxBodySize > 0 && r.ContentLength > config.MaxBodySize {
+				return core.NewHTTPError(http.StatusRequestEntityTooLarge)
+			}
+			if config.Check != nil {
+				if err := config.Check(c); err != nil {
+					return err
+				}
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContinue(t *testing.T) {
+	e := core.New()
+	e.Use(ContinueWithConfig(ContinueConfig{
+		MaxBodySize: 10,
+		Check: func(c *core.Context) error {
+			if c.Request().Header.Get(core.Authorization) == "" {
+				return core.NewHTTPError(http.StatusUnauthorized)
+			}
+			return nil
+		},
+	}))
+	e.Post("/", func(c *core.Context) error {
+		b, _ := ioutil.ReadAll(c.Request().Body)
+		return c.String(http.StatusOK, string(b))
+	})
+	s := httptest.NewServer(e)
+	defer s.Close()
+
+	// send writes the head only and returns the first response line.
+	send := func(head string) string {
+		conn, err := net.Dial("tcp", s.Listener.Addr().String())
+		assert.NoError(t, err)
+		defer conn.Close()
+		conn.Write([]byte("POST / HTTP/1.1\r\nHost: x\r\nExpect: 100-continue\r\n" + head + "\r\n"))
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		return strings.TrimSpace(line)
+	}
+	assert.Equal(t, "HTTP/1.1 401 Unauthorized", send("Content-Length: 5\r\n"))
+	assert.True(t, strings.HasPrefix(send("Authorization: x\r\nContent-Length: 50\r\n"), "HTTP/1.1 413 "))
+	assert.Equal(t, "HTTP/1.1 100 Continue", send("Authorization: x\r\nContent-Length: 5\r\n"))
+
+	// Without expectation
+	req, _ := http.NewRequest(core.POST, "/", strings.NewReader("hello"))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, "hello", rec.Body.String())
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// CorrelationConfig defines the config for Correlation middleware.
+	CorrelationConfig struct {
+		// Networks lists the IPs and CIDRs of the calling services, checked
+		// against Context.RealIP. The headers of other callers are ignored.
+		// Required, unless Trust is set.
+		Networks []string
+
+		// Trust reports whether the caller is a service whose headers are
+		// honored, e.g. by checking a shared secret header. It's used instead
+		// of Networks.
+		// Optional.
+		Trust func(c *core.Context) bool
+
+		// Tenant also restores the tenant, which the calling services then
+		// choose for the request.
+		// Optional. Default value false, the tenant is left to the Tenant
+		// middleware.
+		Tenant bool
+
+		// MaxTimeout bounds the deadline accepted from the caller.
+		// Optional. Default value 0, unbounded.
+		MaxTimeout time.Duration
+	}
+)
+
+// DefaultCorrelationConfig is the default Correlation middleware config.
+var DefaultCorrelationConfig = CorrelationConfig{}
+
+// Correlation returns a middleware which restores on the context what a
+// calling thinkgo service at one of the given IPs or CIDRs propagated with
+// Context.Correlate or Context.Client: the request ID, locale and deadline.
+// Requests from elsewhere are served as they are, since any client can send
+// the headers. Values resolved by earlier middleware are kept.
+//
+// Malformed networks panic.
+func Correlation(networks ...string) core.MiddlewareFunc {
+	c := DefaultCorrelationConfig
+	c.Networks = networks
+	return CorrelationWithConfig(c)
+}
+
+// CorrelationWithConfig returns a Correlation middleware from config.
+// See `Correlation()`.
+func CorrelationWithConfig(config CorrelationConfig) core.MiddlewareFunc {
+	if config.Trust == nil {
+		if len(config.Networks) == 0 {
+			panic("correlation middleware requires the networks of the calling services")
+		}
+		nets := parseNetworks(config.Networks)
+		config.Trust = func(c *core.Context) bool {
+			return inNetworks(c.RealIP(), nets)
+		}
+	}
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			if !config.Trust(c) {
+				return next(c)
+			}
+			h := c.Request().Header
+			if id := h.Get(core.XRequestID); c.RequestID() == "" && validRequestID(id, DefaultRequestIDConfig.MaxLength) {
+				c.SetRequestID(id)
+			}
+			if l := h.Get(core.XLocale); l != "" {
+				c.SetLocale(l)
+			}
+			if t := h.Get(core.XTenant); config.Tenant && t != "" && c.Tenant() == "" {
+				c.SetTenant(t)
+			}
+			ms, err := strconv.ParseInt(h.Get(core.XRequestTimeout), 10, 64)
+			if err != nil || ms <= 0 {
+				return next(c)
+			}
+			d := time.Duration(ms) * time.Millisecond
+			if config.MaxTimeout > 0 && d > config.MaxTimeout {
+				d = config.MaxTimeout
+			}
+			parent := c.StdContext()
+			ctx, cancel := context.WithTimeout(parent, d)
+			defer cancel()
+			c.SetStdContext(ctx)
+			err = next(c)
+			c.SetStdContext(parent)
+			return err
+		}
+	}
+}
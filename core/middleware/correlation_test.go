@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorrelation(t *testing.T) {
+	// Downstream service
+	down := core.New()
+	down.Use(CorrelationWithConfig(CorrelationConfig{Networks: LoopbackNetworks, Tenant: true}))
+	down.Get("/", func(c *core.Context) error {
+		deadline, ok := c.StdContext().Deadline()
+		assert.True(t, ok)
+		assert.True(t, time.Until(deadline) <= time.Minute)
+		return c.String(http.StatusOK, c.RequestID()+" "+c.Locale()+" "+c.Tenant())
+	})
+	srv := httptest.NewServer(down)
+	defer srv.Close()
+
+	// Upstream service
+	up := core.New()
+	up.Use(RequestID(), Timeout(time.Minute))
+	up.Get("/", func(c *core.Context) error {
+		c.SetLocale("fr")
+		c.SetTenant("acme")
+		res, err := c.Client(nil).Get(srv.URL)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		b, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, string(b))
+	})
+	req, _ := http.NewRequest(core.GET, "/", nil)
+	req.Header.Set(core.XRequestID, "r1")
+	rec := httptest.NewRecorder()
+	up.ServeHTTP(rec, req)
+	assert.Equal(t, "r1 fr acme", rec.Body.String())
+
+	// Correlate keeps the context of the request
+	req, _ = http.NewRequest(core.GET, "/", nil)
+	c := core.NewContext(req, core.NewResponse(httptest.NewRecorder(), up), up)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	out, _ := http.NewRequest(core.GET, srv.URL, nil)
+	out = c.Correlate(out.WithContext(ctx))
+	assert.Equal(t, ctx, out.Context())
+	assert.NotEqual(t, "", out.Header.Get(core.XRequestTimeout))
+}
+
+func TestCorrelationTrust(t *testing.T) {
+	handler := func(c *core.Context) error {
+		_, ok := c.StdContext().Deadline()
+		return c.String(http.StatusOK, c.RequestID()+"|"+c.Locale()+"|"+c.Tenant()+"|"+strconv.FormatBool(ok))
+	}
+	do := func(mw core.MiddlewareFunc, remote string, header ...string) string {
+		e := core.New()
+		e.SetLocales("en", "fr")
+		e.Use(mw)
+		e.Get("/", handler)
+		req := httptest.NewRequest(core.GET, "/", nil)
+		req.RemoteAddr = remote + ":1234"
+		req.Header.Set(core.XRequestID, "r1")
+		req.Header.Set(core.XLocale, "fr")
+		req.Header.Set(core.XTenant, "acme")
+		req.Header.Set(core.XRequestTimeout, "1000")
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+
+	// Trusted service, the tenant is only taken when asked for
+	mw := Correlation("10.0.0.0/8")
+	assert.Equal(t, "r1|fr||true", do(mw, "10.0.0.1"))
+	mw = CorrelationWithConfig(CorrelationConfig{Networks: []string{"10.0.0.0/8"}, Tenant: true})
+	assert.Equal(t, "r1|fr|acme|true", do(mw, "10.0.0.1"))
+
+	// Other callers
+	assert.Equal(t, "|en||false", do(mw, "192.0.2.1"))
+
+	// Shared secret
+	mw = CorrelationWithConfig(CorrelationConfig{Trust: func(c *core.Context) bool {
+		return c.Request().Header.Get("X-Service-Secret") == "s3cret"
+	}})
+	assert.Equal(t, "r1|fr||true", do(mw, "192.0.2.1", "X-Service-Secret", "s3cret"))
+	assert.Equal(t, "|en||false", do(mw, "192.0.2.1", "X-Service-Secret", "guess"))
+
+	defer func() {
+		assert.NotNil(t, recover())
+	}()
+	Correlation()
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// CORSConfig defines the config for CORS middleware.
+	CORSConfig struct {
+		// AllowOrigins lists the origins allowed to access the resources, "*"
+		// allows any origin and a single wildcard matches a subdomain, e.g.
+		// "https://*.example.com".
+		// Optional. Default value []string{"*"}.
+		AllowOrigins []string
+
+		// AllowMethods lists the methods allowed in cross-origin requests.
+		// Optional. Default value DefaultCORSConfig.AllowMethods.
+		AllowMethods []string
+
+		// AllowHeaders lists the request headers allowed in cross-origin
+		// requests, the headers asked by the preflight request are allowed
+		// when empty.
+		// Optional.
+		AllowHeaders []string
+
+		// AllowCredentials allows requests with cookies or HTTP authentication.
+		// The actual origin is echoed instead of "*" then.
+		// Optional. Default value false.
+		AllowCredentials bool
+
+		// ExposeHeaders lists the response headers readable by clients.
+		// Optional.
+		ExposeHeaders []string
+
+		// MaxAge is how long, in seconds, preflight results may be cached.
+		// Optional. Default value 0, not sent.
+		MaxAge int
+	}
+)
+
+// DefaultCORSConfig is the default CORS middleware config.
+var DefaultCORSConfig = CORSConfig{
+	AllowOrigins: []string{"*"},
+	AllowMethods: []string{core.GET, core.HEAD, core.PUT, core.PATCH, core.POST, core.DELETE},
+}
+
+// CORS returns a Cross-Origin Resource Sharing middleware allowing any origin.
+func CORS() core.MiddlewareFunc {
+	return CORSWithConfig(DefaultCORSConfig)
+}
+
+// CORSWithConfig returns a CORS middleware from config.
+// See `CORS()`.
+//
+// Preflight requests are answered by the middleware itself, so no OPTIONS
+// route is needed; register it with Echo.Use to cover unmatched routes too.
+func CORSWithConfig(config CORSConfig) core.MiddlewareFunc {
+	if len(config.AllowOrigins) == 0 {
+		config.AllowOrigins = DefaultCORSConfig.AllowOrigins
+	}
+	if len(config.AllowMethods) == 0 {
+		config.AllowMethods = DefaultCORSConfig.AllowMethods
+	}
+	allowMethods := strings.Join(config.AllowMethods, ",")
+	allowHeaders := strings.Join(config.AllowHeaders, ",")
+	exposeHeaders := strings.Join(config.ExposeHeaders, ",")
+	maxAge := strconv.Itoa(config.MaxAge)
+
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			req := c.Request()
+			header := c.Response().Header()
+			origin := req.Header.Get(core.Origin)
+			preflight := req.Method == core.OPTIONS && req.Header.Get(core.AccessControlRequestMethod) != ""
+			c.Response().AddVary(core.Origin)
+			if origin == "" {
+				return next(c)
+			}
+			allowOrigin := matchOrigin(origin, config.AllowOrigins, config.AllowCredentials)
+			if allowOrigin == "" {
+				if preflight {
+					return c.NoContent(http.StatusNoContent)
+				}
+				return next(c)
+			}
+
+			header.Set(core.AccessControlAllowOrigin, allowOrigin)
+			if config.AllowCredentials {
+				header.Set(core.AccessControlAllowCredentials, "true")
+			}
+			if !preflight {
+				if exposeHeaders != "" {
+					header.Set(core.AccessControlExposeHeaders, exposeHeaders)
+				}
+				return next(c)
+			}
+
+			c.Response().AddVary(core.AccessControlRequestMethod, core.AccessControlRequestHeaders)
+			header.Set(core.AccessControlAllowMethods, allowMethods)
+			if allowHeaders != "" {
+				header.Set(core.AccessControlAllowHeaders, allowHeaders)
+			} else if h := req.Header.Get(core.AccessControlRequestHeaders); h != "" {
+				header.Set(core.AccessControlAllowHeaders, h)
+			}
+			if config.MaxAge > 0 {
+				header.Set(core.AccessControlMaxAge, maxAge)
+			}
+			return c.NoContent(http.StatusNoContent)
+		}
+	}
+}
+
+// matchOrigin returns the Access-Control-Allow-Origin value for origin, or ""
+// when it's not allowed.
+func matchOrigin(origin string, allowed []string, credentials bool) string {
+	for _, o := range allowed {
+		switch {
+		case o == "*":
+			if credentials {
+				return origin
+			}
+			return "*"
+		case strings.EqualFold(o, origin):
+			return origin
+		case matchWildcard(strings.ToLower(o), strings.ToLower(origin)):
+			return origin
+		}
+	}
+	return ""
+}
+
+func matchWildcard(pattern, s string) bool {
+	i := strings.IndexByte(pattern, '*')
+	if i == -1 {
+		return false
+	}
+	prefix, suffix := pattern[:i], pattern[i+1:]
+	return len(s) > len(prefix)+len(suffix) &&
+		strings.HasPrefix(s, prefix) && strings.HasSuffix(s, suffix) &&
+		!strings.ContainsAny(s[len(prefix):len(s)-len(suffix)], "/:")
+}
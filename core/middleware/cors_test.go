@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	e := core.New()
+	e.Use(CORSWithConfig(CORSConfig{
+		AllowOrigins:     []string{"https://*.example.com", "https://app.test"},
+		AllowCredentials: true,
+		ExposeHeaders:    []string{"X-Total"},
+		MaxAge:           600,
+	}))
+	e.Get("/users", func(c *core.Context) error {
+		return c.String(http.StatusOK, "users")
+	})
+	do := func(method, origin string, header ...string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/users", nil)
+		if origin != "" {
+			req.Header.Set(core.Origin, origin)
+		}
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Simple request
+	rec := do(core.GET, "https://api.example.com")
+	assert.Equal(t, "users", rec.Body.String())
+	assert.Equal(t, "https://api.example.com", rec.Header().Get(core.AccessControlAllowOrigin))
+	assert.Equal(t, "true", rec.Header().Get(core.AccessControlAllowCredentials))
+	assert.Equal(t, "X-Total", rec.Header().Get(core.AccessControlExposeHeaders))
+
+	// Preflight without an OPTIONS route
+	rec = do(core.OPTIONS, "https://app.test", core.AccessControlRequestMethod, core.PUT, core.AccessControlRequestHeaders, "X-Token")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "https://app.test", rec.Header().Get(core.AccessControlAllowOrigin))
+	assert.Contains(t, rec.Header().Get(core.AccessControlAllowMethods), core.PUT)
+	assert.Equal(t, "X-Token", rec.Header().Get(core.AccessControlAllowHeaders))
+	assert.Equal(t, "600", rec.Header().Get(core.AccessControlMaxAge))
+
+	// Disallowed origins
+	rec = do(core.GET, "https://evil.test")
+	assert.Equal(t, "users", rec.Body.String())
+	assert.Equal(t, "", rec.Header().Get(core.AccessControlAllowOrigin))
+	rec = do(core.GET, "https://example.com")
+	assert.Equal(t, "", rec.Header().Get(core.AccessControlAllowOrigin))
+	rec = do(core.OPTIONS, "https://evil.test", core.AccessControlRequestMethod, core.PUT)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "", rec.Header().Get(core.AccessControlAllowMethods))
+
+	// Any origin
+	h := CORS()(func(c *core.Context) error { return nil })
+	req, _ := http.NewRequest(core.GET, "/", nil)
+	req.Header.Set(core.Origin, "https://x.test")
+	rec = httptest.NewRecorder()
+	h(core.NewContext(req, core.NewResponse(rec, e), e))
+	assert.Equal(t, "*", rec.Header().Get(core.AccessControlAllowOrigin))
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"hash/fnv"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// ExperimentConfig defines the config for Experiment middleware.
+	ExperimentConfig struct {
+		// Name identifies the experiment, see ExperimentBucket.
+		// Required.
+		Name string
+
+		// Buckets are the variants of the experiment and their share of the
+		// traffic.
+		// Required.
+		Buckets []Bucket
+
+		// UserID returns the ID of the signed in user, "" for a visitor, so a
+		// user lands in the same bucket on every device.
+		// Optional. Default value nil, the bucket is kept in a cookie only.
+		UserID func(c *core.Context) string
+
+		// Cookie keeps the bucket of the visitor.
+		// Optional. Default value "_exp_" + Name.
+		Cookie string
+
+		// MaxAge of the cookie, in seconds.
+		// Optional. Default value 30 days.
+		MaxAge int
+	}
+
+	// Bucket is a variant of an experiment.
+	Bucket struct {
+		Name string
+		// Weight is the share of the traffic relative to the other buckets.
+		Weight int
+	}
+)
+
+// experimentsKey holds the buckets of the request by experiment, under a
+// name templates can use: {{.Experiments.checkout}}.
+const experimentsKey = "Experiments"
+
+// Experiment returns a middleware which assigns the requests to a bucket of
+// the A/B test name, see ExperimentWithConfig.
+func Experiment(name string, buckets ...Bucket) core.MiddlewareFunc {
+	return ExperimentWithConfig(ExperimentConfig{Name: name, Buckets: buckets})
+}
+
+// ExperimentWithConfig returns an Experiment middleware from config.
+//
+// A request is assigned a bucket at random, in proportion to the weights,
+// which sticks: a signed in user is assigned by a hash of its ID, a visitor
+// keeps the bucket in a cookie. The bucket is available to handlers through
+// ExperimentBucket and to templates rendered with the context store as
+// {{.Experiments.<name>}}; Split routes it to a handler.
+func ExperimentWithConfig(config ExperimentConfig) core.MiddlewareFunc {
+	if config.Name == "" {
+		panic("experiment name required")
+	}
+	total := 0
+	for _, b := range config.Buckets {
+		if b.Weight < 0 {
+			panic("experiment " + config.Name + ": negative weight")
+		}
+		total += b.Weight
+	}
+	if total == 0 {
+		panic("experiment " + config.Name + ": no bucket")
+	}
+	if config.Cookie == "" {
+		config.Cookie = "_exp_" + config.Name
+	}
+	if config.MaxAge == 0 {
+		config.MaxAge = 30 * 24 * 3600
+	}
+	pick := func(id string) string {
+		h := fnv.New32a()
+		h.Write([]byte(config.Name + "\x00" + id))
+		n := int(h.Sum32() % uint32(total))
+		for _, b := range config.Buckets {
+			if n < b.Weight {
+				return b.Name
+			}
+			n -= b.Weight
+		}
+		return config.Buckets[len(config.Buckets)-1].Name
+	}
+	valid := func(name string) bool {
+		for _, b := range config.Buckets {
+			if b.Name == name && b.Weight > 0 {
+				return true
+			}
+		}
+		return false
+	}
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			var bucket string
+			if config.UserID != nil {
+				if id := config.UserID(c); id != "" {
+					bucket = pick(id)
+				}
+			}
+			if bucket == "" {
+				if ck, err := c.Cookie(config.Cookie); err == nil && valid(ck.Value) {
+					bucket = ck.Value
+				} else {
+					bucket = pick(c.NewID())
+				}
+			}
+			if ck, err := c.Cookie(config.Cookie); err != nil || ck.Value != bucket {
+				cookie := c.DefaultCookie(config.Cookie, bucket)
+				cookie.MaxAge = config.MaxAge
+				c.WriteCookie(cookie)
+			}
+			buckets, _ := c.Get(experimentsKey).(map[string]string)
+			if buckets == nil {
+				buckets = make(map[string]string)
+				c.Set(experimentsKey, buckets)
+			}
+			buckets[config.Name] = bucket
+			return next(c)
+		}
+	}
+}
+
+// ExperimentBucket returns the bucket of the request in the experiment name,
+// "" if it wasn't assigned by the Experiment middleware.
+func ExperimentBucket(c *core.Context, name string) string {
+	buckets, _ := c.Get(experimentsKey).(map[string]string)
+	return buckets[name]
+}
+
+// Split returns a handler serving the requests with the handler of their
+// bucket in experiment, h if there's none, e.g. to try a new checkout on a
+// share of the traffic:
+//
+//	e.Use(middleware.Experiment("checkout",
+//		middleware.Bucket{Name: "a", Weight: 90},
+//		middleware.Bucket{Name: "b", Weight: 10},
+//	))
+//	e.Get("/checkout", middleware.Split("checkout", checkout, map[string]core.HandlerFunc{
+//		"b": newCheckout,
+//	}))
+func Split(experiment string, h core.HandlerFunc, variants map[string]core.HandlerFunc) core.HandlerFunc {
+	return func(c *core.Context) error {
+		if v, ok := variants[ExperimentBucket(c, experiment)]; ok {
+			return v(c)
+		}
+		return h(c)
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExperiment(t *testing.T) {
+	e := core.New()
+	e.SetIDGenerator(&core.SequenceIDs{})
+	e.Use(ExperimentWithConfig(ExperimentConfig{
+		Name:    "checkout",
+		Buckets: []Bucket{{"a", 50}, {"b", 50}},
+		UserID:  func(c *core.Context) string { return c.Request().Header.Get("X-User") },
+	}))
+	e.Get("/", Split("checkout", func(c *core.Context) error {
+		return c.String(http.StatusOK, "a")
+	}, map[string]core.HandlerFunc{
+		"b": func(c *core.Context) error {
+			return c.String(http.StatusOK, "b:"+ExperimentBucket(c, "checkout"))
+		},
+	}))
+	serve := func(user, cookie string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(core.GET, "/", nil)
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		if cookie != "" {
+			req.Header.Set("Cookie", "_exp_checkout="+cookie)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Buckets stick through the cookie
+	rec := serve("", "b")
+	assert.Equal(t, "b:b", rec.Body.String())
+	assert.Equal(t, "", rec.Header().Get("Set-Cookie"))
+	rec = serve("", "a")
+	assert.Equal(t, "a", rec.Body.String())
+
+	// and the user ID
+	first := serve("42", "").Body.String()
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, first, serve("42", "").Body.String())
+	}
+
+	// Visitors are spread over the buckets and get a cookie
+	seen := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		rec = serve("", "")
+		seen[rec.Body.String()] = true
+		assert.Contains(t, rec.Header().Get("Set-Cookie"), "_exp_checkout=")
+	}
+	assert.Equal(t, 2, len(seen))
+
+	// An unknown bucket is replaced
+	rec = serve("", "c")
+	assert.Contains(t, rec.Header().Get("Set-Cookie"), "_exp_checkout=")
+}
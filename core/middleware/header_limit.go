@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// HeaderLimitConfig defines the config for HeaderLimit middleware.
+	HeaderLimitConfig struct {
+		// MaxFields is the maximum number of header fields, a repeated field
+		// counting once per value.
+		// Optional. Default value 100, negative disables the check.
+		MaxFields int
+
+		// MaxBytes is the maximum size of the header fields, each counted as
+		// "Name: value\r\n".
+		// Optional. Default value 32768, negative disables the check.
+		MaxBytes int
+
+		// MaxFieldBytes is the maximum size of a single field.
+		// Optional. Default value 8192, negative disables the check.
+		MaxFieldBytes int
+
+		// Metrics collects the header sizes of the requests.
+		// Optional.
+		Metrics *HeaderMetrics
+	}
+
+	// HeaderMetrics collects the header sizes seen by HeaderLimit, it's safe
+	// for concurrent use. It's an expvar.Var, so it can be published with the
+	// other metrics of the process and served by expvar.Handler:
+	//
+	//	metrics := middleware.NewHeaderMetrics()
+	//	expvar.Publish("headers", metrics)
+	//	e.Use(middleware.HeaderLimitWithConfig(middleware.HeaderLimitConfig{Metrics: metrics}))
+	HeaderMetrics struct {
+		requests  int64
+		rejected  int64
+		fields    int64
+		bytes     int64
+		maxFields int64
+		maxBytes  int64
+	}
+
+	// HeaderStats is a snapshot of HeaderMetrics.
+	HeaderStats struct {
+		Requests  int64 `json:"requests"`
+		Rejected  int64 `json:"rejected"`
+		Fields    int64 `json:"fields"`
+		Bytes     int64 `json:"bytes"`
+		MaxFields int64 `json:"max_fields"`
+		MaxBytes  int64 `json:"max_bytes"`
+	}
+
+	headerSize struct {
+		fields, bytes int
+	}
+)
+
+// headerSizeKey holds the headerSize of the request.
+const headerSizeKey = "_header_size"
+
+// DefaultHeaderLimitConfig is the default HeaderLimit middleware config.
+var DefaultHeaderLimitConfig = HeaderLimitConfig{
+	MaxFields:     100,
+	MaxBytes:      32 << 10,
+	MaxFieldBytes: 8 << 10,
+}
+
+// HeaderLimit returns a middleware which answers "431 - Request Header Fields
+// Too Large" to requests with pathological headers, well below the limit of
+// the server (http.Server.MaxHeaderBytes, 1MB by default).
+func HeaderLimit() core.MiddlewareFunc {
+	return HeaderLimitWithConfig(DefaultHeaderLimitConfig)
+}
+
+// HeaderLimitWithConfig returns a HeaderLimit middleware from config.
+// See `HeaderLimit()`.
+//
+// Field names which aren't in canonical form, e.g. set by a handler in front
+// of the server, are canonicalized first so a field can't dodge the count by
+// varying its case. The sizes of every request are available to handlers
+// through HeaderSize.
+func HeaderLimitWithConfig(config HeaderLimitConfig) core.MiddlewareFunc {
+	if config.MaxFields == 0 {
+		config.MaxFields = DefaultHeaderLimitConfig.MaxFields
+	}
+	if config.MaxBytes == 0 {
+		config.MaxBytes = DefaultHeaderLimitConfig.MaxBytes
+	}
+	if config.MaxFieldBytes == 0 {
+		config.MaxFieldBytes = DefaultHeaderLimitConfig.MaxFieldBytes
+	}
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			h := c.Request().Header
+			canonicalizeHeader(h)
+			size, largest := measureHeader(h)
+			c.Set(headerSizeKey, size)
+			tooLarge := (config.MaxFields > 0 && size.fields > config.MaxFields) ||
+				(config.MaxBytes > 0 && size.bytes > config.MaxBytes) ||
+				(config.MaxFieldBytes > 0 && largest > config.MaxFieldBytes)
+			if m := config.Metrics; m != nil {
+				m.observe(size, tooLarge)
+			}
+			if tooLarge {
+				return core.NewHTTPError(http.StatusRequestHeaderFieldsTooLarge)
+			}
+			return next(c)
+		}
+	}
+}
+
+// HeaderSize returns the number of header fields of the request and their
+// size in bytes, as measured by HeaderLimit.
+func HeaderSize(c *core.Context) (fields, bytes int) {
+	if s, ok := c.Get(headerSizeKey).(headerSize); ok {
+		return s.fields, s.bytes
+	}
+	return 0, 0
+}
+
+// NewHeaderMetrics returns empty HeaderMetrics.
+func NewHeaderMetrics() *HeaderMetrics {
+	return new(HeaderMetrics)
+}
+
+// Stats returns a snapshot of the metrics.
+func (m *HeaderMetrics) Stats() HeaderStats {
+	return HeaderStats{
+		Requests:  atomic.LoadInt64(&m.requests),
+		Rejected:  atomic.LoadInt64(&m.rejected),
+		Fields:    atomic.LoadInt64(&m.fields),
+		Bytes:     atomic.LoadInt64(&m.bytes),
+		MaxFields: atomic.LoadInt64(&m.maxFields),
+		MaxBytes:  atomic.LoadInt64(&m.maxBytes),
+	}
+}
+
+// Handler returns a handler serving the metrics as JSON, e.g. for an
+// internal endpoint:
+//
+//	internal := e.Group("/internal", middleware.AllowNetworks(middleware.PrivateNetworks...))
+//	internal.Get("/metrics/headers", metrics.Handler())
+func (m *HeaderMetrics) Handler() core.HandlerFunc {
+	return func(c *core.Context) error {
+		return c.JSON(http.StatusOK, m.Stats())
+	}
+}
+
+// String implements expvar.Var, it returns the metrics as JSON.
+func (m *HeaderMetrics) String() string {
+	b, _ := json.Marshal(m.Stats())
+	return string(b)
+}
+
+func (m *HeaderMetrics) observe(s headerSize, rejected bool) {
+	atomic.AddInt64(&m.requests, 1)
+	if rejected {
+		atomic.AddInt64(&m.rejected, 1)
+	}
+	atomic.AddInt64(&m.fields, int64(s.fields))
+	atomic.AddInt64(&m.bytes, int64(s.bytes))
+	storeMax(&m.maxFields, int64(s.fields))
+	storeMax(&m.maxBytes, int64(s.bytes))
+}
+
+func storeMax(addr *int64, v int64) {
+	for {
+		old := atomic.LoadInt64(addr)
+		if v <= old || atomic.CompareAndSwapInt64(addr, old, v) {
+			return
+		}
+	}
+}
+
+// canonicalizeHeader merges the fields of h whose name isn't canonical into
+// the canonical ones.
+func canonicalizeHeader(h http.Header) {
+	for name, values := range h {
+		if cn := http.CanonicalHeaderKey(name); cn != name {
+			delete(h, name)
+			h[cn] = append(h[cn], values...)
+		}
+	}
+}
+
+// measureHeader returns the size of h and of its largest field.
+func measureHeader(h http.Header) (s headerSize, largest int) {
+	for name, values := range h {
+		for _, v := range values {
+			n := len(name) + len(v) + 4
+			s.fields++
+			s.bytes += n
+			if n > largest {
+				largest = n
+			}
+		}
+	}
+	return
+}
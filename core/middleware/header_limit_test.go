@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeaderLimit(t *testing.T) {
+	e := core.New()
+	metrics := NewHeaderMetrics()
+	e.Use(HeaderLimitWithConfig(HeaderLimitConfig{MaxFields: 3, MaxFieldBytes: 64, Metrics: metrics}))
+	e.Get("/", func(c *core.Context) error {
+		fields, bytes := HeaderSize(c)
+		return c.JSON(http.StatusOK, []int{fields, bytes})
+	})
+	serve := func(h http.Header) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(core.GET, "/", nil)
+		req.Header = h
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.Header{"A": {"1"}, "b": {"22"}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "[2,13]", rec.Body.String())
+
+	// Field count, non canonical names are merged
+	rec = serve(http.Header{"X-A": {"1", "2"}, "x-a": {"3"}, "X-B": {"4"}})
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, rec.Code)
+
+	// Field size
+	rec = serve(http.Header{"Cookie": {strings.Repeat("x", 100)}})
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, rec.Code)
+
+	s := metrics.Stats()
+	assert.Equal(t, int64(3), s.Requests)
+	assert.Equal(t, int64(2), s.Rejected)
+	assert.Equal(t, int64(4), s.MaxFields)
+	assert.Equal(t, int64(110), s.MaxBytes)
+
+	// Published as an expvar
+	expvar.Publish("test_headers", metrics)
+	rec = httptest.NewRecorder()
+	expvar.Handler().ServeHTTP(rec, httptest.NewRequest(core.GET, "/debug/vars", nil))
+	assert.Contains(t, rec.Body.String(), `"test_headers": {"requests":3,"rejected":2,"fields":7,"bytes":155,"max_fields":4,"max_bytes":110}`)
+}
@@ -0,0 +1,260 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// IdempotencyConfig defines the config for Idempotency middleware.
+	IdempotencyConfig struct {
+		// Store keeps the responses.
+		// Optional. Default value is a MemoryIdempotencyStore.
+		Store IdempotencyStore
+
+		// TTL is how long responses are replayed.
+		// Optional. Default value 24 hours.
+		TTL time.Duration
+
+		// Header holds the idempotency key.
+		// Optional. Default value "Idempotency-Key".
+		Header string
+
+		// Scope returns the client of the request, e.g. its user ID or API
+		// key, so one client can't replay the responses of another.
+		// Optional. Default value nil, all clients share the keys.
+		Scope func(c *core.Context) string
+	}
+
+	// IdempotentResponse is a cached response.
+	IdempotentResponse struct {
+		Status  int
+		Header  http.Header
+		Body    []byte
+		Digest  [sha256.Size]byte // of the request body
+		Expires time.Time
+	}
+
+	// IdempotencyStore keeps responses by idempotency key.
+	IdempotencyStore interface {
+		Get(key string) (*IdempotentResponse, bool)
+		Set(key string, r *IdempotentResponse)
+		Delete(key string)
+	}
+
+	// MemoryIdempotencyStore is an in-memory IdempotencyStore.
+	MemoryIdempotencyStore struct {
+		// Clock tells the time for expiry, the Echo clock once used by the
+		// middleware, else core.SystemClock if nil.
+		Clock core.Clock
+
+		mu    sync.Mutex
+		m     map[string]*IdempotentResponse
+		sweep time.Time
+	}
+
+	idempotencyWriter struct {
+		io.Writer
+		http.ResponseWriter
+	}
+)
+
+// IdempotentReplayed is set on replayed responses.
+const IdempotentReplayed = "Idempotent-Replayed"
+
+// DefaultIdempotencyConfig is the default Idempotency middleware config.
+var DefaultIdempotencyConfig = IdempotencyConfig{
+	TTL:    24 * time.Hour,
+	Header: "Idempotency-Key",
+}
+
+// Idempotency returns a middleware which honors the Idempotency-Key header on
+// POST requests: the first response is cached and retries with the same key
+// are answered from the cache instead of executing the handler again, e.g. to
+// prevent double charges. 5xx responses are not cached so they can be retried.
+func Idempotency() core.MiddlewareFunc {
+	return IdempotencyWithConfig(DefaultIdempotencyConfig)
+}
+
+// IdempotencyWithConfig returns an Idempotency middleware from config.
+// See `Idempotency()`.
+//
+// A retry arriving while the first request is still running gets 409 Conflict,
+// and reusing a key with a different body gets 422 Unprocessable Entity.
+func IdempotencyWithConfig(config IdempotencyConfig) core.MiddlewareFunc {
+	if config.Store == nil {
+		config.Store = NewMemoryIdempotencyStore()
+	}
+	if config.TTL == 0 {
+		config.TTL = DefaultIdempotencyConfig.TTL
+	}
+	if config.Header == "" {
+		config.Header = DefaultIdempotencyConfig.Header
+	}
+	var (
+		mu       sync.Mutex
+		inflight = make(map[string]bool)
+		once     sync.Once
+	)
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			if m, ok := config.Store.(*MemoryIdempotencyStore); ok {
+				once.Do(func() { m.defaultClock(c.Echo().Clock()) })
+			}
+			r := c.Request()
+			key := r.Header.Get(config.Header)
+			if r.Method != core.POST || key == "" {
+				return next(c)
+			}
+			key = r.URL.Path + " " + key
+			if config.Scope != nil {
+				key = config.Scope(c) + " " + key
+			}
+
+			var body []byte
+			if r.Body != nil {
+				var err error
+				if body, err = ioutil.ReadAll(r.Body); err != nil {
+					return err
+				}
+				r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			}
+			digest := sha256.Sum256(body)
+
+			mu.Lock()
+			if inflight[key] {
+				mu.Unlock()
+				return core.NewHTTPError(http.StatusConflict, "request with this idempotency key is in progress")
+			}
+			if cached, ok := config.Store.Get(key); ok {
+				if c.Now().Before(cached.Expires) {
+					mu.Unlock()
+					if cached.Digest != digest {
+						return core.NewHTTPError(http.StatusUnprocessableEntity, "idempotency key reused with a different request")
+					}
+					return replay(c, cached)
+				}
+				config.Store.Delete(key)
+			}
+			inflight[key] = true
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				delete(inflight, key)
+				mu.Unlock()
+			}()
+
+			buf := new(bytes.Buffer)
+			w := c.Response().Writer()
+			c.Response().SetWriter(&idempotencyWriter{Writer: io.MultiWriter(w, buf), ResponseWriter: w})
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+			c.Response().SetWriter(w)
+
+			status := c.Response().Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status < http.StatusInternalServerError {
+				config.Store.Set(key, &IdempotentResponse{
+					Status:  status,
+					Header:  cloneHeader(c.Response().Header()),
+					Body:    buf.Bytes(),
+					Digest:  digest,
+					Expires: c.Now().Add(config.TTL),
+				})
+			}
+			return nil
+		}
+	}
+}
+
+func replay(c *core.Context, cached *IdempotentResponse) error {
+	h := c.Response().Header()
+	for k, v := range cached.Header {
+		h[k] = append([]string(nil), v...)
+	}
+	h.Set(IdempotentReplayed, "true")
+	c.Response().WriteHeader(cached.Status)
+	_, err := c.Response().Write(cached.Body)
+	return err
+}
+
+func cloneHeader(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for k, v := range h {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
+
+func (w *idempotencyWriter) Write(b []byte) (int, error) {
+	return w.Writer.Write(b)
+}
+
+func (w *idempotencyWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// NewMemoryIdempotencyStore returns an empty MemoryIdempotencyStore.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{m: make(map[string]*IdempotentResponse)}
+}
+
+// Get implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Get(key string) (*IdempotentResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.m[key]
+	return r, ok
+}
+
+// Set implements IdempotencyStore. Expired responses are dropped once per
+// minute.
+func (s *MemoryIdempotencyStore) Set(key string, r *IdempotentResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if now.After(s.sweep) {
+		for k, old := range s.m {
+			if !now.Before(old.Expires) {
+				delete(s.m, k)
+			}
+		}
+		s.sweep = now.Add(time.Minute)
+	}
+	s.m[key] = r
+}
+
+// Delete implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Delete(key string) {
+	s.mu.Lock()
+	delete(s.m, key)
+	s.mu.Unlock()
+}
+
+// defaultClock sets the clock unless one was given.
+func (s *MemoryIdempotencyStore) defaultClock(clock core.Clock) {
+	s.mu.Lock()
+	if s.Clock == nil {
+		s.Clock = clock
+	}
+	s.mu.Unlock()
+}
+
+func (s *MemoryIdempotencyStore) now() time.Time {
+	if s.Clock == nil {
+		return time.Now()
+	}
+	return s.Clock.Now()
+}
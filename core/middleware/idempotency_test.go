@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotency(t *testing.T) {
+	e := core.New()
+	clock := core.NewManualClock(time.Unix(0, 0))
+	e.SetClock(clock)
+	charges := 0
+	h := IdempotencyWithConfig(IdempotencyConfig{TTL: time.Minute})(func(c *core.Context) error {
+		charges++
+		c.Response().Header().Set("X-Charge", strconv.Itoa(charges))
+		return c.String(http.StatusCreated, "charged "+strconv.Itoa(charges))
+	})
+	do := func(key, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(core.POST, "/pay", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		c := core.NewContext(req, core.NewResponse(rec, e), e)
+		if err := h(c); err != nil {
+			e.DefaultHTTPErrorHandler(err, c)
+		}
+		return rec
+	}
+
+	rec := do("k1", "10")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "charged 1", rec.Body.String())
+
+	// Replayed
+	rec = do("k1", "10")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "charged 1", rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get("X-Charge"))
+	assert.Equal(t, "true", rec.Header().Get(IdempotentReplayed))
+	assert.Equal(t, 1, charges)
+
+	// Same key, different request
+	rec = do("k1", "20")
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	// No key
+	do("", "10")
+	assert.Equal(t, 2, charges)
+
+	// Expired
+	clock.Advance(time.Minute)
+	rec = do("k1", "10")
+	assert.Equal(t, "charged 3", rec.Body.String())
+}
+
+func TestIdempotencyScope(t *testing.T) {
+	e := core.New()
+	charges := 0
+	h := IdempotencyWithConfig(IdempotencyConfig{
+		Scope: func(c *core.Context) string { return c.Request().Header.Get("X-User") },
+	})(func(c *core.Context) error {
+		charges++
+		return c.String(http.StatusCreated, "charged "+c.Request().Header.Get("X-User"))
+	})
+	do := func(user string) string {
+		req, _ := http.NewRequest(core.POST, "/pay", strings.NewReader("10"))
+		req.Header.Set("Idempotency-Key", "k1")
+		req.Header.Set("X-User", user)
+		rec := httptest.NewRecorder()
+		h(core.NewContext(req, core.NewResponse(rec, e), e))
+		return rec.Body.String()
+	}
+	assert.Equal(t, "charged alice", do("alice"))
+	assert.Equal(t, "charged bob", do("bob"))
+	assert.Equal(t, "charged alice", do("alice"))
+	assert.Equal(t, 2, charges)
+}
+
+func TestMemoryIdempotencyStoreSweep(t *testing.T) {
+	clock := core.NewManualClock(time.Unix(1000, 0))
+	s := NewMemoryIdempotencyStore()
+	s.Clock = clock
+	s.Set("a", &IdempotentResponse{Expires: clock.Now().Add(time.Second)})
+	clock.Advance(2 * time.Minute)
+	s.Set("b", &IdempotentResponse{Expires: clock.Now().Add(time.Hour)})
+	assert.Equal(t, 1, len(s.m))
+	_, ok := s.Get("b")
+	assert.True(t, ok)
+}
@@ -0,0 +1,229 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// IntrospectConfig defines the config for Introspect middleware.
+	IntrospectConfig struct {
+		// Endpoint is the OAuth2 token introspection endpoint (RFC 7662).
+		// Required.
+		Endpoint string
+
+		// ClientID and ClientSecret authenticate the resource server to the
+		// endpoint with HTTP basic authentication.
+		// Optional.
+		ClientID     string
+		ClientSecret string
+
+		// Client sends the introspection requests.
+		// Optional. Default value is a client with a 10 seconds timeout.
+		Client *http.Client
+
+		// CacheTTL is how long active tokens are cached, never beyond their
+		// expiry.
+		// Optional. Default value 5 minutes, negative disables caching.
+		CacheTTL time.Duration
+
+		// NegativeTTL is how long inactive tokens are cached.
+		// Optional. Default value 30 seconds, negative disables caching.
+		NegativeTTL time.Duration
+
+		// MaxEntries bounds the cache size.
+		// Optional. Default value 10000.
+		MaxEntries int
+
+		// ContextKey is the key the claims are stored under in the context.
+		// Optional. Default value "claims".
+		ContextKey string
+	}
+
+	// Introspection is the introspection response of a token.
+	Introspection struct {
+		Active    bool   `json:"active"`
+		Scope     string `json:"scope,omitempty"`
+		ClientID  string `json:"client_id,omitempty"`
+		Username  string `json:"username,omitempty"`
+		TokenType string `json:"token_type,omitempty"`
+		Exp       int64  `json:"exp,omitempty"`
+		Iat       int64  `json:"iat,omitempty"`
+		Sub       string `json:"sub,omitempty"`
+		Iss       string `json:"iss,omitempty"`
+
+		// Raw holds every member of the response, including extensions.
+		Raw map[string]interface{} `json:"-"`
+	}
+
+	introspectionCache struct {
+		mu  sync.Mutex
+		max int
+		m   map[[sha256.Size]byte]introspectionEntry
+	}
+
+	introspectionEntry struct {
+		claims  *Introspection
+		expires time.Time
+	}
+)
+
+// DefaultIntrospectConfig is the default Introspect middleware config.
+var DefaultIntrospectConfig = IntrospectConfig{
+	CacheTTL:    5 * time.Minute,
+	NegativeTTL: 30 * time.Second,
+	MaxEntries:  10000,
+	ContextKey:  "claims",
+}
+
+// Introspect returns a middleware which validates opaque OAuth2 bearer tokens
+// against an introspection endpoint and stores the claims in the context.
+func Introspect(endpoint string) core.MiddlewareFunc {
+	c := DefaultIntrospectConfig
+	c.Endpoint = endpoint
+	return IntrospectWithConfig(c)
+}
+
+// IntrospectWithConfig returns an Introspect middleware from config.
+// See `Introspect()`.
+//
+// Inactive tokens are answered with 401 Unauthorized, and an unreachable
+// endpoint with 503 Service Unavailable.
+func IntrospectWithConfig(config IntrospectConfig) core.MiddlewareFunc {
+	if config.Endpoint == "" {
+		panic("introspect middleware requires an endpoint")
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if config.CacheTTL == 0 {
+		config.CacheTTL = DefaultIntrospectConfig.CacheTTL
+	}
+	if config.NegativeTTL == 0 {
+		config.NegativeTTL = DefaultIntrospectConfig.NegativeTTL
+	}
+	if config.MaxEntries == 0 {
+		config.MaxEntries = DefaultIntrospectConfig.MaxEntries
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultIntrospectConfig.ContextKey
+	}
+	cache := &introspectionCache{max: config.MaxEntries, m: make(map[[sha256.Size]byte]introspectionEntry)}
+
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			h := c.Request().Header.Get(core.Authorization)
+			if len(h) < 7 || !strings.EqualFold(h[:7], "Bearer ") {
+				c.Response().Header().Set(core.WWWAuthenticate, "Bearer")
+				return core.NewHTTPError(http.StatusUnauthorized)
+			}
+			token := strings.TrimSpace(h[7:])
+			key := sha256.Sum256([]byte(token))
+			now := c.Now()
+
+			claims, ok := cache.get(key, now)
+			if !ok {
+				var err error
+				if claims, err = introspect(c, config, token); err != nil {
+					c.Echo().Logger().Error(err)
+					return core.NewHTTPError(http.StatusServiceUnavailable)
+				}
+				ttl := config.CacheTTL
+				if !claims.Active {
+					ttl = config.NegativeTTL
+				}
+				expires := now.Add(ttl)
+				if claims.Exp != 0 && time.Unix(claims.Exp, 0).Before(expires) {
+					expires = time.Unix(claims.Exp, 0)
+				}
+				if ttl > 0 {
+					cache.set(key, introspectionEntry{claims, expires}, now)
+				}
+			}
+			if !claims.Active || (claims.Exp != 0 && !now.Before(time.Unix(claims.Exp, 0))) {
+				c.Response().Header().Set(core.WWWAuthenticate, `Bearer error="invalid_token"`)
+				return core.NewHTTPError(http.StatusUnauthorized)
+			}
+			c.Set(config.ContextKey, claims)
+			return next(c)
+		}
+	}
+}
+
+// HasScope reports whether the token was granted scope.
+func (i *Introspection) HasScope(scope string) bool {
+	for _, s := range strings.Fields(i.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func introspect(c *core.Context, config IntrospectConfig, token string) (*Introspection, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequest(core.POST, config.Endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(c.StdContext())
+	req.Header.Set(core.ContentType, core.ApplicationForm)
+	req.Header.Set("Accept", core.ApplicationJSON)
+	if config.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(config.ClientID), url.QueryEscape(config.ClientSecret))
+	}
+	res, err := config.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("introspection: endpoint returned %s", res.Status)
+	}
+	var raw json.RawMessage
+	if err = json.NewDecoder(res.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+	claims := new(Introspection)
+	if err = json.Unmarshal(raw, claims); err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(raw, &claims.Raw); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (c *introspectionCache) get(key [sha256.Size]byte, now time.Time) (*Introspection, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.m[key]
+	if !ok || !now.Before(e.expires) {
+		return nil, false
+	}
+	return e.claims, true
+}
+
+func (c *introspectionCache) set(key [sha256.Size]byte, e introspectionEntry, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.m) >= c.max {
+		for k, v := range c.m {
+			if !now.Before(v.expires) {
+				delete(c.m, k)
+			}
+		}
+		if len(c.m) >= c.max {
+			c.m = make(map[[sha256.Size]byte]introspectionEntry)
+		}
+	}
+	c.m[key] = e
+}
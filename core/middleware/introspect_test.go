@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntrospect(t *testing.T) {
+	calls := 0
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "api", user)
+		assert.Equal(t, "secret", pass)
+		w.Header().Set(core.ContentType, core.ApplicationJSON)
+		if r.FormValue("token") == "good" {
+			w.Write([]byte(`{"active":true,"scope":"read write","sub":"42","tenant":"acme"}`))
+			return
+		}
+		w.Write([]byte(`{"active":false}`))
+	}))
+	defer idp.Close()
+
+	e := core.New()
+	clock := core.NewManualClock(time.Unix(1500000000, 0))
+	e.SetClock(clock)
+	mw := IntrospectWithConfig(IntrospectConfig{
+		Endpoint:     idp.URL,
+		ClientID:     "api",
+		ClientSecret: "secret",
+		CacheTTL:     time.Minute,
+		NegativeTTL:  time.Second,
+	})
+	h := mw(func(c *core.Context) error {
+		claims := c.Get("claims").(*Introspection)
+		assert.True(t, claims.HasScope("write"))
+		assert.Equal(t, "acme", claims.Raw["tenant"])
+		return c.String(http.StatusOK, claims.Sub)
+	})
+	do := func(auth string) int {
+		req, _ := http.NewRequest(core.GET, "/", nil)
+		if auth != "" {
+			req.Header.Set(core.Authorization, "Bearer "+auth)
+		}
+		rec := httptest.NewRecorder()
+		c := core.NewContext(req, core.NewResponse(rec, e), e)
+		if err := h(c); err != nil {
+			return err.(*core.HTTPError).Code()
+		}
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, do(""))
+	assert.Equal(t, http.StatusOK, do("good"))
+	assert.Equal(t, http.StatusOK, do("good"))
+	assert.Equal(t, 1, calls)
+
+	// Negative results are cached briefly
+	assert.Equal(t, http.StatusUnauthorized, do("bad"))
+	assert.Equal(t, http.StatusUnauthorized, do("bad"))
+	assert.Equal(t, 2, calls)
+	clock.Advance(time.Second)
+	do("bad")
+	assert.Equal(t, 3, calls)
+
+	// Positive results expire
+	clock.Advance(time.Minute)
+	do("good")
+	assert.Equal(t, 4, calls)
+
+	// Unreachable endpoint
+	idp.Close()
+	clock.Advance(time.Hour)
+	assert.Equal(t, http.StatusServiceUnavailable, do("good"))
+}
@@ -0,0 +1,293 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// LoggerConfig defines the config for Logger middleware.
+	LoggerConfig struct {
+		// Format of the log lines, Apache style:
+		//
+		//	%h     remote IP, see Context.RealIP
+		//	%l     remote logname, always "-"
+		//	%u     basic auth user or "-"
+		//	%t     time the request was received, [02/Jan/2006:15:04:05 -0700]
+		//	%r     request line, e.g. GET /path?q=1 HTTP/1.1
+		//	%m     method
+		//	%U     path
+		//	%q     query string, with its leading "?"
+		//	%H     protocol
+		//	%s %>s status
+		//	%b     bytes out, "-" if none
+		//	%B     bytes out
+		//	%I     bytes in
+		//	%O     bytes out
+		//	%D     latency in microseconds
+		//	%T     latency in seconds
+		//	%L     latency as a duration, e.g. 1.2ms
+		//	%{X}i  request header X
+		//	%{X}o  response header X
+		//	%{id}L request ID, see Context.RequestID
+		//	%%     percent sign
+		//
+		// Optional. Default value CommonLogFormat.
+		Format string
+
+		// Output receives the log lines.
+		// Optional. Default value is the logger of the Echo instance.
+		Output io.Writer
+	}
+
+	accessLog struct {
+		c       *core.Context
+		start   time.Time
+		latency time.Duration
+		in      int64
+	}
+
+	logField func(buf *bytes.Buffer, l *accessLog)
+
+	countingReader struct {
+		io.ReadCloser
+		n int64
+	}
+)
+
+// Access log formats.
+const (
+	CommonLogFormat   = `%h %l %u %t "%r" %>s %b`
+	CombinedLogFormat = `%h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-Agent}i"`
+)
+
+// DefaultLoggerConfig is the default Logger middleware config.
+var DefaultLoggerConfig = LoggerConfig{
+	Format: CommonLogFormat,
+}
+
+var logBufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// Logger returns a middleware which logs every request in the common log
+// format.
+func Logger() core.MiddlewareFunc {
+	return LoggerWithConfig(DefaultLoggerConfig)
+}
+
+// LoggerWithConfig returns a Logger middleware from config.
+// See `Logger()`.
+func LoggerWithConfig(config LoggerConfig) core.MiddlewareFunc {
+	if config.Format == "" {
+		config.Format = DefaultLoggerConfig.Format
+	}
+	fields := compileLogFormat(config.Format)
+	var mu sync.Mutex
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			req := c.Request()
+			l := &accessLog{c: c, start: c.Now()}
+			var body *countingReader
+			if req.Body != nil {
+				body = &countingReader{ReadCloser: req.Body}
+				req.Body = body
+			}
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+			l.latency = c.Now().Sub(l.start)
+			if body != nil {
+				l.in = body.n
+			}
+
+			buf := logBufferPool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer logBufferPool.Put(buf)
+			for _, f := range fields {
+				f(buf, l)
+			}
+			if config.Output == nil {
+				c.Echo().Logger().Info("%s", buf.String())
+				return nil
+			}
+			buf.WriteByte('\n')
+			mu.Lock()
+			config.Output.Write(buf.Bytes())
+			mu.Unlock()
+			return nil
+		}
+	}
+}
+
+func compileLogFormat(format string) []logField {
+	var fields []logField
+	literal := func(s string) logField {
+		return func(buf *bytes.Buffer, _ *accessLog) {
+			buf.WriteString(s)
+		}
+	}
+	for {
+		i := strings.IndexByte(format, '%')
+		if i == -1 || i == len(format)-1 {
+			if format != "" {
+				fields = append(fields, literal(format))
+			}
+			return fields
+		}
+		if i > 0 {
+			fields = append(fields, literal(format[:i]))
+		}
+		format = format[i+1:]
+		arg := ""
+		if format[0] == '{' {
+			if j := strings.IndexByte(format, '}'); j != -1 && j < len(format)-1 {
+				arg, format = format[1:j], format[j+1:]
+			}
+		}
+		if strings.HasPrefix(format, ">") && len(format) > 1 {
+			format = format[1:]
+		}
+		verb := format[0]
+		format = format[1:]
+		fields = append(fields, logVerb(verb, arg))
+	}
+}
+
+func logVerb(verb byte, arg string) logField {
+	switch verb {
+	case 'h':
+		return func(buf *bytes.Buffer, l *accessLog) {
+			buf.WriteString(l.c.RealIP())
+		}
+	case 'l':
+		return func(buf *bytes.Buffer, _ *accessLog) {
+			buf.WriteByte('-')
+		}
+	case 'u':
+		return func(buf *bytes.Buffer, l *accessLog) {
+			if user, _, ok := l.c.Request().BasicAuth(); ok && user != "" {
+				buf.WriteString(user)
+				return
+			}
+			buf.WriteByte('-')
+		}
+	case 't':
+		return func(buf *bytes.Buffer, l *accessLog) {
+			buf.WriteString(l.start.Format("[02/Jan/2006:15:04:05 -0700]"))
+		}
+	case 'r':
+		return func(buf *bytes.Buffer, l *accessLog) {
+			r := l.c.Request()
+			buf.WriteString(r.Method + " " + r.URL.RequestURI() + " " + r.Proto)
+		}
+	case 'm':
+		return func(buf *bytes.Buffer, l *accessLog) {
+			buf.WriteString(l.c.Request().Method)
+		}
+	case 'U':
+		return func(buf *bytes.Buffer, l *accessLog) {
+			p := l.c.Request().URL.Path
+			if p == "" {
+				p = "/"
+			}
+			buf.WriteString(p)
+		}
+	case 'q':
+		return func(buf *bytes.Buffer, l *accessLog) {
+			if q := l.c.Request().URL.RawQuery; q != "" {
+				buf.WriteString("?" + q)
+			}
+		}
+	case 'H':
+		return func(buf *bytes.Buffer, l *accessLog) {
+			buf.WriteString(l.c.Request().Proto)
+		}
+	case 's':
+		return func(buf *bytes.Buffer, l *accessLog) {
+			buf.WriteString(strconv.Itoa(status(l.c)))
+		}
+	case 'b':
+		return func(buf *bytes.Buffer, l *accessLog) {
+			if n := l.c.Response().Size(); n > 0 {
+				buf.WriteString(strconv.FormatInt(n, 10))
+				return
+			}
+			buf.WriteByte('-')
+		}
+	case 'B', 'O':
+		return func(buf *bytes.Buffer, l *accessLog) {
+			buf.WriteString(strconv.FormatInt(l.c.Response().Size(), 10))
+		}
+	case 'I':
+		return func(buf *bytes.Buffer, l *accessLog) {
+			buf.WriteString(strconv.FormatInt(l.in, 10))
+		}
+	case 'D':
+		return func(buf *bytes.Buffer, l *accessLog) {
+			buf.WriteString(strconv.FormatInt(int64(l.latency/time.Microsecond), 10))
+		}
+	case 'T':
+		return func(buf *bytes.Buffer, l *accessLog) {
+			buf.WriteString(strconv.FormatInt(int64(l.latency/time.Second), 10))
+		}
+	case 'L':
+		if arg == "id" {
+			return func(buf *bytes.Buffer, l *accessLog) {
+				if id := l.c.RequestID(); id != "" {
+					buf.WriteString(id)
+					return
+				}
+				buf.WriteByte('-')
+			}
+		}
+		return func(buf *bytes.Buffer, l *accessLog) {
+			buf.WriteString(l.latency.String())
+		}
+	case 'i':
+		return func(buf *bytes.Buffer, l *accessLog) {
+			if v := l.c.Request().Header.Get(arg); v != "" {
+				buf.WriteString(v)
+				return
+			}
+			buf.WriteByte('-')
+		}
+	case 'o':
+		return func(buf *bytes.Buffer, l *accessLog) {
+			if v := l.c.Response().Header().Get(arg); v != "" {
+				buf.WriteString(v)
+				return
+			}
+			buf.WriteByte('-')
+		}
+	}
+	s := "%" + string(verb)
+	if verb == '%' {
+		s = "%"
+	}
+	return func(buf *bytes.Buffer, _ *accessLog) {
+		buf.WriteString(s)
+	}
+}
+
+// status returns the response status, 200 if nothing was written.
+func status(c *core.Context) int {
+	if s := c.Response().Status(); s != 0 {
+		return s
+	}
+	return 200
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	r.n += int64(n)
+	return n, err
+}
@@ -53,6 +53,7 @@ func TestLogger(t *testing.T) {
 func TestLoggerIPAddress(t *testing.T) {
 	e := core.New()
 	req, _ := http.NewRequest(core.GET, "/", nil)
+	req.RemoteAddr = "10.0.0.1:80"
 	rec := httptest.NewRecorder()
 	c := core.NewContext(req, core.NewResponse(rec, e), e)
 	buf := new(bytes.Buffer)
@@ -64,9 +65,18 @@ func TestLoggerIPAddress(t *testing.T) {
 
 	mw := Logger()
 
-	// With X-Real-IP
+	// The headers of untrusted peers are ignored
 	req.Header.Add(core.XRealIP, ip)
 	mw(h)(c)
+	assert.Contains(t, buf.String(), "10.0.0.1")
+	assert.NotContains(t, buf.String(), ip)
+
+	// Trusting every address honors them as before
+	e.SetTrustedProxies("0.0.0.0/0", "::/0")
+
+	// With X-Real-IP
+	buf.Reset()
+	mw(h)(c)
 	assert.Contains(t, buf.String(), ip)
 
 	// With X-Forwarded-For
@@ -78,6 +88,35 @@ func TestLoggerIPAddress(t *testing.T) {
 
 	// with req.RemoteAddr
 	buf.Reset()
+	req.Header.Del(core.XForwardedFor)
+	req.RemoteAddr = ip + ":80"
 	mw(h)(c)
 	assert.Contains(t, buf.String(), ip)
 }
+
+func TestLoggerFormat(t *testing.T) {
+	e := core.New()
+	e.SetIDGenerator(&core.SequenceIDs{Prefix: "r"})
+	req, _ := http.NewRequest(core.POST, "/users?page=2", bytes.NewReader([]byte("name=joe")))
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("User-Agent", "curl")
+	req.Header.Set(core.ContentType, core.ApplicationForm)
+	req.SetBasicAuth("joe", "secret")
+	rec := httptest.NewRecorder()
+	c := core.NewContext(req, core.NewResponse(rec, e), e)
+	buf := new(bytes.Buffer)
+	mw := LoggerWithConfig(LoggerConfig{
+		Format: CombinedLogFormat + ` %I %{X-Total}o %{id}L %q 100%%`,
+		Output: buf,
+	})
+	h := func(c *core.Context) error {
+		c.SetRequestID(c.NewID())
+		c.Request().ParseForm()
+		c.Response().Header().Set("X-Total", "42")
+		return c.String(http.StatusCreated, "created")
+	}
+	mw(h)(c)
+	line := buf.String()
+	assert.Contains(t, line, `10.0.0.1 - joe [`)
+	assert.Contains(t, line, `] "POST /users?page=2 HTTP/1.1" 201 7 "-" "curl" 8 42 r1 ?page=2 100%`+"\n")
+}
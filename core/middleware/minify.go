@@ -0,0 +1,318 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// MinifyFunc minifies a response body.
+	MinifyFunc func([]byte) ([]byte, error)
+
+	// MinifyConfig defines the config for the Minify middleware.
+	MinifyConfig struct {
+		// MinSize is the body size below which responses are sent as is.
+		MinSize int
+
+		// Exclude lists path prefixes which are never minified.
+		Exclude []string
+
+		// Minifiers maps media types to their minifier. Defaults to
+		// DefaultMinifiers.
+		Minifiers map[string]MinifyFunc
+	}
+)
+
+var (
+	// DefaultMinifiers are the minifiers used when none are configured.
+	DefaultMinifiers = map[string]MinifyFunc{
+		core.TextHTML:              MinifyHTML,
+		"text/css":                 MinifyCSS,
+		core.ApplicationJavaScript: MinifyJS,
+		"text/javascript":          MinifyJS,
+		core.ApplicationJSON:       MinifyJSON,
+	}
+
+	// DefaultMinifyConfig is the default Minify middleware config.
+	DefaultMinifyConfig = MinifyConfig{
+		MinSize: 512,
+	}
+)
+
+// Minify returns a middleware which minifies HTML, CSS, JS and JSON responses.
+// Register it after Gzip so responses are minified before being compressed.
+func Minify() core.MiddlewareFunc {
+	return MinifyWithConfig(DefaultMinifyConfig)
+}
+
+// MinifyWithConfig returns a Minify middleware from config.
+func MinifyWithConfig(config MinifyConfig) core.MiddlewareFunc {
+	if config.Minifiers == nil {
+		config.Minifiers = DefaultMinifiers
+	}
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			req := c.Request()
+			if req.Method == core.HEAD || (req.Header.Get(core.Upgrade)) == core.WebSocket {
+				return next(c)
+			}
+			for _, p := range config.Exclude {
+				if strings.HasPrefix(req.URL.Path, p) {
+					return next(c)
+				}
+			}
+
+			res := c.Response()
+			w := core.NewBufferWriter(res.Writer())
+			res.SetWriter(w)
+			err := next(c)
+			res.SetWriter(w.ResponseWriter)
+			body, ok := w.Buffered()
+			if !ok {
+				return err
+			}
+
+			h := w.Header()
+			ct := h.Get(core.ContentType)
+			if i := strings.Index(ct, ";"); i != -1 {
+				ct = ct[:i]
+			}
+			if fn := config.Minifiers[strings.TrimSpace(strings.ToLower(ct))]; fn != nil &&
+				len(body) >= config.MinSize && h.Get(core.ContentEncoding) == "" {
+				if b, merr := fn(body); merr == nil {
+					body = b
+					if h.Get(core.ContentLength) != "" {
+						h.Set(core.ContentLength, strconv.Itoa(len(body)))
+					}
+				}
+			}
+			w.Send(body)
+			return err
+		}
+	}
+}
+
+// MinifyJSON removes insignificant space from JSON.
+func MinifyJSON(b []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := json.Compact(buf, b); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MinifyCSS removes comments and insignificant space from CSS.
+func MinifyCSS(b []byte) ([]byte, error) {
+	out := make([]byte, 0, len(b))
+	// stop is the index of the next '{', ';' or '}' after a space, telling
+	// declarations ("color : red;") from selectors ("a :hover {").
+	stop := -1
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c == '"' || c == '\'':
+			j := skipString(b, i)
+			out = append(out, b[i:j]...)
+			i = j - 1
+		case c == '/' && i+1 < len(b) && b[i+1] == '*':
+			end := bytes.Index(b[i+2:], []byte("*/"))
+			if end == -1 {
+				return out, nil
+			}
+			i += end + 3
+		case isSpace(c):
+			for i+1 < len(b) && isSpace(b[i+1]) {
+				i++
+			}
+			if len(out) == 0 || strings.IndexByte("{};:,>(", out[len(out)-1]) != -1 {
+				continue
+			}
+			if i+1 < len(b) && strings.IndexByte("{};,>)", b[i+1]) != -1 {
+				continue
+			}
+			if i+1 < len(b) && b[i+1] == ':' {
+				if stop < i {
+					stop = i + 1
+					for stop < len(b) && strings.IndexByte("{;}", b[stop]) == -1 {
+						stop++
+					}
+				}
+				if stop == len(b) || b[stop] != '{' {
+					continue
+				}
+			}
+			out = append(out, ' ')
+		case c == '}' && len(out) > 0 && out[len(out)-1] == ';':
+			out[len(out)-1] = '}'
+		default:
+			out = append(out, c)
+		}
+	}
+	return bytes.TrimSpace(out), nil
+}
+
+// MinifyJS removes indentation and blank lines from JavaScript. It stays
+// conservative: comments are kept and strings are never touched.
+func MinifyJS(b []byte) ([]byte, error) {
+	out := make([]byte, 0, len(b))
+	lineStart := true
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			j := skipString(b, i)
+			out = append(out, b[i:j]...)
+			i = j - 1
+			lineStart = false
+		case c == '\n':
+			if !lineStart {
+				out = bytes.TrimRight(out, " \t\r")
+				out = append(out, '\n')
+			}
+			lineStart = true
+		case lineStart && isSpace(c):
+		default:
+			out = append(out, c)
+			lineStart = false
+		}
+	}
+	return bytes.TrimSpace(out), nil
+}
+
+// MinifyHTML removes comments (but conditional ones) and collapses space in
+// HTML. The content of pre, textarea, script and style elements and quoted
+// attribute values are kept as is.
+func MinifyHTML(b []byte) ([]byte, error) {
+	out := make([]byte, 0, len(b))
+	var (
+		inTag bool
+		quote byte
+	)
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case quote != 0:
+			out = append(out, c)
+			if c == quote {
+				quote = 0
+			}
+		case inTag:
+			switch {
+			case c == '"' || c == '\'':
+				quote = c
+				out = append(out, c)
+			case c == '>':
+				inTag = false
+				out = append(out, c)
+			case isSpace(c):
+				for i+1 < len(b) && isSpace(b[i+1]) {
+					i++
+				}
+				if i+1 < len(b) && b[i+1] != '>' && b[i+1] != '/' && b[i+1] != '=' && out[len(out)-1] != '=' {
+					out = append(out, ' ')
+				}
+			default:
+				out = append(out, c)
+			}
+		case bytes.HasPrefix(b[i:], []byte("<!--")):
+			end := bytes.Index(b[i+4:], []byte("-->"))
+			if end == -1 {
+				return append(out, b[i:]...), nil
+			}
+			if i+4 < len(b) && b[i+4] == '[' {
+				out = append(out, b[i:i+4+end+3]...)
+			}
+			i += 4 + end + 2
+		case c == '<':
+			if name := rawElement(b[i+1:]); name != "" {
+				end := indexClosing(b[i:], name)
+				if end == -1 {
+					return append(out, b[i:]...), nil
+				}
+				out = append(out, b[i:i+end]...)
+				i += end - 1
+				continue
+			}
+			inTag = true
+			out = append(out, c)
+		case isSpace(c):
+			for i+1 < len(b) && isSpace(b[i+1]) {
+				i++
+			}
+			if len(out) == 0 || out[len(out)-1] != ' ' {
+				out = append(out, ' ')
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return bytes.TrimSpace(out), nil
+}
+
+// rawElement returns the name of the element starting at b if its content must
+// not be rewritten.
+func rawElement(b []byte) string {
+	for _, name := range []string{"pre", "textarea", "script", "style"} {
+		if len(b) > len(name) && equalFoldASCII(b[:len(name)], name) {
+			if c := b[len(name)]; c == '>' || isSpace(c) {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// indexClosing returns the index of the closing tag of the element name in b,
+// or -1.
+func indexClosing(b []byte, name string) int {
+	for i := 0; ; i += 2 {
+		j := bytes.Index(b[i:], []byte("</"))
+		if j == -1 {
+			return -1
+		}
+		i += j
+		if len(b) >= i+2+len(name) && equalFoldASCII(b[i+2:i+2+len(name)], name) {
+			return i
+		}
+	}
+}
+
+// equalFoldASCII reports whether b equals the lower case ASCII s, ignoring
+// case.
+func equalFoldASCII(b []byte, s string) bool {
+	if len(b) != len(s) {
+		return false
+	}
+	for i := range b {
+		c := b[i]
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		if c != s[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// skipString returns the index following the string literal starting at i.
+func skipString(b []byte, i int) int {
+	q := b[i]
+	for j := i + 1; j < len(b); j++ {
+		switch b[j] {
+		case '\\':
+			j++
+		case q:
+			return j + 1
+		}
+	}
+	return len(b)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
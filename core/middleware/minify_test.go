@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinify(t *testing.T) {
+	e := core.New()
+	page := "<html>\n  <body class=\"a  b\">\n    <!-- note -->\n    <p>hello   world</p>\n<pre>  keep\n  this</pre>\n  </body>\n</html>\n"
+	h := func(c *core.Context) error {
+		return c.HTML(http.StatusCreated, page)
+	}
+	mw := MinifyWithConfig(MinifyConfig{Exclude: []string{"/raw"}})
+
+	req, _ := http.NewRequest(core.GET, "/", nil)
+	rec := httptest.NewRecorder()
+	c := core.NewContext(req, core.NewResponse(rec, e), e)
+	assert.NoError(t, mw(h)(c))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "<html> <body class=\"a  b\"> <p>hello world</p> <pre>  keep\n  this</pre> </body> </html>", rec.Body.String())
+
+	// Excluded path
+	req, _ = http.NewRequest(core.GET, "/raw/page", nil)
+	rec = httptest.NewRecorder()
+	c = core.NewContext(req, core.NewResponse(rec, e), e)
+	assert.NoError(t, mw(h)(c))
+	assert.Equal(t, page, rec.Body.String())
+
+	// Below threshold
+	req, _ = http.NewRequest(core.GET, "/", nil)
+	rec = httptest.NewRecorder()
+	c = core.NewContext(req, core.NewResponse(rec, e), e)
+	assert.NoError(t, Minify()(h)(c))
+	assert.Equal(t, page, rec.Body.String())
+}
+
+func TestMinifiers(t *testing.T) {
+	css, _ := MinifyCSS([]byte("/* c */\na > b ,\n .x:hover {\n  color : red ;\n  content: \"a  b\";\n}\n@media screen and (max-width: 10px) { }"))
+	assert.Equal(t, `a>b,.x:hover{color:red;content:"a  b"}@media screen and (max-width:10px){}`, string(css))
+
+	// Space before a pseudo-class is a descendant combinator
+	css, _ = MinifyCSS([]byte("@media print {\n  a :hover { margin : 0 }\n}"))
+	assert.Equal(t, `@media print{a :hover{margin:0}}`, string(css))
+
+	js, _ := MinifyJS([]byte("function f() {\n    var s = `a\n    b`;\n\n    return s;\n}\n"))
+	assert.Equal(t, "function f() {\nvar s = `a\n    b`;\nreturn s;\n}", string(js))
+
+	j, _ := MinifyJSON([]byte("{\n  \"a\": [1, 2]\n}"))
+	assert.Equal(t, `{"a":[1,2]}`, string(j))
+
+	html, _ := MinifyHTML([]byte(strings.Repeat(" ", 3) + "<a  href = \"x\" >t</a>"))
+	assert.Equal(t, `<a href="x">t</a>`, string(html))
+
+	// Closing tags are found whatever the case, after non-ASCII text
+	html, _ = MinifyHTML([]byte("<p>İ  ẞ</p> <PRE>a  İ</Pre>  <p>b  c</p>"))
+	assert.Equal(t, "<p>İ ẞ</p> <PRE>a  İ</Pre> <p>b c</p>", string(html))
+}
+
+func TestMinifyPush(t *testing.T) {
+	e := core.New()
+	req, _ := http.NewRequest(core.GET, "/", nil)
+	rec := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	c := core.NewContext(req, core.NewResponse(rec, e), e)
+	h := func(c *core.Context) error {
+		return c.HTML(http.StatusOK, "<p>a</p>")
+	}
+	assert.NoError(t, Minify()(Push("/app.css")(h))(c))
+	assert.Equal(t, []string{"/app.css"}, rec.pushed)
+}
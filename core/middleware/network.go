@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// NetworkConfig defines the config for the AllowNetworks middleware.
+	NetworkConfig struct {
+		// Networks lists the allowed IPs and CIDRs.
+		Networks []string
+
+		// ClientCert also allows TLS clients presenting a verified certificate.
+		ClientCert bool
+
+		// RealIP checks `Context#RealIP` instead of the peer address, which
+		// only differ behind trusted proxies, see `Echo#SetTrustedProxies`.
+		RealIP bool
+	}
+)
+
+var (
+	// LoopbackNetworks are the loopback CIDRs.
+	LoopbackNetworks = []string{"127.0.0.0/8", "::1/128"}
+
+	// PrivateNetworks are the loopback and private CIDRs.
+	PrivateNetworks = []string{"127.0.0.0/8", "::1/128", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"}
+)
+
+// AllowNetworks returns a middleware which only lets requests from the given
+// IPs or CIDRs through, answering "403 - Forbidden" to others. It is meant to
+// guard internal endpoints like /metrics, /debug or /admin:
+//
+//	e.Group("/debug", middleware.AllowNetworks(middleware.PrivateNetworks...))
+//
+// Malformed networks panic.
+func AllowNetworks(networks ...string) core.MiddlewareFunc {
+	return AllowNetworksWithConfig(NetworkConfig{Networks: networks})
+}
+
+// AllowNetworksWithConfig returns an AllowNetworks middleware from config.
+func AllowNetworksWithConfig(config NetworkConfig) core.MiddlewareFunc {
+	nets := parseNetworks(config.Networks)
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			req := c.Request()
+			if config.ClientCert && req.TLS != nil && len(req.TLS.VerifiedChains) > 0 {
+				return next(c)
+			}
+			addr := req.RemoteAddr
+			if config.RealIP {
+				addr = c.RealIP()
+			} else if host, _, err := net.SplitHostPort(addr); err == nil {
+				addr = host
+			}
+			if inNetworks(addr, nets) {
+				return next(c)
+			}
+			return core.NewHTTPError(http.StatusForbidden)
+		}
+	}
+}
+
+func parseNetworks(networks []string) []*net.IPNet {
+	nets, err := core.ParseNetworks(networks...)
+	if err != nil {
+		panic("network: " + err.Error())
+	}
+	return nets
+}
+
+// inNetworks reports whether the IP addr is in one of nets.
+func inNetworks(addr string, nets []*net.IPNet) bool {
+	if ip := net.ParseIP(addr); ip != nil {
+		for _, n := range nets {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
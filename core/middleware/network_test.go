@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowNetworks(t *testing.T) {
+	e := core.New()
+	h := func(c *core.Context) error {
+		return c.String(http.StatusOK, "test")
+	}
+	mw := AllowNetworks(PrivateNetworks...)
+	req, _ := http.NewRequest(core.GET, "/metrics", nil)
+
+	// Private peer
+	for _, addr := range []string{"127.0.0.1:1234", "[::1]:1234", "192.168.1.20:80"} {
+		req.RemoteAddr = addr
+		c := core.NewContext(req, core.NewResponse(httptest.NewRecorder(), e), e)
+		assert.NoError(t, mw(h)(c), addr)
+	}
+
+	// Public peer
+	req.RemoteAddr = "8.8.8.8:1234"
+	c := core.NewContext(req, core.NewResponse(httptest.NewRecorder(), e), e)
+	he := mw(h)(c).(*core.HTTPError)
+	assert.Equal(t, http.StatusForbidden, he.Code())
+
+	// Forged header is ignored by default
+	req.Header.Set(core.XRealIP, "127.0.0.1")
+	c = core.NewContext(req, core.NewResponse(httptest.NewRecorder(), e), e)
+	he = mw(h)(c).(*core.HTTPError)
+	assert.Equal(t, http.StatusForbidden, he.Code())
+
+	// Verified client certificate
+	mw = AllowNetworksWithConfig(NetworkConfig{Networks: LoopbackNetworks, ClientCert: true})
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{}}}
+	c = core.NewContext(req, core.NewResponse(httptest.NewRecorder(), e), e)
+	assert.NoError(t, mw(h)(c))
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// PushConfig defines the config for the Push middleware.
+	PushConfig struct {
+		// Assets are pushed on every page load.
+		Assets []string
+
+		// Manifest returns additional assets per request. Optional.
+		Manifest func(*core.Context) []string
+
+		// Cookie marks visitors which already got the assets, so repeat visits
+		// rely on the browser cache instead. Empty disables the check.
+		Cookie string
+
+		// MaxAge of the cookie in seconds.
+		MaxAge int
+	}
+)
+
+var (
+	// DefaultPushConfig is the default Push middleware config.
+	DefaultPushConfig = PushConfig{
+		Cookie: "_pushed",
+		MaxAge: 86400 * 7,
+	}
+
+	assetRe = regexp.MustCompile(`(?i)<(?:link[^>]+rel=["']?(?:stylesheet|preload)["']?[^>]*href|script[^>]+src)=["']?([^"' >]+)`)
+)
+
+// Push returns a middleware which pushes the given assets (critical CSS/JS) over
+// HTTP/2 before the page handler runs. It does nothing when push is not
+// supported or the visitor already got the assets.
+func Push(assets ...string) core.MiddlewareFunc {
+	config := DefaultPushConfig
+	config.Assets = assets
+	return PushWithConfig(config)
+}
+
+// PushWithConfig returns a Push middleware from config.
+func PushWithConfig(config PushConfig) core.MiddlewareFunc {
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			req := c.Request()
+			if req.Method != core.GET {
+				return next(c)
+			}
+			if _, ok := c.Response().Writer().(http.Pusher); !ok {
+				return next(c)
+			}
+			if config.Cookie != "" {
+				if _, err := req.Cookie(config.Cookie); err == nil {
+					return next(c)
+				}
+			}
+			assets := config.Assets
+			if config.Manifest != nil {
+				assets = append(assets[:len(assets):len(assets)], config.Manifest(c)...)
+			}
+			pushed := false
+			for _, a := range assets {
+				if err := c.Response().Push(a, nil); err != nil {
+					break
+				}
+				pushed = true
+			}
+			if pushed && config.Cookie != "" {
+				http.SetCookie(c.Response(), &http.Cookie{
+					Name:     config.Cookie,
+					Value:    "1",
+					Path:     "/",
+					MaxAge:   config.MaxAge,
+					HttpOnly: true,
+				})
+			}
+			return next(c)
+		}
+	}
+}
+
+// AssetsFromHTML lists the stylesheets and scripts referenced by an HTML page or
+// template source, for deriving a push manifest from a template.
+func AssetsFromHTML(html []byte) []string {
+	var assets []string
+	for _, m := range assetRe.FindAllSubmatch(html, -1) {
+		assets = append(assets, string(m[1]))
+	}
+	return assets
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/henrylee2cn/thinkgo/core/http2"
+	"github.com/henrylee2cn/thinkgo/core/http2/hpack"
+	"github.com/stretchr/testify/assert"
+)
+
+type pushRecorder struct {
+	*httptest.ResponseRecorder
+	pushed []string
+}
+
+func (r *pushRecorder) Push(target string, opts *http.PushOptions) error {
+	r.pushed = append(r.pushed, target)
+	return nil
+}
+
+func TestPush(t *testing.T) {
+	e := core.New()
+	h := func(c *core.Context) error {
+		return c.HTML(http.StatusOK, "<html></html>")
+	}
+	mw := Push("/app.css", "/app.js")
+
+	// First visit
+	req, _ := http.NewRequest(core.GET, "/", nil)
+	rec := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	c := core.NewContext(req, core.NewResponse(rec, e), e)
+	assert.NoError(t, mw(h)(c))
+	assert.Equal(t, []string{"/app.css", "/app.js"}, rec.pushed)
+	assert.Contains(t, rec.Header().Get("Set-Cookie"), DefaultPushConfig.Cookie+"=1")
+
+	// Repeat visit
+	req.AddCookie(&http.Cookie{Name: DefaultPushConfig.Cookie, Value: "1"})
+	rec = &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	c = core.NewContext(req, core.NewResponse(rec, e), e)
+	assert.NoError(t, mw(h)(c))
+	assert.Equal(t, 0, len(rec.pushed))
+}
+
+// h2Get gets the root of srv over HTTP/2, push enabled, and returns the paths
+// promised and the headers of the response.
+func h2Get(t *testing.T, srv *httptest.Server) (pushed []string, header map[string]string) {
+	conn, err := tls.Dial("tcp", srv.Listener.Addr().String(), &tls.Config{
+		InsecureSkipVerify: true,
+		NextProtos:         []string{"h2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err = conn.Write([]byte(http2.ClientPreface)); err != nil {
+		t.Fatal(err)
+	}
+	fr := http2.NewFramer(conn, conn)
+	fr.WriteSettings()
+
+	var block bytes.Buffer
+	enc := hpack.NewEncoder(&block)
+	for _, f := range [][2]string{{":method", "GET"}, {":scheme", "https"}, {":authority", srv.Listener.Addr().String()}, {":path", "/"}} {
+		enc.WriteField(hpack.HeaderField{Name: f[0], Value: f[1]})
+	}
+	fr.WriteHeaders(http2.HeadersFrameParam{StreamID: 1, BlockFragment: block.Bytes(), EndStream: true, EndHeaders: true})
+
+	header = map[string]string{}
+	dec := hpack.NewDecoder(4096, nil)
+	for {
+		f, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch f := f.(type) {
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				fr.WriteSettingsAck()
+			}
+		case *http2.PushPromiseFrame:
+			fields, _ := dec.DecodeFull(f.HeaderBlockFragment())
+			for _, hf := range fields {
+				if hf.Name == ":path" {
+					pushed = append(pushed, hf.Value)
+				}
+			}
+		case *http2.HeadersFrame:
+			fields, _ := dec.DecodeFull(f.HeaderBlockFragment())
+			if f.StreamID == 1 {
+				for _, hf := range fields {
+					header[hf.Name] = hf.Value
+				}
+				if f.StreamEnded() {
+					return
+				}
+			}
+		case *http2.DataFrame:
+			if f.StreamID == 1 && f.StreamEnded() {
+				return
+			}
+		}
+	}
+}
+
+func TestPushHTTP2(t *testing.T) {
+	e := core.New()
+	e.Use(Push("/app.css", "/app.js"))
+	e.Get("/", func(c *core.Context) error {
+		return c.HTML(http.StatusOK, "<html></html>")
+	})
+	srv := httptest.NewUnstartedServer(nil)
+	srv.Config = e.Server("")
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	pushed, header := h2Get(t, srv)
+	assert.Equal(t, []string{"/app.css", "/app.js"}, pushed)
+	assert.Equal(t, "200", header[":status"])
+	assert.Contains(t, header["set-cookie"], DefaultPushConfig.Cookie+"=1")
+}
+
+func TestAssetsFromHTML(t *testing.T) {
+	html := `<link rel="stylesheet" href="/a.css"><script src='/b.js'></script><link rel=icon href=/f.ico>`
+	assert.Equal(t, []string{"/a.css", "/b.js"}, AssetsFromHTML([]byte(html)))
+}
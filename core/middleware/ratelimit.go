@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// RateLimitConfig defines the config for RateLimit middleware.
+	RateLimitConfig struct {
+		// Rate is the number of requests allowed per second, on average.
+		// Required.
+		Rate float64
+
+		// Burst is the number of requests allowed at once.
+		// Optional. Default value is Rate rounded up.
+		Burst int
+
+		// KeyFunc identifies the client of a request.
+		// Optional. Default value is the client IP: the peer address unless
+		// it's a trusted proxy, see Context.RealIP, so clients can't pick
+		// their key with proxy headers.
+		KeyFunc func(*core.Context) string
+
+		// Store keeps the token buckets.
+		// Optional. Default value is a MemoryRateLimitStore.
+		Store RateLimitStore
+	}
+
+	// RateLimitStore keeps a token bucket per key.
+	RateLimitStore interface {
+		// Take takes a token from the bucket of key, refilled at rate tokens
+		// per second up to burst. If none is left, it returns how long to wait
+		// for the next one.
+		Take(key string, rate float64, burst int, now time.Time) (ok bool, wait time.Duration, err error)
+	}
+
+	// MemoryRateLimitStore is an in-memory RateLimitStore.
+	MemoryRateLimitStore struct {
+		mu      sync.Mutex
+		buckets map[string]*tokenBucket
+		sweep   time.Time
+	}
+
+	tokenBucket struct {
+		tokens float64
+		last   time.Time
+	}
+)
+
+// clientIP keys requests by client IP. Proxy headers only count when sent by
+// a trusted proxy, see Echo.SetTrustedProxies.
+func clientIP(c *core.Context) string {
+	return c.RealIP()
+}
+
+// RetryAfter is the Retry-After header.
+const RetryAfter = "Retry-After"
+
+// RateLimit returns a token bucket rate limiting middleware keyed by client IP,
+// allowing rate requests per second with bursts of burst requests. Requests
+// over the limit get 429 Too Many Requests with a Retry-After header.
+func RateLimit(rate float64, burst int) core.MiddlewareFunc {
+	return RateLimitWithConfig(RateLimitConfig{Rate: rate, Burst: burst})
+}
+
+// RateLimitWithConfig returns a RateLimit middleware from config.
+// See `RateLimit()`.
+func RateLimitWithConfig(config RateLimitConfig) core.MiddlewareFunc {
+	if config.Rate <= 0 {
+		panic("rate limit middleware requires a positive rate")
+	}
+	if config.Burst <= 0 {
+		config.Burst = int(math.Ceil(config.Rate))
+	}
+	if config.KeyFunc == nil {
+		config.KeyFunc = clientIP
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryRateLimitStore()
+	}
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			ok, wait, err := config.Store.Take(config.KeyFunc(c), config.Rate, config.Burst, c.Now())
+			if err != nil {
+				return err
+			}
+			if !ok {
+				c.Response().Header().Set(RetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				return core.NewHTTPError(http.StatusTooManyRequests)
+			}
+			return next(c)
+		}
+	}
+}
+
+// NewMemoryRateLimitStore returns an empty MemoryRateLimitStore.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*tokenBucket)}
+}
+
+// Take implements RateLimitStore.
+func (s *MemoryRateLimitStore) Take(key string, rate float64, burst int, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Full buckets are dropped once a minute to bound memory.
+	if now.Sub(s.sweep) > time.Minute {
+		for k, b := range s.buckets {
+			if b.fill(rate, burst, now) >= float64(burst) {
+				delete(s.buckets, k)
+			}
+		}
+		s.sweep = now
+	}
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(burst), last: now}
+		s.buckets[key] = b
+	}
+	if b.fill(rate, burst, now) < 1 {
+		return false, time.Duration((1 - b.tokens) / rate * float64(time.Second)), nil
+	}
+	b.tokens--
+	return true, 0, nil
+}
+
+// fill adds the tokens earned since the last call.
+func (b *tokenBucket) fill(rate float64, burst int, now time.Time) float64 {
+	if now.After(b.last) {
+		b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+		b.last = now
+	}
+	return b.tokens
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	e := core.New()
+	clock := core.NewManualClock(time.Unix(1500000000, 0))
+	e.SetClock(clock)
+	h := RateLimit(0.5, 2)(func(c *core.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	do := func(ip string) (int, string) {
+		req, _ := http.NewRequest(core.GET, "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		c := core.NewContext(req, core.NewResponse(rec, e), e)
+		if err := h(c); err != nil {
+			return err.(*core.HTTPError).Code(), rec.Header().Get(RetryAfter)
+		}
+		return rec.Code, ""
+	}
+
+	// Burst
+	code, _ := do("10.0.0.1")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = do("10.0.0.1")
+	assert.Equal(t, http.StatusOK, code)
+	code, retry := do("10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, code)
+	assert.Equal(t, "2", retry)
+
+	// Other clients are not affected
+	code, _ = do("10.0.0.2")
+	assert.Equal(t, http.StatusOK, code)
+
+	// Refill
+	clock.Advance(time.Second)
+	code, retry = do("10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, code)
+	assert.Equal(t, "1", retry)
+	clock.Advance(time.Second)
+	code, _ = do("10.0.0.1")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestRateLimitProxyHeaders(t *testing.T) {
+	e := core.New()
+	h := RateLimit(1, 1)(func(c *core.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	do := func(peer, forwardedFor string) int {
+		req, _ := http.NewRequest(core.GET, "/", nil)
+		req.RemoteAddr = peer + ":1234"
+		req.Header.Set(core.XForwardedFor, forwardedFor)
+		rec := httptest.NewRecorder()
+		c := core.NewContext(req, core.NewResponse(rec, e), e)
+		if err := h(c); err != nil {
+			return err.(*core.HTTPError).Code()
+		}
+		return rec.Code
+	}
+
+	// Without trusted proxies a client can't get new keys by forging headers
+	assert.Equal(t, http.StatusOK, do("192.0.2.1", "198.51.100.1"))
+	assert.Equal(t, http.StatusTooManyRequests, do("192.0.2.1", "198.51.100.2"))
+
+	// Behind a trusted proxy the clients it forwards are told apart
+	assert.NoError(t, e.SetTrustedProxies("10.0.0.0/8"))
+	assert.Equal(t, http.StatusOK, do("10.0.0.1", "198.51.100.1"))
+	assert.Equal(t, http.StatusOK, do("10.0.0.1", "198.51.100.2"))
+	assert.Equal(t, http.StatusTooManyRequests, do("10.0.0.1", "198.51.100.2"))
+}
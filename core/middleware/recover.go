@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// RecoverConfig defines the config for Recover middleware.
+	RecoverConfig struct {
+		// StackSize is the maximum size of the stack trace.
+		// Optional. Default value 4KB.
+		StackSize int
+
+		// StackAll includes the stacks of all goroutines.
+		// Optional. Default value false.
+		StackAll bool
+
+		// DisableStack leaves the stack trace out of the error.
+		// Optional. Default value false.
+		DisableStack bool
+	}
+)
+
+// DefaultRecoverConfig is the default Recover middleware config.
+var DefaultRecoverConfig = RecoverConfig{
+	StackSize: 4 << 10,
+}
+
+// Recover returns a middleware which recovers from panics anywhere in the chain
+// and routes a 500 error carrying the stack trace through the HTTPErrorHandler.
+// The default handler logs it, and only shows it to the client in debug mode.
+func Recover() core.MiddlewareFunc {
+	return RecoverWithConfig(DefaultRecoverConfig)
+}
+
+// RecoverWithConfig returns a Recover middleware from config.
+// See `Recover()`.
+func RecoverWithConfig(config RecoverConfig) core.MiddlewareFunc {
+	if config.StackSize == 0 {
+		config.StackSize = DefaultRecoverConfig.StackSize
+	}
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) (err error) {
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+				err = fmt.Errorf("panic recover: %v", r)
+				if !config.DisableStack {
+					stack := make([]byte, config.StackSize)
+					n := runtime.Stack(stack, config.StackAll)
+					err = fmt.Errorf("panic recover: %v\n%s", r, stack[:n])
+				}
+				c.Error(err)
+				err = nil
+			}()
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// ReplayConfig defines the config for Replay middleware.
+	ReplayConfig struct {
+		// TimestampHeader holds when the request was made, in Unix seconds.
+		// Optional. Default value "X-Timestamp".
+		TimestampHeader string
+
+		// NonceHeader holds a value unique to the request.
+		// Optional. Default value "X-Nonce".
+		NonceHeader string
+
+		// MaxSkew is how far the timestamp may be from the server time, either
+		// way. Nonces are remembered for twice as long.
+		// Optional. Default value 5 minutes.
+		MaxSkew time.Duration
+
+		// Store remembers the nonces seen.
+		// Optional. Default value is a MemoryNonceStore.
+		Store NonceStore
+
+		// Scope returns the client of the request, e.g. its API key, so nonces
+		// only need to be unique per client.
+		// Optional. Default value nil, all clients share the nonces.
+		Scope func(c *core.Context) string
+	}
+
+	// NonceStore remembers nonces, e.g. in a shared cache when several
+	// instances serve the API.
+	NonceStore interface {
+		// Seen records nonce until expires and reports whether it was already
+		// recorded and not expired at now. It must be atomic.
+		Seen(nonce string, expires, now time.Time) bool
+	}
+
+	// MemoryNonceStore is an in-memory NonceStore.
+	MemoryNonceStore struct {
+		mu    sync.Mutex
+		m     map[string]time.Time // nonce > expiry
+		sweep time.Time
+	}
+)
+
+// maxNonceLength bounds the nonces accepted.
+const maxNonceLength = 128
+
+// DefaultReplayConfig is the default Replay middleware config.
+var DefaultReplayConfig = ReplayConfig{
+	TimestampHeader: "X-Timestamp",
+	NonceHeader:     "X-Nonce",
+	MaxSkew:         5 * time.Minute,
+}
+
+// Replay returns a middleware which rejects replayed requests of an open API
+// whose clients sign their requests, the signature covering the timestamp
+// and nonce headers: a request is only accepted once, and only around the
+// time it was made.
+func Replay() core.MiddlewareFunc {
+	return ReplayWithConfig(DefaultReplayConfig)
+}
+
+// ReplayWithConfig returns a Replay middleware from config.
+// See `Replay()`.
+//
+// Requests missing the headers get "400 - Bad Request", stale or replayed
+// ones "401 - Unauthorized".
+func ReplayWithConfig(config ReplayConfig) core.MiddlewareFunc {
+	if config.TimestampHeader == "" {
+		config.TimestampHeader = DefaultReplayConfig.TimestampHeader
+	}
+	if config.NonceHeader == "" {
+		config.NonceHeader = DefaultReplayConfig.NonceHeader
+	}
+	if config.MaxSkew == 0 {
+		config.MaxSkew = DefaultReplayConfig.MaxSkew
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryNonceStore()
+	}
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			h := c.Request().Header
+			nonce := h.Get(config.NonceHeader)
+			ts, err := strconv.ParseInt(h.Get(config.TimestampHeader), 10, 64)
+			if err != nil || nonce == "" || len(nonce) > maxNonceLength {
+				return core.NewHTTPError(http.StatusBadRequest, "missing or malformed "+config.TimestampHeader+" or "+config.NonceHeader)
+			}
+			now := c.Now()
+			if d := now.Sub(time.Unix(ts, 0)); d > config.MaxSkew || d < -config.MaxSkew {
+				return core.NewHTTPError(http.StatusUnauthorized, "request timestamp out of range")
+			}
+			if config.Scope != nil {
+				nonce = config.Scope(c) + " " + nonce
+			}
+			if config.Store.Seen(nonce, now.Add(2*config.MaxSkew), now) {
+				return core.NewHTTPError(http.StatusUnauthorized, "request replayed")
+			}
+			return next(c)
+		}
+	}
+}
+
+// NewMemoryNonceStore returns an empty MemoryNonceStore.
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{m: make(map[string]time.Time)}
+}
+
+// Seen implements NonceStore. Expired nonces are dropped once per minute.
+func (s *MemoryNonceStore) Seen(nonce string, expires, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.After(s.sweep) {
+		for n, exp := range s.m {
+			if !now.Before(exp) {
+				delete(s.m, n)
+			}
+		}
+		s.sweep = now.Add(time.Minute)
+	}
+	if exp, ok := s.m[nonce]; ok && now.Before(exp) {
+		return true
+	}
+	s.m[nonce] = expires
+	return false
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplay(t *testing.T) {
+	clock := core.NewManualClock(time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC))
+	e := core.New()
+	e.SetClock(clock)
+	e.Use(ReplayWithConfig(ReplayConfig{
+		Scope: func(c *core.Context) string { return c.Request().Header.Get("X-Key") },
+	}))
+	e.Post("/", func(c *core.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	serve := func(ts time.Time, nonce, key string) int {
+		req, _ := http.NewRequest(core.POST, "/", nil)
+		req.Header.Set("X-Timestamp", strconv.FormatInt(ts.Unix(), 10))
+		req.Header.Set("X-Nonce", nonce)
+		req.Header.Set("X-Key", key)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	now := clock.Now()
+	assert.Equal(t, http.StatusNoContent, serve(now, "n1", "a"))
+	assert.Equal(t, http.StatusUnauthorized, serve(now, "n1", "a"))
+	// Nonces are scoped per client
+	assert.Equal(t, http.StatusNoContent, serve(now, "n1", "b"))
+	assert.Equal(t, http.StatusBadRequest, serve(now, "", "a"))
+
+	// Skew
+	assert.Equal(t, http.StatusUnauthorized, serve(now.Add(-6*time.Minute), "n2", "a"))
+	assert.Equal(t, http.StatusUnauthorized, serve(now.Add(6*time.Minute), "n3", "a"))
+	assert.Equal(t, http.StatusNoContent, serve(now.Add(4*time.Minute), "n4", "a"))
+
+	// Still remembered while the timestamp is in range
+	clock.Advance(4 * time.Minute)
+	assert.Equal(t, http.StatusUnauthorized, serve(now, "n1", "a"))
+}
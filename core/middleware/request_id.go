@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// RequestIDConfig defines the config for RequestID middleware.
+	RequestIDConfig struct {
+		// Header carries the request ID.
+		// Optional. Default value "X-Request-ID".
+		Header string
+
+		// Generator returns a new request ID.
+		// Optional. Default value uses the IDGenerator of the Echo instance.
+		Generator func(*core.Context) string
+
+		// MaxLength bounds incoming IDs, longer or non-printable ones are
+		// replaced.
+		// Optional. Default value 128.
+		MaxLength int
+	}
+)
+
+// DefaultRequestIDConfig is the default RequestID middleware config.
+var DefaultRequestIDConfig = RequestIDConfig{
+	Header:    core.XRequestID,
+	MaxLength: 128,
+}
+
+// RequestID returns a middleware which propagates the X-Request-ID header of
+// the request, or generates one, so requests can be correlated across
+// services. The ID is available through c.RequestID(), echoed in the response
+// and included by the logger and the default error handler.
+func RequestID() core.MiddlewareFunc {
+	return RequestIDWithConfig(DefaultRequestIDConfig)
+}
+
+// RequestIDWithConfig returns a RequestID middleware from config.
+// See `RequestID()`.
+func RequestIDWithConfig(config RequestIDConfig) core.MiddlewareFunc {
+	if config.Header == "" {
+		config.Header = DefaultRequestIDConfig.Header
+	}
+	if config.Generator == nil {
+		config.Generator = func(c *core.Context) string {
+			return c.NewID()
+		}
+	}
+	if config.MaxLength == 0 {
+		config.MaxLength = DefaultRequestIDConfig.MaxLength
+	}
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			id := c.Request().Header.Get(config.Header)
+			if !validRequestID(id, config.MaxLength) {
+				id = config.Generator(c)
+			}
+			c.SetRequestID(id)
+			c.Response().Header().Set(config.Header, id)
+			return next(c)
+		}
+	}
+}
+
+func validRequestID(id string, max int) bool {
+	if id == "" || len(id) > max {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	e := core.New()
+	e.SetIDGenerator(&core.SequenceIDs{Prefix: "req-"})
+	var id string
+	h := RequestID()(func(c *core.Context) error {
+		id = c.RequestID()
+		return nil
+	})
+	do := func(header string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(core.GET, "/", nil)
+		if header != "" {
+			req.Header.Set(core.XRequestID, header)
+		}
+		rec := httptest.NewRecorder()
+		c := core.NewContext(req, core.NewResponse(rec, e), e)
+		h(c)
+		return rec
+	}
+
+	// Generated
+	rec := do("")
+	assert.Equal(t, "req-1", id)
+	assert.Equal(t, "req-1", rec.Header().Get(core.XRequestID))
+
+	// Propagated
+	rec = do("upstream-42")
+	assert.Equal(t, "upstream-42", id)
+	assert.Equal(t, "upstream-42", rec.Header().Get(core.XRequestID))
+
+	// Rejected
+	do(strings.Repeat("x", 200))
+	assert.Equal(t, "req-2", id)
+	do("bad id")
+	assert.Equal(t, "req-3", id)
+}
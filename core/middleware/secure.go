@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// SecureConfig defines the config for Secure middleware. An empty value
+	// leaves the matching header unset, so start from DefaultSecureConfig to
+	// override single options.
+	SecureConfig struct {
+		// XSSProtection is the X-XSS-Protection header.
+		// Optional. Default value "1; mode=block".
+		XSSProtection string
+
+		// ContentTypeNosniff is the X-Content-Type-Options header.
+		// Optional. Default value "nosniff".
+		ContentTypeNosniff string
+
+		// XFrameOptions is the X-Frame-Options header, e.g. "DENY",
+		// "SAMEORIGIN" or "ALLOW-FROM uri".
+		// Optional. Default value "SAMEORIGIN".
+		XFrameOptions string
+
+		// HSTSMaxAge is the max-age in seconds of the Strict-Transport-Security
+		// header, which is only sent over HTTPS.
+		// Optional. Default value 31536000, one year.
+		HSTSMaxAge int
+
+		// HSTSExcludeSubdomains drops includeSubDomains from the
+		// Strict-Transport-Security header.
+		// Optional. Default value false.
+		HSTSExcludeSubdomains bool
+
+		// HSTSPreload adds preload to the Strict-Transport-Security header.
+		// Optional. Default value false.
+		HSTSPreload bool
+
+		// ContentSecurityPolicy is the Content-Security-Policy header.
+		// Optional. Default value "".
+		ContentSecurityPolicy string
+
+		// ReferrerPolicy is the Referrer-Policy header.
+		// Optional. Default value "strict-origin-when-cross-origin".
+		ReferrerPolicy string
+	}
+)
+
+// DefaultSecureConfig is the default Secure middleware config.
+var DefaultSecureConfig = SecureConfig{
+	XSSProtection:      "1; mode=block",
+	ContentTypeNosniff: "nosniff",
+	XFrameOptions:      "SAMEORIGIN",
+	HSTSMaxAge:         31536000,
+	ReferrerPolicy:     "strict-origin-when-cross-origin",
+}
+
+// Secure returns a middleware which sets security headers protecting against
+// cross-site scripting, content type sniffing, clickjacking and protocol
+// downgrades.
+func Secure() core.MiddlewareFunc {
+	return SecureWithConfig(DefaultSecureConfig)
+}
+
+// SecureWithConfig returns a Secure middleware from config.
+// See `Secure()`.
+func SecureWithConfig(config SecureConfig) core.MiddlewareFunc {
+	hsts := ""
+	if config.HSTSMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d", config.HSTSMaxAge)
+		if !config.HSTSExcludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if config.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+	headers := [][2]string{
+		{core.XXSSProtection, config.XSSProtection},
+		{core.XContentTypeOptions, config.ContentTypeNosniff},
+		{core.XFrameOptions, config.XFrameOptions},
+		{core.ContentSecurityPolicy, config.ContentSecurityPolicy},
+		{core.ReferrerPolicy, config.ReferrerPolicy},
+	}
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			header := c.Response().Header()
+			for _, h := range headers {
+				if h[1] != "" {
+					header.Set(h[0], h[1])
+				}
+			}
+			r := c.Request()
+			if hsts != "" && (r.TLS != nil || r.Header.Get(core.XForwardedProto) == "https") {
+				header.Set(core.StrictTransportSecurity, hsts)
+			}
+			return next(c)
+		}
+	}
+}
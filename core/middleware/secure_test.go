@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecure(t *testing.T) {
+	e := core.New()
+	req, _ := http.NewRequest(core.GET, "/", nil)
+	rec := httptest.NewRecorder()
+	c := core.NewContext(req, core.NewResponse(rec, e), e)
+	h := func(c *core.Context) error {
+		return c.String(http.StatusOK, "test")
+	}
+
+	// Defaults
+	Secure()(h)(c)
+	assert.Equal(t, "1; mode=block", rec.Header().Get(core.XXSSProtection))
+	assert.Equal(t, "nosniff", rec.Header().Get(core.XContentTypeOptions))
+	assert.Equal(t, "SAMEORIGIN", rec.Header().Get(core.XFrameOptions))
+	assert.Equal(t, "strict-origin-when-cross-origin", rec.Header().Get(core.ReferrerPolicy))
+	assert.Equal(t, "", rec.Header().Get(core.StrictTransportSecurity))
+	assert.Equal(t, "", rec.Header().Get(core.ContentSecurityPolicy))
+
+	// Overrides over TLS
+	req, _ = http.NewRequest(core.GET, "/", nil)
+	req.TLS = new(tls.ConnectionState)
+	rec = httptest.NewRecorder()
+	c = core.NewContext(req, core.NewResponse(rec, e), e)
+	config := DefaultSecureConfig
+	config.XFrameOptions = "DENY"
+	config.XSSProtection = ""
+	config.HSTSPreload = true
+	config.ContentSecurityPolicy = "default-src 'self'"
+	SecureWithConfig(config)(h)(c)
+	assert.Equal(t, "DENY", rec.Header().Get(core.XFrameOptions))
+	assert.Equal(t, "", rec.Header().Get(core.XXSSProtection))
+	assert.Equal(t, "max-age=31536000; includeSubDomains; preload", rec.Header().Get(core.StrictTransportSecurity))
+	assert.Equal(t, "default-src 'self'", rec.Header().Get(core.ContentSecurityPolicy))
+
+	// Behind a TLS terminating proxy
+	req, _ = http.NewRequest(core.GET, "/", nil)
+	req.Header.Set(core.XForwardedProto, "https")
+	rec = httptest.NewRecorder()
+	c = core.NewContext(req, core.NewResponse(rec, e), e)
+	Secure()(h)(c)
+	assert.Equal(t, "max-age=31536000; includeSubDomains", rec.Header().Get(core.StrictTransportSecurity))
+}
@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// SecretFunc returns the signing key of the client of a request, e.g. looked
+	// up in a secrets provider by API key or tenant. An empty secret leaves the
+	// response unsigned.
+	SecretFunc func(c *core.Context) (keyID string, secret []byte, err error)
+
+	// SignConfig defines the config for Sign middleware.
+	SignConfig struct {
+		// Secret returns the signing key.
+		// Required.
+		Secret SecretFunc
+
+		// Algorithm is SignHMAC or SignJWS.
+		// Optional. Default value SignHMAC.
+		Algorithm string
+
+		// Header carries the signature.
+		// Optional. Default value "X-Signature".
+		Header string
+	}
+)
+
+// Signature algorithms.
+const (
+	// SignHMAC signs "<created>.<body>" with HMAC-SHA256, the header reads
+	// `keyId="k1",algorithm="hmac-sha256",created=1500000000,signature="<base64>"`.
+	SignHMAC = "hmac-sha256"
+
+	// SignJWS produces a detached HS256 JWS (RFC 7515 Appendix F) of the body,
+	// the header reads "<protected>..<signature>".
+	SignJWS = "jws"
+)
+
+// Signature errors.
+var (
+	ErrSignatureInvalid   = errors.New("invalid signature")
+	ErrSignatureMalformed = errors.New("malformed signature")
+)
+
+// DefaultSignConfig is the default Sign middleware config.
+var DefaultSignConfig = SignConfig{
+	Algorithm: SignHMAC,
+	Header:    "X-Signature",
+}
+
+// Sign returns a middleware which signs response bodies with the key of the
+// client, so webhook receivers and peer services can authenticate them.
+func Sign(secret SecretFunc) core.MiddlewareFunc {
+	c := DefaultSignConfig
+	c.Secret = secret
+	return SignWithConfig(c)
+}
+
+// SignWithConfig returns a Sign middleware from config.
+// See `Sign()`.
+func SignWithConfig(config SignConfig) core.MiddlewareFunc {
+	if config.Secret == nil {
+		panic("sign middleware requires a secret function")
+	}
+	if config.Algorithm == "" {
+		config.Algorithm = DefaultSignConfig.Algorithm
+	}
+	if config.Algorithm != SignHMAC && config.Algorithm != SignJWS {
+		panic("sign middleware: unsupported algorithm " + config.Algorithm)
+	}
+	if config.Header == "" {
+		config.Header = DefaultSignConfig.Header
+	}
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			keyID, secret, err := config.Secret(c)
+			if err != nil {
+				return err
+			}
+			if len(secret) == 0 || c.Request().Header.Get(core.Upgrade) == core.WebSocket {
+				return next(c)
+			}
+
+			res := c.Response()
+			w := core.NewBufferWriter(res.Writer())
+			res.SetWriter(w)
+			if err = next(c); err != nil {
+				c.Error(err)
+			}
+			res.SetWriter(w.ResponseWriter)
+			body, ok := w.Buffered()
+			if !ok {
+				return nil
+			}
+			var sig string
+			if config.Algorithm == SignJWS {
+				sig = signJWS(keyID, secret, body)
+			} else {
+				sig = signHMAC(keyID, secret, c.Now().Unix(), body)
+			}
+			w.Header().Set(config.Header, sig)
+			w.Send(body)
+			return nil
+		}
+	}
+}
+
+// VerifySignature checks a signature header value produced by Sign against
+// body, it's meant for the receiving side.
+func VerifySignature(value string, body, secret []byte) error {
+	if strings.Count(value, ".") == 2 {
+		parts := strings.Split(value, ".")
+		if parts[1] != "" {
+			return ErrSignatureMalformed
+		}
+		want := jwsMAC(secret, parts[0], body)
+		if !hmac.Equal([]byte(parts[2]), []byte(want)) {
+			return ErrSignatureInvalid
+		}
+		return nil
+	}
+	params := make(map[string]string)
+	for _, p := range strings.Split(value, ",") {
+		i := strings.IndexByte(p, '=')
+		if i == -1 {
+			return ErrSignatureMalformed
+		}
+		params[strings.TrimSpace(p[:i])] = strings.Trim(strings.TrimSpace(p[i+1:]), `"`)
+	}
+	created, err := strconv.ParseInt(params["created"], 10, 64)
+	if err != nil || params["algorithm"] != SignHMAC {
+		return ErrSignatureMalformed
+	}
+	got, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil {
+		return ErrSignatureMalformed
+	}
+	if !hmac.Equal(got, hmacSum(secret, created, body)) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+// SignatureCreated returns when an hmac-sha256 signature was made, so
+// receivers can reject stale messages.
+func SignatureCreated(value string) (time.Time, error) {
+	for _, p := range strings.Split(value, ",") {
+		p = strings.TrimSpace(p)
+		if strings.HasPrefix(p, "created=") {
+			n, err := strconv.ParseInt(p[len("created="):], 10, 64)
+			if err != nil {
+				return time.Time{}, ErrSignatureMalformed
+			}
+			return time.Unix(n, 0), nil
+		}
+	}
+	return time.Time{}, ErrSignatureMalformed
+}
+
+func signHMAC(keyID string, secret []byte, created int64, body []byte) string {
+	return fmt.Sprintf(`keyId=%q,algorithm=%q,created=%d,signature=%q`,
+		keyID, SignHMAC, created, base64.StdEncoding.EncodeToString(hmacSum(secret, created, body)))
+}
+
+func hmacSum(secret []byte, created int64, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(created, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+func signJWS(keyID string, secret, body []byte) string {
+	h, _ := json.Marshal(map[string]string{"alg": "HS256", "kid": keyID})
+	protected := base64.RawURLEncoding.EncodeToString(h)
+	return protected + ".." + jwsMAC(secret, protected, body)
+}
+
+func jwsMAC(secret []byte, protected string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(protected))
+	mac.Write([]byte{'.'})
+	mac.Write([]byte(base64.RawURLEncoding.EncodeToString(body)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	e := core.New()
+	e.SetClock(core.NewManualClock(time.Unix(1500000000, 0)))
+	secrets := map[string][]byte{"acme": []byte("s3cret")}
+	secret := func(c *core.Context) (string, []byte, error) {
+		id := c.Request().Header.Get("X-Client")
+		return id, secrets[id], nil
+	}
+	h := func(c *core.Context) error {
+		return c.String(http.StatusOK, "payload")
+	}
+	do := func(mw core.MiddlewareFunc, client string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(core.GET, "/", nil)
+		req.Header.Set("X-Client", client)
+		rec := httptest.NewRecorder()
+		c := core.NewContext(req, core.NewResponse(rec, e), e)
+		mw(h)(c)
+		return rec
+	}
+
+	// HMAC
+	rec := do(Sign(secret), "acme")
+	sig := rec.Header().Get("X-Signature")
+	assert.Contains(t, sig, `keyId="acme",algorithm="hmac-sha256",created=1500000000,`)
+	assert.Equal(t, "payload", rec.Body.String())
+	assert.NoError(t, VerifySignature(sig, rec.Body.Bytes(), secrets["acme"]))
+	assert.Equal(t, ErrSignatureInvalid, VerifySignature(sig, []byte("tampered"), secrets["acme"]))
+	created, err := SignatureCreated(sig)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1500000000), created.Unix())
+
+	// JWS
+	rec = do(SignWithConfig(SignConfig{Secret: secret, Algorithm: SignJWS}), "acme")
+	sig = rec.Header().Get("X-Signature")
+	assert.Contains(t, sig, "..")
+	assert.NoError(t, VerifySignature(sig, rec.Body.Bytes(), secrets["acme"]))
+	assert.Equal(t, ErrSignatureInvalid, VerifySignature(sig, rec.Body.Bytes(), []byte("other")))
+
+	// Unknown client
+	rec = do(Sign(secret), "nobody")
+	assert.Equal(t, "", rec.Header().Get("X-Signature"))
+	assert.Equal(t, "payload", rec.Body.String())
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// StrictConfig defines the config for Strict middleware.
+	StrictConfig struct {
+		// MaxHeaders bounds the number of header fields.
+		// Optional. Default value 100.
+		MaxHeaders int
+
+		// MaxHeaderSize bounds the size of one header field.
+		// Optional. Default value 8KB.
+		MaxHeaderSize int
+	}
+)
+
+// DefaultStrictConfig is the default Strict middleware config.
+var DefaultStrictConfig = StrictConfig{
+	MaxHeaders:    100,
+	MaxHeaderSize: 8 << 10,
+}
+
+// Strict returns a middleware rejecting requests which may be used for request
+// smuggling or header abuse, for servers exposed directly to the internet:
+// conflicting Content-Length/Transfer-Encoding, unknown transfer codings, CR,
+// LF or NUL in headers (400 Bad Request), too many or too large header fields
+// (431 Request Header Fields Too Large). The reason is logged.
+func Strict() core.MiddlewareFunc {
+	return StrictWithConfig(DefaultStrictConfig)
+}
+
+// StrictWithConfig returns a Strict middleware from config.
+// See `Strict()`.
+func StrictWithConfig(config StrictConfig) core.MiddlewareFunc {
+	if config.MaxHeaders == 0 {
+		config.MaxHeaders = DefaultStrictConfig.MaxHeaders
+	}
+	if config.MaxHeaderSize == 0 {
+		config.MaxHeaderSize = DefaultStrictConfig.MaxHeaderSize
+	}
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			if code, reason := checkStrict(c.Request(), config); code != 0 {
+				c.Echo().Logger().Warn("strict: %s from %s: %s %s", reason, c.RealIP(), c.Request().Method, c.Request().URL.Path)
+				c.Response().Header().Set("Connection", "close")
+				return core.NewHTTPError(code, http.StatusText(code))
+			}
+			return next(c)
+		}
+	}
+}
+
+func checkStrict(r *http.Request, config StrictConfig) (int, string) {
+	cl := r.Header["Content-Length"]
+	for i := 1; i < len(cl); i++ {
+		if cl[i] != cl[0] {
+			return http.StatusBadRequest, "conflicting Content-Length"
+		}
+	}
+	te := r.TransferEncoding
+	if len(te) == 0 {
+		te = r.Header["Transfer-Encoding"]
+	}
+	if len(te) > 0 {
+		if len(cl) > 0 {
+			return http.StatusBadRequest, "both Content-Length and Transfer-Encoding"
+		}
+		if len(te) != 1 || !strings.EqualFold(strings.TrimSpace(te[0]), "chunked") {
+			return http.StatusBadRequest, "unsupported Transfer-Encoding"
+		}
+	}
+	n := 0
+	for name, values := range r.Header {
+		if strings.ContainsAny(name, "\r\n\x00") {
+			return http.StatusBadRequest, "invalid header name"
+		}
+		for _, v := range values {
+			n++
+			if strings.ContainsAny(v, "\r\n\x00") {
+				return http.StatusBadRequest, "CR, LF or NUL in header " + name
+			}
+			if len(name)+len(v) > config.MaxHeaderSize {
+				return http.StatusRequestHeaderFieldsTooLarge, "header " + name + " too large"
+			}
+		}
+	}
+	if n > config.MaxHeaders {
+		return http.StatusRequestHeaderFieldsTooLarge, "too many header fields"
+	}
+	return 0, ""
+}
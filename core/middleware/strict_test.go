@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrict(t *testing.T) {
+	e := core.New()
+	e.SetLogOutput(ioutil.Discard)
+	h := StrictWithConfig(StrictConfig{MaxHeaders: 5, MaxHeaderSize: 64})(func(c *core.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	do := func(f func(r *http.Request)) int {
+		req, _ := http.NewRequest(core.POST, "/", nil)
+		f(req)
+		rec := httptest.NewRecorder()
+		c := core.NewContext(req, core.NewResponse(rec, e), e)
+		if err := h(c); err != nil {
+			return err.(*core.HTTPError).Code()
+		}
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, do(func(r *http.Request) {
+		r.Header.Set("X-Ok", "fine")
+	}))
+	assert.Equal(t, http.StatusBadRequest, do(func(r *http.Request) {
+		r.Header["Content-Length"] = []string{"5", "6"}
+	}))
+	assert.Equal(t, http.StatusBadRequest, do(func(r *http.Request) {
+		r.Header.Set("Content-Length", "5")
+		r.TransferEncoding = []string{"chunked"}
+	}))
+	assert.Equal(t, http.StatusBadRequest, do(func(r *http.Request) {
+		r.Header.Set("Transfer-Encoding", "chunked, identity")
+	}))
+	assert.Equal(t, http.StatusBadRequest, do(func(r *http.Request) {
+		r.Header["X-Smuggle"] = []string{"a\r\nContent-Length: 0"}
+	}))
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, do(func(r *http.Request) {
+		r.Header.Set("X-Big", strings.Repeat("x", 100))
+	}))
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, do(func(r *http.Request) {
+		for _, k := range []string{"A", "B", "C", "D", "E", "F"} {
+			r.Header.Set(k, "1")
+		}
+	}))
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// TenantResolver extracts the tenant from a request. It returns "" if the
+	// request doesn't name one.
+	TenantResolver func(*core.Context) string
+
+	// TenantConfig defines the config for the Tenant middleware.
+	TenantConfig struct {
+		// Resolvers are tried in order, the first non-empty result wins.
+		Resolvers []TenantResolver
+
+		// Required rejects requests without tenant with "400 - Bad Request".
+		Required bool
+
+		// Allowed restricts the tenants to a known set. Optional.
+		Allowed map[string]bool
+	}
+)
+
+// Tenant returns a middleware which resolves the tenant of a request and stores
+// it on the context, see `Context#Tenant`.
+func Tenant(resolvers ...TenantResolver) core.MiddlewareFunc {
+	return TenantWithConfig(TenantConfig{Resolvers: resolvers})
+}
+
+// TenantWithConfig returns a Tenant middleware from config.
+func TenantWithConfig(config TenantConfig) core.MiddlewareFunc {
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			for _, r := range config.Resolvers {
+				if t := r(c); t != "" {
+					if config.Allowed != nil && !config.Allowed[t] {
+						return core.NewHTTPError(http.StatusNotFound, "unknown tenant")
+					}
+					c.SetTenant(t)
+					break
+				}
+			}
+			if config.Required && c.Tenant() == "" {
+				return core.NewHTTPError(http.StatusBadRequest, "missing tenant")
+			}
+			return next(c)
+		}
+	}
+}
+
+// TenantFromSubdomain resolves the tenant from the label of the host right
+// before domain, e.g. "acme" for "acme.example.com" and "www.acme.example.com"
+// with domain "example.com".
+func TenantFromSubdomain(domain string) TenantResolver {
+	suffix := "." + strings.TrimPrefix(domain, ".")
+	return func(c *core.Context) string {
+		host := c.Request().Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if !strings.HasSuffix(host, suffix) {
+			return ""
+		}
+		sub := strings.TrimSuffix(host, suffix)
+		if i := strings.LastIndex(sub, "."); i != -1 {
+			sub = sub[i+1:]
+		}
+		return sub
+	}
+}
+
+// TenantFromHeader resolves the tenant from a request header.
+func TenantFromHeader(name string) TenantResolver {
+	return func(c *core.Context) string {
+		return c.Request().Header.Get(name)
+	}
+}
+
+// TenantFromParam resolves the tenant from a path parameter.
+func TenantFromParam(name string) TenantResolver {
+	return func(c *core.Context) string {
+		return c.Param(name)
+	}
+}
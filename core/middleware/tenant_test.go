@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenant(t *testing.T) {
+	e := core.New()
+	h := func(c *core.Context) error {
+		return c.String(http.StatusOK, c.TenantKey("k"))
+	}
+	mw := TenantWithConfig(TenantConfig{
+		Resolvers: []TenantResolver{TenantFromHeader("X-Tenant"), TenantFromSubdomain("example.com")},
+		Required:  true,
+	})
+
+	// Subdomain
+	req, _ := http.NewRequest(core.GET, "http://acme.example.com:8080/", nil)
+	rec := httptest.NewRecorder()
+	c := core.NewContext(req, core.NewResponse(rec, e), e)
+	assert.NoError(t, mw(h)(c))
+	assert.Equal(t, "acme:k", rec.Body.String())
+
+	// Label right before the domain
+	req, _ = http.NewRequest(core.GET, "http://www.acme.example.com/", nil)
+	rec = httptest.NewRecorder()
+	c = core.NewContext(req, core.NewResponse(rec, e), e)
+	assert.NoError(t, mw(h)(c))
+	assert.Equal(t, "acme", c.Tenant())
+
+	// Header takes precedence
+	req.Header.Set("X-Tenant", "beta")
+	rec = httptest.NewRecorder()
+	c = core.NewContext(req, core.NewResponse(rec, e), e)
+	assert.NoError(t, mw(h)(c))
+	assert.Equal(t, "beta", c.Tenant())
+
+	// Missing tenant
+	req, _ = http.NewRequest(core.GET, "http://example.com/", nil)
+	rec = httptest.NewRecorder()
+	c = core.NewContext(req, core.NewResponse(rec, e), e)
+	he := mw(h)(c).(*core.HTTPError)
+	assert.Equal(t, http.StatusBadRequest, he.Code())
+}
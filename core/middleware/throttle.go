@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// ThrottleConfig defines the config for Throttle middleware.
+	ThrottleConfig struct {
+		// BytesPerSecond is the bandwidth allowed.
+		// Required.
+		BytesPerSecond int
+
+		// Burst is the number of bytes which may be written at once.
+		// Optional. Default value is BytesPerSecond / 10, at least 1KB.
+		Burst int
+
+		// KeyFunc makes the requests with the same key share the bandwidth,
+		// e.g. the client IP. Each request is throttled on its own when nil.
+		// Optional.
+		KeyFunc func(*core.Context) string
+	}
+
+	// bandwidth is a token bucket of bytes, refilled by the Echo clock.
+	bandwidth struct {
+		mu     sync.Mutex
+		clock  core.Clock
+		rate   float64
+		burst  int
+		tokens float64
+		last   time.Time
+		users  int
+	}
+
+	throttledWriter struct {
+		http.ResponseWriter
+		bw  *bandwidth
+		ctx context.Context
+	}
+)
+
+// Throttle returns a middleware which caps the bandwidth of each response to
+// bytesPerSecond, e.g. to share large downloads fairly.
+func Throttle(bytesPerSecond int) core.MiddlewareFunc {
+	return ThrottleWithConfig(ThrottleConfig{BytesPerSecond: bytesPerSecond})
+}
+
+// ThrottlePerIP returns a middleware which caps the bandwidth of all responses
+// to a client IP to bytesPerSecond. The IP is the peer address unless it's a
+// trusted proxy, see Context.RealIP.
+func ThrottlePerIP(bytesPerSecond int) core.MiddlewareFunc {
+	return ThrottleWithConfig(ThrottleConfig{
+		BytesPerSecond: bytesPerSecond,
+		KeyFunc:        clientIP,
+	})
+}
+
+// ThrottleWithConfig returns a Throttle middleware from config.
+// See `Throttle()`.
+func ThrottleWithConfig(config ThrottleConfig) core.MiddlewareFunc {
+	if config.BytesPerSecond <= 0 {
+		panic("throttle middleware requires a positive bandwidth")
+	}
+	if config.Burst <= 0 {
+		config.Burst = config.BytesPerSecond / 10
+		if config.Burst < 1<<10 {
+			config.Burst = 1 << 10
+		}
+	}
+	var (
+		mu     sync.Mutex
+		shared = make(map[string]*bandwidth)
+	)
+	newBandwidth := func(c *core.Context) *bandwidth {
+		return &bandwidth{
+			clock:  c.Echo().Clock(),
+			rate:   float64(config.BytesPerSecond),
+			burst:  config.Burst,
+			tokens: float64(config.Burst),
+			last:   c.Now(),
+		}
+	}
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			var bw *bandwidth
+			if config.KeyFunc == nil {
+				bw = newBandwidth(c)
+			} else {
+				key := config.KeyFunc(c)
+				mu.Lock()
+				if bw = shared[key]; bw == nil {
+					bw = newBandwidth(c)
+					shared[key] = bw
+				}
+				bw.users++
+				mu.Unlock()
+				defer func() {
+					mu.Lock()
+					if bw.users--; bw.users == 0 {
+						delete(shared, key)
+					}
+					mu.Unlock()
+				}()
+			}
+			res := c.Response()
+			w := res.Writer()
+			res.SetWriter(&throttledWriter{ResponseWriter: w, bw: bw, ctx: c.StdContext()})
+			err := next(c)
+			res.SetWriter(w)
+			return err
+		}
+	}
+}
+
+// reserve takes n bytes from the bucket and returns how long to wait before
+// writing them.
+func (b *bandwidth) reserve(n int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.clock.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > float64(b.burst) {
+		b.tokens = float64(b.burst)
+	}
+	b.last = now
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+func (w *throttledWriter) Write(b []byte) (n int, err error) {
+	for len(b) > 0 {
+		chunk := b
+		if len(chunk) > w.bw.burst {
+			chunk = chunk[:w.bw.burst]
+		}
+		if d := w.bw.reserve(len(chunk)); d > 0 {
+			t := time.NewTimer(d)
+			select {
+			case <-t.C:
+			case <-w.ctx.Done():
+				t.Stop()
+				return n, w.ctx.Err()
+			}
+		}
+		m, err := w.ResponseWriter.Write(chunk)
+		n += m
+		if err != nil {
+			return n, err
+		}
+		b = b[len(chunk):]
+	}
+	return n, nil
+}
+
+func (w *throttledWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *throttledWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("throttle: hijack not supported")
+}
+
+func (w *throttledWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottle(t *testing.T) {
+	e := core.New()
+	body := bytes.Repeat([]byte("x"), 3000)
+	h := ThrottleWithConfig(ThrottleConfig{BytesPerSecond: 10000, Burst: 1000})(func(c *core.Context) error {
+		c.Response().WriteHeader(http.StatusOK)
+		_, err := c.Response().Write(body)
+		return err
+	})
+	req, _ := http.NewRequest(core.GET, "/", nil)
+	rec := httptest.NewRecorder()
+	c := core.NewContext(req, core.NewResponse(rec, e), e)
+	start := time.Now()
+	assert.NoError(t, h(c))
+	assert.True(t, time.Since(start) >= 150*time.Millisecond)
+	assert.Equal(t, body, rec.Body.Bytes())
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+// Timeout returns a middleware which bounds requests by d. Downstream handlers
+// observe the deadline through c.StdContext() (or c.Done()) and are expected to
+// stop their work once it is canceled; the request is then answered with
+// 503 Service Unavailable unless a response was already sent.
+func Timeout(d time.Duration) core.MiddlewareFunc {
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			parent := c.StdContext()
+			ctx, cancel := context.WithTimeout(parent, d)
+			defer cancel()
+			c.SetStdContext(ctx)
+			err := next(c)
+			c.SetStdContext(parent)
+			if ctx.Err() == context.DeadlineExceeded && !c.Response().Committed() {
+				return core.NewHTTPError(http.StatusServiceUnavailable)
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout(t *testing.T) {
+	e := core.New()
+	req, _ := http.NewRequest(core.GET, "/", nil)
+	rec := httptest.NewRecorder()
+	c := core.NewContext(req, core.NewResponse(rec, e), e)
+
+	// Handler giving up once canceled
+	h := Timeout(10 * time.Millisecond)(func(c *core.Context) error {
+		select {
+		case <-c.StdContext().Done():
+			return c.StdContext().Err()
+		case <-time.After(time.Second):
+			return c.String(http.StatusOK, "late")
+		}
+	})
+	he := h(c).(*core.HTTPError)
+	assert.Equal(t, http.StatusServiceUnavailable, he.Code())
+
+	// Fast handler
+	h = Timeout(time.Second)(func(c *core.Context) error {
+		_, ok := c.Deadline()
+		assert.True(t, ok)
+		return c.String(http.StatusOK, "ok")
+	})
+	assert.NoError(t, h(c))
+	_, ok := c.StdContext().Deadline()
+	assert.False(t, ok)
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// TicketConfig defines the config for WebSocket tickets.
+	TicketConfig struct {
+		// Secret signs the tickets. Instances behind a load balancer must
+		// share it, though a ticket is only known as used by the instance
+		// which redeemed it.
+		// Optional. Default value is a random key.
+		Secret []byte
+
+		// TTL is how long a ticket may be redeemed after it was issued.
+		// Optional. Default value 30 seconds.
+		TTL time.Duration
+
+		// Param is the query parameter carrying the ticket.
+		// Optional. Default value "ticket".
+		Param string
+
+		// ContextKey is the key the user is stored under in the context.
+		// Optional. Default value "user".
+		ContextKey string
+
+		// BindIP rejects tickets presented from another IP than the one they
+		// were issued to, see Context.RealIP.
+		// Optional. Default value false.
+		BindIP bool
+	}
+
+	// Tickets issues and redeems short-lived one-time tickets which hand off
+	// the authentication of an HTTP request to a WebSocket handshake, since
+	// browser WebSocket clients can't set headers:
+	//
+	//	tickets := middleware.NewTickets(middleware.DefaultTicketConfig)
+	//	e.Post("/ws-ticket", func(c *core.Context) error {
+	//		t, err := tickets.Issue(c, currentUser(c))
+	//		...
+	//	})
+	//	e.Group("/ws", tickets.Middleware()).WebSocket("", chat)
+	//
+	// and the client connects to /ws?ticket=<t>.
+	Tickets struct {
+		config TicketConfig
+		mu     sync.Mutex
+		used   map[string]time.Time // nonce > expiry
+	}
+)
+
+// Ticket errors.
+var (
+	ErrTicketInvalid = errors.New("invalid ticket")
+	ErrTicketExpired = errors.New("expired ticket")
+	ErrTicketUsed    = errors.New("ticket already used")
+)
+
+// DefaultTicketConfig is the default WebSocket ticket config.
+var DefaultTicketConfig = TicketConfig{
+	TTL:        30 * time.Second,
+	Param:      "ticket",
+	ContextKey: "user",
+}
+
+// NewTickets returns a Tickets from config.
+func NewTickets(config TicketConfig) *Tickets {
+	if len(config.Secret) == 0 {
+		config.Secret = make([]byte, 32)
+		if _, err := rand.Read(config.Secret); err != nil {
+			panic(err)
+		}
+	}
+	if config.TTL == 0 {
+		config.TTL = DefaultTicketConfig.TTL
+	}
+	if config.Param == "" {
+		config.Param = DefaultTicketConfig.Param
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultTicketConfig.ContextKey
+	}
+	return &Tickets{config: config, used: make(map[string]time.Time)}
+}
+
+// Issue returns a ticket for user, to be redeemed within the TTL.
+func (t *Tickets) Issue(c *core.Context, user string) (string, error) {
+	payload := make([]byte, 24, 24+len(user))
+	if _, err := rand.Read(payload[:16]); err != nil {
+		return "", err
+	}
+	binary.BigEndian.PutUint64(payload[16:], uint64(c.Now().Add(t.config.TTL).UnixNano()))
+	payload = append(payload, user...)
+	enc := base64.RawURLEncoding.EncodeToString(payload)
+	return enc + "." + t.sign(c, enc), nil
+}
+
+// Redeem checks a ticket and returns its user. A ticket can be redeemed once.
+func (t *Tickets) Redeem(c *core.Context, ticket string) (string, error) {
+	i := strings.LastIndexByte(ticket, '.')
+	if i == -1 || !hmac.Equal([]byte(ticket[i+1:]), []byte(t.sign(c, ticket[:i]))) {
+		return "", ErrTicketInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(ticket[:i])
+	if err != nil || len(payload) < 24 {
+		return "", ErrTicketInvalid
+	}
+	now := c.Now()
+	expires := time.Unix(0, int64(binary.BigEndian.Uint64(payload[16:24])))
+	if !now.Before(expires) {
+		return "", ErrTicketExpired
+	}
+	nonce := string(payload[:16])
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.used[nonce]; ok {
+		return "", ErrTicketUsed
+	}
+	for n, exp := range t.used {
+		if !now.Before(exp) {
+			delete(t.used, n)
+		}
+	}
+	t.used[nonce] = expires
+	return string(payload[24:]), nil
+}
+
+// Middleware returns a middleware which redeems the ticket of the request
+// before the WebSocket upgrade and stores its user in the context, requests
+// without a valid ticket get 401 Unauthorized.
+func (t *Tickets) Middleware() core.MiddlewareFunc {
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			user, err := t.Redeem(c, c.Query(t.config.Param))
+			if err != nil {
+				return core.NewHTTPError(http.StatusUnauthorized, err.Error())
+			}
+			c.Set(t.config.ContextKey, user)
+			return next(c)
+		}
+	}
+}
+
+func (t *Tickets) sign(c *core.Context, enc string) string {
+	mac := hmac.New(sha256.New, t.config.Secret)
+	mac.Write([]byte(enc))
+	if t.config.BindIP {
+		mac.Write([]byte{0})
+		mac.Write([]byte(c.RealIP()))
+	}
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
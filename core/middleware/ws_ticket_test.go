@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTickets(t *testing.T) {
+	clock := core.NewManualClock(time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC))
+	e := core.New()
+	e.SetClock(clock)
+	tickets := NewTickets(DefaultTicketConfig)
+	e.Get("/ticket", func(c *core.Context) error {
+		ticket, err := tickets.Issue(c, "alice")
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, ticket)
+	})
+	e.Group("/ws", tickets.Middleware()).Get("", func(c *core.Context) error {
+		return c.String(http.StatusOK, c.Get("user").(string))
+	})
+	serve := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(core.GET, path, nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	ticket := serve("/ticket").Body.String()
+	rec := serve("/ws?ticket=" + ticket)
+	assert.Equal(t, "alice", rec.Body.String())
+
+	// One-time
+	rec = serve("/ws?ticket=" + ticket)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Tampered
+	rec = serve("/ws?ticket=x" + serve("/ticket").Body.String())
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = serve("/ws")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Expired
+	ticket = serve("/ticket").Body.String()
+	clock.Advance(time.Minute)
+	rec = serve("/ws?ticket=" + ticket)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,421 @@
+package msgpack
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrShortBuffer is returned for truncated input.
+var ErrShortBuffer = errors.New("msgpack: unexpected end of data")
+
+// Unmarshal decodes the MessagePack data into the value pointed to by v.
+// Into an interface{}, maps decode as map[string]interface{} (or
+// map[interface{}]interface{} for other keys), arrays as []interface{},
+// integers as int64 or uint64 and binary data as []byte.
+func Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("msgpack: Unmarshal(non-pointer)")
+	}
+	d := &decoder{data: data}
+	if err := d.decode(rv.Elem()); err != nil {
+		return err
+	}
+	if d.pos != len(d.data) {
+		return errors.New("msgpack: trailing data")
+	}
+	return nil
+}
+
+// Decode reads all of r and decodes it into the value pointed to by v.
+func Decode(r io.Reader, v interface{}) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return Unmarshal(data, v)
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, ErrShortBuffer
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *decoder) uint(n int) (uint64, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// value reads the next object as a generic value.
+func (d *decoder) value() (interface{}, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xe0 == 0xa0:
+		return d.str(int(c & 0x1f))
+	case c&0xf0 == 0x90:
+		return d.array(int(c & 0x0f))
+	case c&0xf0 == 0x80:
+		return d.mapValue(int(c & 0x0f))
+	}
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return d.uint(1 << (c - 0xcc))
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		n, err := d.uint(size)
+		switch size {
+		case 1:
+			return int64(int8(n)), err
+		case 2:
+			return int64(int16(n)), err
+		case 4:
+			return int64(int32(n)), err
+		}
+		return int64(n), err
+	case 0xca:
+		n, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := d.uint(8)
+		return math.Float64frombits(n), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(int(n))
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.next(int(n))
+		return append([]byte(nil), b...), err
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(int(n))
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapValue(int(n))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(1 << (c - 0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.ext(int(n))
+	}
+	return nil, fmt.Errorf("msgpack: invalid code %#x", c)
+}
+
+func (d *decoder) str(n int) (string, error) {
+	b, err := d.next(n)
+	return string(b), err
+}
+
+func (d *decoder) array(n int) ([]interface{}, error) {
+	if n > len(d.data)-d.pos {
+		return nil, ErrShortBuffer
+	}
+	a := make([]interface{}, n)
+	for i := range a {
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		a[i] = v
+	}
+	return a, nil
+}
+
+func (d *decoder) mapValue(n int) (interface{}, error) {
+	if n > len(d.data)-d.pos {
+		return nil, ErrShortBuffer
+	}
+	keys := make([]interface{}, n)
+	vals := make([]interface{}, n)
+	strKeys := true
+	for i := 0; i < n; i++ {
+		k, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := k.(string); !ok {
+			strKeys = false
+		}
+		if keys[i] = k; k != nil && !reflect.TypeOf(k).Comparable() {
+			return nil, errors.New("msgpack: unhashable map key")
+		}
+		if vals[i], err = d.value(); err != nil {
+			return nil, err
+		}
+	}
+	if strKeys {
+		m := make(map[string]interface{}, n)
+		for i, k := range keys {
+			m[k.(string)] = vals[i]
+		}
+		return m, nil
+	}
+	m := make(map[interface{}]interface{}, n)
+	for i, k := range keys {
+		m[k] = vals[i]
+	}
+	return m, nil
+}
+
+// ext decodes the timestamp extension, other types are returned as Ext.
+func (d *decoder) ext(n int) (interface{}, error) {
+	t, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	if int8(t[0]) != extTimestamp {
+		return Ext{Type: int8(t[0]), Data: append([]byte(nil), b...)}, nil
+	}
+	switch n {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(b)), 0), nil
+	case 8:
+		x := binary.BigEndian.Uint64(b)
+		return time.Unix(int64(x&(1<<34-1)), int64(x>>34)), nil
+	case 12:
+		return time.Unix(int64(binary.BigEndian.Uint64(b[4:])), int64(binary.BigEndian.Uint32(b))), nil
+	}
+	return nil, errors.New("msgpack: invalid timestamp")
+}
+
+// Ext is an extension value of an application defined type.
+type Ext struct {
+	Type int8
+	Data []byte
+}
+
+// decode reads the next object into v. The object is decoded generically
+// first, then assigned.
+func (d *decoder) decode(v reflect.Value) error {
+	x, err := d.value()
+	if err != nil {
+		return err
+	}
+	return assign(v, x)
+}
+
+func assign(v reflect.Value, x interface{}) error {
+	if x == nil {
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return nil
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return assign(v.Elem(), x)
+	}
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		v.Set(reflect.ValueOf(x))
+		return nil
+	}
+	mismatch := func() error {
+		return fmt.Errorf("msgpack: cannot decode %T into %s", x, v.Type())
+	}
+	switch x := x.(type) {
+	case bool:
+		if v.Kind() != reflect.Bool {
+			return mismatch()
+		}
+		v.SetBool(x)
+	case int64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if v.OverflowInt(x) {
+				return mismatch()
+			}
+			v.SetInt(x)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if x < 0 || v.OverflowUint(uint64(x)) {
+				return mismatch()
+			}
+			v.SetUint(uint64(x))
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(float64(x))
+		default:
+			return mismatch()
+		}
+	case uint64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if x > math.MaxInt64 || v.OverflowInt(int64(x)) {
+				return mismatch()
+			}
+			v.SetInt(int64(x))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if v.OverflowUint(x) {
+				return mismatch()
+			}
+			v.SetUint(x)
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(float64(x))
+		default:
+			return mismatch()
+		}
+	case float64:
+		if v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64 {
+			return mismatch()
+		}
+		v.SetFloat(x)
+	case string:
+		switch {
+		case v.Kind() == reflect.String:
+			v.SetString(x)
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			v.SetBytes([]byte(x))
+		default:
+			return mismatch()
+		}
+	case []byte:
+		switch {
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			v.SetBytes(x)
+		case v.Kind() == reflect.String:
+			v.SetString(string(x))
+		default:
+			return mismatch()
+		}
+	case time.Time:
+		if v.Type() != timeType {
+			return mismatch()
+		}
+		v.Set(reflect.ValueOf(x))
+	case []interface{}:
+		switch v.Kind() {
+		case reflect.Slice:
+			s := reflect.MakeSlice(v.Type(), len(x), len(x))
+			for i, e := range x {
+				if err := assign(s.Index(i), e); err != nil {
+					return err
+				}
+			}
+			v.Set(s)
+		case reflect.Array:
+			for i := 0; i < v.Len() && i < len(x); i++ {
+				if err := assign(v.Index(i), x[i]); err != nil {
+					return err
+				}
+			}
+		default:
+			return mismatch()
+		}
+	case map[string]interface{}:
+		switch v.Kind() {
+		case reflect.Struct:
+			return assignStruct(v, x)
+		case reflect.Map:
+			return assignMap(v, reflect.ValueOf(x))
+		}
+		return mismatch()
+	case map[interface{}]interface{}:
+		if v.Kind() != reflect.Map {
+			return mismatch()
+		}
+		return assignMap(v, reflect.ValueOf(x))
+	default:
+		return mismatch()
+	}
+	return nil
+}
+
+func assignStruct(v reflect.Value, m map[string]interface{}) error {
+	for _, f := range cachedFields(v.Type()) {
+		x, ok := m[f.name]
+		if !ok {
+			for k, val := range m {
+				if strings.EqualFold(k, f.name) {
+					x, ok = val, true
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		if err := assign(v.FieldByIndex(f.index), x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func assignMap(v reflect.Value, m reflect.Value) error {
+	t := v.Type()
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(t))
+	}
+	for _, k := range m.MapKeys() {
+		kv := reflect.New(t.Key()).Elem()
+		if err := assign(kv, k.Interface()); err != nil {
+			return err
+		}
+		ev := reflect.New(t.Elem()).Elem()
+		if err := assign(ev, m.MapIndex(k).Interface()); err != nil {
+			return err
+		}
+		v.SetMapIndex(kv, ev)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode(t *testing.T) {
+	for _, tt := range []struct {
+		v   interface{}
+		hex string
+	}{
+		{nil, "c0"},
+		{true, "c3"},
+		{1, "01"},
+		{-1, "ff"},
+		{-33, "d0df"},
+		{200, "ccc8"},
+		{70000, "ce00011170"},
+		{int64(math.MinInt64), "d38000000000000000"},
+		{1.5, "cb3ff8000000000000"},
+		{float32(1.5), "ca3fc00000"},
+		{"abc", "a3616263"},
+		{strings.Repeat("a", 32), "d920" + strings.Repeat("61", 32)},
+		{[]byte{1, 2}, "c4020102"},
+		{[]int{1, 2}, "920102"},
+		{map[string]int{"b": 2, "a": 1}, "82a16101a16202"},
+		{time.Unix(1, 0), "d6ff00000001"},
+	} {
+		b, err := Marshal(tt.v)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.hex, hex.EncodeToString(b))
+	}
+}
+
+type inner struct {
+	X int
+}
+
+type record struct {
+	inner
+	Name    string            `msgpack:"name"`
+	Tags    []string          `msgpack:"tags,omitempty"`
+	Score   float64           `msgpack:"score"`
+	Ptr     *int              `msgpack:"ptr"`
+	Attrs   map[string]uint16 `msgpack:"attrs"`
+	When    time.Time         `msgpack:"when"`
+	Skipped string            `msgpack:"-"`
+	Any     interface{}       `msgpack:"any"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	n := 7
+	in := record{
+		inner: inner{X: -5},
+		Name:  "bob",
+		Score: 2.5,
+		Ptr:   &n,
+		Attrs: map[string]uint16{"a": 300},
+		When:  time.Unix(1500000000, 123),
+		Any:   []interface{}{int64(1), "x"},
+	}
+	b, err := Marshal(&in)
+	assert.NoError(t, err)
+
+	var out record
+	assert.NoError(t, Unmarshal(b, &out))
+	assert.Equal(t, in.X, out.X)
+	assert.Equal(t, in.Name, out.Name)
+	assert.Nil(t, out.Tags)
+	assert.Equal(t, in.Score, out.Score)
+	assert.Equal(t, 7, *out.Ptr)
+	assert.Equal(t, in.Attrs, out.Attrs)
+	assert.True(t, in.When.Equal(out.When))
+	assert.Equal(t, in.Any, out.Any)
+
+	var generic interface{}
+	assert.NoError(t, Unmarshal(b, &generic))
+	assert.Equal(t, "bob", generic.(map[string]interface{})["name"])
+
+	var small struct{ X int8 }
+	b, _ = Marshal(map[string]int{"X": 1000})
+	assert.Error(t, Unmarshal(b, &small))
+	assert.Equal(t, ErrShortBuffer, Unmarshal([]byte{0x92, 0x01}, &generic))
+}
+
+func TestDecode(t *testing.T) {
+	b, _ := Marshal(map[string]int{"a": 1})
+	var out map[string]int
+	assert.NoError(t, Decode(bytes.NewReader(b), &out))
+	assert.Equal(t, map[string]int{"a": 1}, out)
+}
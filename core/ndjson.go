@@ -0,0 +1,43 @@
+package core
+
+import (
+	"encoding/json"
+)
+
+// NDJSON sends the values received from ch as newline delimited JSON (JSON
+// Lines) with status code, until ch is closed, flushing each value within the
+// stream flush interval, see Echo.SetStreamFlushInterval, even if the next one
+// is long to come, so a streaming client can process a large result set or a
+// live feed as it comes.
+//
+// It stops with the error of the request's context once it's canceled or past
+// its deadline, or with the error of an encoding; the producer should then
+// stop sending, e.g. by selecting on the context too.
+func (c *Context) NDJSON(code int, ch <-chan interface{}) error {
+	ctx := c.StdContext()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	interval := c.echo.env.streamFlush
+	if interval <= 0 {
+		interval = DefaultStreamFlushInterval
+	}
+	c.response.Header().Set(ContentType, ApplicationNDJSON)
+	c.response.WriteHeader(code)
+	w := &streamWriter{c: c, ctx: ctx, interval: interval}
+	defer w.stop()
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case v, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if err := enc.Encode(v); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
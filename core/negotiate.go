@@ -0,0 +1,177 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultOffers are the media types Negotiate serves by default, in order of
+// preference.
+var DefaultOffers = []string{ApplicationJSON, ApplicationXML, TextHTML, TextPlain}
+
+// SetOffers sets the media types Negotiate serves, in order of preference:
+// the first one is the default, used when the request has no Accept header or
+// accepts none of them. Supported types are ApplicationJSON, ApplicationXML,
+// ApplicationYAML, ApplicationMsgpack, TextHTML and TextPlain, others panic.
+func (e *Echo) SetOffers(offers ...string) {
+	for _, o := range offers {
+		switch o {
+		case ApplicationJSON, ApplicationXML, ApplicationYAML, ApplicationMsgpack, TextHTML, TextPlain:
+		default:
+			panic("echo => unsupported offer " + o)
+		}
+	}
+	e.env.offers = append([]string(nil), offers...)
+}
+
+// Negotiate sends data with status code in the format the Accept header of the
+// request prefers among the offers, see Echo.SetOffers: JSON, XML, YAML,
+// MessagePack, plain text through fmt.Sprint, or HTML rendered with the
+// template named by the Layout of the context, or else the route path, like
+// BaseController.Render does.
+func (c *Context) Negotiate(code int, data interface{}) error {
+	offers := c.echo.env.offers
+	if len(offers) == 0 {
+		offers = DefaultOffers
+	}
+	c.response.AddVary(Accept)
+	switch NegotiateType(c.request.Header.Get(Accept), offers) {
+	case ApplicationXML:
+		return c.XML(code, data)
+	case ApplicationYAML:
+		return c.YAML(code, data)
+	case ApplicationMsgpack:
+		return c.Msgpack(code, data)
+	case TextHTML:
+		name := c.Layout
+		if name == "" {
+			name = c.path
+		}
+		return c.Render(code, name, data)
+	case TextPlain:
+		return c.String(code, fmt.Sprint(data))
+	}
+	return c.JSON(code, data)
+}
+
+// NegotiateType returns the offer the Accept header value accept prefers, the
+// first offer if none is acceptable. Among offers of equal quality, the first
+// one wins.
+func NegotiateType(accept string, offers []string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	accept = strings.TrimSpace(accept)
+	if accept == "" {
+		return offers[0]
+	}
+	ranges := parseAccept(accept)
+	best, bestQ := offers[0], 0.0
+	for _, o := range offers {
+		if q := acceptQuality(ranges, o); q > bestQ {
+			best, bestQ = o, q
+		}
+	}
+	return best
+}
+
+// NegotiateEncoding returns the offer the Accept-Encoding header value
+// accept prefers, "" if none is acceptable. "*" matches the offers not
+// listed. Among offers of equal quality, the first one wins.
+func NegotiateEncoding(accept string, offers []string) string {
+	values := splitAccept(accept)
+	best, bestQ := "", 0.0
+	for _, o := range offers {
+		q, star := -1.0, -1.0
+		for _, v := range values {
+			switch v.value {
+			case strings.ToLower(o):
+				q = v.q
+			case "*":
+				star = v.q
+			}
+		}
+		if q < 0 {
+			q = star
+		}
+		if q > bestQ {
+			best, bestQ = o, q
+		}
+	}
+	return best
+}
+
+type (
+	acceptValue struct {
+		value string
+		q     float64
+	}
+
+	acceptRange struct {
+		typ, sub string
+		q        float64
+	}
+)
+
+// splitAccept splits the value of an Accept style header into its lower
+// cased values and their qualities.
+func splitAccept(accept string) []acceptValue {
+	var values []acceptValue
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		v := acceptValue{value: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		if v.value == "" {
+			continue
+		}
+		for _, p := range params[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if q, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					v.q = q
+				}
+			}
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for _, v := range splitAccept(accept) {
+		mt := v.value
+		i := strings.IndexByte(mt, '/')
+		if i == -1 {
+			if mt != "*" {
+				continue
+			}
+			mt, i = "*/*", 1
+		}
+		ranges = append(ranges, acceptRange{typ: mt[:i], sub: mt[i+1:], q: v.q})
+	}
+	return ranges
+}
+
+// acceptQuality returns the quality of the most specific range matching the
+// media type mt.
+func acceptQuality(ranges []acceptRange, mt string) float64 {
+	i := strings.IndexByte(mt, '/')
+	typ, sub := mt[:i], mt[i+1:]
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		s := -1
+		switch {
+		case r.typ == typ && r.sub == sub:
+			s = 2
+		case r.typ == typ && r.sub == "*":
+			s = 1
+		case r.typ == "*" && r.sub == "*":
+			s = 0
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
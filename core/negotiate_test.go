@@ -0,0 +1,91 @@
+package core
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	offers := []string{"gzip", "deflate"}
+	tests := []struct {
+		accept, encoding string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"deflate, gzip", "gzip"},
+		{"gzip;q=0.5, deflate", "deflate"},
+		{"GZIP;q=0.5, Deflate;q=0.6", "deflate"},
+		{"*", "gzip"},
+		{"*;q=0.5, gzip;q=0.1", "deflate"},
+		{"gzip;q=0, *", "deflate"},
+		{"gzip;q=0, deflate;q=0", ""},
+		{"br, identity", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.encoding, NegotiateEncoding(tt.accept, offers), fmt.Sprint(tt.accept))
+	}
+}
+
+func TestNegotiateType(t *testing.T) {
+	offers := []string{ApplicationJSON, ApplicationXML, TextHTML}
+	tests := []struct {
+		accept, typ string
+	}{
+		{"", ApplicationJSON},
+		{"*/*", ApplicationJSON},
+		{"application/xml", ApplicationXML},
+		{"Application/XML", ApplicationXML},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", TextHTML},
+		{"application/xml;q=0.5, text/html;q=0.6", TextHTML},
+		{"application/*;q=0.5, application/xml", ApplicationXML},
+		{"text/*", TextHTML},
+		{"text/*, text/html;q=0", ApplicationJSON},
+		{"*/*;q=0.1, application/json;q=0", ApplicationXML},
+		{"image/png", ApplicationJSON},
+		{"*;q=0.5, text/html", TextHTML},
+		{"application/xml;q=x", ApplicationXML},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.typ, NegotiateType(tt.accept, offers), fmt.Sprint(tt.accept))
+	}
+	assert.Equal(t, "", NegotiateType("*/*", nil))
+}
+
+func TestNegotiate(t *testing.T) {
+	type doc struct {
+		A int `json:"a" xml:"a" yaml:"a"`
+	}
+	e := New()
+	e.SetRenderer(nameRenderer("views"))
+	serve := func(accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(GET, "/", nil)
+		req.Header.Set(Accept, accept)
+		rec := httptest.NewRecorder()
+		c := NewContext(req, NewResponse(rec, e), e)
+		c.Layout = "page.html"
+		assert.NoError(t, c.Negotiate(http.StatusOK, doc{1}))
+		return rec
+	}
+	rec := serve("")
+	assert.Equal(t, ApplicationJSONCharsetUTF8, rec.Header().Get(ContentType))
+	assert.Equal(t, Accept, rec.Header().Get(Vary))
+	assert.Equal(t, `{"a":1}`, rec.Body.String())
+	assert.Equal(t, "views:page.html", serve("text/html").Body.String())
+	assert.Equal(t, "{1}", serve("text/plain").Body.String())
+	assert.Equal(t, xml.Header+"<doc><a>1</a></doc>", serve("application/xml").Body.String())
+
+	e.SetOffers(ApplicationYAML, TextPlain)
+	assert.Equal(t, "a: 1\n", serve("application/json").Body.String())
+	assert.Equal(t, "{1}", serve("text/*").Body.String())
+
+	defer func() {
+		assert.NotNil(t, recover())
+	}()
+	e.SetOffers(ApplicationJSON, "image/png")
+}
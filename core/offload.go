@@ -0,0 +1,106 @@
+package core
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	pathpkg "path"
+	"path/filepath"
+	"strings"
+)
+
+// Headers delegating the sending of a file to the fronting server.
+const (
+	// XSendfile is understood by Apache mod_xsendfile and lighttpd.
+	XSendfile = "X-Sendfile"
+	// XAccelRedirect is understood by nginx.
+	XAccelRedirect = "X-Accel-Redirect"
+)
+
+// FileOffload configures the delegation of file responses to a fronting
+// server, see Echo.SetFileOffload.
+type FileOffload struct {
+	// Header is XSendfile or XAccelRedirect.
+	Header string
+
+	// Root is the directory of the files to offload, others are sent by Go.
+	// Required with XAccelRedirect, all the files by default with XSendfile.
+	Root string
+
+	// Location is the internal location of nginx serving Root, e.g.
+	// "/protected/" for:
+	//
+	//	location /protected/ {
+	//		internal;
+	//		alias /var/www/files/;
+	//	}
+	//
+	// Required with XAccelRedirect.
+	Location string
+}
+
+// SetFileOffload makes File, Attachment, Inline, ServeDir and ServeFile answer
+// with an empty response carrying the X-Sendfile or X-Accel-Redirect header,
+// letting the fronting server send the file instead of streaming it through
+// Go, e.g. for a high volume download service. Handlers still decide who gets
+// which file. nil restores the default. An invalid config panics.
+func (e *Echo) SetFileOffload(o *FileOffload) {
+	if o != nil {
+		o2 := *o
+		switch o2.Header {
+		case XSendfile:
+		case XAccelRedirect:
+			if o2.Root == "" || o2.Location == "" {
+				panic("echo => X-Accel-Redirect offload requires Root and Location")
+			}
+		default:
+			panic("echo => unsupported file offload header " + o2.Header)
+		}
+		if o2.Root != "" {
+			root, err := filepath.Abs(o2.Root)
+			if err != nil {
+				panic("echo => " + err.Error())
+			}
+			o2.Root = root
+		}
+		o = &o2
+	}
+	e.env.offload = o
+}
+
+// offload sends the file named name of fs through the fronting server and
+// reports whether it did, fs being on disk and name under Root.
+func (o *FileOffload) offload(c *Context, fs http.FileSystem, name string) bool {
+	dir, ok := fs.(http.Dir)
+	if !ok {
+		return false
+	}
+	d := string(dir)
+	if d == "" {
+		d = "."
+	}
+	file, err := filepath.Abs(filepath.Join(d, filepath.FromSlash(pathpkg.Clean("/"+name))))
+	if err != nil {
+		return false
+	}
+	value := file
+	if o.Root != "" {
+		rel, err := filepath.Rel(o.Root, file)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return false
+		}
+		if o.Header == XAccelRedirect {
+			u := url.URL{Path: pathpkg.Join("/", o.Location, filepath.ToSlash(rel))}
+			value = u.EscapedPath()
+		}
+	}
+	h := c.response.Header()
+	if h.Get(ContentType) == "" {
+		if ct := mime.TypeByExtension(filepath.Ext(file)); ct != "" {
+			h.Set(ContentType, ct)
+		}
+	}
+	h.Set(o.Header, value)
+	c.response.WriteHeader(http.StatusOK)
+	return true
+}
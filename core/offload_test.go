@@ -0,0 +1,102 @@
+package core
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileOffload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "offload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	root := filepath.Join(dir, "files")
+	os.Mkdir(root, 0755)
+	ioutil.WriteFile(filepath.Join(root, "a b.txt"), []byte("offloaded"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "secret.txt"), []byte("sent by go"), 0644)
+
+	e := New()
+	e.ServeDir("/static/", dir)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(GET, path, nil))
+		return rec
+	}
+
+	e.SetFileOffload(&FileOffload{Header: XSendfile, Root: root})
+	rec := get("/static/files/a%20b.txt")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, filepath.Join(root, "a b.txt"), rec.Header().Get(XSendfile))
+	assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get(ContentType))
+	assert.Equal(t, "", rec.Body.String())
+
+	// Files outside Root are sent by Go
+	rec = get("/static/secret.txt")
+	assert.Equal(t, "", rec.Header().Get(XSendfile))
+	assert.Equal(t, "sent by go", rec.Body.String())
+
+	e.SetFileOffload(&FileOffload{Header: XAccelRedirect, Root: root, Location: "/protected/"})
+	rec = get("/static/files/a%20b.txt")
+	assert.Equal(t, "/protected/a%20b.txt", rec.Header().Get(XAccelRedirect))
+	assert.Equal(t, "", rec.Body.String())
+	assert.Equal(t, "sent by go", get("/static/secret.txt").Body.String())
+
+	// Without Root, X-Sendfile offloads every file
+	e.SetFileOffload(&FileOffload{Header: XSendfile})
+	assert.Equal(t, filepath.Join(dir, "secret.txt"), get("/static/secret.txt").Header().Get(XSendfile))
+
+	e.SetFileOffload(nil)
+	assert.Equal(t, "offloaded", get("/static/files/a%20b.txt").Body.String())
+}
+
+func TestFileOffloadContainment(t *testing.T) {
+	root, _ := filepath.Abs("files")
+	o := &FileOffload{Header: XAccelRedirect, Root: root, Location: "/protected/"}
+	e := New()
+	for _, tt := range []struct {
+		fs       http.FileSystem
+		name     string
+		location string
+	}{
+		{http.Dir(root), "a.txt", "/protected/a.txt"},
+		{http.Dir(root), "../secret.txt", "/protected/secret.txt"},
+		{http.Dir(root), "/sub/../../../etc/passwd", "/protected/etc/passwd"},
+		{http.Dir(filepath.Join(root, "sub")), "a.txt", "/protected/sub/a.txt"},
+		{http.Dir(root + "-other"), "a.txt", ""},
+		{http.Dir(filepath.Dir(root)), "secret.txt", ""},
+		{http.Dir(filepath.Dir(root)), "files/../secret.txt", ""},
+		{httpFS{}, "a.txt", ""},
+	} {
+		rec := httptest.NewRecorder()
+		c := NewContext(httptest.NewRequest(GET, "/", nil), NewResponse(rec, e), e)
+		assert.Equal(t, tt.location != "", o.offload(c, tt.fs, tt.name), tt.name)
+		assert.Equal(t, tt.location, rec.Header().Get(XAccelRedirect), tt.name)
+	}
+}
+
+// httpFS is a file system which isn't on disk.
+type httpFS struct{}
+
+func (httpFS) Open(name string) (http.File, error) { return nil, os.ErrNotExist }
+
+func TestSetFileOffloadInvalid(t *testing.T) {
+	for _, o := range []*FileOffload{
+		{Header: "X-Other"},
+		{Header: XAccelRedirect, Location: "/protected/"},
+		{Header: XAccelRedirect, Root: "files"},
+	} {
+		func() {
+			defer func() {
+				assert.NotNil(t, recover(), o.Header)
+			}()
+			New().SetFileOffload(o)
+		}()
+	}
+}
@@ -0,0 +1,140 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	pathpkg "path"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	// PackedFS is a read-only file system compiled into the binary by
+	// `thinkgo pack`, e.g. the views of the application, so it runs without
+	// its source tree on Go versions without embed. It implements
+	// http.FileSystem, and fs.FS through FS() on Go 1.16+.
+	PackedFS struct {
+		modTime time.Time
+		files   map[string]string
+		dirs    map[string][]string // dir > sorted entry names
+	}
+
+	packedFile struct {
+		*bytes.Reader
+		fs   *PackedFS
+		name string
+		size int64
+		dir  bool
+		pos  int // Readdir position
+	}
+
+	packedInfo struct {
+		name    string
+		size    int64
+		dir     bool
+		modTime time.Time
+	}
+)
+
+// NewPackedFS returns a PackedFS of files, keyed by slash separated paths
+// relative to the packing directory. It's called by the generated code.
+func NewPackedFS(modTime time.Time, files map[string]string) *PackedFS {
+	p := &PackedFS{modTime: modTime, files: make(map[string]string, len(files)), dirs: map[string][]string{".": nil}}
+	seen := make(map[string]bool)
+	for name, data := range files {
+		name = pathpkg.Clean(strings.TrimPrefix(name, "/"))
+		p.files[name] = data
+		for child, dir := name, pathpkg.Dir(name); !seen[child]; child, dir = dir, pathpkg.Dir(dir) {
+			seen[child] = true
+			p.dirs[dir] = append(p.dirs[dir], pathpkg.Base(child))
+			if dir == "." {
+				break
+			}
+		}
+	}
+	for _, entries := range p.dirs {
+		sort.Strings(entries)
+	}
+	return p
+}
+
+// Files returns the paths of the packed files, sorted.
+func (p *PackedFS) Files() []string {
+	names := make([]string, 0, len(p.files))
+	for name := range p.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReadFile returns the content of a packed file.
+func (p *PackedFS) ReadFile(name string) ([]byte, error) {
+	data, ok := p.files[p.clean(name)]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return []byte(data), nil
+}
+
+// Open implements http.FileSystem.
+func (p *PackedFS) Open(name string) (http.File, error) {
+	name = p.clean(name)
+	if data, ok := p.files[name]; ok {
+		return &packedFile{Reader: bytes.NewReader([]byte(data)), fs: p, name: name, size: int64(len(data))}, nil
+	}
+	if _, ok := p.dirs[name]; ok {
+		return &packedFile{Reader: bytes.NewReader(nil), fs: p, name: name, dir: true}, nil
+	}
+	return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+}
+
+func (p *PackedFS) clean(name string) string {
+	return pathpkg.Clean(strings.TrimPrefix(name, "/"))
+}
+
+func (f *packedFile) Close() error {
+	return nil
+}
+
+func (f *packedFile) Stat() (os.FileInfo, error) {
+	return &packedInfo{name: pathpkg.Base(f.name), size: f.size, dir: f.dir, modTime: f.fs.modTime}, nil
+}
+
+func (f *packedFile) Readdir(count int) ([]os.FileInfo, error) {
+	if !f.dir {
+		return nil, errors.New("not a directory")
+	}
+	entries := f.fs.dirs[f.name][f.pos:]
+	if count > 0 && len(entries) > count {
+		entries = entries[:count]
+	}
+	infos := make([]os.FileInfo, len(entries))
+	for i, e := range entries {
+		name := pathpkg.Join(f.name, e)
+		data, isFile := f.fs.files[name]
+		infos[i] = &packedInfo{name: e, size: int64(len(data)), dir: !isFile, modTime: f.fs.modTime}
+	}
+	f.pos += len(entries)
+	if count > 0 && len(infos) == 0 {
+		return nil, io.EOF
+	}
+	return infos, nil
+}
+
+func (i *packedInfo) Name() string       { return i.name }
+func (i *packedInfo) Size() int64        { return i.size }
+func (i *packedInfo) ModTime() time.Time { return i.modTime }
+func (i *packedInfo) IsDir() bool        { return i.dir }
+func (i *packedInfo) Sys() interface{}   { return nil }
+
+func (i *packedInfo) Mode() os.FileMode {
+	if i.dir {
+		return os.ModeDir | 0555
+	}
+	return 0444
+}
@@ -0,0 +1,55 @@
+//go:build go1.16
+// +build go1.16
+
+package core
+
+import (
+	"io/fs"
+	"os"
+)
+
+type packedIOFS struct {
+	p *PackedFS
+}
+
+// FS returns p as an fs.FS, which also implements fs.ReadFileFS.
+func (p *PackedFS) FS() fs.FS {
+	return packedIOFS{p}
+}
+
+func (f packedIOFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &os.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	return f.p.Open(name)
+}
+
+func (f packedIOFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &os.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	return f.p.ReadFile(name)
+}
+
+func (f packedIOFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	d, err := f.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := d.(*packedFile).Readdir(-1)
+	if err != nil {
+		return nil, &os.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	entries := make([]fs.DirEntry, len(infos))
+	for i, info := range infos {
+		entries[i] = packedDirEntry{info}
+	}
+	return entries, nil
+}
+
+type packedDirEntry struct {
+	fs.FileInfo
+}
+
+func (e packedDirEntry) Type() fs.FileMode          { return e.Mode().Type() }
+func (e packedDirEntry) Info() (fs.FileInfo, error) { return e.FileInfo, nil }
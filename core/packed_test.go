@@ -0,0 +1,94 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newPackedFS() *PackedFS {
+	return NewPackedFS(time.Date(2016, 3, 1, 12, 0, 0, 0, time.UTC), map[string]string{
+		"index.html":      "<p>home</p>",
+		"blog/index.html": "<p>blog</p>",
+		"/css/site.css":   "body{}",
+		"js/app.js":       "app()",
+		"docs/a/b.txt":    "b",
+		"docs/readme.txt": "readme",
+	})
+}
+
+func TestPackedFSLookup(t *testing.T) {
+	p := newPackedFS()
+	assert.Equal(t, []string{"blog/index.html", "css/site.css", "docs/a/b.txt", "docs/readme.txt", "index.html", "js/app.js"}, p.Files())
+
+	for _, name := range []string{"css/site.css", "/css/site.css", "css/../css/site.css"} {
+		b, err := p.ReadFile(name)
+		assert.NoError(t, err, name)
+		assert.Equal(t, "body{}", string(b), name)
+	}
+	for _, name := range []string{"css/missing.css", "css", "site.css"} {
+		_, err := p.ReadFile(name)
+		assert.True(t, os.IsNotExist(err), name)
+	}
+
+	f, err := p.Open("/docs")
+	if assert.NoError(t, err) {
+		fi, _ := f.Stat()
+		assert.True(t, fi.IsDir())
+		assert.Equal(t, "docs", fi.Name())
+		infos, err := f.Readdir(-1)
+		assert.NoError(t, err)
+		if assert.Equal(t, 2, len(infos)) {
+			assert.Equal(t, "a", infos[0].Name())
+			assert.True(t, infos[0].IsDir())
+			assert.Equal(t, "readme.txt", infos[1].Name())
+			assert.Equal(t, int64(6), infos[1].Size())
+		}
+	}
+	f, err = p.Open("/")
+	if assert.NoError(t, err) {
+		infos, _ := f.Readdir(-1)
+		names := make([]string, len(infos))
+		for i, fi := range infos {
+			names[i] = fi.Name()
+		}
+		assert.Equal(t, []string{"blog", "css", "docs", "index.html", "js"}, names)
+	}
+	_, err = p.Open("/nope")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPackedFSServe(t *testing.T) {
+	e := New()
+	e.SetFileSystem("/static/", "", newPackedFS())
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(GET, path, nil))
+		return rec
+	}
+
+	for _, tt := range []struct {
+		path, body, contentType string
+	}{
+		{"/static/css/site.css", "body{}", "text/css; charset=utf-8"},
+		{"/static/js/app.js", "app()", "text/javascript; charset=utf-8"},
+		{"/static/docs/readme.txt", "readme", "text/plain; charset=utf-8"},
+		{"/static/index.html", "<p>home</p>", "text/html; charset=utf-8"},
+		{"/static/blog/", "<p>blog</p>", "text/html; charset=utf-8"},
+	} {
+		rec := get(tt.path)
+		assert.Equal(t, http.StatusOK, rec.Code, tt.path)
+		assert.Equal(t, tt.body, rec.Body.String(), tt.path)
+		assert.Equal(t, tt.contentType, rec.Header().Get(ContentType), tt.path)
+		assert.Equal(t, "Tue, 01 Mar 2016 12:00:00 GMT", rec.Header().Get("Last-Modified"), tt.path)
+	}
+
+	assert.Equal(t, http.StatusNotFound, get("/static/missing.css").Code)
+	assert.Equal(t, http.StatusNotFound, get("/static/css/site.css/x").Code)
+	// Directories without an index aren't listed
+	assert.Equal(t, http.StatusForbidden, get("/static/docs/").Code)
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	// plugChain holds the named global middleware, shared with groups. The
+	// chain is copied on write and swapped atomically, so a request runs on
+	// the chain it started with.
+	plugChain struct {
+		mu    sync.Mutex
+		chain atomic.Value // []plug
+	}
+
+	plug struct {
+		name string
+		mw   MiddlewareFunc
+	}
+)
+
+// Plug inserts the named middleware into the global chain while serving, e.g.
+// to enable a debug dump temporarily in production. Plugged middleware runs
+// for every route, before the middleware added with Use. Plugging a name
+// again replaces the middleware in place, otherwise it's appended.
+func (e *Echo) Plug(name string, m Middleware) {
+	e.plugs.set(name, wrapMiddleware(m), -1)
+}
+
+// PlugAt is like Plug, but inserts the middleware at index i of the chain.
+func (e *Echo) PlugAt(i int, name string, m Middleware) {
+	if i < 0 {
+		i = 0
+	}
+	e.plugs.set(name, wrapMiddleware(m), i)
+}
+
+// Unplug removes the named middleware, requests in flight finish on the old
+// chain. It reports whether the middleware was plugged.
+func (e *Echo) Unplug(name string) bool {
+	return e.plugs.set(name, nil, -1)
+}
+
+// Plugged returns the names of the plugged middleware, in order.
+func (e *Echo) Plugged() []string {
+	chain := e.plugs.load()
+	names := make([]string, len(chain))
+	for i, p := range chain {
+		names[i] = p.name
+	}
+	return names
+}
+
+func (p *plugChain) load() []plug {
+	chain, _ := p.chain.Load().([]plug)
+	return chain
+}
+
+// set replaces, inserts at i (appends if i < 0) or, if mw is nil, removes the
+// named middleware. It reports whether the name was plugged.
+func (p *plugChain) set(name string, mw MiddlewareFunc, i int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	old := p.load()
+	chain := make([]plug, 0, len(old)+1)
+	found := false
+	for _, pl := range old {
+		if pl.name == name {
+			found = true
+			if mw == nil {
+				continue
+			}
+			pl.mw = mw
+		}
+		chain = append(chain, pl)
+	}
+	if !found && mw != nil {
+		if i < 0 || i > len(chain) {
+			i = len(chain)
+		}
+		chain = append(chain, plug{})
+		copy(chain[i+1:], chain[i:])
+		chain[i] = plug{name, mw}
+	}
+	p.chain.Store(chain)
+	return found
+}
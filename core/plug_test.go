@@ -0,0 +1,94 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// traceMW returns a middleware adding name to the X-Trace response header.
+func traceMW(name string) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			c.Response().Header().Add("X-Trace", name)
+			return next(c)
+		}
+	}
+}
+
+func TestPlug(t *testing.T) {
+	e := New()
+	e.Use(traceMW("use"))
+	g := e.Group("/g", traceMW("group"))
+	h := func(c *Context) error {
+		return c.NoContent(http.StatusOK)
+	}
+	e.Get("/", h)
+	g.Get("/x", h)
+	trace := func(path string) string {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(GET, path, nil))
+		return strings.Join(rec.Header()["X-Trace"], " ")
+	}
+
+	assert.Equal(t, "use", trace("/"))
+	assert.Equal(t, "use group", trace("/g/x"))
+
+	// Plugged middleware runs first, in order, for the groups too
+	e.Plug("a", traceMW("a"))
+	e.Plug("b", traceMW("b"))
+	e.PlugAt(0, "first", traceMW("first"))
+	assert.Equal(t, []string{"first", "a", "b"}, e.Plugged())
+	assert.Equal(t, "first a b use", trace("/"))
+	assert.Equal(t, "first a b use group", trace("/g/x"))
+
+	// Plugging a name again replaces it in place
+	e.Plug("a", traceMW("a2"))
+	e.PlugAt(-5, "zero", traceMW("zero"))
+	e.PlugAt(100, "last", traceMW("last"))
+	assert.Equal(t, []string{"zero", "first", "a", "b", "last"}, e.Plugged())
+	assert.Equal(t, "zero first a2 b last use group", trace("/g/x"))
+
+	// Groups share the chain, whenever they were made
+	g2 := e.Group("/h")
+	g2.Get("/y", h)
+	assert.True(t, e.Unplug("zero"))
+	assert.True(t, e.Unplug("last"))
+	assert.False(t, e.Unplug("zero"))
+	assert.Equal(t, "first a2 b use", trace("/h/y"))
+	assert.Equal(t, "first a2 b use group", trace("/g/x"))
+}
+
+func TestPlugInFlight(t *testing.T) {
+	e := New()
+	started, release := make(chan struct{}), make(chan struct{})
+	e.Plug("a", traceMW("a"))
+	e.Get("/", func(c *Context) error {
+		if c.Query("wait") != "" {
+			close(started)
+			<-release
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	// A request runs on the chain it started with
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		e.ServeHTTP(rec, httptest.NewRequest(GET, "/?wait=1", nil))
+		close(done)
+	}()
+	<-started
+	e.Unplug("a")
+	e.Plug("b", traceMW("b"))
+	close(release)
+	<-done
+	assert.Equal(t, []string{"a"}, rec.Header()["X-Trace"])
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(GET, "/", nil))
+	assert.Equal(t, []string{"b"}, rec.Header()["X-Trace"])
+}
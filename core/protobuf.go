@@ -0,0 +1,70 @@
+package core
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+)
+
+type (
+	// ProtoMarshaler is implemented by protobuf messages generated with
+	// marshal methods (e.g. gogo/protobuf).
+	ProtoMarshaler interface {
+		Marshal() ([]byte, error)
+	}
+
+	// ProtoUnmarshaler is the decoding counterpart of ProtoMarshaler.
+	ProtoUnmarshaler interface {
+		Unmarshal([]byte) error
+	}
+
+	// ProtoCodecer marshals protobuf messages with a protobuf runtime.
+	ProtoCodecer interface {
+		Marshal(msg interface{}) ([]byte, error)
+		Unmarshal(b []byte, msg interface{}) error
+	}
+)
+
+// ProtoCodec is used for messages without marshal methods. Set it to a codec
+// wrapping your protobuf runtime, e.g. proto.Marshal and proto.Unmarshal of
+// github.com/golang/protobuf/proto.
+var ProtoCodec ProtoCodecer
+
+// ErrProtobufUnsupported is returned for messages which can't be marshaled.
+var ErrProtobufUnsupported = errors.New("protobuf: type has no marshal methods and ProtoCodec is not set")
+
+// Protobuf sends a protobuf response with status code.
+func (c *Context) Protobuf(code int, msg interface{}) error {
+	b, err := marshalProto(msg)
+	if err != nil {
+		return err
+	}
+	c.response.Header().Set(ContentType, ApplicationProtobuf)
+	c.response.WriteHeader(code)
+	c.response.Write(b)
+	return nil
+}
+
+func marshalProto(msg interface{}) ([]byte, error) {
+	if m, ok := msg.(ProtoMarshaler); ok {
+		return m.Marshal()
+	}
+	if ProtoCodec != nil {
+		return ProtoCodec.Marshal(msg)
+	}
+	return nil, ErrProtobufUnsupported
+}
+
+func bindProto(r io.Reader, msg interface{}) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if m, ok := msg.(ProtoUnmarshaler); ok {
+		return m.Unmarshal(b)
+	}
+	if ProtoCodec != nil {
+		return ProtoCodec.Unmarshal(b, msg)
+	}
+	return ErrProtobufUnsupported
+}
@@ -0,0 +1,205 @@
+package core
+
+import (
+	"net"
+	"strings"
+)
+
+// ForwardedElement is one hop of a Forwarded header (RFC 7239).
+type ForwardedElement struct {
+	For   string
+	By    string
+	Proto string
+	Host  string
+}
+
+// ParseForwarded parses the values of Forwarded headers, the hop closest to the
+// client first. Unknown parameters are ignored.
+func ParseForwarded(values ...string) []ForwardedElement {
+	var elems []ForwardedElement
+	for _, v := range values {
+		for _, part := range splitQuoted(v, ',') {
+			var fe ForwardedElement
+			for _, pair := range splitQuoted(part, ';') {
+				i := strings.Index(pair, "=")
+				if i == -1 {
+					continue
+				}
+				key := strings.ToLower(strings.TrimSpace(pair[:i]))
+				val := unquote(strings.TrimSpace(pair[i+1:]))
+				switch key {
+				case "for":
+					fe.For = val
+				case "by":
+					fe.By = val
+				case "proto":
+					fe.Proto = strings.ToLower(val)
+				case "host":
+					fe.Host = val
+				}
+			}
+			elems = append(elems, fe)
+		}
+	}
+	return elems
+}
+
+// splitQuoted splits s at sep, ignoring separators inside quoted strings.
+func splitQuoted(s string, sep byte) []string {
+	var (
+		parts  []string
+		quoted bool
+		start  int
+	)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quoted {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+func unquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	s = s[1 : len(s)-1]
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
+
+// nodeIP extracts the IP of a Forwarded node ("192.0.2.1:80", "[2001:db8::1]",
+// "unknown", ...). It returns "" for obfuscated or unknown nodes.
+func nodeIP(node string) string {
+	if strings.HasPrefix(node, "[") {
+		if i := strings.Index(node, "]"); i != -1 {
+			node = node[1:i]
+		}
+	} else if strings.Count(node, ":") == 1 {
+		node = node[:strings.Index(node, ":")]
+	}
+	if net.ParseIP(node) == nil {
+		return ""
+	}
+	return node
+}
+
+// ParseNetworks parses IPs and CIDRs into networks, an IP being a network of
+// its own.
+func ParseNetworks(networks ...string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(networks))
+	for _, s := range networks {
+		if !strings.Contains(s, "/") {
+			if strings.Contains(s, ":") {
+				s += "/128"
+			} else {
+				s += "/32"
+			}
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// SetTrustedProxies sets the IPs or CIDRs of the proxies in front of the
+// server, whose proxy headers (Forwarded, X-Real-IP and X-Forwarded-For) are
+// honored by `Context#RealIP`. Without trusted proxies the headers are ignored,
+// since any client can send them.
+//
+// The headers used to be honored whatever the peer, which a server behind a
+// proxy relied on to log and limit its clients. It should now trust its proxy,
+// or trust every address to keep the former behavior, letting any client pick
+// its IP:
+//
+//	e.SetTrustedProxies("0.0.0.0/0", "::/0")
+//
+// Think sets them from the trustedproxies setting of the config.
+func (e *Echo) SetTrustedProxies(proxies ...string) error {
+	nets, err := ParseNetworks(proxies...)
+	if err != nil {
+		return err
+	}
+	e.trustedProxies = nets
+	return nil
+}
+
+func (e *Echo) trusted(ip string) bool {
+	if ip := net.ParseIP(ip); ip != nil {
+		for _, n := range e.trustedProxies {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RealIP returns the client IP address. The proxy headers are only honored
+// when the peer is a trusted proxy, see `Echo#SetTrustedProxies`: the first
+// present of Forwarded, X-Real-IP and X-Forwarded-For is used, the forwarding
+// chain being walked back to the first untrusted hop. Otherwise it's the peer
+// address.
+func (c *Context) RealIP() string {
+	r := c.request
+	remote := r.RemoteAddr
+	if ip, _, err := net.SplitHostPort(remote); err == nil {
+		remote = ip
+	}
+	e := c.echo
+	if !e.trusted(remote) {
+		return remote
+	}
+	var chain []string
+	if fwd := r.Header[Forwarded]; len(fwd) > 0 {
+		for _, fe := range ParseForwarded(fwd...) {
+			chain = append(chain, nodeIP(fe.For))
+		}
+	} else if ip := r.Header.Get(XRealIP); ip != "" {
+		return ip
+	} else if xff := r.Header.Values(XForwardedFor); len(xff) > 0 {
+		// A client may send its own lines ahead of the one of the proxy.
+		for _, ip := range strings.Split(strings.Join(xff, ","), ",") {
+			chain = append(chain, strings.TrimSpace(ip))
+		}
+	}
+	// Walk from the closest hop back, skipping trusted proxies.
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := chain[i]
+		if ip == "" {
+			// Obfuscated or unknown hop, the chain can't be followed further.
+			break
+		}
+		if !e.trusted(ip) || i == 0 {
+			return ip
+		}
+	}
+	return remote
+}
+
+// Forwarded returns the parsed Forwarded header of the request.
+func (c *Context) Forwarded() []ForwardedElement {
+	return ParseForwarded(c.request.Header[Forwarded]...)
+}
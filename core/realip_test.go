@@ -0,0 +1,66 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRealIP(t *testing.T) {
+	e := New()
+	tests := []struct {
+		remote  string
+		header  http.Header
+		trusted []string
+		ip      string
+	}{
+		// Headers are ignored without trusted proxies
+		{"192.0.2.1:1234", nil, nil, "192.0.2.1"},
+		{"192.0.2.1:1234", http.Header{Forwarded: {"for=198.51.100.1"}}, nil, "192.0.2.1"},
+		{"192.0.2.1:1234", http.Header{XRealIP: {"198.51.100.1"}}, nil, "192.0.2.1"},
+		{"192.0.2.1:1234", http.Header{XForwardedFor: {"198.51.100.1"}}, nil, "192.0.2.1"},
+
+		// and from untrusted peers
+		{"192.0.2.1:1234", http.Header{XRealIP: {"198.51.100.1"}}, []string{"10.0.0.0/8"}, "192.0.2.1"},
+
+		// Trusted peer
+		{"10.0.0.1:1234", nil, []string{"10.0.0.0/8"}, "10.0.0.1"},
+		{"10.0.0.1:1234", http.Header{Forwarded: {`for="[2001:db8::1]:80"`}}, []string{"10.0.0.0/8"}, "2001:db8::1"},
+		{"10.0.0.1:1234", http.Header{XRealIP: {"198.51.100.1"}}, []string{"10.0.0.0/8"}, "198.51.100.1"},
+		{"10.0.0.1:1234", http.Header{XForwardedFor: {"198.51.100.1, 10.0.0.2"}}, []string{"10.0.0.0/8"}, "198.51.100.1"},
+
+		// Forgeries left of the first untrusted hop are skipped
+		{"10.0.0.1:1234", http.Header{XForwardedFor: {"127.0.0.1, 198.51.100.1, 10.0.0.2"}}, []string{"10.0.0.0/8"}, "198.51.100.1"},
+		{"10.0.0.1:1234", http.Header{Forwarded: {"for=127.0.0.1, for=198.51.100.1"}}, []string{"10.0.0.0/8"}, "198.51.100.1"},
+		// even when sent as a line of their own ahead of the proxy's
+		{"10.0.0.1:1234", http.Header{XForwardedFor: {"127.0.0.1", "198.51.100.1"}}, []string{"10.0.0.0/8"}, "198.51.100.1"},
+		{"10.0.0.1:1234", http.Header{XForwardedFor: {"127.0.0.1", "198.51.100.1, 10.0.0.2"}}, []string{"10.0.0.0/8"}, "198.51.100.1"},
+
+		// Trusting every address honors the headers of any peer
+		{"192.0.2.1:1234", http.Header{XForwardedFor: {"198.51.100.1"}}, []string{"0.0.0.0/0", "::/0"}, "198.51.100.1"},
+
+		// Same precedence: Forwarded, X-Real-IP, X-Forwarded-For
+		{"10.0.0.1:1234", http.Header{Forwarded: {"for=198.51.100.1"}, XRealIP: {"198.51.100.2"}}, []string{"10.0.0.1"}, "198.51.100.1"},
+		{"10.0.0.1:1234", http.Header{XRealIP: {"198.51.100.2"}, XForwardedFor: {"198.51.100.3"}}, []string{"10.0.0.1"}, "198.51.100.2"},
+
+		// Unknown hop
+		{"10.0.0.1:1234", http.Header{Forwarded: {"for=unknown"}}, []string{"10.0.0.1"}, "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		assert.NoError(t, e.SetTrustedProxies(tt.trusted...))
+		req, _ := http.NewRequest(GET, "/", nil)
+		req.RemoteAddr = tt.remote
+		for k, v := range tt.header {
+			for _, v := range v {
+				req.Header.Add(k, v)
+			}
+		}
+		c := NewContext(req, NewResponse(httptest.NewRecorder(), e), e)
+		assert.Equal(t, tt.ip, c.RealIP(), fmt.Sprint(tt.remote, tt.header, tt.trusted))
+	}
+
+	assert.Error(t, e.SetTrustedProxies("10.0.0.0/33"))
+}
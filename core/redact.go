@@ -0,0 +1,60 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// RedactFunc returns the value logged in place of a field tagged
+// `redact:"true"`.
+type RedactFunc func(field reflect.StructField, v reflect.Value) interface{}
+
+// RedactMask replaces redacted values by default.
+const RedactMask = "[REDACTED]"
+
+// DefaultRedact masks every redacted value with RedactMask.
+func DefaultRedact(reflect.StructField, reflect.Value) interface{} {
+	return RedactMask
+}
+
+// SetRedactFunc sets the function used to mask fields tagged `redact:"true"`
+// in dumps, logs and debug error pages.
+func (e *Echo) SetRedactFunc(fn RedactFunc) {
+	e.env.redact = fn
+}
+
+// Redact returns a copy of i safe for logging: structs become maps keyed by
+// their json names, honoring omitempty, with the fields tagged
+// `redact:"true"` masked by fn (DefaultRedact if nil). Other values are
+// returned as they are.
+func Redact(i interface{}, fn RedactFunc) interface{} {
+	if fn == nil {
+		fn = DefaultRedact
+	}
+	w := &jsonWalker{
+		field: func(f reflect.StructField, v reflect.Value) (interface{}, fieldAction) {
+			if f.Tag.Get("redact") == "true" {
+				return fn(f, v), fieldReplace
+			}
+			return nil, fieldWalk
+		},
+	}
+	return w.value(reflect.ValueOf(i))
+}
+
+// Redact is Redact using the redaction function of the Echo instance.
+func (c *Context) Redact(i interface{}) interface{} {
+	return Redact(i, c.echo.env.redact)
+}
+
+// Bound returns the value last passed to Bind, or nil.
+func (c *Context) Bound() interface{} {
+	return c.bound
+}
+
+func mapKey(v reflect.Value) string {
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -7,6 +7,9 @@ package core
 import (
 	"fmt"
 	"io"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/henrylee2cn/thinkgo/core/template"
 )
@@ -21,6 +24,18 @@ type Template struct {
 	pathmap map[string]string
 	// 一旦解析就不可变的模板 [[模块]/[主题]/[控制器]/[操作]]:bool
 	permanent map[string]bool
+	// fromDisk is set when the views are read from disk, so debug mode can
+	// pick up new files.
+	fromDisk bool
+	// files are the view files, read by read.
+	files []string
+	read  func(string) ([]byte, error)
+	// pristine is a never executed copy, which can still be cloned.
+	pristine *template.Template
+	// parseErr is the error of the last parse of the views, reported by
+	// Render until a parse succeeds.
+	parseErr error
+	mu       sync.RWMutex // guards pathmap in debug mode
 }
 
 func NewRender() *Template {
@@ -47,17 +62,116 @@ func (t *Template) SetDebug(debug bool) {
 	t.debug = debug
 }
 
+// Render executes the template name. In debug mode, templates are re-read
+// from disk on every call along with the common views (e.g. layouts), and new
+// view files are picked up, so changes show without restarting the server.
 func (t *Template) Render(w io.Writer, name string, data interface{}) error {
+	return t.RenderFuncs(w, name, data, nil)
+}
+
+// RenderFuncs is Render with funcs overriding the template functions, e.g. a
+// translation function bound to the locale of the request. funcs can only
+// override functions registered with AddFuncs, since views are parsed ahead.
+func (t *Template) RenderFuncs(w io.Writer, name string, data interface{}, funcs template.FuncMap) error {
+	if !t.debug {
+		if t.parseErr != nil {
+			return t.parseErr
+		}
+		f := t.pathmap[name]
+		if f == "" {
+			return fmt.Errorf("索引模板不存在: %s", name)
+		}
+		if len(funcs) == 0 {
+			return t.Template.ExecuteTemplate(w, f, data)
+		}
+		if t.pristine == nil {
+			return fmt.Errorf("template functions can't be overridden after rendering: %s", name)
+		}
+		tpl, err := t.pristine.Clone()
+		if err != nil {
+			return err
+		}
+		return tpl.Funcs(funcs).ExecuteTemplate(w, f, data)
+	}
+
+	t.mu.RLock()
 	f := t.pathmap[name]
+	t.mu.RUnlock()
+	if f == "" && t.fromDisk {
+		t.mu.Lock()
+		t.index(WalkRelFiles(t.basepath, t.suffix))
+		f = t.pathmap[name]
+		t.mu.Unlock()
+	}
 	if f == "" {
 		return fmt.Errorf("索引模板不存在: %s", name)
 	}
-	if !t.debug {
-		return t.Template.ExecuteTemplate(w, f, data)
-	} else if t.permanent[name] {
-		return template.Must(t.Template.Clone()).ExecuteTemplate(w, f, data)
+	base := t.Template
+	if t.pristine != nil {
+		base = t.pristine
+	}
+	tpl, err := base.Clone()
+	if err != nil {
+		return err
+	}
+	if len(funcs) > 0 {
+		tpl.Funcs(funcs)
 	}
-	return template.Must(template.Must(t.Template.Clone()).ParseFiles(f)).ExecuteTemplate(w, f, data)
+	if !t.permanent[name] {
+		if _, err = tpl.ParseFiles(append(t.commonFiles(f), f)...); err != nil {
+			return err
+		}
+	}
+	return tpl.ExecuteTemplate(w, f, data)
+}
+
+// AddFuncs registers template functions, such as date formatting or URL
+// generation, and parses the views again so they can use them. It must be
+// called before the first Render.
+func (t *Template) AddFuncs(funcs template.FuncMap) error {
+	t.Template.Funcs(funcs)
+	if t.debug {
+		return nil
+	}
+	t.parseErr = t.parse()
+	return t.parseErr
+}
+
+// parse parses the view files.
+func (t *Template) parse() error {
+	for _, f := range t.files {
+		b, err := t.read(f)
+		if err != nil {
+			return err
+		}
+		if _, err = t.Template.New(f).Parse(string(b)); err != nil {
+			return err
+		}
+	}
+	var err error
+	t.pristine, err = t.Template.Clone()
+	return err
+}
+
+// commonFiles returns the common view files read from disk, but f.
+func (t *Template) commonFiles(f string) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var files []string
+	for name, file := range t.pathmap {
+		if strings.HasPrefix(name, "/common/") && !t.permanent[name] && file != f {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// HasTemplate implements TemplateIndex.
+func (t *Template) HasTemplate(name string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.pathmap[name] != ""
 }
 
 func (t *Template) Map() map[string]string {
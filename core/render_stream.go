@@ -0,0 +1,117 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultStreamFlushInterval is how long RenderStream, JSONStream and NDJSON
+// hold back what they wrote before flushing it by default.
+const DefaultStreamFlushInterval = 200 * time.Millisecond
+
+// streamWriter writes straight to the response and fails once the request's
+// context is done. What's written is flushed within interval by a timer, so
+// it isn't held back while the source is slow to come up with more, or after
+// every write when zero. stop flushes what's left and stops the timer.
+type streamWriter struct {
+	c        *Context
+	ctx      context.Context
+	interval time.Duration
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	stopped bool
+}
+
+// SetStreamFlushInterval sets how long RenderStream, JSONStream and NDJSON may
+// hold back what they wrote before flushing it, DefaultStreamFlushInterval by
+// default.
+func (e *Echo) SetStreamFlushInterval(d time.Duration) {
+	e.env.streamFlush = d
+}
+
+// RenderStream renders a template with data like Render, but writes it to the
+// response as it's executed instead of buffering it, flushing what's written
+// within the stream flush interval, so a very large HTML report starts to show
+// at once and doesn't sit in memory.
+//
+// Rendering stops with the error of the request's context once it's canceled
+// or past its deadline, e.g. set by the Timeout middleware. The status code is
+// sent before rendering, so a template error can't change it anymore.
+func (c *Context) RenderStream(code int, name string, data interface{}) error {
+	r := c.echo.rendererFor(name)
+	if r == nil {
+		return RendererNotRegistered
+	}
+	ctx := c.StdContext()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	interval := c.echo.env.streamFlush
+	if interval <= 0 {
+		interval = DefaultStreamFlushInterval
+	}
+	c.response.Header().Set(ContentType, TextHTMLCharsetUTF8)
+	c.response.WriteHeader(code)
+	w := &streamWriter{c: c, ctx: ctx, interval: interval}
+	err := r.Render(w, c.tenantView(r, name), data)
+	w.stop()
+	if err == nil {
+		err = ctx.Err()
+	}
+	return err
+}
+
+func (w *streamWriter) Write(b []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n, err := w.c.response.Write(b)
+	if err != nil || w.stopped {
+		return n, err
+	}
+	if w.interval <= 0 {
+		w.flush()
+	} else if w.timer == nil {
+		w.timer = time.AfterFunc(w.interval, w.flushPending)
+	}
+	return n, err
+}
+
+// flushPending flushes on behalf of the timer, unless stopped meanwhile.
+func (w *streamWriter) flushPending() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.stopped {
+		w.flush()
+	}
+	w.timer = nil
+}
+
+// stop flushes what's left and stops the timer, the writer mustn't be used
+// afterwards.
+func (w *streamWriter) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.stopped = true
+	w.flush()
+}
+
+func (w *streamWriter) flush() {
+	switch f := w.c.response.Writer().(type) {
+	case http.Flusher:
+		f.Flush()
+	case interface {
+		Flush() error
+	}:
+		f.Flush()
+	}
+}
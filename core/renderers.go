@@ -0,0 +1,110 @@
+package core
+
+import (
+	"bytes"
+	"path"
+)
+
+// AddRenderer registers r under key, either a file extension such as ".md"
+// or ".amber", or an engine name, so several template engines can coexist
+// next to the one set with SetRenderer.
+//
+// Context.Render picks the renderer registered for the extension of the
+// template name and falls back to the default renderer; Context.RenderWith
+// selects one by key. Renderers must be added before the server starts.
+func (e *Echo) AddRenderer(key string, r Renderer) {
+	if e.env.renderers == nil {
+		e.env.renderers = make(map[string]Renderer)
+	}
+	if r == nil {
+		delete(e.env.renderers, key)
+		return
+	}
+	e.env.renderers[key] = r
+	if fr, ok := r.(FuncRenderer); ok && len(e.env.funcs) > 0 {
+		if err := fr.AddFuncs(e.env.funcs); err != nil {
+			e.logger.Error("%v", err)
+		}
+	}
+	if t, ok := r.(*Template); ok {
+		t.SetDebug(e.debug)
+	}
+}
+
+// Renderer returns the renderer registered under key with AddRenderer, or the
+// default renderer if key is empty.
+func (e *Echo) Renderer(key string) Renderer {
+	if key == "" {
+		return e.defaultRenderer()
+	}
+	return e.env.renderers[key]
+}
+
+// defaultRenderer returns the renderer of e, the one of the Echo instance for
+// a group which didn't set its own.
+func (e *Echo) defaultRenderer() Renderer {
+	if e.renderer != nil {
+		return e.renderer
+	}
+	return e.root().renderer
+}
+
+// rendererFor returns the renderer of the template name, by extension.
+func (e *Echo) rendererFor(name string) Renderer {
+	if ext := path.Ext(name); ext != "" {
+		if r, ok := e.env.renderers[ext]; ok {
+			return r
+		}
+	}
+	return e.defaultRenderer()
+}
+
+// renderers returns every renderer: the default one, the ones of the groups,
+// then the added ones.
+func (e *Echo) renderers() []Renderer {
+	rs := make([]Renderer, 0, len(e.env.renderers)+len(e.env.groupRenderers)+1)
+	if r := e.root().renderer; r != nil {
+		rs = append(rs, r)
+	}
+	rs = append(rs, e.env.groupRenderers...)
+	for _, r := range e.env.renderers {
+		rs = append(rs, r)
+	}
+	return rs
+}
+
+// RenderWith is Render with the renderer registered under key with
+// Echo.AddRenderer, whatever the extension of the template name.
+func (c *Context) RenderWith(code int, key, name string, data interface{}) error {
+	return c.render(code, c.echo.Renderer(key), name, data)
+}
+
+func (c *Context) render(code int, r Renderer, name string, data interface{}) (err error) {
+	if r == nil {
+		return RendererNotRegistered
+	}
+	buf := new(bytes.Buffer)
+	if err = r.Render(buf, c.tenantView(r, name), data); err != nil {
+		return
+	}
+	c.response.Header().Set(ContentType, TextHTMLCharsetUTF8)
+	c.response.WriteHeader(code)
+	c.response.Write(buf.Bytes())
+	return
+}
+
+// RenderString renders a template with data into a string rather than a
+// response, e.g. the body of an email or an HTML fragment of a JSON payload.
+func (e *Echo) RenderString(name string, data interface{}) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := e.Render(buf, name, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// RenderString renders a template with data into a string, see
+// Echo.RenderString.
+func (c *Context) RenderString(name string, data interface{}) (string, error) {
+	return c.echo.RenderString(name, data)
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"net"
 	"net/http"
+	"strings"
 )
 
 type (
@@ -12,7 +13,11 @@ type (
 		status    int
 		size      int64
 		committed bool
-		echo      *Echo
+		// notModified drops what is written after a 304 Not Modified, see
+		// Context.LastModified.
+		notModified bool
+		echo        *Echo
+		guard       *responseGuard
 	}
 )
 
@@ -25,7 +30,35 @@ func (r *Response) SetWriter(w http.ResponseWriter) {
 }
 
 func (r *Response) Header() http.Header {
-	return r.writer.Header()
+	h := r.writer.Header()
+	if r.guard != nil && r.guard.header != nil {
+		r.guard.headerCalled(h)
+	}
+	return h
+}
+
+// AddVary appends the given header names to the Vary response header. Names
+// already listed (case-insensitively) are skipped, and nothing is added once
+// the response varies on "*".
+func (r *Response) AddVary(headers ...string) {
+	h := r.Header()
+	listed := map[string]bool{}
+	for _, v := range h[Vary] {
+		for _, f := range strings.Split(v, ",") {
+			listed[strings.ToLower(strings.TrimSpace(f))] = true
+		}
+	}
+	if listed["*"] {
+		return
+	}
+	for _, name := range headers {
+		k := strings.ToLower(strings.TrimSpace(name))
+		if k == "" || listed[k] {
+			continue
+		}
+		listed[k] = true
+		h.Add(Vary, http.CanonicalHeaderKey(strings.TrimSpace(name)))
+	}
 }
 
 func (r *Response) Writer() http.ResponseWriter {
@@ -33,17 +66,34 @@ func (r *Response) Writer() http.ResponseWriter {
 }
 
 func (r *Response) WriteHeader(code int) {
+	if r.notModified {
+		return
+	}
 	if r.committed {
-		r.echo.Logger().Warn("response already committed")
+		if r.guard != nil {
+			r.guard.rewrite(r, code)
+		} else {
+			r.echo.Logger().Warn("response already committed")
+		}
 		return
 	}
 	r.status = code
 	r.writer.WriteHeader(code)
 	r.committed = true
+	if r.guard != nil {
+		r.guard.committed(r.writer.Header())
+	}
 }
 
 func (r *Response) Write(b []byte) (n int, err error) {
+	if r.notModified {
+		return len(b), nil
+	}
 	n, err = r.writer.Write(b)
+	if r.guard != nil {
+		// After the write, which may sniff the Content-Type into the header
+		r.guard.committed(r.writer.Header())
+	}
 	r.size += int64(n)
 	return n, err
 }
@@ -58,6 +108,15 @@ func (r *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	return r.writer.(http.Hijacker).Hijack()
 }
 
+// Push wraps response writer's Push function (HTTP/2 server push). It returns
+// http.ErrNotSupported when the connection can't push.
+func (r *Response) Push(target string, opts *http.PushOptions) error {
+	if p, ok := r.writer.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
 // CloseNotify wraps response writer's CloseNotify function.
 func (r *Response) CloseNotify() <-chan bool {
 	return r.writer.(http.CloseNotifier).CloseNotify()
@@ -80,5 +139,7 @@ func (r *Response) reset(w http.ResponseWriter, e *Echo) {
 	r.size = 0
 	r.status = http.StatusOK
 	r.committed = false
+	r.notModified = false
 	r.echo = e
+	r.guard = nil
 }
@@ -1,12 +1,25 @@
 package core
 
-import "net/http"
+import (
+	"net/http"
+	"sync"
+)
 
 type (
 	Router struct {
-		tree   *node
-		routes []Route
-		echo   *Echo
+		tree    *node
+		routes  []Route
+		echo    *Echo
+		entries []routeEntry
+		mu      sync.RWMutex // guards tree and routes
+		wmu     sync.Mutex   // serializes changes
+	}
+	// routeEntry records a registration so the tree can be rebuilt.
+	routeEntry struct {
+		method string
+		path   string
+		h      HandlerFunc
+		e      *Echo
 	}
 	node struct {
 		kind          kind
@@ -31,6 +44,7 @@ type (
 		post    HandlerFunc
 		put     HandlerFunc
 		trace   HandlerFunc
+		custom  map[string]HandlerFunc // e.g. PURGE, PROPFIND
 	}
 )
 
@@ -51,6 +65,89 @@ func NewRouter(e *Echo) *Router {
 }
 
 func (r *Router) Add(method, path string, h HandlerFunc, e *Echo) {
+	r.addRoute(Route{Method: method, Path: path}, h, e, false)
+}
+
+// addRoute adds the route rt, also listing it in the routes if list is set.
+// The routes are copied on change, so slices returned by Routes are kept.
+func (r *Router) addRoute(rt Route, h HandlerFunc, e *Echo, list bool) {
+	r.wmu.Lock()
+	defer r.wmu.Unlock()
+	r.entries = append(r.entries, routeEntry{rt.Method, rt.Path, h, e})
+	routes := r.routes
+	if list {
+		routes = append(routes[:len(routes):len(routes)], rt)
+	}
+	r.mu.Lock()
+	r.add(rt.Method, rt.Path, h, e)
+	r.routes = routes
+	r.mu.Unlock()
+}
+
+// Routes returns the listed routes.
+func (r *Router) Routes() []Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.routes
+}
+
+// Remove unregisters the route method > path. Requests in flight finish on
+// the removed handler.
+func (r *Router) Remove(method, path string) bool {
+	return r.change(method, path, nil, nil, nil)
+}
+
+// Replace swaps the handler of the route method > path.
+func (r *Router) Replace(method, path string, h HandlerFunc, e *Echo) bool {
+	return r.change(method, path, h, e, nil)
+}
+
+// change replaces (h != nil) or removes the route method > path, then swaps
+// in a tree rebuilt from the remaining registrations. The listed route is
+// dropped along, or renamed to handler if set.
+func (r *Router) change(method, path string, h HandlerFunc, e *Echo, handler Handler) bool {
+	r.wmu.Lock()
+	defer r.wmu.Unlock()
+	found := false
+	entries := make([]routeEntry, 0, len(r.entries))
+	for _, en := range r.entries {
+		if en.method == method && en.path == path {
+			found = true
+			if h == nil {
+				continue
+			}
+			en.h, en.e = h, e
+		}
+		entries = append(entries, en)
+	}
+	if !found {
+		return false
+	}
+	tmp := &Router{tree: &node{methodHandler: new(methodHandler)}}
+	for _, en := range entries {
+		tmp.add(en.method, en.path, en.h, en.e)
+	}
+	routes := make([]Route, 0, len(r.routes))
+	for _, rt := range r.routes {
+		if rt.Method == method && rt.Path == path {
+			if h == nil {
+				continue
+			}
+			if handler != nil {
+				rt.Handler = handler
+			}
+		}
+		routes = append(routes, rt)
+	}
+	r.entries = entries
+	r.mu.Lock()
+	r.tree = tmp.tree
+	r.routes = routes
+	r.mu.Unlock()
+	return true
+}
+
+func (r *Router) add(method, path string, h HandlerFunc, e *Echo) {
 	ppath := path        // Pristine path
 	pnames := []string{} // Param names
 
@@ -164,7 +261,7 @@ func (r *Router) insert(method, path string, h HandlerFunc, t kind, ppath string
 			// Node already exists
 			if h != nil {
 				cn.addHandler(method, h)
-				cn.ppath = path
+				cn.ppath = ppath
 				cn.pnames = pnames
 				cn.echo = e
 			}
@@ -238,6 +335,11 @@ func (n *node) addHandler(method string, h HandlerFunc) {
 		n.methodHandler.connect = h
 	case TRACE:
 		n.methodHandler.trace = h
+	default:
+		if n.methodHandler.custom == nil {
+			n.methodHandler.custom = make(map[string]HandlerFunc)
+		}
+		n.methodHandler.custom[method] = h
 	}
 }
 
@@ -262,7 +364,7 @@ func (n *node) findHandler(method string) HandlerFunc {
 	case TRACE:
 		return n.methodHandler.trace
 	default:
-		return nil
+		return n.methodHandler.custom[method]
 	}
 }
 
@@ -272,13 +374,24 @@ func (n *node) check405() HandlerFunc {
 			return methodNotAllowedHandler
 		}
 	}
+	for _, h := range n.methodHandler.custom {
+		if h != nil {
+			return methodNotAllowedHandler
+		}
+	}
 	return notFoundHandler
 }
 
 func (r *Router) Find(method, path string, ctx *Context) (h HandlerFunc, e *Echo) {
 	h = notFoundHandler
 	e = r.echo
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	cn := r.tree // Current node as root
+	t := ctx.trace
+	if t != nil {
+		defer func() { t.end(cn, method, ctx.pvalues) }()
+	}
 
 	var (
 		search = path
@@ -312,9 +425,18 @@ func (r *Router) Find(method, path string, ctx *Context) (h HandlerFunc, e *Echo
 		}
 
 		if l == pl {
+			if t != nil {
+				t.step(cn, "enter", search, "")
+			}
 			// Continue search
 			search = search[l:]
 		} else {
+			if t != nil {
+				t.step(cn, "reject", search, "prefix %q doesn't match", cn.prefix)
+				if nn != nil {
+					t.step(nn, "backtrack", ns, "")
+				}
+			}
 			cn = nn
 			search = ns
 			if nk == pkind {
@@ -343,6 +465,9 @@ func (r *Router) Find(method, path string, ctx *Context) (h HandlerFunc, e *Echo
 			cn = c
 			continue
 		}
+		if t != nil {
+			t.step(cn, "reject", search, "no static child for %q", search[:1])
+		}
 
 		// Param node
 	Param:
@@ -363,19 +488,30 @@ func (r *Router) Find(method, path string, ctx *Context) (h HandlerFunc, e *Echo
 			search = search[i:]
 			continue
 		}
+		if t != nil {
+			t.step(cn, "reject", search, "no param child")
+		}
 
 		// Match-any node
 	MatchAny:
 		// c = cn.getChild()
-		if cn = cn.findChildByKind(mkind); cn == nil {
+		if c = cn.findChildByKind(mkind); c == nil {
+			if t != nil {
+				t.step(cn, "reject", search, "no match-any child")
+			}
+			cn = nil
 			// Not found
 			return
 		}
+		cn = c
 		ctx.pvalues[len(cn.pnames)-1] = search
 		goto End
 	}
 
 End:
+	if t != nil {
+		t.step(cn, "match", search, "route %q", cn.ppath)
+	}
 	ctx.path = cn.ppath
 	ctx.pnames = cn.pnames
 	h = cn.findHandler(method)
@@ -389,9 +525,13 @@ End:
 
 		// Dig further for match-any, might have an empty value for *, e.g.
 		// serving a directory. Issue #207.
-		if cn = cn.findChildByKind(mkind); cn == nil {
+		if c = cn.findChildByKind(mkind); c == nil {
 			return
 		}
+		cn = c
+		if t != nil {
+			t.step(cn, "match", "", "route %q with an empty match-any", cn.ppath)
+		}
 		ctx.pvalues[len(cn.pnames)-1] = ""
 		if h = cn.findHandler(method); h == nil {
 			h = cn.check405()
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func routeA(c *Context) error { return c.String(http.StatusOK, "a") }
+func routeB(c *Context) error { return c.String(http.StatusOK, "b") }
+
+func TestReplaceRemoveRoutes(t *testing.T) {
+	e := New()
+	e.Get("/x", routeA)
+	e.Get("/y", routeA)
+	before := e.Routes()
+
+	assert.True(t, e.Replace(GET, "/x", routeB))
+	assert.False(t, e.Replace(GET, "/z", routeB))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(GET, "/x", nil))
+	assert.Equal(t, "b", rec.Body.String())
+	assert.Equal(t, "/x", e.URI(routeB))
+
+	assert.True(t, e.Remove(GET, "/y"))
+	assert.False(t, e.Remove(GET, "/y"))
+	routes := e.Routes()
+	assert.Equal(t, 1, len(routes))
+	assert.Equal(t, "/x", routes[0].Path)
+
+	// Slices returned earlier are left alone
+	assert.Equal(t, 2, len(before))
+	assert.Equal(t, "/y", before[1].Path)
+	assert.NotEqual(t, routes[0].Handler, before[0].Handler)
+}
+
+// TestRoutesConcurrency is meant for -race: routes may be changed while
+// being listed.
+func TestRoutesConcurrency(t *testing.T) {
+	e := New()
+	e.Get("/x", routeA)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			e.Replace(GET, "/x", routeB)
+			e.Get("/y", routeA)
+			e.Remove(GET, "/y")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			for _, r := range e.Routes() {
+				_ = r.Path
+			}
+			e.URI(routeB)
+		}
+	}()
+	wg.Wait()
+	assert.Equal(t, 1, len(e.Routes()))
+}
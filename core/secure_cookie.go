@@ -0,0 +1,178 @@
+package core
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type (
+	// SecureCookie signs, and optionally encrypts, cookie values so they can't
+	// be read or tampered with client-side.
+	SecureCookie struct {
+		// MaxAge bounds the age of the values accepted by Decode, whatever
+		// the expiry of the cookie says. Zero disables the check.
+		MaxAge time.Duration
+		keys   []secureCookieKey
+	}
+
+	// SecureCookieKey is a key pair of a SecureCookie.
+	SecureCookieKey struct {
+		// HashKey signs the values with HMAC-SHA256, at least 32 bytes are
+		// recommended.
+		HashKey []byte
+		// BlockKey encrypts the values with AES-GCM if set, it must be 16, 24
+		// or 32 bytes long.
+		BlockKey []byte
+	}
+
+	secureCookieKey struct {
+		hash []byte
+		aead cipher.AEAD
+	}
+)
+
+// Secure cookie errors.
+var (
+	ErrSecureCookieInvalid = errors.New("secure cookie: invalid value")
+	ErrSecureCookieExpired = errors.New("secure cookie: expired value")
+	ErrNoSecureCookie      = errors.New("secure cookie: not configured, see Echo.SetSecureCookie")
+)
+
+// NewSecureCookie returns a SecureCookie with a 30 days MaxAge. The first key
+// encodes the values, all of them decode, so keys can be rotated by adding a
+// new one in front and dropping the last one once the old cookies expired.
+func NewSecureCookie(keys ...SecureCookieKey) (*SecureCookie, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("secure cookie: no key")
+	}
+	s := &SecureCookie{MaxAge: 30 * 24 * time.Hour}
+	for _, k := range keys {
+		if len(k.HashKey) == 0 {
+			return nil, errors.New("secure cookie: empty hash key")
+		}
+		key := secureCookieKey{hash: k.HashKey}
+		if len(k.BlockKey) > 0 {
+			block, err := aes.NewCipher(k.BlockKey)
+			if err != nil {
+				return nil, err
+			}
+			if key.aead, err = cipher.NewGCM(block); err != nil {
+				return nil, err
+			}
+		}
+		s.keys = append(s.keys, key)
+	}
+	return s, nil
+}
+
+// Encode signs and encrypts value for the cookie name, created at now.
+func (s *SecureCookie) Encode(name, value string, now time.Time) (string, error) {
+	k := s.keys[0]
+	data := make([]byte, 8, 8+len(value))
+	binary.BigEndian.PutUint64(data, uint64(now.Unix()))
+	data = append(data, value...)
+	if k.aead != nil {
+		nonce := make([]byte, k.aead.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return "", err
+		}
+		data = k.aead.Seal(nonce, nonce, data, []byte(name))
+	}
+	enc := base64.RawURLEncoding.EncodeToString(data)
+	return enc + "." + base64.RawURLEncoding.EncodeToString(k.mac(name, enc)), nil
+}
+
+// Decode verifies and decrypts a value produced by Encode for the cookie name.
+func (s *SecureCookie) Decode(name, encoded string, now time.Time) (string, error) {
+	i := strings.LastIndexByte(encoded, '.')
+	if i == -1 {
+		return "", ErrSecureCookieInvalid
+	}
+	enc := encoded[:i]
+	sig, err := base64.RawURLEncoding.DecodeString(encoded[i+1:])
+	if err != nil {
+		return "", ErrSecureCookieInvalid
+	}
+	data, err := base64.RawURLEncoding.DecodeString(enc)
+	if err != nil {
+		return "", ErrSecureCookieInvalid
+	}
+	for _, k := range s.keys {
+		if !hmac.Equal(sig, k.mac(name, enc)) {
+			continue
+		}
+		if k.aead != nil {
+			ns := k.aead.NonceSize()
+			if len(data) < ns {
+				return "", ErrSecureCookieInvalid
+			}
+			if data, err = k.aead.Open(nil, data[:ns], data[ns:], []byte(name)); err != nil {
+				return "", ErrSecureCookieInvalid
+			}
+		}
+		if len(data) < 8 {
+			return "", ErrSecureCookieInvalid
+		}
+		created := time.Unix(int64(binary.BigEndian.Uint64(data)), 0)
+		if s.MaxAge > 0 && now.Sub(created) > s.MaxAge {
+			return "", ErrSecureCookieExpired
+		}
+		return string(data[8:]), nil
+	}
+	return "", ErrSecureCookieInvalid
+}
+
+func (k secureCookieKey) mac(name, enc string) []byte {
+	m := hmac.New(sha256.New, k.hash)
+	m.Write([]byte(name))
+	m.Write([]byte{'|'})
+	m.Write([]byte(enc))
+	return m.Sum(nil)
+}
+
+// SetSecureCookie sets the SecureCookie used by Context.SetSecureCookie and
+// Context.GetSecureCookie.
+func (e *Echo) SetSecureCookie(s *SecureCookie) {
+	e.env.secureCookie = s
+}
+
+// SetSecureCookie encodes the value of cookie with the SecureCookie of the
+// Echo and sends it, see WriteCookie and DefaultCookie.
+func (c *Context) SetSecureCookie(cookie *http.Cookie) error {
+	s := c.echo.env.secureCookie
+	if s == nil {
+		return ErrNoSecureCookie
+	}
+	v, err := s.Encode(cookie.Name, cookie.Value, c.Now())
+	if err != nil {
+		return err
+	}
+	cookie.Value = v
+	c.WriteCookie(cookie)
+	return nil
+}
+
+// GetSecureCookie returns the decoded value of the named cookie set with
+// SetSecureCookie. It returns http.ErrNoCookie if the cookie is missing and
+// ErrSecureCookieInvalid if it was tampered with.
+func (c *Context) GetSecureCookie(name string) (string, error) {
+	s := c.echo.env.secureCookie
+	if s == nil {
+		return "", ErrNoSecureCookie
+	}
+	cookie, err := c.request.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return s.Decode(name, cookie.Value, c.Now())
+}
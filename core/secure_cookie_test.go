@@ -0,0 +1,198 @@
+package core
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	hashKey1  = bytes.Repeat([]byte("h"), 32)
+	hashKey2  = bytes.Repeat([]byte("H"), 32)
+	blockKey1 = bytes.Repeat([]byte("b"), 32)
+	blockKey2 = bytes.Repeat([]byte("B"), 16)
+)
+
+func newSecureCookie(t *testing.T, keys ...SecureCookieKey) *SecureCookie {
+	s, err := NewSecureCookie(keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSecureCookieRoundTrip(t *testing.T) {
+	now := time.Date(2016, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, key := range []SecureCookieKey{
+		{HashKey: hashKey1},
+		{HashKey: hashKey1, BlockKey: blockKey1},
+	} {
+		s := newSecureCookie(t, key)
+		for _, v := range []string{"", "user=42", "a;b c,d\"é"} {
+			enc, err := s.Encode("session", v, now)
+			assert.NoError(t, err)
+			assert.False(t, strings.ContainsAny(enc, ";, \""), enc)
+			got, err := s.Decode("session", enc, now)
+			assert.NoError(t, err)
+			assert.Equal(t, v, got)
+		}
+	}
+
+	// Signed values can be read, encrypted ones can't
+	enc, _ := newSecureCookie(t, SecureCookieKey{HashKey: hashKey1}).Encode("session", "user=42", now)
+	data, _ := base64.RawURLEncoding.DecodeString(enc[:strings.IndexByte(enc, '.')])
+	assert.True(t, bytes.Contains(data, []byte("user=42")))
+	enc, _ = newSecureCookie(t, SecureCookieKey{HashKey: hashKey1, BlockKey: blockKey1}).Encode("session", "user=42", now)
+	data, _ = base64.RawURLEncoding.DecodeString(enc[:strings.IndexByte(enc, '.')])
+	assert.False(t, bytes.Contains(data, []byte("user=42")))
+}
+
+func TestSecureCookieTampering(t *testing.T) {
+	now := time.Date(2016, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, key := range []SecureCookieKey{
+		{HashKey: hashKey1},
+		{HashKey: hashKey1, BlockKey: blockKey1},
+	} {
+		s := newSecureCookie(t, key)
+		enc, _ := s.Encode("session", "user=42", now)
+		i := strings.IndexByte(enc, '.')
+		flip := func(s string, i int) string {
+			b := []byte(s)
+			if b[i] == 'A' {
+				b[i] = 'B'
+			} else {
+				b[i] = 'A'
+			}
+			return string(b)
+		}
+		forged := base64.RawURLEncoding.EncodeToString(append(make([]byte, 8), "user=1"...))
+		for _, v := range []string{
+			flip(enc, 0),     // value
+			flip(enc, i+1),   // signature
+			enc[:i],          // no signature
+			enc[:i] + ".",    // empty signature
+			forged + enc[i:], // another value
+			enc[:i] + ".!!",  // bad base64
+			"!!" + enc[i:],   // bad base64
+			enc + "A",        // longer signature
+			"",               // empty
+		} {
+			_, err := s.Decode("session", v, now)
+			assert.Equal(t, ErrSecureCookieInvalid, err, v)
+		}
+		// The value is bound to the cookie name
+		_, err := s.Decode("admin", enc, now)
+		assert.Equal(t, ErrSecureCookieInvalid, err)
+		// and to the key
+		_, err = newSecureCookie(t, SecureCookieKey{HashKey: hashKey2, BlockKey: key.BlockKey}).Decode("session", enc, now)
+		assert.Equal(t, ErrSecureCookieInvalid, err)
+	}
+}
+
+func TestSecureCookieExpiry(t *testing.T) {
+	now := time.Date(2016, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := newSecureCookie(t, SecureCookieKey{HashKey: hashKey1, BlockKey: blockKey1})
+	assert.Equal(t, 30*24*time.Hour, s.MaxAge)
+	s.MaxAge = time.Hour
+	enc, _ := s.Encode("session", "user=42", now)
+
+	v, err := s.Decode("session", enc, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, "user=42", v)
+	_, err = s.Decode("session", enc, now.Add(time.Hour+time.Second))
+	assert.Equal(t, ErrSecureCookieExpired, err)
+
+	// Zero MaxAge disables the check
+	s.MaxAge = 0
+	_, err = s.Decode("session", enc, now.Add(10*365*24*time.Hour))
+	assert.NoError(t, err)
+}
+
+func TestSecureCookieKeyRotation(t *testing.T) {
+	now := time.Date(2016, 3, 1, 12, 0, 0, 0, time.UTC)
+	oldKey := SecureCookieKey{HashKey: hashKey1, BlockKey: blockKey1}
+	newKey := SecureCookieKey{HashKey: hashKey2, BlockKey: blockKey2}
+	old := newSecureCookie(t, oldKey)
+	oldEnc, _ := old.Encode("session", "old", now)
+
+	// The new key encodes, both decode
+	rotated := newSecureCookie(t, newKey, oldKey)
+	newEnc, _ := rotated.Encode("session", "new", now)
+	v, err := rotated.Decode("session", oldEnc, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", v)
+	v, err = rotated.Decode("session", newEnc, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", v)
+	_, err = old.Decode("session", newEnc, now)
+	assert.Equal(t, ErrSecureCookieInvalid, err)
+
+	// Once the old key is dropped, its values are refused
+	_, err = newSecureCookie(t, newKey).Decode("session", oldEnc, now)
+	assert.Equal(t, ErrSecureCookieInvalid, err)
+}
+
+func TestNewSecureCookie(t *testing.T) {
+	for _, keys := range [][]SecureCookieKey{
+		nil,
+		{{}},
+		{{HashKey: hashKey1}, {BlockKey: blockKey1}},
+		{{HashKey: hashKey1, BlockKey: []byte("short")}},
+	} {
+		_, err := NewSecureCookie(keys...)
+		assert.Error(t, err)
+	}
+}
+
+func TestContextSecureCookie(t *testing.T) {
+	e := New()
+	clock := NewManualClock(time.Date(2016, 3, 1, 12, 0, 0, 0, time.UTC))
+	e.SetClock(clock)
+	newContext := func(cookie string) (*Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(GET, "/", nil)
+		if cookie != "" {
+			req.Header.Set("Cookie", cookie)
+		}
+		rec := httptest.NewRecorder()
+		return NewContext(req, NewResponse(rec, e), e), rec
+	}
+
+	// Not configured
+	c, _ := newContext("")
+	assert.Equal(t, ErrNoSecureCookie, c.SetSecureCookie(c.DefaultCookie("session", "user=42")))
+	_, err := c.GetSecureCookie("session")
+	assert.Equal(t, ErrNoSecureCookie, err)
+
+	s := newSecureCookie(t, SecureCookieKey{HashKey: hashKey1, BlockKey: blockKey1})
+	s.MaxAge = time.Hour
+	e.SetSecureCookie(s)
+	c, rec := newContext("")
+	assert.NoError(t, c.SetSecureCookie(c.DefaultCookie("session", "user=42")))
+	cookies := (&http.Response{Header: rec.Header()}).Cookies()
+	if assert.Equal(t, 1, len(cookies)) {
+		assert.True(t, cookies[0].HttpOnly)
+		assert.NotEqual(t, "user=42", cookies[0].Value)
+	}
+	sent := cookies[0].Name + "=" + cookies[0].Value
+
+	c, _ = newContext(sent)
+	v, err := c.GetSecureCookie("session")
+	assert.NoError(t, err)
+	assert.Equal(t, "user=42", v)
+
+	c, _ = newContext("")
+	_, err = c.GetSecureCookie("session")
+	assert.Equal(t, http.ErrNoCookie, err)
+
+	// The clock of the Echo tells the age of the value
+	clock.Advance(2 * time.Hour)
+	c, _ = newContext(sent)
+	_, err = c.GetSecureCookie("session")
+	assert.Equal(t, ErrSecureCookieExpired, err)
+}
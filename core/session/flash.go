@@ -0,0 +1,61 @@
+package session
+
+import "github.com/henrylee2cn/thinkgo/core"
+
+// flashKey is the session value holding the pending flash messages.
+const flashKey = "_flash"
+
+// Flash queues a message under key (e.g. "success", "error") for the next
+// request, typically the target of a redirect after a form post. It's a no-op
+// without the Sessions middleware.
+func Flash(c *core.Context, key, msg string) {
+	if s := Get(c); s != nil {
+		s.Flash(key, msg)
+	}
+}
+
+// Flashes returns the pending flash messages by key and clears them.
+func Flashes(c *core.Context) map[string][]string {
+	if s := Get(c); s != nil {
+		return s.Flashes()
+	}
+	return nil
+}
+
+// Flash queues a message under key for the next request.
+func (s *Session) Flash(key, msg string) {
+	flashes := s.peekFlashes()
+	if flashes == nil {
+		flashes = make(map[string][]string)
+	}
+	flashes[key] = append(flashes[key], msg)
+	s.Set(flashKey, flashes)
+}
+
+// Flashes returns the pending flash messages by key and clears them.
+func (s *Session) Flashes() map[string][]string {
+	flashes := s.peekFlashes()
+	s.Delete(flashKey)
+	return flashes
+}
+
+// peekFlashes reads the flash messages, which come back as generic maps from
+// stores that serialize values, e.g. to JSON.
+func (s *Session) peekFlashes() map[string][]string {
+	switch v := s.Get(flashKey).(type) {
+	case map[string][]string:
+		return v
+	case map[string]interface{}:
+		flashes := make(map[string][]string, len(v))
+		for key, msgs := range v {
+			list, _ := msgs.([]interface{})
+			for _, m := range list {
+				if m, ok := m.(string); ok {
+					flashes[key] = append(flashes[key], m)
+				}
+			}
+		}
+		return flashes
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlash(t *testing.T) {
+	e := core.New()
+	e.Use(Sessions(DefaultConfig))
+	e.Post("/form", func(c *core.Context) error {
+		Flash(c, "success", "saved")
+		Flash(c, "success", "mailed")
+		return c.Redirect(http.StatusSeeOther, "/")
+	})
+	e.Get("/", func(c *core.Context) error {
+		return c.JSON(http.StatusOK, Flashes(c))
+	})
+	serve := func(method string, ck []*http.Cookie) *httptest.ResponseRecorder {
+		path := "/"
+		if method == core.POST {
+			path = "/form"
+		}
+		req, _ := http.NewRequest(method, path, nil)
+		for _, c := range ck {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(core.POST, nil)
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	ck := (&http.Response{Header: rec.Header()}).Cookies()
+
+	rec = serve(core.GET, ck)
+	assert.Equal(t, `{"success":["saved","mailed"]}`, rec.Body.String())
+
+	// Cleared on read
+	rec = serve(core.GET, ck)
+	assert.Equal(t, "null", rec.Body.String())
+
+	// JSON round trip of serializing stores
+	s := &Session{values: map[string]interface{}{flashKey: map[string]interface{}{"error": []interface{}{"oops"}}}}
+	assert.Equal(t, map[string][]string{"error": {"oops"}}, s.Flashes())
+}
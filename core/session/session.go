@@ -0,0 +1,283 @@
+// Package session provides cookie-identified server side sessions for core.
+//
+//	e.Use(session.Sessions(session.DefaultConfig))
+//	...
+//	s := session.Get(c)
+//	s.Set("cart", items)
+package session
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// Store persists session values by session ID.
+	Store interface {
+		// Get returns the values of a session, nil if it doesn't exist or
+		// has expired.
+		Get(id string) (map[string]interface{}, error)
+		Save(id string, values map[string]interface{}, maxAge time.Duration) error
+		Delete(id string) error
+	}
+
+	// Config defines the config for the Sessions middleware.
+	Config struct {
+		Store Store
+
+		// Cookie options.
+		CookieName string
+		Path       string
+		Domain     string
+		Secure     bool
+		HTTPOnly   bool
+
+		// MaxAge is the lifetime of the session cookie and of stored sessions.
+		MaxAge time.Duration
+	}
+
+	// Session holds the values of one client session. It is saved to the store
+	// after the handler returns if it was modified.
+	Session struct {
+		id      string
+		values  map[string]interface{}
+		config  *Config
+		ctx     *core.Context
+		dirty   bool
+		removed []string // IDs to delete from the store
+	}
+
+	// MemoryStore is an in-process Store.
+	MemoryStore struct {
+		// Clock tells the time for expiry, the Echo clock once used by the
+		// Sessions middleware, else core.SystemClock if nil.
+		Clock core.Clock
+
+		mu       sync.Mutex
+		sessions map[string]memorySession
+		sweep    time.Time
+	}
+
+	memorySession struct {
+		values  map[string]interface{}
+		expires time.Time
+	}
+)
+
+// contextKey is where the session is kept in the core.Context store.
+const contextKey = "_session"
+
+// DefaultConfig is the default Sessions middleware config.
+var DefaultConfig = Config{
+	CookieName: "THINKGOSESSID",
+	Path:       "/",
+	HTTPOnly:   true,
+	MaxAge:     24 * time.Hour,
+}
+
+// Sessions returns a middleware which loads the session of the request. A
+// memory store is used if config.Store is nil.
+func Sessions(config Config) core.MiddlewareFunc {
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	if config.CookieName == "" {
+		config.CookieName = DefaultConfig.CookieName
+	}
+	if config.Path == "" {
+		config.Path = DefaultConfig.Path
+	}
+	if config.MaxAge == 0 {
+		config.MaxAge = DefaultConfig.MaxAge
+	}
+	var once sync.Once
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			if m, ok := config.Store.(*MemoryStore); ok {
+				once.Do(func() { m.defaultClock(c.Echo().Clock()) })
+			}
+			s := &Session{config: &config, ctx: c}
+			if id := c.GetCookie(config.CookieName); id != "" {
+				values, err := config.Store.Get(id)
+				if err != nil {
+					return err
+				}
+				if values != nil {
+					s.id, s.values = id, values
+				}
+			}
+			c.Set(contextKey, s)
+			err := next(c)
+			if serr := s.save(); err == nil {
+				err = serr
+			}
+			return err
+		}
+	}
+}
+
+// Get returns the session of the request, nil without the Sessions middleware.
+func Get(c *core.Context) *Session {
+	s, _ := c.Get(contextKey).(*Session)
+	return s
+}
+
+// ID returns the session ID, empty until a value is set.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Get returns a session value.
+func (s *Session) Get(key string) interface{} {
+	return s.values[key]
+}
+
+// Set sets a session value.
+func (s *Session) Set(key string, value interface{}) {
+	if s.id == "" {
+		s.renew()
+	}
+	if s.values == nil {
+		s.values = map[string]interface{}{}
+	}
+	s.values[key] = value
+	s.dirty = true
+}
+
+// Delete removes a session value.
+func (s *Session) Delete(key string) {
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.dirty = true
+	}
+}
+
+// Values returns all session values.
+func (s *Session) Values() map[string]interface{} {
+	return s.values
+}
+
+// Regenerate moves the session to a new ID, keeping its values. Call it when
+// the privilege level changes (login, logout) to prevent session fixation.
+func (s *Session) Regenerate() {
+	if s.id != "" {
+		s.removed = append(s.removed, s.id)
+	}
+	s.renew()
+	s.dirty = true
+}
+
+// Destroy removes the session and expires its cookie.
+func (s *Session) Destroy() {
+	if s.id != "" {
+		s.removed = append(s.removed, s.id)
+	}
+	s.id, s.values, s.dirty = "", nil, false
+	s.setCookie("", -1)
+}
+
+// renew picks a new ID from the Echo ID generator and sends the cookie right
+// away, before the handler gets a chance to commit the response.
+func (s *Session) renew() {
+	s.id = s.ctx.NewID()
+	s.setCookie(s.id, int(s.config.MaxAge/time.Second))
+}
+
+func (s *Session) setCookie(value string, maxAge int) {
+	c := s.config
+	http.SetCookie(s.ctx.Response().Writer(), &http.Cookie{
+		Name:     c.CookieName,
+		Value:    value,
+		Path:     c.Path,
+		Domain:   c.Domain,
+		MaxAge:   maxAge,
+		Secure:   c.Secure,
+		HttpOnly: c.HTTPOnly,
+	})
+}
+
+func (s *Session) save() error {
+	for _, id := range s.removed {
+		if err := s.config.Store.Delete(id); err != nil {
+			return err
+		}
+	}
+	s.removed = nil
+	if !s.dirty || s.id == "" {
+		return nil
+	}
+	s.dirty = false
+	return s.config.Store.Save(s.id, s.values, s.config.MaxAge)
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: map[string]memorySession{}}
+}
+
+// Get implements Store.
+func (m *MemoryStore) Get(id string) (map[string]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	if m.now().After(s.expires) {
+		delete(m.sessions, id)
+		return nil, nil
+	}
+	values := make(map[string]interface{}, len(s.values))
+	for k, v := range s.values {
+		values[k] = v
+	}
+	return values, nil
+}
+
+// Save implements Store. Expired sessions are dropped once per minute.
+func (m *MemoryStore) Save(id string, values map[string]interface{}, maxAge time.Duration) error {
+	v := make(map[string]interface{}, len(values))
+	for k, val := range values {
+		v[k] = val
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	if now.After(m.sweep) {
+		for id, s := range m.sessions {
+			if now.After(s.expires) {
+				delete(m.sessions, id)
+			}
+		}
+		m.sweep = now.Add(time.Minute)
+	}
+	m.sessions[id] = memorySession{v, now.Add(maxAge)}
+	return nil
+}
+
+// Delete implements Store.
+func (m *MemoryStore) Delete(id string) error {
+	m.mu.Lock()
+	delete(m.sessions, id)
+	m.mu.Unlock()
+	return nil
+}
+
+// defaultClock sets the clock unless one was given.
+func (m *MemoryStore) defaultClock(clock core.Clock) {
+	m.mu.Lock()
+	if m.Clock == nil {
+		m.Clock = clock
+	}
+	m.mu.Unlock()
+}
+
+func (m *MemoryStore) now() time.Time {
+	if m.Clock == nil {
+		return time.Now()
+	}
+	return m.Clock.Now()
+}
@@ -0,0 +1,94 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessions(t *testing.T) {
+	e := core.New()
+	e.Use(Sessions(DefaultConfig))
+	e.Get("/set", func(c *core.Context) error {
+		Get(c).Set("n", 1)
+		return c.NoContent(http.StatusOK)
+	})
+	e.Get("/get", func(c *core.Context) error {
+		n, _ := Get(c).Get("n").(int)
+		return c.JSON(http.StatusOK, n)
+	})
+	e.Get("/destroy", func(c *core.Context) error {
+		Get(c).Destroy()
+		return c.NoContent(http.StatusOK)
+	})
+
+	serve := func(path string, ck *http.Cookie) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(core.GET, path, nil)
+		if ck != nil {
+			req.AddCookie(ck)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("/get", nil)
+	assert.Equal(t, "0", rec.Body.String())
+	assert.Equal(t, "", rec.Header().Get("Set-Cookie"))
+
+	cookies := (&http.Response{Header: serve("/set", nil).Header()}).Cookies()
+	assert.Equal(t, 1, len(cookies))
+	assert.Equal(t, "1", serve("/get", cookies[0]).Body.String())
+
+	serve("/destroy", cookies[0])
+	assert.Equal(t, "0", serve("/get", cookies[0]).Body.String())
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	clock := core.NewManualClock(time.Unix(1000, 0))
+	m := NewMemoryStore()
+	m.Clock = clock
+	m.Save("a", map[string]interface{}{"n": 1}, time.Second)
+	clock.Advance(2 * time.Minute)
+	m.Save("b", map[string]interface{}{"n": 2}, time.Hour)
+	assert.Equal(t, 1, len(m.sessions))
+	v, _ := m.Get("b")
+	assert.Equal(t, 2, v["n"])
+}
+
+func TestMemoryStoreEchoClock(t *testing.T) {
+	e := core.New()
+	clock := core.NewManualClock(time.Unix(1000, 0))
+	e.SetClock(clock)
+	config := DefaultConfig
+	config.Store = NewMemoryStore()
+	config.MaxAge = time.Hour
+	e.Use(Sessions(config))
+	e.Get("/", func(c *core.Context) error {
+		s := Get(c)
+		n, _ := s.Get("n").(int)
+		s.Set("n", n+1)
+		return c.JSON(http.StatusOK, n+1)
+	})
+
+	serve := func(ck *http.Cookie) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(core.GET, "/", nil)
+		if ck != nil {
+			req.AddCookie(ck)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	cookies := (&http.Response{Header: serve(nil).Header()}).Cookies()
+	assert.Equal(t, clock, config.Store.(*MemoryStore).Clock)
+	assert.Equal(t, "2", serve(cookies[0]).Body.String())
+
+	// The session expires on the Echo clock
+	clock.Advance(2 * time.Hour)
+	assert.Equal(t, "1", serve(cookies[0]).Body.String())
+}
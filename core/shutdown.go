@@ -0,0 +1,171 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core/websocket"
+)
+
+type (
+	// ShutdownReport tells what happened during Shutdown or Close, e.g. to
+	// tune the drain timeout.
+	ShutdownReport struct {
+		Started  time.Time
+		Duration time.Duration
+
+		// InFlight is the number of requests being served when the shutdown
+		// began, Drained the ones which completed in time and Abandoned the
+		// ones still running when it gave up.
+		InFlight  int
+		Drained   int
+		Abandoned int
+
+		// WebSockets is the number of WebSocket connections closed.
+		WebSockets int
+
+		// Hooks reports the OnShutdown hooks, in the order they ran.
+		Hooks []HookReport
+
+		// Errors are the errors of the servers and of the hooks.
+		Errors []error
+	}
+
+	// HookReport reports a shutdown hook or a cache warmer.
+	HookReport struct {
+		Name     string
+		Duration time.Duration
+		// Err is the value the hook panicked with, as an error, or the error
+		// the cache warmer returned.
+		Err error
+	}
+)
+
+// ShutdownReport returns the report of the last Shutdown or Close, nil if the
+// servers weren't stopped yet.
+func (e *Echo) ShutdownReport() *ShutdownReport {
+	e.hooks.Lock()
+	defer e.hooks.Unlock()
+	return e.hooks.report
+}
+
+// String summarizes the report on one line.
+func (r *ShutdownReport) String() string {
+	return fmt.Sprintf("shutdown in %v: %d/%d requests drained, %d abandoned, %d WebSockets closed, %d hooks, %d errors",
+		r.Duration, r.Drained, r.InFlight, r.Abandoned, r.WebSockets, len(r.Hooks), len(r.Errors))
+}
+
+// stop stops the servers with fn, runs the hooks and reports.
+func (e *Echo) stop(fn func(*http.Server) error) error {
+	clock := e.env.clock
+	r := &ShutdownReport{
+		Started:  clock.Now(),
+		InFlight: int(atomic.LoadInt64(&e.servers.inflight)),
+	}
+	var err error
+	for _, s := range e.servers.take() {
+		if serr := fn(s); serr != nil {
+			r.Errors = append(r.Errors, serr)
+			if err == nil {
+				err = serr
+			}
+		}
+	}
+	// Once the servers are stopped, as no WebSocket can be added anymore.
+	r.WebSockets = e.servers.closeSockets()
+	r.Abandoned = int(atomic.LoadInt64(&e.servers.inflight))
+	if r.Drained = r.InFlight - r.Abandoned; r.Drained < 0 {
+		r.Drained = 0
+	}
+	r.Hooks = e.hooks.run(clock)
+	for _, h := range r.Hooks {
+		if h.Err != nil {
+			r.Errors = append(r.Errors, h.Err)
+		}
+	}
+	r.Duration = clock.Now().Sub(r.Started)
+
+	e.hooks.Lock()
+	e.hooks.report = r
+	e.hooks.Unlock()
+	if len(r.Errors) > 0 || r.Abandoned > 0 {
+		e.logger.Warn("%v", r)
+	} else {
+		e.logger.Notice("%v", r)
+	}
+	for _, h := range r.Hooks {
+		if h.Err != nil {
+			e.logger.Error("\thook %s failed after %v: %v", h.Name, h.Duration, h.Err)
+		} else {
+			e.logger.Info("\thook %s took %v", h.Name, h.Duration)
+		}
+	}
+	return err
+}
+
+// run runs the shutdown hooks, if not already run, and reports them.
+func (l *shutdownHooks) run(clock Clock) []HookReport {
+	l.Lock()
+	hooks := l.hooks
+	l.hooks = nil
+	l.Unlock()
+	reports := make([]HookReport, 0, len(hooks))
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := HookReport{Name: runtime.FuncForPC(reflect.ValueOf(hooks[i]).Pointer()).Name()}
+		start := clock.Now()
+		h.Err = runHook(hooks[i])
+		h.Duration = clock.Now().Sub(start)
+		reports = append(reports, h)
+	}
+	return reports
+}
+
+func runHook(fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if err, _ = r.(error); err == nil {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+	fn()
+	return nil
+}
+
+// addSocket tracks ws until removeSocket, it fails once the servers were
+// stopped.
+func (l *serverList) addSocket(ws *websocket.Conn) bool {
+	l.Lock()
+	defer l.Unlock()
+	if l.stopped {
+		return false
+	}
+	if l.sockets == nil {
+		l.sockets = make(map[*websocket.Conn]struct{})
+	}
+	l.sockets[ws] = struct{}{}
+	return true
+}
+
+func (l *serverList) removeSocket(ws *websocket.Conn) {
+	l.Lock()
+	delete(l.sockets, ws)
+	l.Unlock()
+}
+
+// closeSockets closes the open WebSockets, which the servers don't track once
+// upgraded, and returns how many.
+func (l *serverList) closeSockets() int {
+	l.Lock()
+	sockets := l.sockets
+	l.sockets = nil
+	l.Unlock()
+	for ws := range sockets {
+		ws.Close()
+	}
+	return len(sockets)
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// running waits until n servers of e are running.
+func running(e *Echo, n int) {
+	for {
+		e.servers.Lock()
+		l := len(e.servers.list)
+		e.servers.Unlock()
+		if l == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	e := New()
+	var hooks []string
+	e.OnShutdown(func() { hooks = append(hooks, "first") })
+	e.OnShutdown(func() { hooks = append(hooks, "second") })
+
+	done := make(chan error)
+	go func() {
+		done <- e.Run("127.0.0.1:0")
+	}()
+	running(e, 1)
+	assert.NoError(t, e.Shutdown(context.Background()))
+	assert.NoError(t, <-done)
+	assert.Equal(t, []string{"second", "first"}, hooks)
+	r := e.ShutdownReport()
+	assert.Equal(t, 2, len(r.Hooks))
+
+	// Servers can't run anymore, hooks only run once.
+	assert.Equal(t, ServerStopped, e.Run("127.0.0.1:0"))
+	assert.NoError(t, e.Close())
+	assert.Equal(t, 2, len(hooks))
+}
+
+func TestShutdownWebSockets(t *testing.T) {
+	e := New()
+	opened := make(chan struct{}, 2)
+	e.WebSocket("/ws", func(c *Context) error {
+		opened <- struct{}{}
+		var msg string
+		return websocket.Message.Receive(c.Socket(), &msg)
+	})
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+
+	ws, err := websocket.Dial(url, "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	<-opened
+	assert.NoError(t, e.Shutdown(context.Background()))
+	assert.Equal(t, 1, e.ShutdownReport().WebSockets)
+	var msg string
+	assert.Error(t, websocket.Message.Receive(ws, &msg))
+
+	// WebSockets upgraded after the shutdown began are closed right away
+	ws2, err := websocket.Dial(url, "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws2.Close()
+	assert.Error(t, websocket.Message.Receive(ws2, &msg))
+	assert.Equal(t, 0, len(opened))
+	e.servers.Lock()
+	assert.Equal(t, 0, len(e.servers.sockets))
+	e.servers.Unlock()
+}
+
+func TestShutdownReport(t *testing.T) {
+	e := New()
+	clock := NewManualClock(time.Date(2016, 3, 1, 12, 0, 0, 0, time.UTC))
+	e.SetClock(clock)
+	e.OnShutdown(func() { clock.Advance(time.Second) })
+	e.OnShutdown(func() { panic("boom") })
+	assert.NoError(t, e.Close())
+	r := e.ShutdownReport()
+	assert.Equal(t, time.Second, r.Duration)
+	if assert.Equal(t, 2, len(r.Hooks)) {
+		assert.Equal(t, "boom", r.Hooks[0].Err.Error())
+		assert.Equal(t, time.Second, r.Hooks[1].Duration)
+		assert.NoError(t, r.Hooks[1].Err)
+	}
+	assert.Equal(t, 1, len(r.Errors))
+	assert.Equal(t, "shutdown in 1s: 0/0 requests drained, 0 abandoned, 0 WebSockets closed, 2 hooks, 1 errors", r.String())
+}
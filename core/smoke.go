@@ -0,0 +1,156 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+type (
+	// SmokeConfig configures Echo.Smoke.
+	SmokeConfig struct {
+		// Params are the sample values of the path params by name, e.g.
+		// {"id": "42"}, "*" for the wildcards. Other params are "1".
+		Params map[string]string
+
+		// Header is sent with every request, e.g. the Authorization of a test
+		// account.
+		Header http.Header
+
+		// Skip leaves routes out, e.g. the ones with side effects.
+		Skip func(Route) bool
+	}
+
+	// SmokeReport is the result of Echo.Smoke.
+	SmokeReport struct {
+		Results []SmokeResult
+		// Failed is the number of results which aren't OK.
+		Failed int
+	}
+
+	// SmokeResult is the outcome of the request to a route.
+	SmokeResult struct {
+		Path     string
+		URL      string
+		Status   int
+		Duration time.Duration
+		// Panic is the value the handler panicked with, past the middleware.
+		Panic interface{}
+	}
+)
+
+// SmokeEnv is the environment variable which makes Think.Run smoke test the
+// routes instead of serving them, its value being the sample params encoded
+// like a query string, e.g. "id=42&slug=hello", or just "1".
+const SmokeEnv = "THINKGO_SMOKE"
+
+// Smoke requests every GET route in process, WebSockets excepted, with the
+// sample params of config, and reports the outcomes, e.g. as a post-deploy
+// smoke test. The requests go through the middleware, like real ones.
+func (e *Echo) Smoke(config SmokeConfig) *SmokeReport {
+	r := new(SmokeReport)
+	for _, route := range e.Routes() {
+		if route.Method != GET || route.WebSocket || (config.Skip != nil && config.Skip(route)) {
+			continue
+		}
+		res := e.smoke(route.Path, config)
+		if !res.OK() {
+			r.Failed++
+		}
+		r.Results = append(r.Results, res)
+	}
+	return r
+}
+
+func (e *Echo) smoke(path string, config SmokeConfig) (res SmokeResult) {
+	res.Path = path
+	res.URL = smokeURL(path, config.Params)
+	req, err := http.NewRequest(GET, res.URL, nil)
+	if err != nil {
+		res.Panic = err
+		return
+	}
+	for k, v := range config.Header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	start := e.env.clock.Now()
+	defer func() {
+		res.Duration = e.env.clock.Now().Sub(start)
+		if v := recover(); v != nil {
+			res.Panic = v
+		}
+	}()
+	e.ServeHTTP(rec, req)
+	res.Status = rec.Code
+	return
+}
+
+// smokeURL fills the params of a route path.
+func smokeURL(path string, params map[string]string) string {
+	segs := strings.Split(path, "/")
+	for i, s := range segs {
+		if s == "*" || strings.HasPrefix(s, ":") {
+			v, ok := params[strings.TrimPrefix(s, ":")]
+			if !ok {
+				v = "1"
+			}
+			if s != "*" {
+				v = url.PathEscape(v)
+			}
+			segs[i] = v
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+// OK reports whether the route answered with a success or a redirection.
+func (r SmokeResult) OK() bool {
+	return r.Panic == nil && r.Status >= 200 && r.Status < 400
+}
+
+// String lists the results, failures first marked with "FAIL".
+func (r *SmokeReport) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "smoke test: %d routes, %d failed\n", len(r.Results), r.Failed)
+	for _, ok := range []bool{false, true} {
+		for _, res := range r.Results {
+			if res.OK() != ok {
+				continue
+			}
+			mark := "ok  "
+			if !ok {
+				mark = "FAIL"
+			}
+			fmt.Fprintf(&buf, "%s %3d %-30s %v", mark, res.Status, res.URL, res.Duration)
+			if res.Panic != nil {
+				fmt.Fprintf(&buf, " panic: %v", res.Panic)
+			}
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.String()
+}
+
+// smokeFromEnv runs Smoke with the params of SmokeEnv, a nil report meaning
+// it isn't set.
+func (e *Echo) smokeFromEnv() (*SmokeReport, error) {
+	v := os.Getenv(SmokeEnv)
+	if v == "" {
+		return nil, nil
+	}
+	q, err := url.ParseQuery(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", SmokeEnv, err)
+	}
+	params := make(map[string]string, len(q))
+	for k := range q {
+		params[k] = q.Get(k)
+	}
+	return e.Smoke(SmokeConfig{Params: params}), nil
+}
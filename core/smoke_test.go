@@ -0,0 +1,108 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSmoke(t *testing.T) {
+	e := New()
+	e.Get("/", func(c *Context) error {
+		return c.String(http.StatusOK, "home")
+	})
+	e.Get("/users/:id", func(c *Context) error {
+		if c.Param("id") != "42" {
+			return c.NoContent(http.StatusNotFound)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+	e.Get("/panic", func(c *Context) error {
+		panic("boom")
+	})
+	e.Get("/skipped", func(c *Context) error {
+		return c.NoContent(http.StatusInternalServerError)
+	})
+	e.Post("/users", func(c *Context) error {
+		return c.NoContent(http.StatusInternalServerError)
+	})
+	e.WebSocket("/ws", func(c *Context) error {
+		return nil
+	})
+	for _, r := range e.Routes() {
+		assert.Equal(t, r.Path == "/ws", r.WebSocket, r.Path)
+	}
+
+	r := e.Smoke(SmokeConfig{
+		Params: map[string]string{"id": "42"},
+		Skip:   func(r Route) bool { return r.Path == "/skipped" },
+	})
+	results := map[string]SmokeResult{}
+	for _, res := range r.Results {
+		results[res.URL] = res
+	}
+	assert.Equal(t, 3, len(results))
+	assert.Equal(t, 1, r.Failed)
+	assert.True(t, results["/"].OK())
+	assert.True(t, results["/users/42"].OK())
+	assert.Equal(t, "boom", results["/panic"].Panic)
+	assert.Contains(t, r.String(), "FAIL   0 /panic")
+
+	// Missing params default to 1
+	r = e.Smoke(SmokeConfig{Skip: func(r Route) bool { return r.Path != "/users/:id" }})
+	assert.Equal(t, "/users/1", r.Results[0].URL)
+	assert.Equal(t, http.StatusNotFound, r.Results[0].Status)
+	assert.Equal(t, 1, r.Failed)
+}
+
+func TestSmokeURL(t *testing.T) {
+	tests := []struct {
+		path   string
+		params map[string]string
+		url    string
+	}{
+		{"/", nil, "/"},
+		{"/users/:id", nil, "/users/1"},
+		{"/users/:id/posts/:slug", map[string]string{"slug": "a b/c"}, "/users/1/posts/a%20b%2Fc"},
+		{"/static/*", map[string]string{"*": "css/app.css"}, "/static/css/app.css"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.url, smokeURL(tt.path, tt.params), fmt.Sprint(tt.path))
+	}
+}
+
+func TestSmokeFromEnv(t *testing.T) {
+	e := New()
+	e.Get("/users/:id", func(c *Context) error {
+		if c.Param("id") != "42" {
+			return c.NoContent(http.StatusNotFound)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+	defer os.Unsetenv(SmokeEnv)
+
+	os.Unsetenv(SmokeEnv)
+	r, err := e.smokeFromEnv()
+	assert.NoError(t, err)
+	assert.True(t, r == nil)
+
+	os.Setenv(SmokeEnv, "id=42")
+	r, err = e.smokeFromEnv()
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, r.Failed)
+		assert.Equal(t, "/users/42", r.Results[0].URL)
+	}
+
+	os.Setenv(SmokeEnv, "1")
+	r, err = e.smokeFromEnv()
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, r.Failed)
+	}
+
+	os.Setenv(SmokeEnv, "id=%zz")
+	_, err = e.smokeFromEnv()
+	assert.Error(t, err)
+}
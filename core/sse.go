@@ -0,0 +1,167 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSSEHeartbeat is how often an idle event stream gets a heartbeat
+// comment by default, well below the idle timeouts of common proxies.
+const DefaultSSEHeartbeat = 15 * time.Second
+
+// ErrEventWriterClosed is returned by the EventWriter methods once it's closed.
+var ErrEventWriterClosed = errors.New("event writer closed")
+
+// EventWriter sends Server-Sent Events, see Context.SSE. It's safe for
+// concurrent use.
+type EventWriter struct {
+	c      *Context
+	ctx    context.Context
+	mu     sync.Mutex
+	closed bool
+	done   chan struct{}
+}
+
+// SetSSEHeartbeat sets how often an idle event stream gets a heartbeat
+// comment, DefaultSSEHeartbeat by default, negative disables them.
+func (e *Echo) SetSSEHeartbeat(d time.Duration) {
+	e.env.sseHeartbeat = d
+}
+
+// SSE starts a stream of Server-Sent Events, e.g. to push updates to a
+// dashboard without WebSockets: it sends the text/event-stream headers and
+// returns the writer of the events, which stays usable until the handler
+// returns or the client goes away.
+//
+//	e.Get("/events", func(c *core.Context) error {
+//		w := c.SSE()
+//		for u := range updates {
+//			if err := w.Send("update", u, ""); err != nil {
+//				return nil
+//			}
+//		}
+//		return nil
+//	})
+func (c *Context) SSE() *EventWriter {
+	if c.sse != nil {
+		return c.sse
+	}
+	h := c.response.Header()
+	h.Set(ContentType, TextEventStream)
+	h.Set(CacheControlHeader, "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	c.response.WriteHeader(http.StatusOK)
+	w := &EventWriter{c: c, ctx: c.StdContext(), done: make(chan struct{})}
+	w.flush()
+	interval := c.echo.env.sseHeartbeat
+	if interval == 0 {
+		interval = DefaultSSEHeartbeat
+	}
+	if interval > 0 {
+		go w.heartbeat(interval)
+	}
+	c.sse = w
+	return w
+}
+
+// LastEventID returns the Last-Event-ID header the client sends when it
+// reconnects, the id of the last event it got, to resume the stream from.
+func (w *EventWriter) LastEventID() string {
+	return w.c.request.Header.Get(LastEventID)
+}
+
+// Send sends and flushes an event. The event type and id are optional, data
+// is sent as is if it's a string or a []byte, as JSON otherwise. It fails
+// once the client is gone.
+func (w *EventWriter) Send(event string, data interface{}, id string) error {
+	var s string
+	switch d := data.(type) {
+	case string:
+		s = d
+	case []byte:
+		s = string(d)
+	default:
+		b, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		s = string(b)
+	}
+	var b bytes.Buffer
+	if id != "" {
+		b.WriteString("id: " + sseField(id) + "\n")
+	}
+	if event != "" {
+		b.WriteString("event: " + sseField(event) + "\n")
+	}
+	s = strings.Replace(strings.Replace(s, "\r\n", "\n", -1), "\r", "\n", -1)
+	for _, line := range strings.Split(s, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	return w.write(b.String())
+}
+
+// Comment sends a comment, which clients ignore.
+func (w *EventWriter) Comment(text string) error {
+	return w.write(": " + sseField(text) + "\n\n")
+}
+
+// Close stops the heartbeats; it's done when the handler returns.
+func (w *EventWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.closed {
+		w.closed = true
+		close(w.done)
+	}
+	return nil
+}
+
+func (w *EventWriter) write(s string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrEventWriterClosed
+	}
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := w.c.response.Write([]byte(s)); err != nil {
+		return err
+	}
+	w.flush()
+	return nil
+}
+
+func (w *EventWriter) flush() {
+	(&streamWriter{c: w.c}).flush()
+}
+
+func (w *EventWriter) heartbeat(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if w.Comment("heartbeat") != nil {
+				return
+			}
+		case <-w.done:
+			return
+		case <-w.ctx.Done():
+			return
+		}
+	}
+}
+
+// sseField strips the line breaks from a single line field.
+func sseField(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSE(t *testing.T) {
+	e := New()
+	e.SetSSEHeartbeat(time.Millisecond)
+	send := make(chan func(w *EventWriter))
+	e.Get("/", func(c *Context) error {
+		w := c.SSE()
+		for fn := range send {
+			fn(w)
+		}
+		return nil
+	})
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(GET, srv.URL, nil)
+	req.Header.Set(LastEventID, "41")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	assert.Equal(t, TextEventStream, res.Header.Get(ContentType))
+	assert.Equal(t, "no-cache", res.Header.Get(CacheControlHeader))
+	lines := readLines(res.Body)
+
+	// Idle streams get heartbeats
+	assert.Equal(t, ": heartbeat", <-lines)
+
+	// next returns the next event, skipping the heartbeats
+	next := func(fn func(w *EventWriter)) []string {
+		send <- fn
+		var event []string
+		for l := range lines {
+			switch {
+			case l == "" && len(event) > 0:
+				return event
+			case l != "" && l != ": heartbeat":
+				event = append(event, l)
+			}
+		}
+		return event
+	}
+	assert.Equal(t, []string{"id: 42", "event: update", "data: line 1", "data: line 2", "data: line 3"}, next(func(w *EventWriter) {
+		assert.Equal(t, "41", w.LastEventID())
+		assert.NoError(t, w.Send("update", "line 1\r\nline 2\rline 3", "42"))
+	}))
+	assert.Equal(t, []string{"event: doc", `data: {"a":1}`}, next(func(w *EventWriter) {
+		assert.NoError(t, w.Send("doc", map[string]int{"a": 1}, ""))
+	}))
+	assert.Equal(t, []string{"event: injected", "data: x"}, next(func(w *EventWriter) {
+		assert.NoError(t, w.Send("inj\necte\rd", []byte("x"), ""))
+	}))
+	assert.Equal(t, []string{": acomment"}, next(func(w *EventWriter) {
+		assert.NoError(t, w.Comment("a\ncomment"))
+	}))
+	close(send)
+}
+
+// TestEventWriterConcurrency is meant for -race: events, heartbeats and Close
+// may run at the same time.
+func TestEventWriterConcurrency(t *testing.T) {
+	e := New()
+	e.SetSSEHeartbeat(time.Microsecond)
+	rec := httptest.NewRecorder()
+	c := NewContext(httptest.NewRequest(GET, "/", nil), NewResponse(rec, e), e)
+	w := c.SSE()
+	assert.True(t, w == c.SSE())
+
+	var wg sync.WaitGroup
+	sent := make(chan struct{}, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; w.Send("tick", "x", "") == nil; n++ {
+				if n == 10 {
+					sent <- struct{}{}
+				}
+			}
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		<-sent
+	}
+	assert.NoError(t, w.Close())
+	assert.NoError(t, w.Close())
+	wg.Wait()
+	assert.Equal(t, ErrEventWriterClosed, w.Send("tick", "x", ""))
+	assert.Equal(t, ErrEventWriterClosed, w.Comment("x"))
+	body := rec.Body.String()
+	assert.True(t, strings.HasSuffix(body, "\n\n"))
+	assert.Contains(t, body, "event: tick\ndata: x\n\n")
+}
+
+func TestEventWriterCanceled(t *testing.T) {
+	e := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	c := NewContext(httptest.NewRequest(GET, "/", nil).WithContext(ctx), NewResponse(httptest.NewRecorder(), e), e)
+	w := c.SSE()
+	defer w.Close()
+	assert.NoError(t, w.Send("", "x", ""))
+	cancel()
+	assert.Equal(t, context.Canceled, w.Send("", "x", ""))
+}
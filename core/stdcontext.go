@@ -0,0 +1,20 @@
+package core
+
+import (
+	"context"
+)
+
+// StdContext returns the standard context of the request. It is canceled when
+// the client goes away or the server shuts down, and carries the deadline set
+// by middleware such as Timeout.
+func (c *Context) StdContext() context.Context {
+	return c.request.Context()
+}
+
+// SetStdContext replaces the request's context, e.g. with a derived context
+// carrying a deadline or values. Downstream handlers see it through
+// StdContext, the request and the embedded context of c.
+func (c *Context) SetStdContext(ctx context.Context) {
+	c.request = c.request.WithContext(ctx)
+	c.Context = ctx
+}
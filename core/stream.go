@@ -0,0 +1,37 @@
+package core
+
+import (
+	"io"
+	"net/http"
+)
+
+// Stream sends the content of r with status code and content type, writing
+// and flushing each chunk as soon as it's read, so a large export or a proxied
+// body doesn't need to be buffered in memory. It stops with the error of the
+// request's context once it's canceled or past its deadline.
+func (c *Context) Stream(code int, contentType string, r io.Reader) error {
+	ctx := c.StdContext()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.response.Header().Set(ContentType, contentType)
+	c.response.WriteHeader(code)
+	_, err := io.Copy(&streamWriter{c: c, ctx: ctx}, r)
+	return err
+}
+
+// StreamWriter calls fn with a writer sending to the response, flushing after
+// every write, and returns the error of the request's context if it's done
+// by then, so fn should give up once a write fails. Headers must be set
+// before, the status code is 200 unless already sent.
+func (c *Context) StreamWriter(fn func(w io.Writer)) error {
+	ctx := c.StdContext()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !c.response.committed {
+		c.response.WriteHeader(http.StatusOK)
+	}
+	fn(&streamWriter{c: c, ctx: ctx})
+	return ctx.Err()
+}
@@ -0,0 +1,315 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rendererFunc adapts a function to Renderer.
+type rendererFunc func(w io.Writer, name string, data interface{}) error
+
+func (f rendererFunc) Render(w io.Writer, name string, data interface{}) error {
+	return f(w, name, data)
+}
+
+// streamServer serves h on a test server, with the default flush interval.
+func streamServer(h HandlerFunc) (*Echo, *httptest.Server) {
+	e := New()
+	e.Get("/", h)
+	return e, httptest.NewServer(e)
+}
+
+// readLines sends the lines of body to the returned channel until it's
+// closed.
+func readLines(body io.Reader) <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		s := bufio.NewScanner(body)
+		for s.Scan() {
+			lines <- s.Text()
+		}
+	}()
+	return lines
+}
+
+func TestRenderStream(t *testing.T) {
+	next := make(chan int)
+	done := make(chan error, 1)
+	e, srv := streamServer(func(c *Context) error {
+		err := c.RenderStream(http.StatusOK, "rows", nil)
+		done <- err
+		return err
+	})
+	defer srv.Close()
+	e.SetRenderer(rendererFunc(func(w io.Writer, name string, data interface{}) error {
+		if _, err := fmt.Fprintf(w, "<h1>%s</h1>\n", name); err != nil {
+			return err
+		}
+		for i := range next {
+			if _, err := fmt.Fprintf(w, "<p>%s %d</p>\n", name, i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	assert.Equal(t, TextHTMLCharsetUTF8, res.Header.Get(ContentType))
+	lines := readLines(res.Body)
+	assert.Equal(t, "<h1>rows</h1>", <-lines)
+	// Each row reaches the client before the next one is rendered
+	for i := 0; i < 3; i++ {
+		next <- i
+		assert.Equal(t, fmt.Sprintf("<p>rows %d</p>", i), <-lines)
+	}
+	close(next)
+	assert.NoError(t, <-done)
+	_, ok := <-lines
+	assert.False(t, ok)
+}
+
+func TestRenderStreamCanceled(t *testing.T) {
+	e := New()
+	started := make(chan struct{})
+	e.SetRenderer(rendererFunc(func(w io.Writer, name string, data interface{}) error {
+		close(started)
+		for {
+			if _, err := w.Write([]byte("row\n")); err != nil {
+				return err
+			}
+		}
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(GET, "/", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	c := NewContext(req, NewResponse(rec, e), e)
+	go func() {
+		<-started
+		cancel()
+	}()
+	assert.Equal(t, context.Canceled, c.RenderStream(http.StatusCreated, "rows", nil))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	// Already canceled, nothing is sent
+	rec = httptest.NewRecorder()
+	c = NewContext(req, NewResponse(rec, e), e)
+	assert.Equal(t, context.Canceled, c.RenderStream(http.StatusOK, "rows", nil))
+	assert.False(t, c.Response().Committed())
+
+	e = New()
+	c = NewContext(httptest.NewRequest(GET, "/", nil), NewResponse(httptest.NewRecorder(), e), e)
+	assert.Equal(t, RendererNotRegistered, c.RenderStream(http.StatusOK, "rows", nil))
+}
+
+func TestStream(t *testing.T) {
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	_, srv := streamServer(func(c *Context) error {
+		err := c.Stream(http.StatusOK, TextPlainCharsetUTF8, pr)
+		done <- err
+		return err
+	})
+	defer srv.Close()
+
+	go pw.Write([]byte("first\n"))
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, TextPlainCharsetUTF8, res.Header.Get(ContentType))
+	lines := readLines(res.Body)
+	// Each chunk reaches the client as soon as it's read
+	assert.Equal(t, "first", <-lines)
+	pw.Write([]byte("second\n"))
+	assert.Equal(t, "second", <-lines)
+
+	// The client goes away, the stream stops with the request's context
+	res.Body.Close()
+	go func() {
+		for {
+			if _, err := pw.Write([]byte("more\n")); err != nil {
+				return
+			}
+		}
+	}()
+	assert.Equal(t, context.Canceled, <-done)
+	pr.Close()
+}
+
+func TestStreamWriter(t *testing.T) {
+	e := New()
+	rec := httptest.NewRecorder()
+	c := NewContext(httptest.NewRequest(GET, "/", nil), NewResponse(rec, e), e)
+	c.Response().Header().Set(ContentType, TextPlainCharsetUTF8)
+	assert.NoError(t, c.StreamWriter(func(w io.Writer) {
+		fmt.Fprint(w, "a")
+		fmt.Fprint(w, "b")
+	}))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ab", rec.Body.String())
+	assert.True(t, rec.Flushed)
+
+	// The status sent before is kept
+	rec = httptest.NewRecorder()
+	c = NewContext(httptest.NewRequest(GET, "/", nil), NewResponse(rec, e), e)
+	c.Response().WriteHeader(http.StatusAccepted)
+	assert.NoError(t, c.StreamWriter(func(w io.Writer) {}))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	// Writes fail once the request's context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	rec = httptest.NewRecorder()
+	c = NewContext(httptest.NewRequest(GET, "/", nil).WithContext(ctx), NewResponse(rec, e), e)
+	assert.Equal(t, context.Canceled, c.StreamWriter(func(w io.Writer) {
+		fmt.Fprint(w, "a")
+		cancel()
+		_, err := fmt.Fprint(w, "b")
+		assert.Equal(t, context.Canceled, err)
+	}))
+	assert.Equal(t, "a", rec.Body.String())
+	assert.Equal(t, context.Canceled, c.StreamWriter(func(w io.Writer) {
+		t.Error("called with a canceled context")
+	}))
+}
+
+// sliceIterator iterates over items, then fails with err if any.
+func sliceIterator(err error, items ...interface{}) JSONIterator {
+	return JSONIteratorFunc(func() (interface{}, bool, error) {
+		if len(items) == 0 {
+			return nil, false, err
+		}
+		item := items[0]
+		items = items[1:]
+		return item, true, nil
+	})
+}
+
+func TestJSONStream(t *testing.T) {
+	e := New()
+	for _, tt := range []struct {
+		it   JSONIterator
+		body string
+		err  string
+	}{
+		{sliceIterator(nil), "[]", ""},
+		{sliceIterator(nil, 1, "a", map[string]int{"b": 2}), `[1,"a",{"b":2}]`, ""},
+		{sliceIterator(errors.New("cursor"), 1, 2), `[1,2`, "cursor"},
+		{sliceIterator(nil, 1, make(chan int)), `[1`, "json: unsupported type: chan int"},
+	} {
+		rec := httptest.NewRecorder()
+		c := NewContext(httptest.NewRequest(GET, "/", nil), NewResponse(rec, e), e)
+		err := c.JSONStream(http.StatusOK, tt.it)
+		if tt.err == "" {
+			assert.NoError(t, err, tt.body)
+		} else if assert.Error(t, err, tt.body) {
+			assert.Equal(t, tt.err, err.Error())
+		}
+		assert.Equal(t, ApplicationJSONCharsetUTF8, rec.Header().Get(ContentType))
+		assert.Equal(t, tt.body, rec.Body.String())
+	}
+}
+
+func TestJSONStreamFlush(t *testing.T) {
+	next := make(chan interface{})
+	done := make(chan error, 1)
+	_, srv := streamServer(func(c *Context) error {
+		err := c.JSONStream(http.StatusOK, JSONIteratorFunc(func() (interface{}, bool, error) {
+			v, ok := <-next
+			return v, ok, nil
+		}))
+		done <- err
+		return err
+	})
+	defer srv.Close()
+
+	go func() { next <- 1 }()
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	// Each item reaches the client before the next one is asked for
+	buf := make([]byte, 16)
+	n, _ := io.ReadAtLeast(res.Body, buf, 2)
+	assert.Equal(t, "[1", string(buf[:n]))
+	next <- 2
+	n, _ = io.ReadAtLeast(res.Body, buf, 2)
+	assert.Equal(t, ",2", string(buf[:n]))
+
+	// The client goes away, the iteration stops with the request's context,
+	// or the write to the closed connection if it fails first
+	res.Body.Close()
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case next <- 3:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	assert.Error(t, <-done)
+}
+
+func TestNDJSON(t *testing.T) {
+	e := New()
+	ch := make(chan interface{}, 3)
+	ch <- 1
+	ch <- map[string]string{"a": "b"}
+	ch <- "c"
+	close(ch)
+	rec := httptest.NewRecorder()
+	c := NewContext(httptest.NewRequest(GET, "/", nil), NewResponse(rec, e), e)
+	assert.NoError(t, c.NDJSON(http.StatusOK, ch))
+	assert.Equal(t, ApplicationNDJSON, rec.Header().Get(ContentType))
+	assert.Equal(t, "1\n{\"a\":\"b\"}\n\"c\"\n", rec.Body.String())
+
+	ch = make(chan interface{}, 2)
+	ch <- 1
+	ch <- make(chan int)
+	rec = httptest.NewRecorder()
+	c = NewContext(httptest.NewRequest(GET, "/", nil), NewResponse(rec, e), e)
+	assert.Error(t, c.NDJSON(http.StatusOK, ch))
+	assert.Equal(t, "1\n", rec.Body.String())
+}
+
+func TestNDJSONFlush(t *testing.T) {
+	ch := make(chan interface{})
+	done := make(chan error, 1)
+	_, srv := streamServer(func(c *Context) error {
+		err := c.NDJSON(http.StatusOK, ch)
+		done <- err
+		return err
+	})
+	defer srv.Close()
+
+	go func() { ch <- 1 }()
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := readLines(res.Body)
+	// Each value reaches the client as soon as it's received
+	assert.Equal(t, "1", <-lines)
+	ch <- 2
+	assert.Equal(t, "2", <-lines)
+
+	// The client goes away, NDJSON stops even though ch stays open
+	res.Body.Close()
+	assert.Equal(t, context.Canceled, <-done)
+}
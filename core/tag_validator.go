@@ -0,0 +1,182 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type (
+	// StructValidator validates structs once bound, see Echo.SetValidator.
+	StructValidator interface {
+		ValidateStruct(i interface{}) error
+	}
+
+	// TagValidator is the default StructValidator. It checks the rules of the
+	// `validate` struct tag, e.g. `validate:"required,min=3"`:
+	//
+	//	required      the value is not the zero value
+	//	min=n, max=n  bounds of numbers, or of the length of strings and slices
+	//	in=a|b|c      the value is one of the listed ones
+	//	email         the value looks like an email address
+	//
+	// Fields are reported under their json, then form tag name. Nested structs
+	// are validated too. An unknown or malformed rule is a programming error,
+	// reported as is rather than as a 422.
+	TagValidator struct{}
+)
+
+var emailRegexp = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+// SetValidator sets the validator run by Context.Bind on bound structs, nil
+// disables it. Types implementing Validator are validated in any case.
+func (e *Echo) SetValidator(v StructValidator) {
+	e.validator = v
+}
+
+// ValidateStruct implements StructValidator, it returns a 422 HTTPError
+// listing every failed field.
+func (TagValidator) ValidateStruct(i interface{}) error {
+	v := &Validation{errors: map[string]string{}}
+	if err := validateStruct(v, reflect.ValueOf(i), ""); err != nil {
+		return err
+	}
+	return v.Err()
+}
+
+func validateStruct(v *Validation, rv reflect.Value, prefix string) error {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct || rv.Type() == timeType {
+		return nil
+	}
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		fv := rv.Field(i)
+		if sf.Anonymous {
+			if err := validateStruct(v, fv, prefix); err != nil {
+				return err
+			}
+			continue
+		}
+		name := prefix + fieldName(sf)
+		if tag := sf.Tag.Get("validate"); tag != "" && tag != "-" {
+			if err := validateField(v, name, fv, tag); err != nil {
+				return err
+			}
+		}
+		if err := validateStruct(v, fv, name+"."); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fieldName(sf reflect.StructField) string {
+	for _, key := range []string{"json", formTag} {
+		name := sf.Tag.Get(key)
+		if i := strings.Index(name, ","); i != -1 {
+			name = name[:i]
+		}
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+func validateField(v *Validation, name string, fv reflect.Value, tag string) error {
+	for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+		fv = fv.Elem()
+	}
+	f := v.Field(name, "")
+	if fv.Kind() != reflect.Ptr && fv.Kind() != reflect.Slice && fv.Kind() != reflect.Map {
+		f.value, _ = formatValue(fv)
+	}
+	empty := isEmptyValue(fv)
+	for _, rule := range strings.Split(tag, ",") {
+		key, arg := rule, ""
+		if i := strings.Index(rule, "="); i != -1 {
+			key, arg = rule[:i], rule[i+1:]
+		}
+		if key == "required" {
+			if empty {
+				f.fail("is required")
+			}
+			continue
+		}
+		if empty || f.failed {
+			continue
+		}
+		switch key {
+		case "min", "max":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return fmt.Errorf("validate: invalid rule %q of %s", rule, name)
+			}
+			validateBound(f, fv, key == "min", n)
+		case "in":
+			f.In(strings.Split(arg, "|")...)
+		case "email":
+			if !emailRegexp.MatchString(f.value) {
+				f.fail("must be an email address")
+			}
+		default:
+			return fmt.Errorf("validate: unknown rule %q of %s", rule, name)
+		}
+	}
+	return nil
+}
+
+func validateBound(f *FieldValidation, fv reflect.Value, min bool, n float64) {
+	switch fv.Kind() {
+	case reflect.String:
+		if min {
+			f.MinLen(int(n))
+		} else {
+			f.MaxLen(int(n))
+		}
+	case reflect.Slice, reflect.Map, reflect.Array:
+		if min && float64(fv.Len()) < n {
+			f.fail("must have at least %v items", n)
+		} else if !min && float64(fv.Len()) > n {
+			f.fail("must have at most %v items", n)
+		}
+	default:
+		if min {
+			f.Float().Min(n)
+		} else {
+			f.Float().Max(n)
+		}
+	}
+}
+
+// validate runs the struct validator then the Validator interface on i,
+// errors other than HTTPError become 422 Unprocessable Entity.
+func (c *Context) validate(i interface{}) error {
+	if v := c.echo.validator; v != nil {
+		if err := v.ValidateStruct(i); err != nil {
+			return err
+		}
+	}
+	if v, ok := i.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			if _, ok := err.(*HTTPError); ok {
+				return err
+			}
+			return NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		}
+	}
+	return nil
+}
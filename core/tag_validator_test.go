@@ -0,0 +1,40 @@
+package core
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagValidator(t *testing.T) {
+	type user struct {
+		Name string `json:"name" validate:"required,min=3"`
+		Role string `json:"role" validate:"in=admin|user"`
+	}
+	v := TagValidator{}
+	assert.NoError(t, v.ValidateStruct(&user{Name: "joe", Role: "user"}))
+
+	err := v.ValidateStruct(&user{Name: "jo", Role: "root"})
+	if he, ok := err.(*HTTPError); assert.True(t, ok) {
+		assert.Equal(t, http.StatusUnprocessableEntity, he.Code())
+	}
+
+	// Bad rules are reported, not panicked on
+	type unknown struct {
+		Name string `json:"name" validate:"required,lenght=3"`
+	}
+	err = v.ValidateStruct(&unknown{Name: "joe"})
+	if assert.Error(t, err) {
+		_, ok := err.(*HTTPError)
+		assert.False(t, ok)
+		assert.Equal(t, `validate: unknown rule "lenght=3" of name`, err.Error())
+	}
+	type invalid struct {
+		Age int `json:"age" validate:"min=x"`
+	}
+	err = v.ValidateStruct(&invalid{Age: 1})
+	if assert.Error(t, err) {
+		assert.Equal(t, `validate: invalid rule "min=x" of age`, err.Error())
+	}
+}
@@ -0,0 +1,132 @@
+package template
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestComponentEscaping(t *testing.T) {
+	data := struct {
+		Text, URL, JS, CSS string
+	}{
+		Text: `<b>"O'Reilly" & co</b>`,
+		URL:  `javascript:alert(1)`,
+		JS:   `</script><script>alert(1)</script>`,
+		CSS:  `expression(alert(1))`,
+	}
+	const card = `{{define "card"}}<div class="card" title="{{.Text}}">{{slot}}</div>{{end}}`
+	tests := []struct {
+		name, src, want string
+	}{
+		{
+			"text",
+			`{{component "card" .}}<p>{{.Text}}</p>{{end}}`,
+			`<div class="card" title="&lt;b&gt;&#34;O&#39;Reilly&#34; &amp; co&lt;/b&gt;"><p>&lt;b&gt;&#34;O&#39;Reilly&#34; &amp; co&lt;/b&gt;</p></div>`,
+		},
+		{
+			"attribute",
+			`{{component "card" .}}<span title="{{.Text}}">x</span>{{end}}`,
+			`<span title="&lt;b&gt;&#34;O&#39;Reilly&#34; &amp; co&lt;/b&gt;">x</span>`,
+		},
+		{
+			"url",
+			`{{component "card" .}}<a href="{{.URL}}">x</a><a href="/q?s={{.Text}}">y</a>{{end}}`,
+			`<a href="#ZgotmplZ">x</a><a href="/q?s=%3cb%3e%22O%27Reilly%22%20%26%20co%3c%2fb%3e">y</a>`,
+		},
+		{
+			"js",
+			`{{component "card" .}}<script>var s = {{.JS}};</script><a onclick="f({{.Text}})">x</a>{{end}}`,
+			`<script>var s = "\u003c/script\u003e\u003cscript\u003ealert(1)\u003c/script\u003e";</script><a onclick="f(&#34;\u003cb\u003e\&#34;O&#39;Reilly\&#34; \u0026 co\u003c/b\u003e&#34;)">x</a>`,
+		},
+		{
+			"css",
+			`{{component "card" .}}<p style="color: {{.CSS}}">x</p>{{end}}`,
+			`<p style="color: ZgotmplZ">x</p>`,
+		},
+		{
+			"variables of the caller",
+			`{{$u := .URL}}{{component "card" .}}<a href="{{$u}}">x</a>{{end}}`,
+			`<a href="#ZgotmplZ">x</a>`,
+		},
+		{
+			"nested",
+			`{{component "card" .}}{{component "card" .}}<i>{{.Text}}</i>{{end}}{{end}}`,
+			`<i>&lt;b&gt;&#34;O&#39;Reilly&#34; &amp; co&lt;/b&gt;</i></div></div>`,
+		},
+	}
+	for _, tt := range tests {
+		tmpl, err := New("page").Parse(card + tt.src)
+		if err != nil {
+			t.Errorf("%s: parse: %v", tt.name, err)
+			continue
+		}
+		var b bytes.Buffer
+		if err = tmpl.Execute(&b, data); err != nil {
+			t.Errorf("%s: execute: %v", tt.name, err)
+			continue
+		}
+		if got := b.String(); !strings.Contains(got, tt.want) {
+			t.Errorf("%s:\ngot  %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestComponentEscapingErrors(t *testing.T) {
+	tests := []struct {
+		name, src, err string
+	}{
+		{
+			"slot in an attribute",
+			`{{define "card"}}<div title="{{slot}}"></div>{{end}}{{component "card" .}}x{{end}}`,
+			`{{slot}} appears in a non-text context`,
+		},
+		{
+			"slot in a script",
+			`{{define "card"}}<script>{{slot}}</script>{{end}}{{component "card" .}}x{{end}}`,
+			`{{slot}} appears in a non-text context`,
+		},
+		{
+			"component called in an attribute",
+			`{{define "card"}}{{slot}}{{end}}<a title="{{component "card" .}}x{{end}}">`,
+			`{{slot}} appears in a non-text context`,
+		},
+		{
+			"body leaving a tag open",
+			`{{define "card"}}<div>{{slot}}</div>{{end}}{{component "card" .}}<a href="{{end}}">`,
+			`{{component "card"}} body ends in a non-text context`,
+		},
+		{
+			"body leaving a script open",
+			`{{define "card"}}<div>{{slot}}</div>{{end}}{{component "card" .}}<script>{{end}}</script>`,
+			`{{component "card"}} body ends in a non-text context`,
+		},
+	}
+	for _, tt := range tests {
+		tmpl, err := New("page").Parse(tt.src)
+		if err != nil {
+			t.Errorf("%s: parse: %v", tt.name, err)
+			continue
+		}
+		err = tmpl.Execute(&bytes.Buffer{}, nil)
+		e, ok := err.(*Error)
+		if !ok {
+			t.Errorf("%s: got error %v, want an escaping error", tt.name, err)
+			continue
+		}
+		if e.ErrorCode != ErrSlotContext || !strings.Contains(e.Error(), tt.err) {
+			t.Errorf("%s: got error %v (code %d), want %q", tt.name, e, e.ErrorCode, tt.err)
+		}
+	}
+}
+
+func TestSlotOutsideComponent(t *testing.T) {
+	tmpl := Must(New("page").Parse(`<p>{{slot}}</p>`))
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != "<p></p>" {
+		t.Errorf("got %q, want %q", got, "<p></p>")
+	}
+}
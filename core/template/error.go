@@ -184,6 +184,15 @@ const (
 	//   Look for missing semicolons inside branches, and maybe add
 	//   parentheses to make it clear which interpretation you intend.
 	ErrSlashAmbig
+
+	// ErrSlotContext: "{{slot}} appears in a non-text context"
+	// Example:
+	//   {{define "card"}}<div title="{{slot}}"></div>{{end}}
+	// Discussion:
+	//   The body of a {{component}} is escaped as HTML text, so {{slot}}
+	//   may only appear where text is expected and the body may not leave
+	//   a tag or attribute open.
+	ErrSlotContext
 )
 
 func (e *Error) Error() string {
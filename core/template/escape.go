@@ -126,6 +126,10 @@ func (e *escaper) escape(c context, n parse.Node) context {
 		return e.escapeBranch(c, &n.BranchNode, "range")
 	case *parse.TemplateNode:
 		return e.escapeTemplate(c, n)
+	case *parse.ComponentNode:
+		return e.escapeComponent(c, n)
+	case *parse.SlotNode:
+		return e.escapeSlot(c, n)
 	case *parse.TextNode:
 		return e.escapeText(c, n)
 	case *parse.WithNode:
@@ -490,6 +494,34 @@ func (e *escaper) escapeTemplate(c context, n *parse.TemplateNode) context {
 	return c
 }
 
+// escapeComponent escapes a {{component}} call node. Its body is emitted by
+// {{slot}}, which may only appear in HTML text, so the body is escaped in that
+// context and has to end in it.
+func (e *escaper) escapeComponent(c context, n *parse.ComponentNode) context {
+	c1 := e.escapeList(context{}, n.List)
+	if c1.state == stateError {
+		return c1
+	}
+	if !c1.eq(context{}) {
+		return context{
+			state: stateError,
+			err:   errorf(ErrSlotContext, n, n.Line, "{{component %q}} body ends in a non-text context: %v", n.Name, c1),
+		}
+	}
+	return e.escapeTemplate(c, &n.TemplateNode)
+}
+
+// escapeSlot escapes a {{slot}} node.
+func (e *escaper) escapeSlot(c context, n *parse.SlotNode) context {
+	if !c.eq(context{}) {
+		return context{
+			state: stateError,
+			err:   errorf(ErrSlotContext, n, 0, "{{slot}} appears in a non-text context: %v", c),
+		}
+	}
+	return c
+}
+
 // escapeTree escapes the named template starting in the given context as
 // necessary and returns its output context.
 func (e *escaper) escapeTree(c context, node parse.Node, name string, line int) (context, string) {
@@ -20,10 +20,20 @@ import (
 // template so that multiple executions of the same template
 // can execute in parallel.
 type state struct {
+	tmpl  *Template
+	wr    io.Writer
+	node  parse.Node  // current node, for errors
+	vars  []variable  // push-down stack of variable values.
+	slots []slotFrame // bodies of the enclosing {{component}} invocations.
+}
+
+// slotFrame holds the body of a {{component}} invocation together with the
+// scope it was written in, so {{slot}} renders it like inline content.
+type slotFrame struct {
 	tmpl *Template
-	wr   io.Writer
-	node parse.Node // current node, for errors
-	vars []variable // push-down stack of variable values.
+	list *parse.ListNode
+	dot  reflect.Value
+	vars []variable
 }
 
 // variable holds the dynamic value of a variable such as $, $x etc.
@@ -227,6 +237,10 @@ func (s *state) walk(dot reflect.Value, node parse.Node) {
 		s.walkRange(dot, node)
 	case *parse.TemplateNode:
 		s.walkTemplate(dot, node)
+	case *parse.ComponentNode:
+		s.walkComponent(dot, node)
+	case *parse.SlotNode:
+		s.walkSlot(node)
 	case *parse.TextNode:
 		if _, err := s.wr.Write(node.Text); err != nil {
 			s.writeError(err)
@@ -369,6 +383,39 @@ func (s *state) walkTemplate(dot reflect.Value, t *parse.TemplateNode) {
 	newState.walk(dot, tmpl.Root)
 }
 
+func (s *state) walkComponent(dot reflect.Value, c *parse.ComponentNode) {
+	s.at(c)
+	tmpl := s.tmpl.tmpl[c.Name]
+	if tmpl == nil {
+		s.errorf("template %q not defined", c.Name)
+	}
+	frame := slotFrame{tmpl: s.tmpl, list: c.List, dot: dot}
+	// Variables declared by the pipeline persist.
+	dot = s.evalPipeline(dot, c.Pipe)
+	frame.vars = append([]variable(nil), s.vars...)
+	newState := *s
+	newState.tmpl = tmpl
+	// No dynamic scoping: template invocations inherit no variables.
+	newState.vars = []variable{{"$", dot}}
+	newState.slots = append(s.slots[:len(s.slots):len(s.slots)], frame)
+	newState.walk(dot, tmpl.Root)
+}
+
+// walkSlot renders the body of the innermost {{component}} invocation with the
+// dot and variables of its caller. Outside a component it renders nothing.
+func (s *state) walkSlot(n *parse.SlotNode) {
+	s.at(n)
+	if len(s.slots) == 0 {
+		return
+	}
+	f := s.slots[len(s.slots)-1]
+	newState := *s
+	newState.tmpl = f.tmpl
+	newState.vars = f.vars
+	newState.slots = s.slots[:len(s.slots)-1]
+	newState.walk(f.dot, f.list)
+}
+
 // Eval functions evaluate pipelines, commands, and their elements and extract
 // values from the data structure by examining fields, calling methods, and so on.
 // The printing of those values happens only through walk functions.
@@ -57,30 +57,34 @@ const (
 	itemText       // plain text
 	itemVariable   // variable starting with '$', such as '$' or  '$1' or '$hello'
 	// Keywords appear after all the rest.
-	itemKeyword  // used only to delimit the keywords
-	itemBlock    // block keyword
-	itemDot      // the cursor, spelled '.'
-	itemDefine   // define keyword
-	itemElse     // else keyword
-	itemEnd      // end keyword
-	itemIf       // if keyword
-	itemNil      // the untyped nil constant, easiest to treat as a keyword
-	itemRange    // range keyword
-	itemTemplate // template keyword
-	itemWith     // with keyword
+	itemKeyword   // used only to delimit the keywords
+	itemBlock     // block keyword
+	itemComponent // component keyword
+	itemDot       // the cursor, spelled '.'
+	itemDefine    // define keyword
+	itemElse      // else keyword
+	itemEnd       // end keyword
+	itemIf        // if keyword
+	itemNil       // the untyped nil constant, easiest to treat as a keyword
+	itemRange     // range keyword
+	itemSlot      // slot keyword
+	itemTemplate  // template keyword
+	itemWith      // with keyword
 )
 
 var key = map[string]itemType{
-	".":        itemDot,
-	"block":    itemBlock,
-	"define":   itemDefine,
-	"else":     itemElse,
-	"end":      itemEnd,
-	"if":       itemIf,
-	"range":    itemRange,
-	"nil":      itemNil,
-	"template": itemTemplate,
-	"with":     itemWith,
+	".":         itemDot,
+	"block":     itemBlock,
+	"component": itemComponent,
+	"define":    itemDefine,
+	"else":      itemElse,
+	"end":       itemEnd,
+	"if":        itemIf,
+	"range":     itemRange,
+	"nil":       itemNil,
+	"slot":      itemSlot,
+	"template":  itemTemplate,
+	"with":      itemWith,
 }
 
 const eof = -1
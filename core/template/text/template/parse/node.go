@@ -69,6 +69,8 @@ const (
 	NodeTemplate                   // A template invocation action.
 	NodeVariable                   // A $ variable.
 	NodeWith                       // A with action.
+	NodeComponent                  // A component invocation action.
+	NodeSlot                       // A slot action.
 )
 
 // Nodes.
@@ -837,3 +839,49 @@ func (t *TemplateNode) tree() *Tree {
 func (t *TemplateNode) Copy() Node {
 	return t.tr.newTemplate(t.Pos, t.Line, t.Name, t.Pipe.CopyPipe())
 }
+
+// ComponentNode represents a {{component}} action: a template invocation whose
+// body is rendered in place of the {{slot}} actions of the invoked template.
+type ComponentNode struct {
+	TemplateNode
+	List *ListNode // The body rendered by {{slot}}.
+}
+
+func (t *Tree) newComponent(pos Pos, line int, name string, pipe *PipeNode, list *ListNode) *ComponentNode {
+	return &ComponentNode{TemplateNode: TemplateNode{tr: t, NodeType: NodeComponent, Pos: pos, Line: line, Name: name, Pipe: pipe}, List: list}
+}
+
+func (c *ComponentNode) String() string {
+	if c.Pipe == nil {
+		return fmt.Sprintf("{{component %q}}%s{{end}}", c.Name, c.List)
+	}
+	return fmt.Sprintf("{{component %q %s}}%s{{end}}", c.Name, c.Pipe, c.List)
+}
+
+func (c *ComponentNode) Copy() Node {
+	return c.tr.newComponent(c.Pos, c.Line, c.Name, c.Pipe.CopyPipe(), c.List.CopyList())
+}
+
+// SlotNode represents a {{slot}} action, replaced by the body of the enclosing
+// {{component}} invocation.
+type SlotNode struct {
+	NodeType
+	Pos
+	tr *Tree
+}
+
+func (t *Tree) newSlot(pos Pos) *SlotNode {
+	return &SlotNode{tr: t, NodeType: NodeSlot, Pos: pos}
+}
+
+func (s *SlotNode) String() string {
+	return "{{slot}}"
+}
+
+func (s *SlotNode) tree() *Tree {
+	return s.tr
+}
+
+func (s *SlotNode) Copy() Node {
+	return s.tr.newSlot(s.Pos)
+}
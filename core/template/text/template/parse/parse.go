@@ -265,6 +265,8 @@ func IsEmptyTree(n Node) bool {
 		return true
 	case *RangeNode:
 	case *TemplateNode:
+	case *ComponentNode:
+	case *SlotNode:
 	case *TextNode:
 		return len(bytes.TrimSpace(n.Text)) == 0
 	case *WithNode:
@@ -358,11 +360,17 @@ func (t *Tree) textOrAction() Node {
 //	control
 //	command ("|" command)*
 // Left delim is past. Now get actions.
-// First word could be a keyword such as range.
+// First word could be a keyword such as range. component and slot are only
+// keywords when no function of that name is defined, so templates written for
+// such functions keep working.
 func (t *Tree) action() (n Node) {
 	switch token := t.nextNonSpace(); token.typ {
 	case itemBlock:
 		return t.blockControl()
+	case itemComponent:
+		if !t.hasFunction(token.val) {
+			return t.componentControl()
+		}
 	case itemElse:
 		return t.elseControl()
 	case itemEnd:
@@ -371,6 +379,10 @@ func (t *Tree) action() (n Node) {
 		return t.ifControl()
 	case itemRange:
 		return t.rangeControl()
+	case itemSlot:
+		if !t.hasFunction(token.val) {
+			return t.slotControl()
+		}
 	case itemTemplate:
 		return t.templateControl()
 	case itemWith:
@@ -425,7 +437,8 @@ func (t *Tree) pipeline(context string) (pipe *PipeNode) {
 			}
 			return
 		case itemBool, itemCharConstant, itemComplex, itemDot, itemField, itemIdentifier,
-			itemNumber, itemNil, itemRawString, itemString, itemVariable, itemLeftParen:
+			itemNumber, itemNil, itemRawString, itemString, itemVariable, itemLeftParen,
+			itemComponent, itemSlot:
 			t.backup()
 			pipe.append(t.command())
 		default:
@@ -571,6 +584,35 @@ func (t *Tree) templateControl() Node {
 	return t.newTemplate(token.pos, t.lex.lineNumber(), name, pipe)
 }
 
+// Component:
+//	{{component stringValue pipeline}} itemList {{end}}
+// Component keyword is past. The pipeline is optional. The item list is the
+// body rendered in place of {{slot}} by the invoked template.
+func (t *Tree) componentControl() Node {
+	const context = "component clause"
+	defer t.popVars(len(t.vars))
+	token := t.nextNonSpace()
+	name := t.parseTemplateName(token, context)
+	line := t.lex.lineNumber()
+	var pipe *PipeNode
+	if t.nextNonSpace().typ != itemRightDelim {
+		t.backup()
+		pipe = t.pipeline(context)
+	}
+	list, next := t.itemList()
+	if next.Type() != nodeEnd {
+		t.errorf("unexpected %s in %s", next, context)
+	}
+	return t.newComponent(token.pos, line, name, pipe, list)
+}
+
+// Slot:
+//	{{slot}}
+// Slot keyword is past.
+func (t *Tree) slotControl() Node {
+	return t.newSlot(t.expect(itemRightDelim, "slot").pos)
+}
+
 func (t *Tree) parseTemplateName(token item, context string) (name string) {
 	switch token.typ {
 	case itemString, itemRawString:
@@ -663,7 +705,7 @@ func (t *Tree) term() Node {
 	switch token := t.nextNonSpace(); token.typ {
 	case itemError:
 		t.errorf("%s", token.val)
-	case itemIdentifier:
+	case itemIdentifier, itemComponent, itemSlot:
 		if !t.hasFunction(token.val) {
 			t.errorf("function %q not defined", token.val)
 		}
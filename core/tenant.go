@@ -0,0 +1,39 @@
+package core
+
+// Tenant returns the tenant the request belongs to, as resolved by the tenant
+// middleware. It is "" for single-tenant applications.
+func (c *Context) Tenant() string {
+	return c.tenant
+}
+
+// SetTenant sets the tenant the request belongs to.
+func (c *Context) SetTenant(tenant string) {
+	c.tenant = tenant
+}
+
+// TemplateIndex is implemented by renderers which can tell whether they
+// have a template, so tenants can override templates, see Context.Render.
+type TemplateIndex interface {
+	HasTemplate(name string) bool
+}
+
+// TenantKey scopes `key` to the tenant of the request, for use as a cache key
+// or a storage prefix. Without tenant it returns `key` unchanged.
+func (c *Context) TenantKey(key string) string {
+	if c.tenant == "" {
+		return key
+	}
+	return c.tenant + ":" + key
+}
+
+// tenantView returns the template a tenant overrides name with, TenantKey(name)
+// if r has it, or name.
+func (c *Context) tenantView(r Renderer, name string) string {
+	if c.tenant == "" {
+		return name
+	}
+	if ti, ok := r.(TemplateIndex); ok && ti.HasTemplate(c.TenantKey(name)) {
+		return c.TenantKey(name)
+	}
+	return name
+}
@@ -0,0 +1,27 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantView(t *testing.T) {
+	e := New()
+	r := NewRender()
+	r.PermanentParse("page", "default")
+	r.PermanentParse("acme:page", "acme")
+	e.SetRenderer(r)
+
+	for tenant, body := range map[string]string{"": "default", "acme": "acme", "beta": "default"} {
+		req, _ := http.NewRequest(GET, "/", nil)
+		rec := httptest.NewRecorder()
+		c := NewContext(req, NewResponse(rec, e), e)
+		c.SetTenant(tenant)
+		if assert.NoError(t, c.Render(http.StatusOK, "page", nil)) {
+			assert.Equal(t, body, rec.Body.String())
+		}
+	}
+}
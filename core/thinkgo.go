@@ -4,7 +4,9 @@
 package core
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"path"
 	"regexp"
@@ -24,6 +26,8 @@ type Think struct {
 	// 框架信息
 	Author  string
 	Version string
+	// 启动错误，由 Run 返回
+	err error
 }
 
 // 重要配置，涉及项目架构，请勿修改
@@ -42,14 +46,16 @@ const (
 
 // 全局运行实例
 var ThinkGo = func() *Think {
+	config, err := getConfig()
 	t := &Think{
 		// 业务数据
 		Echo:    New(),
 		Modules: Modules,
-		Config:  getConfig(),
+		Config:  config,
 		// 框架信息
 		Author:  AUTHOR,
 		Version: VERSION,
+		err:     err,
 	}
 
 	log := t.Echo.Logger()
@@ -58,6 +64,10 @@ var ThinkGo = func() *Think {
 	t.Echo.Blackfile(".html")
 	t.Echo.SetLogLevel(t.Config.LogLevel)
 	t.Echo.SetDebug(t.Config.Debug)
+	if err := t.Echo.SetTrustedProxies(t.Config.TrustProxies...); err != nil && t.err == nil {
+		t.err = fmt.Errorf("trustedproxies: %v", err)
+	}
+	t.Echo.SetMetaDefaults(MetaDefaults{SiteName: t.Config.SiteName, BaseURL: t.Config.SiteURL, Hosts: t.Config.SiteHosts})
 	t.htmlPrepare()
 	t.dirServe()
 	t.Hook()
@@ -68,8 +78,78 @@ var ThinkGo = func() *Think {
 	return t
 }()
 
-func (this *Think) Run() {
-	this.Echo.Run(fmt.Sprintf("%s:%d", this.Config.HttpAddr, this.Config.HttpPort))
+// Run runs the server until it's stopped by Shutdown. Instead of exiting the
+// process, a misconfiguration or a failure to serve is logged and returned,
+// once the logs are flushed and the Echo.OnShutdown hooks have run, so the
+// caller decides how to exit:
+//
+//	if err := core.ThinkGo.Run(); err != nil {
+//		os.Exit(1)
+//	}
+//
+// With the SmokeEnv environment variable set, Run smoke tests the routes
+// instead, see Echo.Smoke, and fails if any of them does. Likewise with
+// WarmEnv, it runs the cache warmers once, see Echo.WarmCache.
+func (this *Think) Run() error {
+	err, done := this.err, false
+	if err == nil {
+		done, err = this.smoke()
+	}
+	if err == nil && !done {
+		done, err = this.warm()
+	}
+	if err == nil && !done {
+		err = this.Echo.Run(fmt.Sprintf("%s:%d", this.Config.HttpAddr, this.Config.HttpPort))
+	} else {
+		this.Echo.hooks.run(this.Echo.env.clock)
+	}
+	if err != nil {
+		log := this.Echo.Logger()
+		log.Error("%v", err)
+		log.Flush()
+	}
+	return err
+}
+
+// smoke runs the smoke test requested by SmokeEnv, if any, and logs the
+// report.
+func (this *Think) smoke() (bool, error) {
+	r, err := this.Echo.smokeFromEnv()
+	if r == nil || err != nil {
+		return false, err
+	}
+	log := this.Echo.Logger()
+	report := strings.TrimSuffix(r.String(), "\n")
+	if r.Failed > 0 {
+		log.Error("%s", report)
+		return true, fmt.Errorf("smoke test: %d of %d routes failed", r.Failed, len(r.Results))
+	}
+	log.Notice("%s", report)
+	return true, nil
+}
+
+// warm runs the cache warmers requested by WarmEnv, if any.
+func (this *Think) warm() (bool, error) {
+	reports, ok := this.Echo.warmFromEnv()
+	if !ok {
+		return false, nil
+	}
+	var failed int
+	for _, r := range reports {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return true, fmt.Errorf("cache warm: %d of %d warmers failed", failed, len(reports))
+	}
+	this.Echo.Logger().Notice("cache warm: %d warmers", len(reports))
+	return true, nil
+}
+
+// Shutdown gracefully stops the server started by Run.
+func (this *Think) Shutdown(ctx context.Context) error {
+	return this.Echo.Shutdown(ctx)
 }
 
 func (this *Think) dirServe() {
@@ -93,19 +173,68 @@ func (this *Think) dirServe() {
 }
 
 func (this *Think) htmlPrepare() {
-	var t = NewRender()
+	t := this.newRender()
+	t.fromDisk = true
+	t.read = ioutil.ReadFile
+	t.files = t.index(WalkRelFiles(t.basepath, t.suffix))
+	t.Template.Delims(t.delims[0], t.delims[1])
+	if !t.debug {
+		// The views may use functions registered later, see
+		// Echo.SetTemplateFuncs, which parses them again: the error is kept
+		// for Render until then.
+		if t.parseErr = t.parse(); t.parseErr != nil {
+			this.Echo.Logger().Notice("views not parsed yet: %v", t.parseErr)
+		}
+	}
+
+	this.Template = t
+	this.Echo.SetRenderer(t)
+}
+
+// LoadViews replaces the views read from disk at startup with the ones packed
+// into the binary by `thinkgo pack application`, typically from the init
+// function of the generated file. Packed views are never re-read from disk.
+func (this *Think) LoadViews(p *PackedFS) error {
+	t := this.newRender()
+	t.Template.Delims(t.delims[0], t.delims[1])
+	var files []string
+	for _, f := range p.Files() {
+		if strings.HasSuffix(f, t.suffix) {
+			files = append(files, f)
+		}
+	}
+	t.read = p.ReadFile
+	t.files = t.index(files)
+	if err := t.parse(); err != nil {
+		return err
+	}
+	for name := range t.pathmap {
+		t.permanent[name] = true
+	}
+
+	this.Template = t
+	this.Echo.SetRenderer(t)
+	return nil
+}
+
+func (this *Think) newRender() *Template {
+	t := NewRender()
 	t.Delims(this.Config.TplLeft, this.Config.TplRight)
 	t.SetBasepath(APP_PACKAGE)
 	t.SetSuffix(this.Config.TplSuffix)
 	t.SetDebug(this.Config.Debug)
+	return t
+}
 
+// index maps the view files among files to their template names, and returns
+// them.
+func (t *Template) index(files []string) (paths []string) {
 	var (
-		paths []string
-		re    = regexp.MustCompile(t.basepath + "(/[^/]+)/" + VIEW_PACKAGE + "(/[^/]+)(/[^/]+)(/[^/]+)" + t.suffix)
-		re2   = regexp.MustCompile(t.basepath + "/" + COMMON_PACKAGE + "/" + VIEW_PACKAGE + "(/[^/]+)" + t.suffix)
+		re  = regexp.MustCompile(t.basepath + "(/[^/]+)/" + VIEW_PACKAGE + "(/[^/]+)(/[^/]+)(/[^/]+)" + t.suffix)
+		re2 = regexp.MustCompile(t.basepath + "/" + COMMON_PACKAGE + "/" + VIEW_PACKAGE + "(/[^/]+)" + t.suffix)
 	)
 
-	for _, f := range WalkRelFiles(t.basepath, t.suffix) {
+	for _, f := range files {
 		a := re.FindStringSubmatch(f)
 		if len(a) < 5 {
 			b := re2.FindStringSubmatch(f)
@@ -119,14 +248,7 @@ func (this *Think) htmlPrepare() {
 		t.pathmap[r] = f
 		paths = append(paths, f)
 	}
-	if !t.debug {
-		t.Template.ParseFiles(paths...)
-	}
-
-	t.Template.Delims(t.delims[0], t.delims[1])
-
-	this.Template = t
-	this.Echo.SetRenderer(t)
+	return
 }
 
 func (this *Think) Hook() {
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type (
+	// MatchTrace records the decisions of the router while matching a
+	// request, to diagnose surprising matches in large route tables.
+	MatchTrace struct {
+		Method string            `json:"method"`
+		Path   string            `json:"path"`
+		Route  string            `json:"route,omitempty"`
+		Status int               `json:"status"`
+		Params map[string]string `json:"params,omitempty"`
+		// Allowed lists the methods registered on the matched route.
+		Allowed []string    `json:"allowed,omitempty"`
+		Steps   []MatchStep `json:"steps"`
+	}
+
+	// MatchStep is one decision of the router.
+	MatchStep struct {
+		// Node is the prefix of the node, e.g. "users/" or ":" for a param.
+		Node string `json:"node"`
+		// Kind is "static", "param" or "any".
+		Kind string `json:"kind"`
+		// Action is "enter", "reject", "backtrack" or "match".
+		Action string `json:"action"`
+		// Search is the part of the path left to match.
+		Search string `json:"search"`
+		Reason string `json:"reason,omitempty"`
+	}
+)
+
+// traceParam is the query parameter which, in debug mode, answers a request
+// with its MatchTrace instead of serving it.
+const traceParam = "_trace"
+
+var kindNames = [...]string{skind: "static", pkind: "param", mkind: "any"}
+
+// TraceMatch matches a request the way ServeHTTP does, without serving it,
+// and returns the decisions of the router. In debug mode, the same trace is
+// returned as JSON for requests carrying a `?_trace` query parameter.
+func (e *Echo) TraceMatch(method, path string) *MatchTrace {
+	if path != "/" {
+		path = strings.TrimRight(path, "/")
+	}
+	c := NewContext(nil, nil, e)
+	c.trace = &MatchTrace{Method: method, Path: path}
+	e.router.Find(method, path, c)
+	return c.trace
+}
+
+func (t *MatchTrace) step(n *node, action, search, format string, args ...interface{}) {
+	node := n.prefix
+	if n.kind == pkind {
+		node = ":"
+	}
+	t.Steps = append(t.Steps, MatchStep{
+		Node:   node,
+		Kind:   kindNames[n.kind],
+		Action: action,
+		Search: search,
+		Reason: fmt.Sprintf(format, args...),
+	})
+}
+
+// end records the outcome of the match on node n, nil if nothing matched.
+func (t *MatchTrace) end(n *node, method string, pvalues []string) {
+	t.Status = http.StatusNotFound
+	if n == nil || n.methodHandler == nil {
+		return
+	}
+	t.Route = n.ppath
+	for _, m := range methods {
+		if n.findHandler(m) != nil {
+			t.Allowed = append(t.Allowed, m)
+		}
+	}
+	for m := range n.methodHandler.custom {
+		t.Allowed = append(t.Allowed, m)
+	}
+	sort.Strings(t.Allowed)
+	if n.findHandler(method) != nil {
+		t.Status = http.StatusOK
+	} else if len(t.Allowed) > 0 {
+		t.Status = http.StatusMethodNotAllowed
+	}
+	if len(n.pnames) > 0 {
+		t.Params = make(map[string]string, len(n.pnames))
+		for i, name := range n.pnames {
+			if i < len(pvalues) {
+				t.Params[name] = pvalues[i]
+			}
+		}
+	}
+}
+
+// wantsTrace reports whether r asks for its MatchTrace, see TraceMatch.
+func (e *Echo) wantsTrace(r *http.Request) bool {
+	if !e.debug || !strings.Contains(r.URL.RawQuery, traceParam) {
+		return false
+	}
+	_, ok := r.URL.Query()[traceParam]
+	return ok
+}
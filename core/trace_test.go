@@ -0,0 +1,74 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceParam(t *testing.T) {
+	e := New()
+	e.Get("/users/:id", func(c *Context) error {
+		return c.String(http.StatusOK, "user "+c.P(0))
+	})
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(GET, path, nil))
+		return rec
+	}
+
+	// Without debug mode the request is served as usual
+	for _, path := range []string{"/users/42?_trace", "/users/42?_trace=1"} {
+		rec := get(path)
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+		assert.Equal(t, "user 42", rec.Body.String(), path)
+	}
+	assert.Equal(t, http.StatusNotFound, get("/nope?_trace").Code)
+
+	e.SetDebug(true)
+	rec := get("/users/42?_trace")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ApplicationJSONCharsetUTF8, rec.Header().Get(ContentType))
+	var mt MatchTrace
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &mt)) {
+		assert.Equal(t, GET, mt.Method)
+		assert.Equal(t, "/users/42", mt.Path)
+		assert.Equal(t, "/users/:id", mt.Route)
+		assert.Equal(t, http.StatusOK, mt.Status)
+		assert.Equal(t, map[string]string{"id": "42"}, mt.Params)
+		assert.Equal(t, []string{GET}, mt.Allowed)
+		assert.True(t, len(mt.Steps) > 0)
+	}
+
+	// Unmatched requests are traced too, not answered with an error
+	rec = get("/nope?_trace")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mt = MatchTrace{}
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &mt)) {
+		assert.Equal(t, http.StatusNotFound, mt.Status)
+		assert.Equal(t, "", mt.Route)
+	}
+
+	// Other parameters don't trigger it
+	rec = get("/users/42?x_trace=1")
+	assert.Equal(t, "user 42", rec.Body.String())
+}
+
+func TestTraceMatch(t *testing.T) {
+	e := New()
+	h := func(c *Context) error { return nil }
+	e.Get("/users/:id", h)
+	e.Put("/users/:id", h)
+
+	mt := e.TraceMatch(POST, "/users/7/")
+	assert.Equal(t, "/users/7", mt.Path)
+	assert.Equal(t, "/users/:id", mt.Route)
+	assert.Equal(t, http.StatusMethodNotAllowed, mt.Status)
+	assert.Equal(t, []string{GET, PUT}, mt.Allowed)
+
+	mt = e.TraceMatch(GET, "/posts")
+	assert.Equal(t, http.StatusNotFound, mt.Status)
+}
@@ -0,0 +1,33 @@
+package core
+
+import "net/http"
+
+// Trailer is the header declaring the trailers of a message.
+const Trailer = "Trailer"
+
+// DeclareTrailer announces the trailers the response will carry, it must be
+// called before the response is committed. Some clients (e.g. gRPC-web) only
+// read declared trailers.
+func (r *Response) DeclareTrailer(keys ...string) {
+	for _, k := range keys {
+		r.Header().Add(Trailer, http.CanonicalHeaderKey(k))
+	}
+}
+
+// SetTrailer sets a response trailer, sent after the body. It can be called
+// at any time before the handler returns, whether the trailer was declared
+// or not.
+func (r *Response) SetTrailer(key, value string) {
+	r.Header().Set(http.TrailerPrefix+http.CanonicalHeaderKey(key), value)
+}
+
+// Trailer returns a request trailer. Trailers follow the body, so they are
+// only available once it was read to the end.
+func (c *Context) Trailer(key string) string {
+	return c.request.Trailer.Get(key)
+}
+
+// Trailers returns the request trailers received so far, see Trailer.
+func (c *Context) Trailers() http.Header {
+	return c.request.Trailer
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+type (
+	// TransformFunc rewrites a buffered response body once the handler chain
+	// has returned, e.g. to inject CDN URLs or wrap API responses. Headers can
+	// still be changed through c.Response().Header().
+	TransformFunc func(c *Context, body *[]byte)
+
+	// BufferWriter holds back a response so it can be rewritten once the
+	// handler returns, see Echo.Transform. Streamed (flushed) and hijacked
+	// responses are passed through untouched.
+	BufferWriter struct {
+		http.ResponseWriter
+		buf         bytes.Buffer
+		code        int
+		passthrough bool
+	}
+)
+
+// Transform registers functions run on every buffered response body, in order.
+func (e *Echo) Transform(fn ...TransformFunc) {
+	e.transforms = append(e.transforms, fn...)
+}
+
+// Transform registers transform functions for the routes of the group.
+func (g *Group) Transform(fn ...TransformFunc) {
+	g.echo.Transform(fn...)
+}
+
+func (e *Echo) transform(c *Context, w *BufferWriter) {
+	body, ok := w.Buffered()
+	if !ok {
+		return
+	}
+	for _, fn := range e.transforms {
+		fn(c, &body)
+	}
+	if w.Header().Get(ContentLength) != "" {
+		w.Header().Set(ContentLength, strconv.Itoa(len(body)))
+	}
+	w.Send(body)
+}
+
+// NewBufferWriter returns a BufferWriter buffering the response sent to w.
+func NewBufferWriter(w http.ResponseWriter) *BufferWriter {
+	return &BufferWriter{ResponseWriter: w}
+}
+
+// Buffered returns the body written so far. ok is false once the response
+// passed through, there's nothing to rewrite then.
+func (w *BufferWriter) Buffered() (body []byte, ok bool) {
+	return w.buf.Bytes(), !w.passthrough
+}
+
+// Send sends the status code held back and body, in place of what was
+// buffered.
+func (w *BufferWriter) Send(body []byte) {
+	if w.code != 0 {
+		w.ResponseWriter.WriteHeader(w.code)
+		w.code = 0
+	}
+	if len(body) > 0 {
+		w.ResponseWriter.Write(body)
+	}
+	w.buf.Reset()
+}
+
+func (w *BufferWriter) WriteHeader(code int) {
+	if w.passthrough || Informational(code) {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.code = code
+}
+
+func (w *BufferWriter) Write(b []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.buf.Write(b)
+}
+
+// Flush sends what is buffered and stops buffering.
+func (w *BufferWriter) Flush() {
+	if !w.passthrough {
+		w.Send(w.buf.Bytes())
+		w.passthrough = true
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *BufferWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.passthrough = true
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (w *BufferWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+// Push implements http.Pusher, so HTTP/2 server push still works behind the
+// buffer. It returns http.ErrNotSupported when the connection can't push.
+func (w *BufferWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"fmt"
+	pathpkg "path"
+	"strings"
+)
+
+// TryUse is Use returning an error for a middleware of unknown type instead
+// of panicking, for registration driven by plugins or configuration. Nothing
+// is added if one of m is invalid.
+func (e *Echo) TryUse(m ...Middleware) error {
+	fns := make([]MiddlewareFunc, len(m))
+	for i, h := range m {
+		fn, err := toMiddleware(h)
+		if err != nil {
+			return fmt.Errorf("echo => middleware %d: %v", i, err)
+		}
+		fns[i] = fn
+	}
+	e.middleware = append(e.middleware, fns...)
+	return nil
+}
+
+// TryGet is Get returning an error instead of panicking, see TryMethod.
+func (e *Echo) TryGet(path string, h Handler) error {
+	return e.TryMethod(GET, path, h)
+}
+
+// TryPost is Post returning an error instead of panicking, see TryMethod.
+func (e *Echo) TryPost(path string, h Handler) error {
+	return e.TryMethod(POST, path, h)
+}
+
+// TryPut is Put returning an error instead of panicking, see TryMethod.
+func (e *Echo) TryPut(path string, h Handler) error {
+	return e.TryMethod(PUT, path, h)
+}
+
+// TryPatch is Patch returning an error instead of panicking, see TryMethod.
+func (e *Echo) TryPatch(path string, h Handler) error {
+	return e.TryMethod(PATCH, path, h)
+}
+
+// TryDelete is Delete returning an error instead of panicking, see TryMethod.
+func (e *Echo) TryDelete(path string, h Handler) error {
+	return e.TryMethod(DELETE, path, h)
+}
+
+// TryMethod is Method returning a descriptive error instead of panicking: for
+// an invalid method, a path syntax error such as an unnamed parameter or a
+// wildcard which isn't last, a handler of unknown type, or path parameters
+// the handler can't take. Nothing is registered on error.
+func (e *Echo) TryMethod(method, path string, h Handler) error {
+	if !validMethod(method) {
+		return fmt.Errorf("echo => invalid method %q", method)
+	}
+	path = pathpkg.Join(e.prefix, "/", path)
+	if err := checkPath(path); err != nil {
+		return fmt.Errorf("echo => %s %s: %v", method, path, err)
+	}
+	fn, err := routeHandler(path, h)
+	if err != nil {
+		return fmt.Errorf("echo => %s %s: %v", method, path, err)
+	}
+	e.addRoute(Route{Method: method, Path: path}, fn, h)
+	return nil
+}
+
+// checkPath reports the syntax errors of a route path.
+func checkPath(path string) error {
+	seen := make(map[string]bool)
+	for i, l := 0, len(path); i < l; i++ {
+		switch path[i] {
+		case ':':
+			j := i + 1
+			for i < l && path[i] != '/' {
+				i++
+			}
+			name := path[j:i]
+			switch {
+			case name == "":
+				return fmt.Errorf("unnamed parameter at offset %d", j-1)
+			case strings.ContainsAny(name, ":*"):
+				return fmt.Errorf("invalid parameter name %q", name)
+			case seen[name]:
+				return fmt.Errorf("duplicate parameter %q", name)
+			}
+			seen[name] = true
+		case '*':
+			if i != l-1 {
+				return fmt.Errorf("wildcard at offset %d must end the path", i)
+			}
+		}
+	}
+	return nil
+}
+
+// TryUse is Use returning an error instead of panicking, see Echo.TryUse.
+func (g *Group) TryUse(m ...Middleware) error {
+	return g.echo.TryUse(m...)
+}
+
+// TryGet is Get returning an error instead of panicking, see Echo.TryMethod.
+func (g *Group) TryGet(path string, h Handler) error {
+	return g.echo.TryMethod(GET, path, h)
+}
+
+// TryPost is Post returning an error instead of panicking, see
+// Echo.TryMethod.
+func (g *Group) TryPost(path string, h Handler) error {
+	return g.echo.TryMethod(POST, path, h)
+}
+
+// TryPut is Put returning an error instead of panicking, see Echo.TryMethod.
+func (g *Group) TryPut(path string, h Handler) error {
+	return g.echo.TryMethod(PUT, path, h)
+}
+
+// TryPatch is Patch returning an error instead of panicking, see
+// Echo.TryMethod.
+func (g *Group) TryPatch(path string, h Handler) error {
+	return g.echo.TryMethod(PATCH, path, h)
+}
+
+// TryDelete is Delete returning an error instead of panicking, see
+// Echo.TryMethod.
+func (g *Group) TryDelete(path string, h Handler) error {
+	return g.echo.TryMethod(DELETE, path, h)
+}
+
+// TryMethod is Method returning an error instead of panicking, see
+// Echo.TryMethod.
+func (g *Group) TryMethod(method, path string, h Handler) error {
+	return g.echo.TryMethod(method, path, h)
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckPath(t *testing.T) {
+	for _, tt := range []struct {
+		path string
+		err  string
+	}{
+		{"/", ""},
+		{"/users/:id", ""},
+		{"/users/:id/posts/:post", ""},
+		{"/files/*", ""},
+		{"/users/:", "unnamed parameter at offset 7"},
+		{"/users/:/posts", "unnamed parameter at offset 7"},
+		{"/users/:id:name", `invalid parameter name "id:name"`},
+		{"/users/:id*", `invalid parameter name "id*"`},
+		{"/a/:id/b/:id", `duplicate parameter "id"`},
+		{"/files/*/raw", "wildcard at offset 7 must end the path"},
+	} {
+		err := checkPath(tt.path)
+		if tt.err == "" {
+			assert.NoError(t, err, tt.path)
+		} else if assert.Error(t, err, tt.path) {
+			assert.Equal(t, tt.err, err.Error(), tt.path)
+		}
+	}
+}
+
+func TestTryMethod(t *testing.T) {
+	for _, tt := range []struct {
+		method, path string
+		h            Handler
+		err          string
+	}{
+		{GET, "/users/:id", routeA, ""},
+		{GET, "/users/:id", func(c *Context, id int) error { return nil }, ""},
+		{"GE T", "/", routeA, `echo => invalid method "GE T"`},
+		{"", "/", routeA, `echo => invalid method ""`},
+		{GET, "/files/*/raw", routeA, "echo => GET /files/*/raw: wildcard at offset 7 must end the path"},
+		{POST, "/a/:id/b/:id", routeA, `echo => POST /a/:id/b/:id: duplicate parameter "id"`},
+		{GET, "/", 42, "echo => GET /: "},
+		{GET, "/:id", func(c *Context, id, n int) error { return nil }, "echo => GET /:id: handler takes 2 path parameters"},
+		{GET, "/:id", func(c *Context, id chan int) error { return nil }, "echo => GET /:id: unsupported type chan int"},
+	} {
+		name := fmt.Sprint(tt.method, " ", tt.path, " ", tt.err)
+		e := New()
+		err := e.TryMethod(tt.method, tt.path, tt.h)
+		if tt.err == "" {
+			assert.NoError(t, err, name)
+			assert.Equal(t, 1, len(e.Routes()), name)
+			continue
+		}
+		if assert.Error(t, err, name) {
+			assert.True(t, strings.HasPrefix(err.Error(), tt.err), fmt.Sprint(name, ": ", err))
+		}
+		assert.Equal(t, 0, len(e.Routes()), name)
+
+		// Method panics on the same routes, with the same message
+		func() {
+			defer func() {
+				assert.Equal(t, err.Error(), fmt.Sprint(recover()), name)
+			}()
+			e.Method(tt.method, tt.path, tt.h)
+		}()
+	}
+}
+
+func TestTryGroup(t *testing.T) {
+	e := New()
+	g := e.Group("/api")
+	assert.NoError(t, g.TryGet("/users/:id", routeA))
+	assert.Error(t, g.TryPost("/users/:", routeA))
+	routes := e.Routes()
+	if assert.Equal(t, 1, len(routes)) {
+		assert.Equal(t, "/api/users/:id", routes[0].Path)
+	}
+}
+
+func TestTryUse(t *testing.T) {
+	e := New()
+	err := e.TryUse(func(c *Context) error { return nil }, 42)
+	if assert.Error(t, err) {
+		assert.True(t, strings.HasPrefix(err.Error(), "echo => middleware 1: "), err.Error())
+	}
+	assert.Equal(t, 0, len(e.middleware))
+
+	assert.NoError(t, e.TryUse(func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			c.Response().Header().Set("X-Try", "1")
+			return next(c)
+		}
+	}, func(w http.ResponseWriter, r *http.Request) {}))
+	assert.Equal(t, 2, len(e.middleware))
+}
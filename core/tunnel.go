@@ -0,0 +1,104 @@
+package core
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// tunnelConn is a hijacked connection whose reads start with the bytes the
+// server had already buffered.
+type tunnelConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// HijackTunnel takes over the connection of a CONNECT request: it answers
+// "200 Connection Established" and returns the client connection, to be paired
+// with the upstream one by Tunnel. Register the handler with
+// `e.Connect("/", h)`, the requested authority is in c.Request().Host.
+func (c *Context) HijackTunnel() (net.Conn, error) {
+	if _, ok := c.response.writer.(http.Hijacker); !ok {
+		return nil, http.ErrNotSupported
+	}
+	conn, rw, err := c.response.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	c.response.status = http.StatusOK
+	c.response.committed = true
+	if _, err = rw.WriteString("HTTP/1.1 200 Connection Established\r\n\r\n"); err == nil {
+		err = rw.Flush()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if rw.Reader.Buffered() > 0 {
+		return &tunnelConn{Conn: conn, r: rw.Reader}, nil
+	}
+	return conn, nil
+}
+
+func (t *tunnelConn) Read(b []byte) (int, error) {
+	if t.r.Buffered() > 0 {
+		return t.r.Read(b)
+	}
+	return t.Conn.Read(b)
+}
+
+// Tunnel copies bytes between a and b in both directions until one side is
+// closed or no byte went through for idle (no limit if zero). Both connections
+// are closed on return, the first error other than EOF is returned.
+func Tunnel(a, b net.Conn, idle time.Duration) error {
+	var (
+		last   = time.Now().UnixNano()
+		once   sync.Once
+		result error
+		wg     sync.WaitGroup
+	)
+	finish := func(err error) {
+		once.Do(func() {
+			result = err
+			a.Close()
+			b.Close()
+		})
+	}
+	pipe := func(dst, src net.Conn) {
+		defer wg.Done()
+		buf := make([]byte, 32<<10)
+		for {
+			if idle > 0 {
+				src.SetReadDeadline(time.Unix(0, atomic.LoadInt64(&last)).Add(idle))
+			}
+			n, err := src.Read(buf)
+			if n > 0 {
+				atomic.StoreInt64(&last, time.Now().UnixNano())
+				if _, werr := dst.Write(buf[:n]); werr != nil {
+					finish(werr)
+					return
+				}
+			}
+			if err != nil {
+				if ne, ok := err.(net.Error); ok && ne.Timeout() &&
+					time.Since(time.Unix(0, atomic.LoadInt64(&last))) < idle {
+					continue // the other direction is active
+				}
+				if err == io.EOF {
+					err = nil
+				}
+				finish(err)
+				return
+			}
+		}
+	}
+	wg.Add(2)
+	go pipe(a, b)
+	go pipe(b, a)
+	wg.Wait()
+	return result
+}
@@ -0,0 +1,35 @@
+//go:build go1.18
+// +build go1.18
+
+package core
+
+import (
+	"net/http"
+)
+
+// JSONHandler adapts a typed function to a HandlerFunc. The request is bound
+// into a new Req with Context.Bind (400 on failure, 422 when validation
+// fails), then fn is called and its result sent as JSON with 200 OK.
+//
+//	e.Post("/users", JSONHandler(func(c *Context, req CreateUser) (*User, error) {
+//		...
+//	}))
+func JSONHandler[Req, Resp any](fn func(c *Context, req Req) (Resp, error)) HandlerFunc {
+	return func(c *Context) error {
+		var req Req
+		if err := c.Bind(&req); err != nil {
+			if _, ok := err.(*HTTPError); ok {
+				return err
+			}
+			if err == UnsupportedMediaType {
+				return NewHTTPError(http.StatusUnsupportedMediaType, err.Error())
+			}
+			return NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		resp, err := fn(c, req)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,188 @@
+package upload
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// Progress is the state of a plain (non-chunked) upload being received.
+	Progress struct {
+		ID       string `json:"id"`
+		Received int64  `json:"received"`
+		// Total is the Content-Length of the request, -1 if unknown.
+		Total     int64     `json:"total"`
+		Done      bool      `json:"done"`
+		StartedAt time.Time `json:"started_at"`
+	}
+
+	// Tracker tracks the progress of plain uploads by upload ID, so UIs can
+	// show progress bars without chunking the upload in JavaScript.
+	//
+	// The client picks a random upload ID, sends it with the upload as the
+	// "upload_id" query parameter (or the X-Progress-ID header), and polls the
+	// progress endpoint with the same ID. Query parameters keep both requests
+	// simple in the CORS sense, so no preflight is needed.
+	Tracker struct {
+		// Linger is how long the progress of a finished upload stays
+		// available. Defaults to one minute.
+		Linger time.Duration
+		// Interval is how often the event stream reports progress. Defaults
+		// to 500 milliseconds.
+		Interval time.Duration
+
+		mu sync.Mutex
+		m  map[string]*tracked
+	}
+
+	tracked struct {
+		p        Progress
+		received int64 // atomic
+		done     int32 // atomic
+		ended    time.Time
+	}
+
+	// CountingReader counts the bytes read through it.
+	CountingReader struct {
+		io.ReadCloser
+		n *int64
+	}
+)
+
+// Progress ID carriers.
+const (
+	ProgressParam  = "upload_id"
+	ProgressHeader = "X-Progress-ID"
+)
+
+// NewCountingReader wraps r, adding the bytes read to *n atomically.
+func NewCountingReader(r io.ReadCloser, n *int64) *CountingReader {
+	return &CountingReader{ReadCloser: r, n: n}
+}
+
+func (r *CountingReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	atomic.AddInt64(r.n, int64(n))
+	return n, err
+}
+
+// NewTracker returns an empty Tracker.
+func NewTracker() *Tracker {
+	return &Tracker{
+		Linger:   time.Minute,
+		Interval: 500 * time.Millisecond,
+		m:        make(map[string]*tracked),
+	}
+}
+
+// Track returns a middleware which counts the request body of uploads carrying
+// an upload ID.
+func (t *Tracker) Track() core.MiddlewareFunc {
+	return func(next core.HandlerFunc) core.HandlerFunc {
+		return func(c *core.Context) error {
+			r := c.Request()
+			id := progressID(c)
+			if id == "" || r.Body == nil {
+				return next(c)
+			}
+			tr := &tracked{p: Progress{ID: id, Total: r.ContentLength, StartedAt: c.Now()}}
+			if tr.p.Total <= 0 {
+				tr.p.Total = -1
+			}
+			t.mu.Lock()
+			t.gc(c.Now())
+			t.m[id] = tr
+			t.mu.Unlock()
+			r.Body = NewCountingReader(r.Body, &tr.received)
+			defer func() {
+				t.mu.Lock()
+				tr.ended = c.Now()
+				atomic.StoreInt32(&tr.done, 1)
+				t.mu.Unlock()
+			}()
+			return next(c)
+		}
+	}
+}
+
+// Get returns the progress of an upload, or nil.
+func (t *Tracker) Get(id string) *Progress {
+	t.mu.Lock()
+	tr := t.m[id]
+	t.mu.Unlock()
+	if tr == nil {
+		return nil
+	}
+	p := tr.p
+	p.Received = atomic.LoadInt64(&tr.received)
+	p.Done = atomic.LoadInt32(&tr.done) == 1
+	return &p
+}
+
+// Serve is the progress endpoint. It answers with the progress as JSON, or
+// with an event stream of it until the upload is done if the client accepts
+// text/event-stream, e.g. through an EventSource. The upload ID is read from
+// the "id" route parameter or the upload_id query parameter.
+func (t *Tracker) Serve(c *core.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		id = progressID(c)
+	}
+	res := c.Response()
+	res.Header().Set(core.CacheControlHeader, "no-store")
+	if !strings.Contains(c.Request().Header.Get("Accept"), "text/event-stream") {
+		p := t.Get(id)
+		if p == nil {
+			return core.NewHTTPError(http.StatusNotFound)
+		}
+		return c.JSON(http.StatusOK, p)
+	}
+
+	res.Header().Set(core.ContentType, "text/event-stream")
+	res.WriteHeader(http.StatusOK)
+	ticker := time.NewTicker(t.Interval)
+	defer ticker.Stop()
+	for {
+		// Report unknown uploads too: the poll may start before the upload.
+		p := t.Get(id)
+		if p == nil {
+			p = &Progress{ID: id, Total: -1}
+		}
+		b, _ := json.Marshal(p)
+		if _, err := res.Write(append(append([]byte("data: "), b...), '\n', '\n')); err != nil {
+			return nil
+		}
+		res.Flush()
+		if p.Done {
+			return nil
+		}
+		select {
+		case <-c.StdContext().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// gc drops the uploads which ended more than Linger ago, t.mu must be held.
+func (t *Tracker) gc(now time.Time) {
+	for id, tr := range t.m {
+		if !tr.ended.IsZero() && now.Sub(tr.ended) > t.Linger {
+			delete(t.m, id)
+		}
+	}
+}
+
+func progressID(c *core.Context) string {
+	if id := c.Query(ProgressParam); id != "" {
+		return id
+	}
+	return c.Request().Header.Get(ProgressHeader)
+}
@@ -0,0 +1,65 @@
+package upload
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker()
+	e := core.New()
+	e.Use(tracker.Track())
+	read := make(chan struct{})
+	resume := make(chan struct{})
+	e.Post("/upload", func(c *core.Context) error {
+		b := make([]byte, 4)
+		io.ReadFull(c.Request().Body, b)
+		read <- struct{}{}
+		<-resume
+		ioutil.ReadAll(c.Request().Body)
+		return c.NoContent(http.StatusNoContent)
+	})
+	e.Get("/progress/:id", tracker.Serve)
+	get := func(accept string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(core.GET, "/progress/u1", nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusNotFound, get(core.ApplicationJSON).Code)
+
+	done := make(chan struct{})
+	go func() {
+		req, _ := http.NewRequest(core.POST, "/upload?upload_id=u1", strings.NewReader("0123456789"))
+		e.ServeHTTP(httptest.NewRecorder(), req)
+		close(done)
+	}()
+	<-read
+	var p Progress
+	rec := get(core.ApplicationJSON)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, int64(4), p.Received)
+	assert.Equal(t, int64(10), p.Total)
+	assert.False(t, p.Done)
+	close(resume)
+	<-done
+
+	p = *tracker.Get("u1")
+	assert.Equal(t, int64(10), p.Received)
+	assert.True(t, p.Done)
+
+	// Event stream ends once the upload is done
+	rec = get("text/event-stream")
+	assert.Equal(t, "text/event-stream", rec.Header().Get(core.ContentType))
+	assert.True(t, strings.HasPrefix(rec.Body.String(), `data: {"id":"u1","received":10,"total":10,"done":true`))
+}
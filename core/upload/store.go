@@ -0,0 +1,142 @@
+package upload
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileStore keeps uploads in a directory, as `<id>` data files next to
+// `<id>.info` JSON files.
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileStore returns a FileStore writing to dir, which is created if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Create implements Store.
+func (s *FileStore) Create(info *Info) error {
+	f, err := os.Create(s.path(info.ID))
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return s.writeInfo(info)
+}
+
+// Info implements Store.
+func (s *FileStore) Info(id string) (*Info, error) {
+	if !validID(id) {
+		return nil, nil
+	}
+	b, err := ioutil.ReadFile(s.path(id) + ".info")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	info := new(Info)
+	return info, json.Unmarshal(b, info)
+}
+
+// Append implements Store.
+func (s *FileStore) Append(id string, offset int64, b []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, err := s.Info(id)
+	if err != nil || info == nil {
+		return err
+	}
+	if info.Offset != offset {
+		return ErrOffsetMismatch
+	}
+	f, err := os.OpenFile(s.path(id), os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteAt(b, info.Offset)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	info.Offset += int64(len(b))
+	return s.writeInfo(info)
+}
+
+// Open implements Store.
+func (s *FileStore) Open(id string) (io.ReadCloser, error) {
+	return os.Open(s.path(id))
+}
+
+// Delete implements Store.
+func (s *FileStore) Delete(id string) error {
+	os.Remove(s.path(id) + ".info")
+	if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Expire implements Store.
+func (s *FileStore) Expire(before time.Time) error {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		id := strings.TrimSuffix(f.Name(), ".info")
+		if id == f.Name() || !validID(id) {
+			continue
+		}
+		info, err := s.Info(id)
+		if err != nil {
+			return err
+		}
+		if info != nil && info.CreatedAt.Before(before) {
+			if err = s.Delete(id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.dir, id)
+}
+
+func (s *FileStore) writeInfo(info *Info) error {
+	b, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path(info.ID)+".info", b, 0644)
+}
+
+// validID keeps user supplied IDs inside the store directory.
+func validID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, c := range id {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,149 @@
+package upload
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// ObjectStorage is the subset of an S3-compatible client used to stream
+	// uploads. size is -1 when unknown, which S3 clients such as minio-go
+	// handle by switching to a multipart upload.
+	ObjectStorage interface {
+		PutObject(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	}
+
+	// ObjectStorageFunc adapts a function to ObjectStorage.
+	ObjectStorageFunc func(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// StreamOptions configures StreamMultipart.
+	StreamOptions struct {
+		// Key returns the object key of a file part. Defaults to a unique ID
+		// from the Echo ID generator, a dash and the sanitized base of the
+		// part's file name, see SafeFilename.
+		Key func(c *core.Context, p *multipart.Part) string
+
+		// Progress is called as file parts are read, with the bytes read so
+		// far for the part.
+		Progress func(key string, n int64)
+
+		// MaxFieldSize limits non-file fields, 1 MiB by default.
+		MaxFieldSize int64
+	}
+
+	// StreamedPart describes a file part stored by StreamMultipart.
+	StreamedPart struct {
+		Field       string
+		Filename    string
+		Key         string
+		ContentType string
+		Size        int64
+	}
+
+	progressReader struct {
+		r        io.Reader
+		ctx      context.Context
+		key      string
+		n        int64
+		progress func(string, int64)
+	}
+)
+
+// PutObject implements ObjectStorage.
+func (f ObjectStorageFunc) PutObject(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	return f(ctx, key, r, size, contentType)
+}
+
+// StreamMultipart reads a multipart request part by part and streams each file
+// part straight to storage, without buffering it in memory or on disk. The
+// other fields are returned as url.Values. Reading stops with the request
+// context's error once the client goes away or a deadline passes.
+func StreamMultipart(c *core.Context, storage ObjectStorage, opts StreamOptions) ([]StreamedPart, url.Values, error) {
+	if opts.Key == nil {
+		opts.Key = func(c *core.Context, p *multipart.Part) string {
+			return c.NewID() + "-" + SafeFilename(p.FileName())
+		}
+	}
+	if opts.MaxFieldSize == 0 {
+		opts.MaxFieldSize = 1 << 20
+	}
+	mr, err := c.Request().MultipartReader()
+	if err != nil {
+		return nil, nil, core.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.StdContext()
+	var parts []StreamedPart
+	fields := url.Values{}
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return parts, fields, nil
+		}
+		if err != nil {
+			return parts, fields, err
+		}
+		if p.FileName() == "" {
+			b := make([]byte, opts.MaxFieldSize+1)
+			n, err := io.ReadFull(p, b)
+			if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+				return parts, fields, err
+			}
+			if int64(n) > opts.MaxFieldSize {
+				return parts, fields, core.NewHTTPError(http.StatusRequestEntityTooLarge)
+			}
+			fields.Add(p.FormName(), string(b[:n]))
+			continue
+		}
+		sp := StreamedPart{
+			Field:       p.FormName(),
+			Filename:    p.FileName(),
+			Key:         opts.Key(c, p),
+			ContentType: p.Header.Get(core.ContentType),
+		}
+		pr := &progressReader{r: p, ctx: ctx, key: sp.Key, progress: opts.Progress}
+		if err = storage.PutObject(ctx, sp.Key, pr, -1, sp.ContentType); err != nil {
+			return parts, fields, err
+		}
+		sp.Size = pr.n
+		parts = append(parts, sp)
+	}
+}
+
+// SafeFilename returns the base of a client supplied file name, Unix or
+// Windows style, with characters other than ASCII letters, digits, '.', '-'
+// and '_' replaced by '_' and leading dots dropped, so it can't name a hidden
+// file nor a parent directory. It returns "file" if nothing is left.
+func SafeFilename(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i != -1 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+	if name = strings.TrimLeft(name, "."); name == "" {
+		return "file"
+	}
+	return name
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := r.r.Read(b)
+	r.n += int64(n)
+	if r.progress != nil && n > 0 {
+		r.progress(r.key, r.n)
+	}
+	return n, err
+}
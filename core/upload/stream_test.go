@@ -0,0 +1,63 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamMultipart(t *testing.T) {
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	mw.WriteField("album", "trip")
+	fw, _ := mw.CreateFormFile("photo", "../a.jpg")
+	fw.Write(bytes.Repeat([]byte("x"), 10000))
+	mw.Close()
+
+	objects := map[string]int{}
+	var progress int64
+	storage := ObjectStorageFunc(func(ctx context.Context, key string, r io.Reader, size int64, ct string) error {
+		b, err := ioutil.ReadAll(r)
+		objects[key] = len(b)
+		return err
+	})
+
+	e := core.New()
+	e.SetIDGenerator(&core.SequenceIDs{})
+	req, _ := http.NewRequest(core.POST, "/", body)
+	req.Header.Set(core.ContentType, mw.FormDataContentType())
+	c := core.NewContext(req, core.NewResponse(httptest.NewRecorder(), e), e)
+	parts, fields, err := StreamMultipart(c, storage, StreamOptions{
+		Progress: func(key string, n int64) { progress = n },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "trip", fields.Get("album"))
+	assert.Equal(t, 1, len(parts))
+	assert.Equal(t, "1-a.jpg", parts[0].Key)
+	assert.Equal(t, int64(10000), parts[0].Size)
+	assert.Equal(t, 10000, objects["1-a.jpg"])
+	assert.Equal(t, int64(10000), progress)
+}
+
+func TestSafeFilename(t *testing.T) {
+	for name, safe := range map[string]string{
+		"a.jpg":             "a.jpg",
+		"../../etc/passwd":  "passwd",
+		`C:\Users\me\a.jpg`: "a.jpg",
+		"..":                "file",
+		".htaccess":         "htaccess",
+		"":                  "file",
+		"résumé final.pdf":  "r_sum__final.pdf",
+		"a/":                "file",
+	} {
+		assert.Equal(t, safe, SafeFilename(name), name)
+	}
+}
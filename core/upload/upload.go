@@ -0,0 +1,328 @@
+// Package upload implements resumable chunked uploads in the spirit of the tus
+// protocol:
+//
+//	POST   /uploads      {"filename", "size", "sha256"} creates an upload
+//	PATCH  /uploads/:id  appends a chunk at the Upload-Offset header
+//	HEAD   /uploads/:id  reports Upload-Offset and Upload-Length
+//	GET    /uploads/:id  reports the progress as JSON
+//	DELETE /uploads/:id  abandons an upload
+//
+// Chunks may carry an `Upload-Checksum: sha256 <base64>` header, and the
+// assembled file is checked against the sha256 given at creation. Uploads not
+// completed in time expire, see Config.Expiry.
+//
+// StreamMultipart streams plain multipart uploads to object storage instead.
+// Tracker reports the progress of plain uploads to polling clients.
+package upload
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+)
+
+type (
+	// Info describes an upload.
+	Info struct {
+		ID        string    `json:"id"`
+		Filename  string    `json:"filename"`
+		Size      int64     `json:"size"`
+		Offset    int64     `json:"offset"`
+		SHA256    string    `json:"sha256,omitempty"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	// Store keeps uploads and their data.
+	Store interface {
+		Create(info *Info) error
+		// Info returns nil if the upload doesn't exist.
+		Info(id string) (*Info, error)
+		// Append writes b at offset, which must be the current end of the
+		// upload data (ErrOffsetMismatch otherwise), and updates the offset.
+		Append(id string, offset int64, b []byte) error
+		// Open returns the upload data.
+		Open(id string) (io.ReadCloser, error)
+		Delete(id string) error
+		// Expire deletes the uploads created before.
+		Expire(before time.Time) error
+	}
+
+	// Config defines the upload config.
+	Config struct {
+		Store Store
+
+		// MaxSize limits the size of an upload, 0 means unlimited.
+		MaxSize int64
+
+		// MaxChunkSize limits the size of a chunk, which is held in memory
+		// while its checksum is verified.
+		MaxChunkSize int64
+
+		// OnComplete is called once an upload is assembled and verified, the
+		// upload is deleted from the store when it returns without error.
+		OnComplete func(c *core.Context, info *Info, data io.Reader) error
+
+		// Expiry is how long an upload may take, it's deleted afterwards.
+		// Optional. Default value DefaultExpiry.
+		Expiry time.Duration
+	}
+
+	// Handler serves resumable uploads.
+	Handler struct {
+		config Config
+		mu     sync.Mutex
+		sweep  time.Time // next sweep of the expired uploads
+	}
+)
+
+// Protocol headers.
+const (
+	HeaderOffset   = "Upload-Offset"
+	HeaderLength   = "Upload-Length"
+	HeaderChecksum = "Upload-Checksum"
+	HeaderExpires  = "Upload-Expires"
+)
+
+const (
+	// DefaultMaxChunkSize is used when Config.MaxChunkSize is 0.
+	DefaultMaxChunkSize = 8 << 20
+
+	// DefaultExpiry is used when Config.Expiry is 0.
+	DefaultExpiry = 24 * time.Hour
+)
+
+var (
+	ErrOffsetMismatch   = errors.New("upload: offset mismatch")
+	ErrChecksumMismatch = errors.New("upload: checksum mismatch")
+)
+
+// Mount registers the upload routes on g.
+func Mount(g *core.Group, config Config) *Handler {
+	if config.Store == nil {
+		panic("upload: store required")
+	}
+	if config.MaxChunkSize == 0 {
+		config.MaxChunkSize = DefaultMaxChunkSize
+	}
+	if config.Expiry == 0 {
+		config.Expiry = DefaultExpiry
+	}
+	h := &Handler{config: config}
+	g.Post("", h.create)
+	g.Head("/:id", h.head)
+	g.Get("/:id", h.progress)
+	g.Patch("/:id", h.patch)
+	g.Delete("/:id", h.delete)
+	return h
+}
+
+func (h *Handler) create(c *core.Context) error {
+	info := new(Info)
+	if err := c.Bind(info); err != nil {
+		return core.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if info.Size <= 0 {
+		return core.NewHTTPError(http.StatusBadRequest, "size required")
+	}
+	if h.config.MaxSize > 0 && info.Size > h.config.MaxSize {
+		return core.NewHTTPError(http.StatusRequestEntityTooLarge)
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	info.ID = hex.EncodeToString(b)
+	info.Offset = 0
+	info.SHA256 = strings.ToLower(info.SHA256)
+	info.CreatedAt = c.Now()
+	if err := h.expire(info.CreatedAt); err != nil {
+		return err
+	}
+	if err := h.config.Store.Create(info); err != nil {
+		return err
+	}
+	h.setHeaders(c, info)
+	c.Response().Header().Set(core.Location, strings.TrimSuffix(c.Request().URL.Path, "/")+"/"+info.ID)
+	return c.JSON(http.StatusCreated, info)
+}
+
+func (h *Handler) head(c *core.Context) error {
+	info, err := h.info(c)
+	if err != nil {
+		return err
+	}
+	h.setHeaders(c, info)
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *Handler) progress(c *core.Context) error {
+	info, err := h.info(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, struct {
+		*Info
+		Complete bool `json:"complete"`
+	}{info, info.Offset == info.Size})
+}
+
+func (h *Handler) patch(c *core.Context) error {
+	info, err := h.info(c)
+	if err != nil {
+		return err
+	}
+	offset, err := strconv.ParseInt(c.Request().Header.Get(HeaderOffset), 10, 64)
+	if err != nil {
+		return core.NewHTTPError(http.StatusBadRequest, "invalid "+HeaderOffset)
+	}
+	if offset != info.Offset {
+		h.setHeaders(c, info)
+		return core.NewHTTPError(http.StatusConflict, ErrOffsetMismatch.Error())
+	}
+	if info.Offset == info.Size {
+		// Already complete, OnComplete ran or is running.
+		h.setHeaders(c, info)
+		return c.NoContent(http.StatusNoContent)
+	}
+	limit := h.config.MaxChunkSize
+	if rest := info.Size - info.Offset; rest < limit {
+		limit = rest
+	}
+	chunk, err := ioutil.ReadAll(io.LimitReader(c.Request().Body, limit+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(chunk)) > limit {
+		return core.NewHTTPError(http.StatusRequestEntityTooLarge)
+	}
+	if err = verifyChunk(c.Request().Header.Get(HeaderChecksum), chunk); err != nil {
+		return err
+	}
+	if err = h.config.Store.Append(info.ID, offset, chunk); err != nil {
+		if err == ErrOffsetMismatch {
+			return core.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return err
+	}
+	info.Offset += int64(len(chunk))
+	if len(chunk) > 0 && info.Offset == info.Size {
+		if err = h.complete(c, info); err != nil {
+			return err
+		}
+	}
+	h.setHeaders(c, info)
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) delete(c *core.Context) error {
+	info, err := h.info(c)
+	if err != nil {
+		return err
+	}
+	if err = h.config.Store.Delete(info.ID); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// complete checks the assembled file and hands it to OnComplete.
+func (h *Handler) complete(c *core.Context, info *Info) error {
+	if info.SHA256 != "" {
+		r, err := h.config.Store.Open(info.ID)
+		if err != nil {
+			return err
+		}
+		sum := sha256.New()
+		_, err = io.Copy(sum, r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+		if hex.EncodeToString(sum.Sum(nil)) != info.SHA256 {
+			h.config.Store.Delete(info.ID)
+			return core.NewHTTPError(http.StatusUnprocessableEntity, ErrChecksumMismatch.Error())
+		}
+	}
+	if h.config.OnComplete == nil {
+		return nil
+	}
+	r, err := h.config.Store.Open(info.ID)
+	if err != nil {
+		return err
+	}
+	err = h.config.OnComplete(c, info, r)
+	r.Close()
+	if err != nil {
+		return err
+	}
+	return h.config.Store.Delete(info.ID)
+}
+
+func (h *Handler) info(c *core.Context) (*Info, error) {
+	info, err := h.config.Store.Info(c.Param("id"))
+	if err != nil {
+		return nil, err
+	}
+	if info != nil && !c.Now().Before(info.CreatedAt.Add(h.config.Expiry)) {
+		if err = h.config.Store.Delete(info.ID); err != nil {
+			return nil, err
+		}
+		info = nil
+	}
+	if info == nil {
+		return nil, core.NewHTTPError(http.StatusNotFound)
+	}
+	return info, nil
+}
+
+// expire deletes the expired uploads, at most once per minute.
+func (h *Handler) expire(now time.Time) error {
+	h.mu.Lock()
+	if now.Before(h.sweep) {
+		h.mu.Unlock()
+		return nil
+	}
+	h.sweep = now.Add(time.Minute)
+	h.mu.Unlock()
+	return h.config.Store.Expire(now.Add(-h.config.Expiry))
+}
+
+func (h *Handler) setHeaders(c *core.Context, info *Info) {
+	hdr := c.Response().Header()
+	hdr.Set(HeaderOffset, strconv.FormatInt(info.Offset, 10))
+	hdr.Set(HeaderLength, strconv.FormatInt(info.Size, 10))
+	hdr.Set(HeaderExpires, info.CreatedAt.Add(h.config.Expiry).UTC().Format(http.TimeFormat))
+	hdr.Set(core.CacheControlHeader, "no-store")
+}
+
+// verifyChunk checks a `sha256 <base64>` checksum header.
+func verifyChunk(header string, chunk []byte) error {
+	if header == "" {
+		return nil
+	}
+	parts := strings.SplitN(header, " ", 2)
+	if len(parts) != 2 || parts[0] != "sha256" {
+		return core.NewHTTPError(http.StatusBadRequest, "unsupported checksum algorithm")
+	}
+	want, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return core.NewHTTPError(http.StatusBadRequest, "invalid "+HeaderChecksum)
+	}
+	sum := sha256.Sum256(chunk)
+	if !bytes.Equal(sum[:], want) {
+		return core.NewHTTPError(http.StatusUnprocessableEntity, ErrChecksumMismatch.Error())
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package upload
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpload(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "upload")
+	defer os.RemoveAll(dir)
+	store, err := NewFileStore(dir)
+	assert.NoError(t, err)
+
+	data := []byte("hello resumable world")
+	sum := sha256.Sum256(data)
+	var got []byte
+	e := core.New()
+	Mount(e.Group("/uploads"), Config{
+		Store: store,
+		OnComplete: func(c *core.Context, info *Info, r io.Reader) error {
+			got, _ = ioutil.ReadAll(r)
+			return nil
+		},
+	})
+	serve := func(method, path string, body []byte, hdr ...string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewReader(body))
+		for i := 0; i < len(hdr); i += 2 {
+			req.Header.Set(hdr[i], hdr[i+1])
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	b, _ := json.Marshal(map[string]interface{}{"filename": "a.txt", "size": len(data), "sha256": hex.EncodeToString(sum[:])})
+	rec := serve(core.POST, "/uploads", b, core.ContentType, core.ApplicationJSON)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	loc := rec.Header().Get(core.Location)
+
+	chunkSum := sha256.Sum256(data[:5])
+	rec = serve(core.PATCH, loc, data[:5], HeaderOffset, "0", HeaderChecksum, "sha256 "+base64.StdEncoding.EncodeToString(chunkSum[:]))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "5", rec.Header().Get(HeaderOffset))
+
+	rec = serve(core.PATCH, loc, data[5:], HeaderOffset, "5", HeaderChecksum, "sha256 "+base64.StdEncoding.EncodeToString(chunkSum[:]))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = serve(core.PATCH, loc, data[5:], HeaderOffset, "0")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = serve(core.HEAD, loc, nil)
+	assert.Equal(t, "5", rec.Header().Get(HeaderOffset))
+	assert.Equal(t, strconv.Itoa(len(data)), rec.Header().Get(HeaderLength))
+
+	rec = serve(core.PATCH, loc, data[5:], HeaderOffset, "5")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, data, got)
+	assert.Equal(t, http.StatusNotFound, serve(core.GET, loc, nil).Code)
+}
+
+func TestUploadCompleteOnce(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "upload")
+	defer os.RemoveAll(dir)
+	store, _ := NewFileStore(dir)
+	clock := core.NewManualClock(time.Unix(1500000000, 0))
+	e := core.New()
+	e.SetClock(clock)
+	var completed int
+	Mount(e.Group("/uploads"), Config{
+		Store:  store,
+		Expiry: time.Hour,
+		OnComplete: func(c *core.Context, info *Info, r io.Reader) error {
+			completed++
+			return errors.New("kept")
+		},
+	})
+	serve := func(method, path string, body []byte, hdr ...string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewReader(body))
+		for i := 0; i < len(hdr); i += 2 {
+			req.Header.Set(hdr[i], hdr[i+1])
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	create := func() string {
+		rec := serve(core.POST, "/uploads", []byte(`{"filename":"a.txt","size":3}`), core.ContentType, core.ApplicationJSON)
+		assert.Equal(t, clock.Now().Add(time.Hour).UTC().Format(http.TimeFormat), rec.Header().Get(HeaderExpires))
+		return rec.Header().Get(core.Location)
+	}
+
+	// The upload is kept as OnComplete failed, empty chunks don't complete it
+	// again.
+	loc := create()
+	assert.Equal(t, http.StatusInternalServerError, serve(core.PATCH, loc, []byte("abc"), HeaderOffset, "0").Code)
+	assert.Equal(t, http.StatusNoContent, serve(core.PATCH, loc, nil, HeaderOffset, "3").Code)
+	assert.Equal(t, 1, completed)
+
+	// Abandoned uploads expire.
+	loc = create()
+	assert.Equal(t, http.StatusOK, serve(core.HEAD, loc, nil).Code)
+	clock.Advance(time.Hour)
+	assert.Equal(t, http.StatusNotFound, serve(core.HEAD, loc, nil).Code)
+
+	// and are swept.
+	create()
+	clock.Advance(2 * time.Hour)
+	create()
+	files, _ := ioutil.ReadDir(dir)
+	assert.Equal(t, 2, len(files))
+}
@@ -0,0 +1,212 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type (
+	// Validation collects the errors of request value checks for endpoints that
+	// don't bind into structs, e.g.
+	//
+	//	c.Validate().Query("page").Int().Min(1)
+	//	c.Validate().Query("q").Required().MaxLen(100)
+	//	if err := c.Validate().Err(); err != nil {
+	//		return err // 422 listing every failed field
+	//	}
+	Validation struct {
+		c      *Context
+		fields []string
+		errors map[string]string
+	}
+
+	// FieldValidation checks a single request value. The first failed check of a
+	// field is kept, the following ones are skipped.
+	FieldValidation struct {
+		v       *Validation
+		name    string
+		value   string
+		num     float64
+		numeric bool
+		failed  bool
+	}
+)
+
+// Validate returns the validation of the current request. Repeated calls return
+// the same instance so errors accumulate.
+func (c *Context) Validate() *Validation {
+	if c.validation == nil {
+		c.validation = &Validation{c: c, errors: map[string]string{}}
+	}
+	return c.validation
+}
+
+// Query starts the checks of a query parameter.
+func (v *Validation) Query(name string) *FieldValidation {
+	return v.Field(name, v.c.Query(name))
+}
+
+// Form starts the checks of a form parameter.
+func (v *Validation) Form(name string) *FieldValidation {
+	return v.Field(name, v.c.Form(name))
+}
+
+// Param starts the checks of a path parameter.
+func (v *Validation) Param(name string) *FieldValidation {
+	return v.Field(name, v.c.Param(name))
+}
+
+// Field starts the checks of an arbitrary value reported under `name`.
+func (v *Validation) Field(name, value string) *FieldValidation {
+	return &FieldValidation{v: v, name: name, value: value}
+}
+
+// Errors returns the failed fields and their messages.
+func (v *Validation) Errors() map[string]string {
+	return v.errors
+}
+
+// HasErrors reports whether a check has failed.
+func (v *Validation) HasErrors() bool {
+	return len(v.errors) > 0
+}
+
+// Err returns a 422 HTTPError listing every failed field, or nil.
+func (v *Validation) Err() error {
+	if !v.HasErrors() {
+		return nil
+	}
+	msgs := make([]string, len(v.fields))
+	for i, f := range v.fields {
+		msgs[i] = f + ": " + v.errors[f]
+	}
+	return NewHTTPError(http.StatusUnprocessableEntity, strings.Join(msgs, "; "))
+}
+
+func (v *Validation) add(name, msg string) {
+	if _, ok := v.errors[name]; !ok {
+		v.fields = append(v.fields, name)
+	}
+	v.errors[name] = msg
+}
+
+func (f *FieldValidation) fail(format string, args ...interface{}) *FieldValidation {
+	f.failed = true
+	f.v.add(f.name, fmt.Sprintf(format, args...))
+	return f
+}
+
+func (f *FieldValidation) skip() bool {
+	return f.failed || f.value == ""
+}
+
+// Value returns the raw value.
+func (f *FieldValidation) Value() string {
+	return f.value
+}
+
+// Required fails if the value is empty.
+func (f *FieldValidation) Required() *FieldValidation {
+	if !f.failed && f.value == "" {
+		return f.fail("is required")
+	}
+	return f
+}
+
+// Int fails if the value is not an integer.
+func (f *FieldValidation) Int() *FieldValidation {
+	if f.skip() {
+		return f
+	}
+	n, err := strconv.ParseInt(f.value, 10, 64)
+	if err != nil {
+		return f.fail("must be an integer")
+	}
+	f.num, f.numeric = float64(n), true
+	return f
+}
+
+// Float fails if the value is not a number.
+func (f *FieldValidation) Float() *FieldValidation {
+	if f.skip() {
+		return f
+	}
+	n, err := strconv.ParseFloat(f.value, 64)
+	if err != nil {
+		return f.fail("must be a number")
+	}
+	f.num, f.numeric = n, true
+	return f
+}
+
+// Min fails if the number is lower than `min`. Requires Int or Float.
+func (f *FieldValidation) Min(min float64) *FieldValidation {
+	if f.skip() || !f.numeric {
+		return f
+	}
+	if f.num < min {
+		return f.fail("must be at least %v", min)
+	}
+	return f
+}
+
+// Max fails if the number is greater than `max`. Requires Int or Float.
+func (f *FieldValidation) Max(max float64) *FieldValidation {
+	if f.skip() || !f.numeric {
+		return f
+	}
+	if f.num > max {
+		return f.fail("must be at most %v", max)
+	}
+	return f
+}
+
+// MinLen fails if the value has less than `n` characters.
+func (f *FieldValidation) MinLen(n int) *FieldValidation {
+	if f.skip() {
+		return f
+	}
+	if utf8.RuneCountInString(f.value) < n {
+		return f.fail("must be at least %d characters", n)
+	}
+	return f
+}
+
+// MaxLen fails if the value has more than `n` characters.
+func (f *FieldValidation) MaxLen(n int) *FieldValidation {
+	if f.skip() {
+		return f
+	}
+	if utf8.RuneCountInString(f.value) > n {
+		return f.fail("must be at most %d characters", n)
+	}
+	return f
+}
+
+// Match fails if the value doesn't match `re`.
+func (f *FieldValidation) Match(re *regexp.Regexp) *FieldValidation {
+	if f.skip() {
+		return f
+	}
+	if !re.MatchString(f.value) {
+		return f.fail("has an invalid format")
+	}
+	return f
+}
+
+// In fails if the value is not one of `values`.
+func (f *FieldValidation) In(values ...string) *FieldValidation {
+	if f.skip() {
+		return f
+	}
+	for _, v := range values {
+		if f.value == v {
+			return f
+		}
+	}
+	return f.fail("must be one of %s", strings.Join(values, ", "))
+}
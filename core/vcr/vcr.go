@@ -0,0 +1,243 @@
+// Package vcr records the HTTP interactions of a client into a cassette file
+// and replays them later, so tests against upstream services are fast and
+// deterministic:
+//
+//	rec, err := vcr.New("testdata/github.json", vcr.ModeAuto, nil)
+//	defer rec.Stop()
+//	client := rec.Client() // hand it to the code under test
+package vcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type (
+	// Mode selects whether interactions are recorded or replayed.
+	Mode int
+
+	// Interaction is a recorded request and its response.
+	Interaction struct {
+		Request  Request  `json:"request"`
+		Response Response `json:"response"`
+	}
+
+	// Request is the recorded part of a request. Bodies are kept as bytes,
+	// base64 encoded in the cassette, so binary ones survive.
+	Request struct {
+		Method string      `json:"method"`
+		URL    string      `json:"url"`
+		Header http.Header `json:"header,omitempty"`
+		Body   []byte      `json:"body,omitempty"`
+	}
+
+	// Response is the recorded part of a response.
+	Response struct {
+		StatusCode int         `json:"status_code"`
+		Header     http.Header `json:"header,omitempty"`
+		Body       []byte      `json:"body,omitempty"`
+	}
+
+	// Matcher reports whether a recorded request matches r, whose body is
+	// passed separately.
+	Matcher func(r *http.Request, body []byte, recorded Request) bool
+
+	// Recorder is an http.RoundTripper recording or replaying a cassette.
+	Recorder struct {
+		// Matcher defaults to DefaultMatcher.
+		Matcher Matcher
+
+		// Filter is called on interactions before they are recorded, e.g.
+		// to remove credentials. By default the Authorization, Cookie and
+		// Set-Cookie headers are dropped.
+		Filter func(*Interaction)
+
+		path         string
+		mode         Mode
+		real         http.RoundTripper
+		mu           sync.Mutex
+		interactions []Interaction
+		used         []bool
+	}
+)
+
+// Modes.
+const (
+	// ModeAuto replays the cassette if it exists and records it otherwise.
+	ModeAuto Mode = iota
+	// ModeRecord always performs and records the requests.
+	ModeRecord
+	// ModeReplay only replays, unmatched requests fail.
+	ModeReplay
+)
+
+// ErrNoInteraction is returned in replay mode for requests not found in the
+// cassette.
+var ErrNoInteraction = errors.New("vcr: no recorded interaction matches the request")
+
+// New returns a Recorder for the cassette at path. real performs the requests
+// when recording, http.DefaultTransport if nil.
+func New(path string, mode Mode, real http.RoundTripper) (*Recorder, error) {
+	if real == nil {
+		real = http.DefaultTransport
+	}
+	r := &Recorder{
+		Matcher: DefaultMatcher,
+		Filter:  DefaultFilter,
+		path:    path,
+		mode:    mode,
+		real:    real,
+	}
+	b, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil && mode != ModeRecord:
+		if err = json.Unmarshal(b, &r.interactions); err != nil {
+			return nil, fmt.Errorf("vcr: %s: %v", path, err)
+		}
+		r.mode = ModeReplay
+		r.used = make([]bool, len(r.interactions))
+	case os.IsNotExist(err) && mode == ModeReplay:
+		return nil, err
+	case err != nil && !os.IsNotExist(err):
+		return nil, err
+	default:
+		r.mode = ModeRecord
+	}
+	return r, nil
+}
+
+// DefaultMatcher matches the method, URL and body.
+func DefaultMatcher(r *http.Request, body []byte, recorded Request) bool {
+	return r.Method == recorded.Method && r.URL.String() == recorded.URL && bytes.Equal(body, recorded.Body)
+}
+
+// DefaultFilter drops credentials from interactions.
+func DefaultFilter(i *Interaction) {
+	i.Request.Header.Del("Authorization")
+	i.Request.Header.Del("Cookie")
+	i.Response.Header.Del("Set-Cookie")
+}
+
+// Recording reports whether requests are performed and recorded.
+func (r *Recorder) Recording() bool {
+	return r.mode == ModeRecord
+}
+
+// Client returns an http.Client using the recorder.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper. Like any RoundTripper it consumes
+// and closes the request body, but leaves req itself untouched.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r.mode == ModeReplay {
+		return r.replay(req, body)
+	}
+	// The real transport gets a copy carrying the body read.
+	out := new(http.Request)
+	*out = *req
+	if req.Body != nil {
+		out.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	return r.record(out, body)
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Identical requests get the recorded responses in order.
+	for i, in := range r.interactions {
+		if r.used[i] || !r.Matcher(req, body, in.Request) {
+			continue
+		}
+		r.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        cloneHeader(in.Response.Header),
+			Body:          ioutil.NopCloser(bytes.NewReader(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%v: %s %s", ErrNoInteraction, req.Method, req.URL)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	res, err := r.real.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+
+	in := Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: cloneHeader(req.Header),
+			Body:   body,
+		},
+		Response: Response{
+			StatusCode: res.StatusCode,
+			Header:     cloneHeader(res.Header),
+			Body:       resBody,
+		},
+	}
+	if r.Filter != nil {
+		r.Filter(&in)
+	}
+	r.mu.Lock()
+	r.interactions = append(r.interactions, in)
+	r.mu.Unlock()
+	return res, nil
+}
+
+// Stop writes the cassette when recording.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	b, err := json.MarshalIndent(r.interactions, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, b, 0644)
+}
+
+func cloneHeader(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for k, v := range h {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
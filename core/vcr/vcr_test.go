@@ -0,0 +1,104 @@
+package vcr
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/henrylee2cn/thinkgo/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordReplay(t *testing.T) {
+	calls := 0
+	e := core.New()
+	e.Post("/echo", func(c *core.Context) error {
+		calls++
+		b, _ := ioutil.ReadAll(c.Request().Body)
+		return c.String(http.StatusOK, strings.ToUpper(string(b)))
+	})
+	upstream := httptest.NewServer(e)
+	defer upstream.Close()
+
+	dir, _ := ioutil.TempDir("", "vcr")
+	defer os.RemoveAll(dir)
+	cassette := filepath.Join(dir, "echo.json")
+
+	do := func(client *http.Client, body string) (string, error) {
+		req, _ := http.NewRequest(core.POST, upstream.URL+"/echo", strings.NewReader(body))
+		req.Header.Set(core.Authorization, "secret")
+		res, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		b, err := ioutil.ReadAll(res.Body)
+		return string(b), err
+	}
+
+	rec, err := New(cassette, ModeAuto, nil)
+	assert.NoError(t, err)
+	assert.True(t, rec.Recording())
+	got, err := do(rec.Client(), "hi")
+	assert.NoError(t, err)
+	assert.Equal(t, "HI", got)
+	assert.NoError(t, rec.Stop())
+	b, _ := ioutil.ReadFile(cassette)
+	assert.NotContains(t, string(b), "secret")
+
+	rec, err = New(cassette, ModeAuto, nil)
+	assert.NoError(t, err)
+	assert.False(t, rec.Recording())
+	got, err = do(rec.Client(), "hi")
+	assert.NoError(t, err)
+	assert.Equal(t, "HI", got)
+	assert.Equal(t, 1, calls)
+
+	_, err = do(rec.Client(), "hi")
+	assert.Error(t, err)
+}
+
+func TestRoundTripBody(t *testing.T) {
+	e := core.New()
+	e.Post("/echo", func(c *core.Context) error {
+		b, _ := ioutil.ReadAll(c.Request().Body)
+		c.Response().Header().Set(core.ContentType, "application/octet-stream")
+		c.Response().WriteHeader(http.StatusOK)
+		_, err := c.Response().Write(b)
+		return err
+	})
+	upstream := httptest.NewServer(e)
+	defer upstream.Close()
+
+	dir, _ := ioutil.TempDir("", "vcr")
+	defer os.RemoveAll(dir)
+	cassette := filepath.Join(dir, "binary.json")
+
+	binary := []byte{0xff, 0x00, 0xfe, 'a'}
+	do := func(rec *Recorder) []byte {
+		body := ioutil.NopCloser(bytes.NewReader(binary))
+		req, _ := http.NewRequest(core.POST, upstream.URL+"/echo", body)
+		res, err := rec.RoundTrip(req)
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		assert.True(t, req.Body == body, "the caller's body is left in place")
+		defer res.Body.Close()
+		b, _ := ioutil.ReadAll(res.Body)
+		return b
+	}
+
+	rec, err := New(cassette, ModeRecord, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, binary, do(rec))
+	assert.NoError(t, rec.Stop())
+
+	rec, err = New(cassette, ModeReplay, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, binary, do(rec))
+}
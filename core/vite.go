@@ -0,0 +1,156 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/henrylee2cn/thinkgo/core/template"
+)
+
+type (
+	// Vite resolves the hashed bundles of a frontend toolchain from its
+	// manifest.json, either Vite's (`build.manifest`) or the flat one of
+	// webpack-manifest-plugin. In dev mode assets are served by the frontend
+	// dev server instead.
+	Vite struct {
+		// Base is the public URL prefix of the built files, e.g. "/public/dist".
+		Base string
+		// DevServer is the frontend dev server URL, e.g. "http://localhost:5173".
+		// Setting it enables dev mode.
+		DevServer string
+
+		chunks map[string]ViteChunk
+	}
+
+	// ViteChunk is an entry of a Vite manifest.
+	ViteChunk struct {
+		File    string   `json:"file"`
+		Src     string   `json:"src"`
+		IsEntry bool     `json:"isEntry"`
+		CSS     []string `json:"css"`
+		Imports []string `json:"imports"`
+	}
+)
+
+// NewVite loads the manifest file. `base` is the URL prefix the built files are
+// served under.
+func NewVite(manifest, base string) (*Vite, error) {
+	b, err := ioutil.ReadFile(manifest)
+	if err != nil {
+		return nil, err
+	}
+	raw := map[string]json.RawMessage{}
+	if err = json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("vite: %s: %v", manifest, err)
+	}
+	v := &Vite{Base: base, chunks: make(map[string]ViteChunk, len(raw))}
+	for name, r := range raw {
+		var c ViteChunk
+		if len(r) > 0 && r[0] == '"' {
+			// webpack-manifest-plugin: "main.js": "/dist/main.1a2b.js"
+			if err = json.Unmarshal(r, &c.File); err != nil {
+				return nil, err
+			}
+			c.IsEntry = true
+		} else if err = json.Unmarshal(r, &c); err != nil {
+			return nil, fmt.Errorf("vite: %s: %v", manifest, err)
+		}
+		v.chunks[name] = c
+	}
+	return v, nil
+}
+
+// Dev reports whether assets come from the dev server.
+func (v *Vite) Dev() bool {
+	return v.DevServer != ""
+}
+
+// Asset returns the URL of the bundle built from the source `name`, e.g.
+// "src/main.ts".
+func (v *Vite) Asset(name string) (string, error) {
+	if v.Dev() {
+		return strings.TrimSuffix(v.DevServer, "/") + "/" + strings.TrimPrefix(name, "/"), nil
+	}
+	c, ok := v.chunks[name]
+	if !ok {
+		return "", fmt.Errorf("vite: %q not found in manifest", name)
+	}
+	return v.url(c.File), nil
+}
+
+// Tags returns the <script> and <link> tags loading the entry `name` together
+// with its CSS and the CSS of its imports.
+func (v *Vite) Tags(name string) (template.HTML, error) {
+	src, err := v.Asset(name)
+	if err != nil {
+		return "", err
+	}
+	b := new(bytes.Buffer)
+	if v.Dev() {
+		fmt.Fprintf(b, `<script type="module" src="%s/@vite/client"></script>`, html.EscapeString(strings.TrimSuffix(v.DevServer, "/")))
+	} else {
+		seen := map[string]bool{}
+		for _, css := range v.css(name, seen) {
+			fmt.Fprintf(b, `<link rel="stylesheet" href="%s">`, html.EscapeString(v.url(css)))
+		}
+	}
+	if strings.HasSuffix(src, ".css") {
+		fmt.Fprintf(b, `<link rel="stylesheet" href="%s">`, html.EscapeString(src))
+	} else {
+		fmt.Fprintf(b, `<script type="module" src="%s"></script>`, html.EscapeString(src))
+	}
+	return template.HTML(b.String()), nil
+}
+
+func (v *Vite) css(name string, seen map[string]bool) []string {
+	if seen[name] {
+		return nil
+	}
+	seen[name] = true
+	c := v.chunks[name]
+	css := append([]string(nil), c.CSS...)
+	for _, i := range c.Imports {
+		css = append(css, v.css(i, seen)...)
+	}
+	return css
+}
+
+func (v *Vite) url(file string) string {
+	if strings.HasPrefix(file, "/") || strings.Contains(file, "://") {
+		return file
+	}
+	return path.Join("/", v.Base, file)
+}
+
+// FuncMap returns the template functions `vite_asset` and `vite_tags`.
+func (v *Vite) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"vite_asset": v.Asset,
+		"vite_tags":  v.Tags,
+	}
+}
+
+// Proxy returns a handler forwarding requests to the dev server, for serving
+// the frontend from the application's origin in dev mode, e.g.
+// `e.Any("/@vite/*", vite.Proxy())`.
+func (v *Vite) Proxy() HandlerFunc {
+	target, err := url.Parse(v.DevServer)
+	if err != nil || !v.Dev() {
+		return func(c *Context) error {
+			return NewHTTPError(http.StatusNotFound)
+		}
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	return func(c *Context) error {
+		proxy.ServeHTTP(c.Response(), c.Request())
+		return nil
+	}
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"runtime"
+	"sync"
+)
+
+// cacheWarmers holds the WarmCache warmers, shared with groups.
+type cacheWarmers struct {
+	sync.Mutex
+	list []func(context.Context) error
+	// started is set once the warmers ran on the start of a server.
+	started bool
+}
+
+// WarmEnv is the environment variable which makes Think.Run run the cache
+// warmers once instead of serving, see the warm command of the thinkgo tool.
+const WarmEnv = "THINKGO_WARM"
+
+// WarmCache registers fn to fill a cache ahead of the requests needing it,
+// so they don't pay for a cold cache after a deploy. Warmers run concurrently
+// in the background once the first server starts, with a context canceled on
+// shutdown, and again on demand through Warm or the warm command of the
+// thinkgo tool, see WarmEnv.
+func (e *Echo) WarmCache(fn func(ctx context.Context) error) {
+	e.warmers.Lock()
+	e.warmers.list = append(e.warmers.list, fn)
+	e.warmers.Unlock()
+}
+
+// Warm runs the cache warmers concurrently, e.g. from an internal route after
+// the cache was flushed, and reports them in the order they were registered.
+// Failures, errors or panics, are logged.
+//
+//	internal.Post("/cache/warm", func(c *core.Context) error {
+//		for _, r := range c.Echo().Warm(c.StdContext()) {
+//			if r.Err != nil {
+//				return r.Err
+//			}
+//		}
+//		return c.NoContent(http.StatusNoContent)
+//	})
+func (e *Echo) Warm(ctx context.Context) []HookReport {
+	e.warmers.Lock()
+	warmers := e.warmers.list
+	e.warmers.Unlock()
+	clock := e.env.clock
+	reports := make([]HookReport, len(warmers))
+	var wg sync.WaitGroup
+	for i, fn := range warmers {
+		wg.Add(1)
+		go func(r *HookReport, fn func(context.Context) error) {
+			defer wg.Done()
+			r.Name = runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+			start := clock.Now()
+			var err error
+			if r.Err = runHook(func() { err = fn(ctx) }); r.Err == nil {
+				r.Err = err
+			}
+			r.Duration = clock.Now().Sub(start)
+		}(&reports[i], fn)
+	}
+	wg.Wait()
+	for _, r := range reports {
+		if r.Err != nil {
+			e.logger.Error("\tcache warmer %s failed after %v: %v", r.Name, r.Duration, r.Err)
+		} else {
+			e.logger.Info("\tcache warmer %s took %v", r.Name, r.Duration)
+		}
+	}
+	return reports
+}
+
+// warmOnStart runs the warmers in the background the first time a server
+// starts.
+func (e *Echo) warmOnStart() {
+	e.warmers.Lock()
+	start := !e.warmers.started && len(e.warmers.list) > 0
+	e.warmers.started = true
+	e.warmers.Unlock()
+	if start {
+		ctx, cancel := context.WithCancel(context.Background())
+		e.OnShutdown(cancel)
+		go e.Warm(ctx)
+	}
+}
+
+// warmFromEnv runs the warmers if WarmEnv is set and reports them, reporting
+// as well whether it ran them.
+func (e *Echo) warmFromEnv() ([]HookReport, bool) {
+	if os.Getenv(WarmEnv) == "" {
+		return nil, false
+	}
+	return e.Warm(context.Background()), true
+}
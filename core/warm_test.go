@@ -0,0 +1,70 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func warmOK(ctx context.Context) error      { return nil }
+func warmFailing(ctx context.Context) error { return errors.New("cache down") }
+func warmPanicking(ctx context.Context) error {
+	panic("boom")
+}
+
+func TestWarm(t *testing.T) {
+	e := New()
+	e.WarmCache(warmOK)
+	// Groups share the warmers
+	e.Group("/admin").Echo().WarmCache(warmFailing)
+	e.WarmCache(warmPanicking)
+
+	reports := e.Warm(context.Background())
+	if assert.Equal(t, 3, len(reports)) {
+		assert.Contains(t, reports[0].Name, "warmOK")
+		assert.NoError(t, reports[0].Err)
+		assert.Contains(t, reports[1].Name, "warmFailing")
+		assert.Equal(t, "cache down", reports[1].Err.Error())
+		assert.Contains(t, reports[2].Name, "warmPanicking")
+		assert.Equal(t, "boom", reports[2].Err.Error())
+	}
+	assert.Equal(t, 0, len(New().Warm(context.Background())))
+}
+
+func TestWarmOnStart(t *testing.T) {
+	e := New()
+	var runs int32
+	started := make(chan context.Context, 2)
+	e.WarmCache(func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		started <- ctx
+		return nil
+	})
+	e.warmOnStart()
+	e.warmOnStart()
+	ctx := <-started
+	assert.NoError(t, e.Close())
+	// The context is canceled on shutdown
+	<-ctx.Done()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+}
+
+func TestWarmFromEnv(t *testing.T) {
+	e := New()
+	e.WarmCache(warmOK)
+	defer os.Unsetenv(WarmEnv)
+
+	os.Unsetenv(WarmEnv)
+	reports, ok := e.warmFromEnv()
+	assert.False(t, ok)
+	assert.Equal(t, 0, len(reports))
+
+	os.Setenv(WarmEnv, "1")
+	reports, ok = e.warmFromEnv()
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(reports))
+}
@@ -0,0 +1,29 @@
+package core
+
+import (
+	"io"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// YAML sends a YAML response with status code. Fields are named after their
+// `yaml` tags, lower-cased otherwise.
+func (c *Context) YAML(code int, i interface{}) error {
+	b, err := yaml.Marshal(i)
+	if err != nil {
+		return err
+	}
+	c.response.Header().Set(ContentType, ApplicationYAMLCharsetUTF8)
+	c.response.WriteHeader(code)
+	c.response.Write(b)
+	return nil
+}
+
+func bindYAML(r io.Reader, i interface{}) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(b, i)
+}
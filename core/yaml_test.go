@@ -0,0 +1,44 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type yamlDoc struct {
+	Name string   `yaml:"name"`
+	Tags []string `yaml:"tags"`
+	Size int
+}
+
+func TestYAML(t *testing.T) {
+	e := New()
+	rec := httptest.NewRecorder()
+	c := NewContext(httptest.NewRequest(GET, "/", nil), NewResponse(rec, e), e)
+	assert.NoError(t, c.YAML(http.StatusCreated, yamlDoc{"a", []string{"x", "y"}, 2}))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, ApplicationYAMLCharsetUTF8, rec.Header().Get(ContentType))
+	assert.Equal(t, "name: a\ntags:\n- x\n- \"y\"\nsize: 2\n", rec.Body.String())
+}
+
+func TestBindYAML(t *testing.T) {
+	e := New()
+	for _, ct := range []string{ApplicationYAML, ApplicationYAMLCharsetUTF8, ApplicationXYAML, TextYAML} {
+		req := httptest.NewRequest(POST, "/", strings.NewReader("name: a\ntags: [x, y]\nsize: 2\n"))
+		req.Header.Set(ContentType, ct)
+		c := NewContext(req, NewResponse(httptest.NewRecorder(), e), e)
+		var doc yamlDoc
+		if assert.NoError(t, c.Bind(&doc), ct) {
+			assert.Equal(t, yamlDoc{"a", []string{"x", "y"}, 2}, doc, ct)
+		}
+	}
+
+	req := httptest.NewRequest(POST, "/", strings.NewReader("name: [a"))
+	req.Header.Set(ContentType, ApplicationYAML)
+	c := NewContext(req, NewResponse(httptest.NewRecorder(), e), e)
+	assert.Error(t, c.Bind(new(yamlDoc)))
+}
@@ -61,6 +61,10 @@ func (c *Command) Runnable() bool {
 var commands = []*Command{
 	cmdNew,
 	cmdRun,
+	cmdPack,
+	cmdI18n,
+	cmdSmoke,
+	cmdWarm,
 }
 
 func Deploy() {
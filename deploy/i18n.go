@@ -0,0 +1,77 @@
+package deploy
+
+import (
+	path "path/filepath"
+	"strings"
+
+	"github.com/henrylee2cn/thinkgo/core/i18n"
+)
+
+var cmdI18n = &Command{
+	UsageLine: "i18n [-dir locales] [-locales en,zh] [-prune] [dir...]",
+	Short:     "extract translation keys into the locale catalogs",
+	Long: `
+I18n walks the given directories, the current one by default, for the keys
+passed to T in Go source (T("key")) and templates ({{T "key"}}), and adds the
+missing ones to the catalog of every locale, <dir>/<locale>.json, with empty
+translations. Existing translations are kept; keys no longer used are listed,
+and removed with -prune.
+
+Locales default to the catalogs found in the locales directory.
+`,
+}
+
+var (
+	i18nDir     string
+	i18nLocales string
+	i18nPrune   bool
+)
+
+func init() {
+	cmdI18n.Run = extractI18n
+	cmdI18n.Flag.StringVar(&i18nDir, "dir", "locales", "catalogs directory")
+	cmdI18n.Flag.StringVar(&i18nLocales, "locales", "", "comma separated locales")
+	cmdI18n.Flag.BoolVar(&i18nPrune, "prune", false, "remove the unused keys")
+}
+
+func extractI18n(cmd *Command, args []string) int {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	var locales []string
+	if i18nLocales != "" {
+		locales = strings.Split(i18nLocales, ",")
+	} else {
+		catalogs, _ := path.Glob(path.Join(i18nDir, "*.json"))
+		for _, c := range catalogs {
+			locales = append(locales, strings.TrimSuffix(path.Base(c), ".json"))
+		}
+	}
+	if len(locales) == 0 {
+		ColorLog("[ERRO] No locale: pass -locales or create %s/<locale>.json\n", i18nDir)
+		return 1
+	}
+	keys, err := i18n.Extract(args...)
+	if err != nil {
+		ColorLog("[ERRO] %v\n", err)
+		return 1
+	}
+	ColorLog("[INFO] Found %d keys\n", len(keys))
+	for _, l := range locales {
+		catalog := path.Join(i18nDir, strings.TrimSpace(l)+".json")
+		added, unused, err := i18n.Merge(catalog, keys, i18nPrune)
+		if err != nil {
+			ColorLog("[ERRO] %s: %v\n", catalog, err)
+			return 1
+		}
+		ColorLog("[SUCC] %s: %d keys added\n", catalog, len(added))
+		for _, k := range unused {
+			if i18nPrune {
+				ColorLog("[INFO] %s: removed unused key %q\n", catalog, k)
+			} else {
+				ColorLog("[WARN] %s: unused key %q\n", catalog, k)
+			}
+		}
+	}
+	return 0
+}
@@ -245,6 +245,8 @@ defmodule = home
 var maingo = `package main
 
 import (
+	"os"
+
 	"github.com/henrylee2cn/thinkgo/core"
 
 	_ "[[[Appname]]]/application"
@@ -253,7 +255,9 @@ import (
 )
 
 func main() {
-	core.ThinkGo.Run()
+	if err := core.ThinkGo.Run(); err != nil {
+		os.Exit(1)
+	}
 }
 `
 
@@ -0,0 +1,103 @@
+package deploy
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	path "path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var cmdPack = &Command{
+	UsageLine: "pack [-o views_packed.go] [-pkg main] [-var packedViews] [-load] dir...",
+	Short:     "pack views and locales into Go source",
+	Long: `
+Pack generates a Go source file holding the files of the given directories, so
+the application runs without its source tree on Go versions without embed.
+Files are keyed by their slash separated path relative to the current
+directory, and served through a core.PackedFS (http.FileSystem, and fs.FS via
+FS() on Go 1.16+):
+
+    //go:generate thinkgo pack -load application locales
+
+With -load, the generated init function loads the packed views into the
+renderer, see core.Think.LoadViews.
+`,
+}
+
+var (
+	packOutput string
+	packPkg    string
+	packVar    string
+	packLoad   bool
+)
+
+func init() {
+	cmdPack.Run = packFiles
+	cmdPack.Flag.StringVar(&packOutput, "o", "views_packed.go", "output file")
+	cmdPack.Flag.StringVar(&packPkg, "pkg", "main", "package name")
+	cmdPack.Flag.StringVar(&packVar, "var", "packedViews", "variable name")
+	cmdPack.Flag.BoolVar(&packLoad, "load", false, "load the packed views into the renderer")
+}
+
+func packFiles(cmd *Command, args []string) int {
+	if len(args) == 0 {
+		cmd.Usage()
+	}
+	files := make(map[string][]byte)
+	for _, dir := range args {
+		err := path.Walk(dir, func(p string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || strings.HasSuffix(p, ".go") {
+				return err
+			}
+			b, err := ioutil.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			files[path.ToSlash(path.Clean(p))] = b
+			return nil
+		})
+		if err != nil {
+			ColorLog("[ERRO] %v\n", err)
+			return 1
+		}
+	}
+	src, err := packSource(files, time.Now())
+	if err != nil {
+		ColorLog("[ERRO] %v\n", err)
+		return 1
+	}
+	if err = ioutil.WriteFile(packOutput, src, 0644); err != nil {
+		ColorLog("[ERRO] %v\n", err)
+		return 1
+	}
+	ColorLog("[SUCC] Packed %d files into %s\n", len(files), packOutput)
+	return 0
+}
+
+// packSource generates the Go source of a core.PackedFS holding files.
+func packSource(files map[string][]byte, modTime time.Time) ([]byte, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by thinkgo pack. DO NOT EDIT.\n\npackage %s\n\n", packPkg)
+	buf.WriteString("import (\n\t\"time\"\n\n\t\"github.com/henrylee2cn/thinkgo/core\"\n)\n\n")
+	fmt.Fprintf(&buf, "var %s = core.NewPackedFS(time.Unix(%d, 0), map[string]string{\n", packVar, modTime.Unix())
+	for _, name := range names {
+		fmt.Fprintf(&buf, "\t%q: %s,\n", name, strconv.Quote(string(files[name])))
+	}
+	buf.WriteString("})\n")
+	if packLoad {
+		fmt.Fprintf(&buf, "\nfunc init() {\n\tif err := core.ThinkGo.LoadViews(%s); err != nil {\n\t\tpanic(err)\n\t}\n}\n", packVar)
+	}
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,85 @@
+package deploy
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	path "path/filepath"
+	"strings"
+)
+
+var cmdSmoke = &Command{
+	UsageLine: "smoke [-p name=value]... [binary]",
+	Short:     "smoke test the GET routes of the app",
+	Long: `
+Smoke runs the app, the given binary or else the one built from the current
+directory, in smoke test mode: instead of serving, it requests every GET route
+in process and reports the ones which don't answer with a 2xx or 3xx status,
+exiting with 1 if there are some. Deploy scripts can run it right after the
+build, or the binary itself with THINKGO_SMOKE=1.
+
+Path params are "1" unless given with -p, e.g. -p id=42 -p '*=index.html'.
+`,
+}
+
+var smokeParams ListOpts
+
+func init() {
+	cmdSmoke.Run = smokeApp
+	cmdSmoke.Flag.Var(&smokeParams, "p", "sample value of a path param, name=value")
+}
+
+func smokeApp(cmd *Command, args []string) int {
+	bin, cleanup, ok := appBinary(args)
+	if !ok {
+		return 1
+	}
+	defer cleanup()
+	params := url.Values{}
+	for _, p := range smokeParams {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 {
+			ColorLog("[ERRO] Invalid param %q, expected name=value\n", p)
+			return 2
+		}
+		params.Set(kv[0], kv[1])
+	}
+	env := "1"
+	if len(params) > 0 {
+		env = params.Encode()
+	}
+	run := exec.Command(bin)
+	// THINKGO_SMOKE is core.SmokeEnv.
+	run.Env = append(os.Environ(), "THINKGO_SMOKE="+env)
+	run.Stdout, run.Stderr = os.Stdout, os.Stderr
+	if err := run.Run(); err != nil {
+		ColorLog("[ERRO] Smoke test failed[ %v ]\n", err)
+		return 1
+	}
+	ColorLog("[SUCC] Smoke test passed\n")
+	return 0
+}
+
+// appBinary returns the binary given in args, or else builds the one of the
+// current directory in a temporary directory, removed by cleanup. Failures
+// are logged.
+func appBinary(args []string) (bin string, cleanup func(), ok bool) {
+	if len(args) > 0 {
+		return args[0], func() {}, true
+	}
+	dir, err := ioutil.TempDir("", "thinkgo-app")
+	if err != nil {
+		ColorLog("[ERRO] %v\n", err)
+		return "", nil, false
+	}
+	bin = path.Join(dir, "app")
+	build := exec.Command("go", "build", "-o", bin)
+	build.Stdout, build.Stderr = os.Stdout, os.Stderr
+	if err = build.Run(); err != nil {
+		os.RemoveAll(dir)
+		ColorLog("[ERRO] Build failed[ %v ]\n", err)
+		return "", nil, false
+	}
+	return bin, func() { os.RemoveAll(dir) }, true
+}
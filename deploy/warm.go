@@ -0,0 +1,41 @@
+package deploy
+
+import (
+	"os"
+	"os/exec"
+)
+
+var cmdWarm = &Command{
+	UsageLine: "warm [binary]",
+	Short:     "run the cache warmers of the app",
+	Long: `
+Warm runs the app, the given binary or else the one built from the current
+directory, in cache warm mode: instead of serving, it runs the cache warmers
+registered with WarmCache once, logging how long each took, and exits with 1
+if one of them failed. It fills shared caches, e.g. after they were flushed;
+the running instances keep their own in-process caches. The binary itself
+does the same with THINKGO_WARM=1.
+`,
+}
+
+func init() {
+	cmdWarm.Run = warmApp
+}
+
+func warmApp(cmd *Command, args []string) int {
+	bin, cleanup, ok := appBinary(args)
+	if !ok {
+		return 1
+	}
+	defer cleanup()
+	run := exec.Command(bin)
+	// THINKGO_WARM is core.WarmEnv.
+	run.Env = append(os.Environ(), "THINKGO_WARM=1")
+	run.Stdout, run.Stderr = os.Stdout, os.Stderr
+	if err := run.Run(); err != nil {
+		ColorLog("[ERRO] Cache warm failed[ %v ]\n", err)
+		return 1
+	}
+	ColorLog("[SUCC] Cache warmed\n")
+	return 0
+}